@@ -2,18 +2,49 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/ElshadHu/verdis/internal/server"
 )
 
 func main() {
-	cfg, err := server.NewDefaultConfig(server.WithAddress("127.0.0.1:6379"),
-		server.WithMaxConnections(1000))
+	addr := flag.String("addr", "127.0.0.1:6379", "address to listen on")
+	replicaOf := flag.String("replicaof", "", "host:port of a leader to replicate")
+	raftID := flag.String("raft-id", "", "id of this node in a raft cluster")
+	raftAddr := flag.String("raft-addr", "", "host:port the raft node talks to its peers on")
+	raftDir := flag.String("raft-dir", "", "directory keeping the raft log (empty = in memory)")
+	raftPeers := flag.String("raft-peers", "", "founding raft cluster as id=host:port,... including this node")
+	clusterEnabled := flag.Bool("cluster", false, "run as a node of a sharded cluster")
+	clusterConfig := flag.String("cluster-config-file", "nodes.conf", "file keeping this node's view of the cluster")
+	flag.Parse()
+
+	opts := []server.ConfigOption{server.WithAddress(*addr),
+		server.WithMaxConnections(1000), server.WithReplicaOf(*replicaOf)}
+	if *raftID != "" {
+		opts = append(opts, server.WithRaft(*raftID, *raftAddr, *raftDir))
+	}
+	for _, peer := range strings.Split(*raftPeers, ",") {
+		if peer == "" {
+			continue
+		}
+		id, peerAddr, ok := strings.Cut(peer, "=")
+		if !ok {
+			log.Fatalf("Invalid raft peer %q, expected id=host:port", peer)
+		}
+		opts = append(opts, server.WithRaftPeer(id, peerAddr))
+	}
+
+	if *clusterEnabled {
+		opts = append(opts, server.WithCluster(*clusterConfig))
+	}
+
+	cfg, err := server.NewDefaultConfig(opts...)
 	if err != nil {
 		log.Fatal("Failed to create config:", err)
 	}
@@ -0,0 +1,136 @@
+package cluster_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/cluster"
+)
+
+func TestKeySlot(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"somekey", 11058},
+		{"foo{hash_tag}", 2515},
+		{"foo", 12182},
+		{"123456789", 12739},
+	}
+	for _, tt := range tests {
+		if got := cluster.KeySlot([]byte(tt.key)); got != tt.want {
+			t.Errorf("KeySlot(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+
+	// keys are hashed by their first non-empty tag
+	same := [][2]string{
+		{"{user1000}.following", "user1000"},
+		{"{user1000}.followers", "{user1000}.following"},
+		{"foo{bar}{zap}", "bar"},
+		{"foo{{bar}}zap", "{bar"},
+		{"foo{}{bar}", "foo{}{bar}"},
+	}
+	for _, pair := range same {
+		a, b := cluster.KeySlot([]byte(pair[0])), cluster.KeySlot([]byte(pair[1]))
+		if a != b {
+			t.Errorf("KeySlot(%q) = %d, KeySlot(%q) = %d, want equal", pair[0], a, pair[1], b)
+		}
+	}
+}
+
+func TestRanges(t *testing.T) {
+	ranges := cluster.Ranges([]int{0, 1, 2, 5, 7, 8})
+	if got := cluster.FormatRanges(ranges); got != "0-2,5,7-8" {
+		t.Fatalf("FormatRanges = %q", got)
+	}
+	parsed, err := cluster.ParseRanges("0-2,5,7-8")
+	if err != nil || !reflect.DeepEqual(parsed, ranges) {
+		t.Fatalf("ParseRanges = %v, %v", parsed, err)
+	}
+	for _, bad := range []string{"16384", "5-2", "x"} {
+		if _, err := cluster.ParseRange(bad); err == nil {
+			t.Errorf("ParseRange(%q) succeeded", bad)
+		}
+	}
+}
+
+func newState(id string) *cluster.State {
+	return cluster.NewState(&cluster.Node{ID: id, Addr: "127.0.0.1:7000"})
+}
+
+// TestMerge_HigherEpochWins verifies a slot only changes hands to a claim
+// with a higher config epoch than its owner's
+func TestMerge_HigherEpochWins(t *testing.T) {
+	s := newState("a")
+	now := time.Now()
+	s.Merge(&cluster.Gossip{ID: "b", Addr: "127.0.0.1:7001", ConfigEpoch: 2, Slots: []cluster.Range{{Start: 0, End: 9}}}, now)
+	if owner := s.Owner(5); owner == nil || owner.ID != "b" {
+		t.Fatalf("slot 5 owned by %v, want b", owner)
+	}
+
+	changed, _ := s.Merge(&cluster.Gossip{ID: "c", Addr: "127.0.0.1:7002", ConfigEpoch: 1, Slots: []cluster.Range{{Start: 5, End: 5}}}, now)
+	if len(changed) != 0 || s.Owner(5).ID != "b" {
+		t.Fatalf("lower epoch took slot 5 (changed %v)", changed)
+	}
+	changed, _ = s.Merge(&cluster.Gossip{ID: "c", Addr: "127.0.0.1:7002", ConfigEpoch: 3, Slots: []cluster.Range{{Start: 5, End: 5}}}, now)
+	if !reflect.DeepEqual(changed, []int{5}) || s.Owner(5).ID != "c" {
+		t.Fatalf("higher epoch did not take slot 5 (changed %v)", changed)
+	}
+	if s.CurrentEpoch != 3 {
+		t.Errorf("current epoch %d, want 3", s.CurrentEpoch)
+	}
+
+	// b no longer claims slot 9, so it is released
+	changed, _ = s.Merge(&cluster.Gossip{ID: "b", Addr: "127.0.0.1:7001", ConfigEpoch: 2, Slots: []cluster.Range{{Start: 0, End: 8}}}, now)
+	if !reflect.DeepEqual(changed, []int{9}) || s.Owner(9) != nil {
+		t.Fatalf("slot 9 not released (changed %v)", changed)
+	}
+}
+
+// TestMerge_LearnsAndForgets verifies nodes spread through gossip and a
+// forgotten node is not added back
+func TestMerge_LearnsAndForgets(t *testing.T) {
+	s := newState("a")
+	now := time.Now()
+	gossip := &cluster.Gossip{ID: "b", Addr: "127.0.0.1:7001", Known: []cluster.Node{{ID: "c", Addr: "127.0.0.1:7002"}}}
+	if _, updated := s.Merge(gossip, now); !updated {
+		t.Fatal("merge reported no update")
+	}
+	if len(s.Nodes) != 3 || s.Nodes["c"].Addr != "127.0.0.1:7002" {
+		t.Fatalf("nodes = %v, want a, b and c", s.Nodes)
+	}
+
+	s.Forget("c", now.Add(time.Minute))
+	s.Merge(gossip, now)
+	if s.Nodes["c"] != nil {
+		t.Fatal("forgotten node gossiped back in")
+	}
+	s.Merge(gossip, now.Add(2*time.Minute))
+	if s.Nodes["c"] == nil {
+		t.Fatal("node not learned again after the forget expired")
+	}
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	s := newState("a")
+	s.BumpEpoch()
+	for slot := range 100 {
+		s.Assign(slot, "a")
+	}
+	s.Merge(&cluster.Gossip{ID: "b", Addr: "127.0.0.1:7001", ConfigEpoch: 4, Slots: []cluster.Range{{Start: 200, End: 300}}}, time.Now())
+	s.Migrating[7] = "b"
+	s.Importing[250] = "b"
+
+	loaded, err := cluster.Unmarshal(s.Marshal())
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got, want := loaded.Format(), s.Format(); got != want {
+		t.Fatalf("loaded state\n%s\nwant\n%s", got, want)
+	}
+	if loaded.Myself != "a" || loaded.CurrentEpoch != 4 {
+		t.Errorf("myself %q at epoch %d, want a at 4", loaded.Myself, loaded.CurrentEpoch)
+	}
+}
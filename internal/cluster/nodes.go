@@ -0,0 +1,229 @@
+package cluster
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrMalformed = errors.New("malformed cluster description")
+
+// Format writes the state as CLUSTER NODES does, one node per line:
+//
+//	id host:port@port flags master ping-sent pong-received config-epoch link-state slot...
+//
+// There is no separate cluster bus, nodes gossip on the client port, so the
+// bus port is the client port. The line of this node also lists the slots it
+// migrates as [slot->-id] and imports as [slot-<-id].
+func (s *State) Format() string {
+	var b strings.Builder
+	for _, id := range s.sortedIDs() {
+		node := s.Nodes[id]
+		flags := "master"
+		if id == s.Myself {
+			flags = "myself,master"
+		}
+		if node.Failing {
+			flags += ",fail?"
+		}
+		addr := node.Addr
+		if addr == "" {
+			addr, flags = ":0", flags+",noaddr"
+		}
+		link := "disconnected"
+		if id == s.Myself || node.Connected {
+			link = "connected"
+		}
+		fmt.Fprintf(&b, "%s %s@%s %s - %d %d %d %s", id, addr, port(addr), flags,
+			node.PingSent, node.PongReceived, node.ConfigEpoch, link)
+		for _, r := range s.Slots(id) {
+			b.WriteString(" " + r.String())
+		}
+		if id == s.Myself {
+			for _, slot := range sortedSlots(s.Migrating) {
+				fmt.Fprintf(&b, " [%d->-%s]", slot, s.Migrating[slot])
+			}
+			for _, slot := range sortedSlots(s.Importing) {
+				fmt.Fprintf(&b, " [%d-<-%s]", slot, s.Importing[slot])
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Marshal encodes the state the way Redis keeps nodes.conf: the CLUSTER NODES
+// lines followed by the epoch variables
+func (s *State) Marshal() []byte {
+	return []byte(s.Format() + fmt.Sprintf("vars currentEpoch %d lastVoteEpoch 0\n", s.CurrentEpoch))
+}
+
+// Unmarshal reads a state written by Marshal. Link details are not kept, every
+// other node starts out disconnected.
+func Unmarshal(data []byte) (*State, error) {
+	var (
+		s     *State
+		nodes []*Node
+		slots = make(map[string][]string)
+		epoch uint64
+	)
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "vars" {
+			for i := 1; i+1 < len(fields); i += 2 {
+				if fields[i] == "currentEpoch" {
+					epoch, _ = strconv.ParseUint(fields[i+1], 10, 64)
+				}
+			}
+			continue
+		}
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("%w: %q", ErrMalformed, scanner.Text())
+		}
+		configEpoch, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: config epoch %q", ErrMalformed, fields[6])
+		}
+		addr, _, _ := strings.Cut(fields[1], "@")
+		addr, _, _ = strings.Cut(addr, ",")
+		if addr == ":0" {
+			addr = ""
+		}
+		node := &Node{ID: fields[0], Addr: addr, ConfigEpoch: configEpoch}
+		nodes = append(nodes, node)
+		slots[node.ID] = fields[8:]
+		if slices.Contains(strings.Split(fields[2], ","), "myself") {
+			if s != nil {
+				return nil, fmt.Errorf("%w: two nodes flagged myself", ErrMalformed)
+			}
+			s = NewState(node)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("%w: no node flagged myself", ErrMalformed)
+	}
+
+	s.CurrentEpoch = epoch
+	for _, node := range nodes {
+		s.Nodes[node.ID] = node
+		s.seeEpoch(node.ConfigEpoch)
+		for _, field := range slots[node.ID] {
+			if err := s.unmarshalSlot(node.ID, field); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// unmarshalSlot reads one slot field of a node's line
+func (s *State) unmarshalSlot(id, field string) error {
+	if inner, ok := strings.CutPrefix(field, "["); ok {
+		inner = strings.TrimSuffix(inner, "]")
+		if slot, other, ok := strings.Cut(inner, "->-"); ok {
+			n, err := ParseSlot(slot)
+			if err != nil {
+				return err
+			}
+			s.Migrating[n] = other
+			return nil
+		}
+		if slot, other, ok := strings.Cut(inner, "-<-"); ok {
+			n, err := ParseSlot(slot)
+			if err != nil {
+				return err
+			}
+			s.Importing[n] = other
+			return nil
+		}
+		return fmt.Errorf("%w: slot %q", ErrMalformed, field)
+	}
+	r, err := ParseRange(field)
+	if err != nil {
+		return err
+	}
+	for slot := r.Start; slot <= r.End; slot++ {
+		s.owners[slot] = id
+	}
+	return nil
+}
+
+// Args encodes the message as the arguments of CLUSTER GOSSIP:
+// id addr config-epoch current-epoch slots to [id addr]...
+func (g *Gossip) Args() []string {
+	args := []string{
+		g.ID, g.Addr,
+		strconv.FormatUint(g.ConfigEpoch, 10),
+		strconv.FormatUint(g.CurrentEpoch, 10),
+		FormatRanges(g.Slots),
+		g.To,
+	}
+	for _, node := range g.Known {
+		args = append(args, node.ID, node.Addr)
+	}
+	return args
+}
+
+// ParseGossip decodes the arguments written by Args
+func ParseGossip(args []string) (*Gossip, error) {
+	if len(args) < 6 || len(args)%2 != 0 {
+		return nil, fmt.Errorf("%w: gossip of %d fields", ErrMalformed, len(args))
+	}
+	configEpoch, err1 := strconv.ParseUint(args[2], 10, 64)
+	currentEpoch, err2 := strconv.ParseUint(args[3], 10, 64)
+	if err := errors.Join(err1, err2); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	slots, err := ParseRanges(args[4])
+	if err != nil {
+		return nil, err
+	}
+	g := &Gossip{
+		ID:           args[0],
+		Addr:         args[1],
+		ConfigEpoch:  configEpoch,
+		CurrentEpoch: currentEpoch,
+		Slots:        slots,
+		To:           args[5],
+	}
+	for i := 6; i < len(args); i += 2 {
+		g.Known = append(g.Known, Node{ID: args[i], Addr: args[i+1]})
+	}
+	return g, nil
+}
+
+// SplitAddr splits a node address into its host and port (0 when unknown)
+func SplitAddr(addr string) (string, int) {
+	host, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0
+	}
+	n, _ := strconv.Atoi(p)
+	return host, n
+}
+
+func port(addr string) string {
+	_, p := SplitAddr(addr)
+	return strconv.Itoa(p)
+}
+
+func sortedSlots(m map[int]string) []int {
+	slots := make([]int, 0, len(m))
+	for slot := range m {
+		slots = append(slots, slot)
+	}
+	slices.Sort(slots)
+	return slots
+}
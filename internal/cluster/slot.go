@@ -0,0 +1,141 @@
+// Package cluster holds the Redis Cluster model a sharded server keeps: the
+// hash slots keys map to, the nodes owning them and how ownership spreads
+// between nodes.
+//
+// Every node numbers the versions of its keys with its own counter. Versions
+// order the writes a node made, not writes across the cluster, and a key
+// migrated to another node keeps the versions its history had on the old one.
+package cluster
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SlotCount is the number of hash slots the keyspace is split into
+const SlotCount = 16384
+
+var ErrInvalidSlot = errors.New("invalid or out of range slot")
+
+// crcTable is the CRC16-CCITT (XModem) table Redis Cluster hashes keys with
+var crcTable = func() [256]uint16 {
+	var table [256]uint16
+	for i := range table {
+		crc := uint16(i) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc = crc<<8 ^ crcTable[byte(crc>>8)^b]
+	}
+	return crc
+}
+
+// KeySlot returns the slot of key. When the key holds a non-empty hash tag,
+// the part between its first '{' and the next '}', only the tag is hashed, so
+// keys sharing a tag land in the same slot.
+func KeySlot(key []byte) int {
+	if open := bytes.IndexByte(key, '{'); open >= 0 {
+		if end := bytes.IndexByte(key[open+1:], '}'); end > 0 {
+			key = key[open+1 : open+1+end]
+		}
+	}
+	return int(crc16(key) & (SlotCount - 1))
+}
+
+// ParseSlot parses a slot number
+func ParseSlot(arg string) (int, error) {
+	slot, err := strconv.Atoi(arg)
+	if err != nil || slot < 0 || slot >= SlotCount {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidSlot, arg)
+	}
+	return slot, nil
+}
+
+// Range is the slots from Start to End, both included
+type Range struct {
+	Start int
+	End   int
+}
+
+func (r Range) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(r.Start)
+	}
+	return strconv.Itoa(r.Start) + "-" + strconv.Itoa(r.End)
+}
+
+// ParseRange parses "start-end" or a single slot
+func ParseRange(s string) (Range, error) {
+	first, last, found := strings.Cut(s, "-")
+	start, err := ParseSlot(first)
+	if err != nil {
+		return Range{}, err
+	}
+	if !found {
+		return Range{Start: start, End: start}, nil
+	}
+	end, err := ParseSlot(last)
+	if err != nil {
+		return Range{}, err
+	}
+	if end < start {
+		return Range{}, fmt.Errorf("%w: %s", ErrInvalidSlot, s)
+	}
+	return Range{Start: start, End: end}, nil
+}
+
+// Ranges folds sorted slots into ranges
+func Ranges(slots []int) []Range {
+	var ranges []Range
+	for _, slot := range slots {
+		if n := len(ranges); n > 0 && ranges[n-1].End == slot-1 {
+			ranges[n-1].End = slot
+			continue
+		}
+		ranges = append(ranges, Range{Start: slot, End: slot})
+	}
+	return ranges
+}
+
+// FormatRanges writes ranges as a comma separated list ("-" when empty)
+func FormatRanges(ranges []Range) string {
+	if len(ranges) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseRanges reads a list written by FormatRanges
+func ParseRanges(s string) ([]Range, error) {
+	if s == "-" || s == "" {
+		return nil, nil
+	}
+	var ranges []Range
+	for _, part := range strings.Split(s, ",") {
+		r, err := ParseRange(part)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
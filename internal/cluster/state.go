@@ -0,0 +1,281 @@
+package cluster
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"time"
+)
+
+// Node is a member of the cluster as one node knows it
+type Node struct {
+	ID string
+
+	// Addr is the "host:port" clients reach the node on
+	Addr string
+
+	// ConfigEpoch orders the node's claims on slots against other nodes': the
+	// higher epoch wins a slot both claim
+	ConfigEpoch uint64
+
+	// PingSent is the Unix millisecond time of a ping the node has not answered yet
+	PingSent int64
+
+	// PongReceived is the Unix millisecond time the node last answered
+	PongReceived int64
+
+	// Connected is set while the gossip link to the node works
+	Connected bool
+
+	// Failing is set when the node has not answered for longer than the node timeout
+	Failing bool
+}
+
+// NewNodeID returns a random node id in the 40 hex digit form Redis uses
+func NewNodeID() string {
+	var b [20]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// State is the cluster as one node sees it: the nodes it knows and the owner
+// of every slot. It is not safe for concurrent use.
+type State struct {
+	// Myself is the id of the node holding the state
+	Myself string
+
+	// CurrentEpoch is the highest epoch seen in the cluster
+	CurrentEpoch uint64
+
+	Nodes map[string]*Node
+
+	// Migrating maps the slots this node hands over to the id of the node taking them
+	Migrating map[int]string
+
+	// Importing maps the slots this node takes over to the id of the node handing them over
+	Importing map[int]string
+
+	// owners holds the id of every slot's node, "" when unassigned
+	owners [SlotCount]string
+
+	// forgotten keeps nodes removed with Forget from being gossiped back in
+	// until the time they map to
+	forgotten map[string]time.Time
+}
+
+// NewState returns the state of a node that knows no other node yet
+func NewState(myself *Node) *State {
+	return &State{
+		Myself:    myself.ID,
+		Nodes:     map[string]*Node{myself.ID: myself},
+		Migrating: make(map[int]string),
+		Importing: make(map[int]string),
+		forgotten: make(map[string]time.Time),
+	}
+}
+
+// Self returns the node holding the state
+func (s *State) Self() *Node {
+	return s.Nodes[s.Myself]
+}
+
+// Owner returns the node serving slot, nil when the slot is unassigned
+func (s *State) Owner(slot int) *Node {
+	return s.Nodes[s.owners[slot]]
+}
+
+// Assign gives slot to the node id ("" leaves it unassigned)
+func (s *State) Assign(slot int, id string) {
+	s.owners[slot] = id
+	if id != s.Myself {
+		delete(s.Migrating, slot)
+	}
+}
+
+// Slots returns the slots owned by the node id
+func (s *State) Slots(id string) []Range {
+	var slots []int
+	for slot, owner := range s.owners {
+		if owner == id {
+			slots = append(slots, slot)
+		}
+	}
+	return Ranges(slots)
+}
+
+// Assigned returns the number of slots some node owns
+func (s *State) Assigned() int {
+	n := 0
+	for _, owner := range s.owners {
+		if owner != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// BumpEpoch gives this node a config epoch above every epoch seen, so its
+// claims win over the claims made so far
+func (s *State) BumpEpoch() {
+	s.CurrentEpoch++
+	s.Self().ConfigEpoch = s.CurrentEpoch
+}
+
+// seeEpoch raises the current epoch to epoch
+func (s *State) seeEpoch(epoch uint64) {
+	s.CurrentEpoch = max(s.CurrentEpoch, epoch)
+}
+
+// Claim records that the node id with config epoch serves slots. A slot
+// changes hands when it is unassigned or its owner's epoch is lower; slots
+// this node imports are left alone until the import completes. It returns the
+// slots that changed hands.
+func (s *State) Claim(id string, epoch uint64, slots []Range) []int {
+	var changed []int
+	for _, r := range slots {
+		for slot := r.Start; slot <= r.End; slot++ {
+			owner := s.owners[slot]
+			if owner == id || s.Importing[slot] != "" {
+				continue
+			}
+			if current := s.Nodes[owner]; current != nil && current.ConfigEpoch >= epoch {
+				continue
+			}
+			s.Assign(slot, id)
+			changed = append(changed, slot)
+		}
+	}
+	return changed
+}
+
+// Forget drops the node id and keeps gossip from adding it back until until
+func (s *State) Forget(id string, until time.Time) {
+	delete(s.Nodes, id)
+	for slot, owner := range s.owners {
+		if owner == id {
+			s.owners[slot] = ""
+		}
+	}
+	for slot, other := range s.Migrating {
+		if other == id {
+			delete(s.Migrating, slot)
+		}
+	}
+	for slot, other := range s.Importing {
+		if other == id {
+			delete(s.Importing, slot)
+		}
+	}
+	s.forgotten[id] = until
+}
+
+// forgottenAt reports whether gossip about the node id is ignored at now
+func (s *State) forgottenAt(id string, now time.Time) bool {
+	until, ok := s.forgotten[id]
+	if ok && now.After(until) {
+		delete(s.forgotten, id)
+		return false
+	}
+	return ok
+}
+
+// Gossip is what a node tells a peer about itself and the nodes it knows. The
+// peer answers with its own, so both learn from one exchange.
+type Gossip struct {
+	ID           string
+	Addr         string
+	ConfigEpoch  uint64
+	CurrentEpoch uint64
+	Slots        []Range
+
+	// To is the address the message was sent to, a node listening on an
+	// unspecified host learns the address peers reach it on from it
+	To string
+
+	// Known lists the other nodes the sender knows, by id and address only
+	Known []Node
+}
+
+// Gossip returns the message this node sends to the peer at to
+func (s *State) Gossip(to string) *Gossip {
+	self := s.Self()
+	g := &Gossip{
+		ID:           self.ID,
+		Addr:         self.Addr,
+		ConfigEpoch:  self.ConfigEpoch,
+		CurrentEpoch: s.CurrentEpoch,
+		Slots:        s.Slots(self.ID),
+		To:           to,
+	}
+	for _, id := range s.sortedIDs() {
+		if id != s.Myself {
+			g.Known = append(g.Known, Node{ID: id, Addr: s.Nodes[id].Addr})
+		}
+	}
+	return g
+}
+
+// Merge takes in what a peer said about itself and the nodes it knows. A node
+// is trusted about the slots it serves: the ones it claims are assigned by the
+// epoch rule of Claim and the ones it no longer claims are released. It
+// returns the slots that changed hands and whether anything worth saving changed.
+func (s *State) Merge(g *Gossip, now time.Time) (changed []int, updated bool) {
+	if g.ID == s.Myself || s.forgottenAt(g.ID, now) {
+		return nil, false
+	}
+	node := s.Nodes[g.ID]
+	if node == nil {
+		node = &Node{ID: g.ID}
+		s.Nodes[g.ID] = node
+		updated = true
+	}
+	if node.Addr != g.Addr || node.ConfigEpoch != g.ConfigEpoch {
+		node.Addr, node.ConfigEpoch = g.Addr, g.ConfigEpoch
+		updated = true
+	}
+	if epoch := max(g.CurrentEpoch, g.ConfigEpoch); epoch > s.CurrentEpoch {
+		s.seeEpoch(epoch)
+		updated = true
+	}
+
+	// two nodes sharing an epoch could both win a slot, the one with the
+	// lower id moves on like Redis does
+	if self := s.Self(); g.ConfigEpoch > 0 && g.ConfigEpoch == self.ConfigEpoch && s.Myself < g.ID {
+		s.BumpEpoch()
+		updated = true
+	}
+
+	changed = s.Claim(g.ID, g.ConfigEpoch, g.Slots)
+	claimed := make(map[int]bool)
+	for _, r := range g.Slots {
+		for slot := r.Start; slot <= r.End; slot++ {
+			claimed[slot] = true
+		}
+	}
+	for slot, owner := range s.owners {
+		if owner == g.ID && !claimed[slot] {
+			s.owners[slot] = ""
+			changed = append(changed, slot)
+		}
+	}
+
+	for _, known := range g.Known {
+		if known.ID == s.Myself || s.Nodes[known.ID] != nil || s.forgottenAt(known.ID, now) {
+			continue
+		}
+		s.Nodes[known.ID] = &Node{ID: known.ID, Addr: known.Addr}
+		updated = true
+	}
+	slices.Sort(changed)
+	return changed, updated || len(changed) > 0
+}
+
+// sortedIDs returns the ids of the known nodes in order
+func (s *State) sortedIDs() []string {
+	ids := make([]string, 0, len(s.Nodes))
+	for id := range s.Nodes {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
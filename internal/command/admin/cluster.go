@@ -0,0 +1,299 @@
+package admin
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/cluster"
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+var errClusterDisabled = errors.New("ERR This instance has cluster support disabled")
+
+// clusterSubcommand is one CLUSTER subcommand with its argument count after
+// the name (max -1 = unlimited)
+type clusterSubcommand struct {
+	min, max int
+	run      func(ctx *command.Context, args [][]byte) command.Result
+}
+
+var clusterSubcommands = map[string]clusterSubcommand{
+	"INFO":             {0, 0, clusterInfo},
+	"MYID":             {0, 0, clusterMyID},
+	"NODES":            {0, 0, clusterNodes},
+	"SLOTS":            {0, 0, clusterSlots},
+	"SHARDS":           {0, 0, clusterShards},
+	"KEYSLOT":          {1, 1, clusterKeySlot},
+	"COUNTKEYSINSLOT":  {1, 1, clusterCountKeysInSlot},
+	"GETKEYSINSLOT":    {2, 2, clusterGetKeysInSlot},
+	"MEET":             {2, 3, clusterMeet},
+	"FORGET":           {1, 1, clusterForget},
+	"ADDSLOTS":         {1, -1, clusterAddSlots},
+	"ADDSLOTSRANGE":    {2, -1, clusterAddSlotsRange},
+	"DELSLOTS":         {1, -1, clusterDelSlots},
+	"DELSLOTSRANGE":    {2, -1, clusterDelSlotsRange},
+	"SETSLOT":          {2, 3, clusterSetSlot},
+	"SET-CONFIG-EPOCH": {1, 1, clusterSetConfigEpoch},
+	"GOSSIP":           {6, -1, clusterGossip},
+}
+
+// Cluster inspects and changes the sharded cluster the server is a node of,
+// with the subcommands of Redis Cluster that clients and cluster tools use.
+// Nodes exchange what they know with GOSSIP once a second, ownership of a
+// slot moves to the node claiming it with the highest config epoch.
+// Usage: CLUSTER INFO | MYID | NODES | SLOTS | SHARDS | KEYSLOT key |
+// COUNTKEYSINSLOT slot | GETKEYSINSLOT slot count | MEET ip port | FORGET id |
+// ADDSLOTS slot [slot ...] | ADDSLOTSRANGE start end [start end ...] |
+// DELSLOTS slot [slot ...] | DELSLOTSRANGE start end [start end ...] |
+// SETSLOT slot IMPORTING id | MIGRATING id | NODE id | STABLE | SET-CONFIG-EPOCH epoch
+func Cluster(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	name := strings.ToUpper(string(args[0]))
+	sub, known := clusterSubcommands[name]
+	if !known {
+		return protocol.NewError("ERR unknown subcommand '" + string(args[0]) + "'. Try CLUSTER HELP.")
+	}
+	if ctx.Cluster == nil && name != "KEYSLOT" {
+		return protocol.NewError(errClusterDisabled.Error())
+	}
+	args = args[1:]
+	if len(args) < sub.min || sub.max >= 0 && len(args) > sub.max {
+		return protocol.NewError("ERR wrong number of arguments for 'cluster|" + strings.ToLower(name) + "' command")
+	}
+	return sub.run(ctx, args)
+}
+
+func clusterInfo(ctx *command.Context, args [][]byte) command.Result {
+	return protocol.NewBulkString([]byte(strings.Join(ctx.Cluster.Info(), "\r\n") + "\r\n"))
+}
+
+func clusterMyID(ctx *command.Context, args [][]byte) command.Result {
+	return protocol.NewBulkString([]byte(ctx.Cluster.MyID()))
+}
+
+func clusterNodes(ctx *command.Context, args [][]byte) command.Result {
+	return protocol.NewBulkString([]byte(ctx.Cluster.Nodes()))
+}
+
+// clusterSlots lists every slot range as [start, end, [host, port, id]]
+func clusterSlots(ctx *command.Context, args [][]byte) command.Result {
+	var ranges []protocol.RESPValue
+	for _, node := range ctx.Cluster.Shards() {
+		host, port := cluster.SplitAddr(node.Addr)
+		for _, r := range node.Slots {
+			ranges = append(ranges, protocol.NewArray([]protocol.RESPValue{
+				protocol.NewInteger(int64(r.Start)),
+				protocol.NewInteger(int64(r.End)),
+				protocol.NewArray([]protocol.RESPValue{
+					protocol.NewBulkString([]byte(host)),
+					protocol.NewInteger(int64(port)),
+					protocol.NewBulkString([]byte(node.ID)),
+				}),
+			}))
+		}
+	}
+	return protocol.NewArray(ranges)
+}
+
+// clusterShards lists every node as a shard of its own: its slot ranges as
+// flat start end pairs and the node's details as field value pairs
+func clusterShards(ctx *command.Context, args [][]byte) command.Result {
+	bulk := func(s string) protocol.RESPValue { return protocol.NewBulkString([]byte(s)) }
+	var shards []protocol.RESPValue
+	for _, node := range ctx.Cluster.Shards() {
+		var slots []protocol.RESPValue
+		for _, r := range node.Slots {
+			slots = append(slots, protocol.NewInteger(int64(r.Start)), protocol.NewInteger(int64(r.End)))
+		}
+		host, port := cluster.SplitAddr(node.Addr)
+		health := "online"
+		if node.Failing {
+			health = "failed"
+		}
+		details := protocol.NewArray([]protocol.RESPValue{
+			bulk("id"), bulk(node.ID),
+			bulk("port"), protocol.NewInteger(int64(port)),
+			bulk("ip"), bulk(host),
+			bulk("endpoint"), bulk(host),
+			bulk("role"), bulk("master"),
+			bulk("replication-offset"), protocol.NewInteger(0),
+			bulk("health"), bulk(health),
+		})
+		shards = append(shards, protocol.NewArray([]protocol.RESPValue{
+			bulk("slots"), protocol.NewArray(slots),
+			bulk("nodes"), protocol.NewArray([]protocol.RESPValue{details}),
+		}))
+	}
+	return protocol.NewArray(shards)
+}
+
+func clusterKeySlot(ctx *command.Context, args [][]byte) command.Result {
+	return protocol.NewInteger(int64(cluster.KeySlot(args[0])))
+}
+
+// keysInSlot returns up to limit live keys of slot (-1 = all)
+func keysInSlot(ctx *command.Context, slot, limit int) []string {
+	var keys []string
+	for _, key := range ctx.Engine.Keys() {
+		if limit >= 0 && len(keys) >= limit {
+			break
+		}
+		if cluster.KeySlot([]byte(key)) == slot && ctx.Engine.Exists(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func clusterCountKeysInSlot(ctx *command.Context, args [][]byte) command.Result {
+	slot, err := cluster.ParseSlot(string(args[0]))
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	return protocol.NewInteger(int64(len(keysInSlot(ctx, slot, -1))))
+}
+
+func clusterGetKeysInSlot(ctx *command.Context, args [][]byte) command.Result {
+	slot, err := cluster.ParseSlot(string(args[0]))
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	count, err := strconv.Atoi(string(args[1]))
+	if err != nil || count < 0 {
+		return protocol.NewError("ERR Invalid number of keys")
+	}
+	keys := keysInSlot(ctx, slot, count)
+	values := make([]protocol.RESPValue, len(keys))
+	for i, key := range keys {
+		values[i] = protocol.NewBulkString([]byte(key))
+	}
+	return protocol.NewArray(values)
+}
+
+func clusterMeet(ctx *command.Context, args [][]byte) command.Result {
+	if _, err := strconv.ParseUint(string(args[1]), 10, 16); err != nil {
+		return protocol.NewError("ERR Invalid base port specified: " + string(args[1]))
+	}
+	return clusterReply(ctx.Cluster.Meet(net.JoinHostPort(string(args[0]), string(args[1]))))
+}
+
+func clusterForget(ctx *command.Context, args [][]byte) command.Result {
+	return clusterReply(ctx.Cluster.Forget(string(args[0])))
+}
+
+// parseSlots reads the slots of ADDSLOTS and DELSLOTS, or the start end pairs
+// of their RANGE forms
+func parseSlots(args [][]byte, ranges bool) ([]int, error) {
+	if ranges && len(args)%2 != 0 {
+		return nil, errors.New("ERR wrong number of arguments for slot ranges")
+	}
+	var slots []int
+	for i := 0; i < len(args); i++ {
+		start, err := cluster.ParseSlot(string(args[i]))
+		if err != nil {
+			return nil, errors.New("ERR " + err.Error())
+		}
+		end := start
+		if ranges {
+			i++
+			if end, err = cluster.ParseSlot(string(args[i])); err != nil {
+				return nil, errors.New("ERR " + err.Error())
+			}
+			if end < start {
+				return nil, errors.New("ERR start slot number " + strconv.Itoa(start) + " is greater than end slot number " + strconv.Itoa(end))
+			}
+		}
+		for slot := start; slot <= end; slot++ {
+			slots = append(slots, slot)
+		}
+	}
+	return slots, nil
+}
+
+func clusterAddSlots(ctx *command.Context, args [][]byte) command.Result {
+	return clusterChangeSlots(args, false, ctx.Cluster.AddSlots)
+}
+
+func clusterAddSlotsRange(ctx *command.Context, args [][]byte) command.Result {
+	return clusterChangeSlots(args, true, ctx.Cluster.AddSlots)
+}
+
+func clusterDelSlots(ctx *command.Context, args [][]byte) command.Result {
+	return clusterChangeSlots(args, false, ctx.Cluster.DelSlots)
+}
+
+func clusterDelSlotsRange(ctx *command.Context, args [][]byte) command.Result {
+	return clusterChangeSlots(args, true, ctx.Cluster.DelSlots)
+}
+
+func clusterChangeSlots(args [][]byte, ranges bool, change func(slots []int) error) command.Result {
+	slots, err := parseSlots(args, ranges)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return clusterReply(change(slots))
+}
+
+func clusterSetSlot(ctx *command.Context, args [][]byte) command.Result {
+	slot, err := cluster.ParseSlot(string(args[0]))
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	state := strings.ToUpper(string(args[1]))
+	var id string
+	switch {
+	case state == "STABLE" && len(args) == 2:
+	case (state == "IMPORTING" || state == "MIGRATING" || state == "NODE") && len(args) == 3:
+		id = string(args[2])
+	default:
+		return protocol.NewError("ERR Invalid CLUSTER SETSLOT action or number of arguments. Try CLUSTER HELP")
+	}
+	return clusterReply(ctx.Cluster.SetSlot(slot, state, id))
+}
+
+func clusterSetConfigEpoch(ctx *command.Context, args [][]byte) command.Result {
+	epoch, err := strconv.ParseUint(string(args[0]), 10, 64)
+	if err != nil {
+		return protocol.NewError("ERR Invalid config epoch specified: " + string(args[0]))
+	}
+	return clusterReply(ctx.Cluster.SetConfigEpoch(epoch))
+}
+
+// clusterGossip is how nodes talk to each other, the reply is this node's gossip
+func clusterGossip(ctx *command.Context, args [][]byte) command.Result {
+	fields := make([]string, len(args))
+	for i, arg := range args {
+		fields[i] = string(arg)
+	}
+	reply, err := ctx.Cluster.Gossip(fields)
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	values := make([]protocol.RESPValue, len(reply))
+	for i, field := range reply {
+		values[i] = protocol.NewBulkString([]byte(field))
+	}
+	return protocol.NewArray(values)
+}
+
+func clusterReply(err error) command.Result {
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func ClusterSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "CLUSTER",
+		Handler:     command.HandlerFunc(Cluster),
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Description: "Inspect and change the sharded cluster: CLUSTER INFO|MYID|NODES|SLOTS|SHARDS|KEYSLOT|COUNTKEYSINSLOT|GETKEYSINSLOT|MEET|FORGET|ADDSLOTS|DELSLOTS|SETSLOT ...",
+		ReadOnly:    false,
+		Mutates:     false,
+	}
+}
@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// infoSection writes one "# Name" block of the INFO reply
+type infoSection struct {
+	name  string
+	write func(ctx *command.Context, b *strings.Builder)
+}
+
+var infoSections = []infoSection{
+	{name: "Replication", write: infoReplication},
+	{name: "Raft", write: infoRaft},
+	{name: "Cluster", write: infoCluster},
+	{name: "Keyspace", write: infoKeyspace},
+}
+
+// Info reports server state, one section per heading.
+// Usage: INFO [section ...]
+func Info(ctx *command.Context, cmd *protocol.Command) command.Result {
+	wanted := make(map[string]bool)
+	for _, arg := range cmd.Args() {
+		wanted[strings.ToLower(string(arg))] = true
+	}
+	all := len(wanted) == 0 || wanted["all"] || wanted["everything"] || wanted["default"]
+
+	var b strings.Builder
+	for _, section := range infoSections {
+		if !all && !wanted[strings.ToLower(section.name)] {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\r\n")
+		}
+		fmt.Fprintf(&b, "# %s\r\n", section.name)
+		section.write(ctx, &b)
+	}
+	return protocol.NewBulkString([]byte(b.String()))
+}
+
+// infoReplication reports the server's role, its link to the leader while it is
+// a replica and the replicas streaming from it
+func infoReplication(ctx *command.Context, b *strings.Builder) {
+	if ctx.Replication == nil {
+		b.WriteString("role:master\r\n")
+		return
+	}
+	for _, line := range ctx.Replication.Info() {
+		b.WriteString(line + "\r\n")
+	}
+}
+
+// infoRaft reports the node's part in its consensus cluster
+func infoRaft(ctx *command.Context, b *strings.Builder) {
+	if ctx.Consensus == nil {
+		b.WriteString("raft_enabled:0\r\n")
+		return
+	}
+	b.WriteString("raft_enabled:1\r\n")
+	for _, line := range ctx.Consensus.Info() {
+		b.WriteString(line + "\r\n")
+	}
+}
+
+// infoCluster reports whether the server is a node of a sharded cluster
+func infoCluster(ctx *command.Context, b *strings.Builder) {
+	if ctx.Cluster == nil {
+		b.WriteString("cluster_enabled:0\r\n")
+		return
+	}
+	b.WriteString("cluster_enabled:1\r\n")
+}
+
+// infoKeyspace lists every database holding keys with its key and expiry
+// counts and current version. A context without databases reports its engine
+// as db0.
+func infoKeyspace(ctx *command.Context, b *strings.Builder) {
+	engines := []storage.Engine{ctx.Engine}
+	if ctx.Databases != nil {
+		engines = make([]storage.Engine, ctx.Databases.Len())
+		for db := range engines {
+			engines[db], _ = ctx.Databases.Get(db)
+		}
+	}
+	for db, engine := range engines {
+		reporter, ok := engine.(storage.StatsReporter)
+		if !ok {
+			continue
+		}
+		keys, expires := reporter.KeyCounts()
+		if keys == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "db%d:keys=%d,expires=%d,current_version=%d\r\n",
+			db, keys, expires, engine.CurrentVersion())
+	}
+}
+
+func InfoSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "INFO",
+		Handler:     command.HandlerFunc(Info),
+		MinArgs:     0,
+		MaxArgs:     -1,
+		Description: "Report server state: INFO [section ...]",
+		ReadOnly:    false,
+		Mutates:     false,
+	}
+}
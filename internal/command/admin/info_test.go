@@ -0,0 +1,44 @@
+package admin_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/admin"
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/database"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+)
+
+// TestInfo_Keyspace verifies INFO keyspace lists only the databases holding
+// keys, with their key and expiry counts
+func TestInfo_Keyspace(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll, database.RegisterAll, admin.RegisterAll)
+	s.Expect("+OK\r\n", "SET", "a", "1")
+	s.Expect("+OK\r\n", "SET", "b", "1", "EX", "100")
+	s.Expect("+OK\r\n", "SET", "c", "1")
+	s.Expect(":1\r\n", "DEL", "c")
+	s.Expect("+OK\r\n", "SELECT", "2")
+	s.Expect("+OK\r\n", "SET", "x", "1", "EX", "100")
+
+	db0, _ := s.Ctx.Databases.Get(0)
+	db2, _ := s.Ctx.Databases.Get(2)
+	info := fmt.Sprintf("# Keyspace\r\ndb0:keys=2,expires=1,current_version=%d\r\n"+
+		"db2:keys=1,expires=1,current_version=%d\r\n", db0.CurrentVersion(), db2.CurrentVersion())
+	s.Expect(fmt.Sprintf("$%d\r\n%s\r\n", len(info), info), "INFO", "keyspace")
+
+	s.Expect("+OK\r\n", "FLUSHDB")
+	info = fmt.Sprintf("# Keyspace\r\ndb0:keys=2,expires=1,current_version=%d\r\n", db0.CurrentVersion())
+	s.Expect(fmt.Sprintf("$%d\r\n%s\r\n", len(info), info), "INFO", "keyspace")
+}
+
+// TestInfo_KeyspaceWithoutDatabases verifies a context with a single engine
+// reports it as db0
+func TestInfo_KeyspaceWithoutDatabases(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll, admin.RegisterAll)
+	s.Expect("+OK\r\n", "SET", "a", "1")
+	s.Ctx.Databases = nil
+
+	info := fmt.Sprintf("# Keyspace\r\ndb0:keys=1,expires=0,current_version=%d\r\n", s.Ctx.Engine.CurrentVersion())
+	s.Expect(fmt.Sprintf("$%d\r\n%s\r\n", len(info), info), "INFO", "keyspace")
+}
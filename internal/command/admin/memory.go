@@ -0,0 +1,54 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// Memory dispatches MEMORY subcommands.
+// Usage: MEMORY DOCTOR
+func Memory(ctx *command.Context, cmd *protocol.Command) command.Result {
+	sub := strings.ToUpper(string(cmd.Args()[0]))
+	switch sub {
+	case "DOCTOR":
+		if len(cmd.Args()) != 1 {
+			return protocol.NewError("ERR wrong number of arguments for 'memory|doctor' command")
+		}
+		return memoryDoctor(ctx)
+	default:
+		return protocol.NewError("ERR unknown subcommand '" + string(cmd.Args()[0]) + "'. Try MEMORY DOCTOR.")
+	}
+}
+
+// memoryDoctor reports, per compression pattern, how many bytes compression saves
+func memoryDoctor(ctx *command.Context) command.Result {
+	reporter, ok := ctx.Engine.(storage.StatsReporter)
+	if !ok {
+		return protocol.NewError("ERR " + storage.ErrUnsupported.Error())
+	}
+	var report strings.Builder
+	report.WriteString("Compression report:\n")
+	for _, stats := range reporter.CompressionStats() {
+		fmt.Fprintf(&report,
+			"pattern=%s codec=%s versions=%d compressed=%d logical_bytes=%d stored_bytes=%d saved_bytes=%d ratio=%.2f\n",
+			stats.Pattern, stats.Codec, stats.Versions, stats.Compressed,
+			stats.LogicalBytes, stats.StoredBytes, stats.Saved(), stats.Ratio())
+	}
+	return protocol.NewBulkString([]byte(report.String()))
+}
+
+func MemorySpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "MEMORY",
+		Handler:     command.HandlerFunc(Memory),
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Description: "Memory introspection: MEMORY DOCTOR",
+		ReadOnly:    true,
+		Mutates:     false,
+	}
+}
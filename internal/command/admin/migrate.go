@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+var errSyntax = errors.New("ERR syntax error")
+
+// Migrate moves keys to another node of the cluster together with their full
+// version histories. The moved versions keep their numbers and timestamps on
+// the target, which records the move as a new version, and the keys are
+// removed here once the target holds them. With COPY they stay here, with
+// REPLACE a live key of the same name on the target is replaced. Replies
+// NOKEY when none of the keys exists.
+// Usage: MIGRATE host port key|"" destination-db timeout [COPY] [REPLACE]
+// [AUTH password] [AUTH2 username password] [KEYS key [key ...]]
+func Migrate(ctx *command.Context, cmd *protocol.Command) command.Result {
+	if ctx.Cluster == nil {
+		return protocol.NewError(errClusterDisabled.Error())
+	}
+	args := cmd.Args()
+	port, err := strconv.ParseUint(string(args[1]), 10, 16)
+	if err != nil {
+		return protocol.NewError("ERR Invalid port: " + string(args[1]))
+	}
+	db, err := strconv.Atoi(string(args[3]))
+	if err != nil || db < 0 {
+		return protocol.NewError("ERR value is not an integer or out of range")
+	}
+	timeout, err := strconv.ParseInt(string(args[4]), 10, 64)
+	if err != nil || timeout < 0 {
+		return protocol.NewError("ERR value is not an integer or out of range")
+	}
+	if timeout == 0 {
+		timeout = 1000
+	}
+	m := &command.Migration{
+		Addr:    net.JoinHostPort(string(args[0]), strconv.FormatUint(port, 10)),
+		DB:      db,
+		Timeout: time.Duration(timeout) * time.Millisecond,
+	}
+
+	for i := 5; i < len(args); i++ {
+		switch opt := strings.ToUpper(string(args[i])); {
+		case opt == "COPY":
+			m.Copy = true
+		case opt == "REPLACE":
+			m.Replace = true
+		case opt == "AUTH" && i+1 < len(args):
+			m.Password = string(args[i+1])
+			i++
+		case opt == "AUTH2" && i+2 < len(args):
+			m.Username, m.Password = string(args[i+1]), string(args[i+2])
+			i += 2
+		case opt == "KEYS":
+			if len(args[2]) != 0 {
+				return protocol.NewError("ERR When using MIGRATE KEYS option, the key argument must be set to the empty string")
+			}
+			for _, key := range args[i+1:] {
+				m.Keys = append(m.Keys, string(key))
+			}
+			i = len(args)
+		default:
+			return protocol.NewError(errSyntax.Error())
+		}
+	}
+	if len(m.Keys) == 0 {
+		if len(args[2]) == 0 {
+			return protocol.NewError(errSyntax.Error())
+		}
+		m.Keys = []string{string(args[2])}
+	}
+
+	moved, err := ctx.Cluster.Migrate(ctx, m)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if moved == 0 {
+		return protocol.NewSimpleString("NOKEY")
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+// RestoreHistory installs a key MIGRATE sent from another node with its
+// history. The source sends ASKING first, so the key lands while the slot is
+// still being imported. Fails with BUSYKEY when the key has a live value here,
+// unless REPLACE is given.
+// Usage: RESTORE-HISTORY key payload [REPLACE]
+func RestoreHistory(ctx *command.Context, cmd *protocol.Command) command.Result {
+	if ctx.Cluster == nil {
+		return protocol.NewError(errClusterDisabled.Error())
+	}
+	args := cmd.Args()
+	replace := false
+	if len(args) == 3 {
+		if !strings.EqualFold(string(args[2]), "REPLACE") {
+			return protocol.NewError(errSyntax.Error())
+		}
+		replace = true
+	}
+	if err := ctx.Cluster.Import(ctx, string(args[0]), args[1], replace); err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func MigrateSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "MIGRATE",
+		Handler:     command.HandlerFunc(Migrate),
+		MinArgs:     5,
+		MaxArgs:     -1,
+		Description: "Move keys with their histories to another cluster node: MIGRATE host port key|\"\" db timeout [COPY] [REPLACE] [AUTH password] [AUTH2 username password] [KEYS key ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+	}
+}
+
+func RestoreHistorySpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "RESTORE-HISTORY",
+		Handler:     command.HandlerFunc(RestoreHistory),
+		MinArgs:     2,
+		MaxArgs:     3,
+		Description: "Install a key migrated from another cluster node with its history: RESTORE-HISTORY key payload [REPLACE]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
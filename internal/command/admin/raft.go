@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// Raft inspects and changes the membership of the server's consensus cluster.
+// NODES lists every member as [id, address, role], ADDNODE and REMOVENODE
+// change one member at a time and return once the change is committed.
+// Usage: RAFT NODES | RAFT ADDNODE id host:port | RAFT REMOVENODE id
+func Raft(ctx *command.Context, cmd *protocol.Command) command.Result {
+	if ctx.Consensus == nil {
+		return protocol.NewError("ERR this server is not part of a raft cluster")
+	}
+	args := cmd.Args()
+	sub := strings.ToUpper(string(args[0]))
+	arity := map[string]int{"NODES": 1, "ADDNODE": 3, "REMOVENODE": 2}
+	want, known := arity[sub]
+	if !known {
+		return protocol.NewError("ERR unknown subcommand '" + string(args[0]) + "'. Try RAFT NODES|ADDNODE|REMOVENODE.")
+	}
+	if len(args) != want {
+		return protocol.NewError("ERR wrong number of arguments for 'raft|" + strings.ToLower(sub) + "' command")
+	}
+
+	var err error
+	switch sub {
+	case "NODES":
+		var nodes []protocol.RESPValue
+		for _, node := range ctx.Consensus.Nodes() {
+			role := "follower"
+			if node.Leader {
+				role = "leader"
+			}
+			nodes = append(nodes, protocol.NewArray([]protocol.RESPValue{
+				protocol.NewBulkString([]byte(node.ID)),
+				protocol.NewBulkString([]byte(node.Addr)),
+				protocol.NewBulkString([]byte(role)),
+			}))
+		}
+		return protocol.NewArray(nodes)
+	case "ADDNODE":
+		err = ctx.Consensus.AddNode(string(args[1]), string(args[2]))
+	default:
+		err = ctx.Consensus.RemoveNode(string(args[1]))
+	}
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func RaftSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "RAFT",
+		Handler:     command.HandlerFunc(Raft),
+		MinArgs:     1,
+		MaxArgs:     3,
+		Description: "Manage the raft cluster: RAFT NODES | RAFT ADDNODE id host:port | RAFT REMOVENODE id",
+		ReadOnly:    false,
+		Mutates:     false,
+	}
+}
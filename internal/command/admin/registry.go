@@ -0,0 +1,14 @@
+package admin
+
+import "github.com/ElshadHu/verdis/internal/command"
+
+// RegisterAll adds all command specs into the router.
+func RegisterAll(router *command.Router) {
+	router.Register(MemorySpec())
+	router.Register(InfoSpec())
+	router.Register(ReplicaOfSpec())
+	router.Register(RaftSpec())
+	router.Register(ClusterSpec())
+	router.Register(MigrateSpec())
+	router.Register(RestoreHistorySpec())
+}
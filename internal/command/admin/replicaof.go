@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// ReplicaOf makes the server a read-only replica of another one, or a leader
+// again with NO ONE. The replica loads a snapshot of the leader and then
+// applies its writes with their original versions.
+// Usage: REPLICAOF host port | REPLICAOF NO ONE
+func ReplicaOf(ctx *command.Context, cmd *protocol.Command) command.Result {
+	if ctx.Replication == nil {
+		return protocol.NewError("ERR replication is not available")
+	}
+	host, port := string(cmd.Args()[0]), string(cmd.Args()[1])
+
+	addr := ""
+	if !strings.EqualFold(host, "no") || !strings.EqualFold(port, "one") {
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			return protocol.NewError("ERR Invalid master port")
+		}
+		addr = net.JoinHostPort(host, port)
+	}
+	if err := ctx.Replication.ReplicaOf(addr); err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func ReplicaOfSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "REPLICAOF",
+		Handler:     command.HandlerFunc(ReplicaOf),
+		MinArgs:     2,
+		MaxArgs:     2,
+		Description: "Replicate another server: REPLICAOF host port | REPLICAOF NO ONE",
+		ReadOnly:    false,
+		Mutates:     false,
+	}
+}
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNotInteger is the reply for an argument that is not a base 10 integer in range
+var ErrNotInteger = errors.New("ERR value is not an integer or out of range")
+
+// ParseInt parses a base 10 integer the way Redis does: no leading '+',
+// no leading zeros and no surrounding whitespace
+func ParseInt(b []byte) (int64, error) {
+	if len(b) == 0 || len(b) > 20 || b[0] == '+' {
+		return 0, ErrNotInteger
+	}
+	if len(b) > 1 && b[0] == '0' {
+		return 0, ErrNotInteger
+	}
+	if len(b) > 1 && b[0] == '-' && b[1] == '0' {
+		return 0, ErrNotInteger
+	}
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	return n, nil
+}
+
+// ParseVersion parses a version argument
+func ParseVersion(arg []byte) (uint64, error) {
+	version, err := strconv.ParseUint(string(arg), 10, 64)
+	if err != nil {
+		return 0, errors.New("ERR invalid version number: " + string(arg))
+	}
+	return version, nil
+}
+
+// SplitAtVersion strips a trailing "AT version" clause from args. ok is false when
+// the clause is absent, in which case args are returned unchanged.
+func SplitAtVersion(args [][]byte) (rest [][]byte, version uint64, ok bool, err error) {
+	n := len(args)
+	if n < 2 || !strings.EqualFold(string(args[n-2]), "AT") {
+		return args, 0, false, nil
+	}
+	version, err = ParseVersion(args[n-1])
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return args[:n-2], version, true, nil
+}
+
+// MaxCommentSize is the longest note a COMMENT clause may attach to a write
+const MaxCommentSize = 1024
+
+// SplitComment strips a trailing "COMMENT text" clause from args. The clause
+// only counts after the command's fixed arguments, so "SET COMMENT x" still
+// sets the key COMMENT. comment is empty when the clause is absent, in which
+// case args are returned unchanged.
+func SplitComment(args [][]byte, fixed int) (rest [][]byte, comment string, err error) {
+	n := len(args)
+	if n < fixed+2 || !strings.EqualFold(string(args[n-2]), "COMMENT") {
+		return args, "", nil
+	}
+	if len(args[n-1]) > MaxCommentSize {
+		return nil, "", fmt.Errorf("ERR comment exceeds %d bytes", MaxCommentSize)
+	}
+	return args[:n-2], string(args[n-1]), nil
+}
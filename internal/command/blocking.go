@@ -0,0 +1,84 @@
+package command
+
+import "time"
+
+// Block runs try until it succeeds, parking between attempts until one of keys
+// is written. The keys are watched before every attempt, so a write landing
+// between a failed attempt and parking still wakes the caller. A zero timeout
+// waits forever. ok is false when the timeout passes or the server shuts down first.
+// With NoWait set it tries once and its caller waits with waitBlocked.
+func Block(ctx *Context, keys []string, timeout time.Duration, try func() (Result, bool)) (Result, bool) {
+	if ctx.NoWait {
+		result, ok := try()
+		if !ok {
+			ctx.Blocked = &Blocked{Keys: keys, Timeout: timeout}
+		}
+		return result, ok
+	}
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		written, cancel := ctx.Engine.Watch(keys...)
+		result, ok := try()
+		if ok {
+			cancel()
+			return result, true
+		}
+
+		if ctx.OnBlock != nil {
+			ctx.OnBlock()
+		}
+		select {
+		case <-written:
+			cancel()
+		case <-expired:
+			cancel()
+			return nil, false
+		case <-ctx.Done:
+			cancel()
+			return nil, false
+		}
+	}
+}
+
+// waitBlocked waits for a command that ran with NoWait and found nothing to
+// take: it runs attempt again each time one of the keys it waits for is
+// written, until the command succeeds, the timeout passes or the server shuts
+// down, and returns the last reply. Keys are watched before every attempt,
+// like Block does.
+func waitBlocked(ctx *Context, blocked *Blocked, attempt func() (Result, *Blocked)) Result {
+	var expired <-chan time.Time
+	if blocked.Timeout > 0 {
+		timer := time.NewTimer(blocked.Timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		written, cancel := ctx.Engine.Watch(blocked.Keys...)
+		result, again := attempt()
+		if again == nil {
+			cancel()
+			return result
+		}
+
+		if ctx.OnBlock != nil {
+			ctx.OnBlock()
+		}
+		select {
+		case <-written:
+			cancel()
+		case <-expired:
+			cancel()
+			return result
+		case <-ctx.Done:
+			cancel()
+			return result
+		}
+	}
+}
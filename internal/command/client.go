@@ -0,0 +1,58 @@
+package command
+
+import (
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// Client is the identity of a connection. Every version the connection writes
+// records it as its author.
+type Client struct {
+	ID   int64
+	Addr string
+
+	// LibName and LibVer are reported by client libraries with CLIENT SETINFO
+	LibName string
+	LibVer  string
+
+	name     string
+	identity string
+	author   *storage.Author
+}
+
+func NewClient(id int64, addr string) *Client {
+	c := &Client{ID: id, Addr: addr}
+	c.refresh()
+	return c
+}
+
+// Name returns the name set with CLIENT SETNAME
+func (c *Client) Name() string {
+	return c.name
+}
+
+// SetName changes the connection name recorded on future writes
+func (c *Client) SetName(name string) {
+	c.name = name
+	c.refresh()
+}
+
+// Identity returns the authenticated or declared user
+func (c *Client) Identity() string {
+	return c.identity
+}
+
+// SetIdentity changes the user recorded on future writes
+func (c *Client) SetIdentity(identity string) {
+	c.identity = identity
+	c.refresh()
+}
+
+// Author returns the author recorded on the connection's writes
+func (c *Client) Author() *storage.Author {
+	return c.author
+}
+
+// refresh replaces the author, versions already written keep the old one
+func (c *Client) refresh() {
+	c.author = &storage.Author{Identity: c.identity, Name: c.name, Addr: c.Addr}
+}
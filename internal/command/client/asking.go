@@ -0,0 +1,29 @@
+package client
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// Asking lets the connection's next command use a slot this node is still
+// importing, as a client sent here with an ASK redirection must do.
+// Usage: ASKING
+func Asking(ctx *command.Context, cmd *protocol.Command) command.Result {
+	if ctx.Cluster == nil {
+		return protocol.NewError("ERR This instance has cluster support disabled")
+	}
+	ctx.Asking = true
+	return protocol.NewSimpleString("OK")
+}
+
+func AskingSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "ASKING",
+		Handler:     command.HandlerFunc(Asking),
+		MinArgs:     0,
+		MaxArgs:     0,
+		Description: "Let the next command use a slot being imported: ASKING",
+		ReadOnly:    false,
+		Mutates:     false,
+	}
+}
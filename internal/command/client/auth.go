@@ -0,0 +1,42 @@
+package client
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// Auth authenticates the connection. The user becomes the identity recorded
+// as author of every version the connection writes from then on.
+// Usage: AUTH [username] password
+func Auth(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	if ctx.Authenticate == nil {
+		return protocol.NewError("ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?")
+	}
+
+	username, password := "default", string(args[0])
+	if len(args) == 2 {
+		username, password = string(args[0]), string(args[1])
+	}
+	if !ctx.Authenticate(username, password) {
+		return protocol.NewError("WRONGPASS invalid username-password pair or user is disabled.")
+	}
+	ctx.Authenticated = true
+	if ctx.Client != nil {
+		ctx.Client.SetIdentity(username)
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func AuthSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "AUTH",
+		Handler:     command.HandlerFunc(Auth),
+		MinArgs:     1,
+		MaxArgs:     2,
+		Description: "Authenticate the connection and record the user on its writes.",
+		ReadOnly:    false,
+		Mutates:     false,
+		NoAuth:      true,
+	}
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// Client dispatches CLIENT subcommands.
+// Usage: CLIENT ID | GETNAME | SETNAME name | SETINFO attr value | INFO
+func Client(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	sub := strings.ToUpper(string(args[0]))
+	arity := map[string]int{"ID": 1, "GETNAME": 1, "SETNAME": 2, "SETINFO": 3, "INFO": 1}
+	want, known := arity[sub]
+	if !known {
+		return protocol.NewError("ERR unknown subcommand '" + string(args[0]) + "'. Try CLIENT ID|GETNAME|SETNAME|SETINFO|INFO.")
+	}
+	if len(args) != want {
+		return protocol.NewError("ERR wrong number of arguments for 'client|" + strings.ToLower(sub) + "' command")
+	}
+	if ctx.Client == nil {
+		return protocol.NewError("ERR no client bound to this context")
+	}
+
+	switch sub {
+	case "ID":
+		return protocol.NewInteger(ctx.Client.ID)
+	case "GETNAME":
+		if ctx.Client.Name() == "" {
+			return protocol.NewNullBulkString()
+		}
+		return protocol.NewBulkString([]byte(ctx.Client.Name()))
+	case "SETNAME":
+		return setName(ctx, string(args[1]))
+	case "SETINFO":
+		return setInfo(ctx, string(args[1]), string(args[2]))
+	default:
+		return protocol.NewBulkString([]byte(info(ctx) + "\n"))
+	}
+}
+
+// setName names the connection, an empty name removes it
+func setName(ctx *command.Context, name string) command.Result {
+	if !validName(name) {
+		return protocol.NewError("ERR Client names cannot contain spaces, newlines or special characters.")
+	}
+	ctx.Client.SetName(name)
+	return protocol.NewSimpleString("OK")
+}
+
+// setInfo records library metadata, or with IDENTITY the user the client
+// declares itself as when no AUTH users are configured
+func setInfo(ctx *command.Context, attr, value string) command.Result {
+	if !validName(value) {
+		return protocol.NewError("ERR " + strings.ToLower(attr) + " cannot contain spaces, newlines or special characters.")
+	}
+	switch strings.ToUpper(attr) {
+	case "LIB-NAME":
+		ctx.Client.LibName = value
+	case "LIB-VER":
+		ctx.Client.LibVer = value
+	case "IDENTITY":
+		if ctx.Authenticate != nil {
+			return protocol.NewError("ERR identity is set by AUTH when users are configured")
+		}
+		ctx.Client.SetIdentity(value)
+	default:
+		return protocol.NewError("ERR Unrecognized option '" + attr + "'")
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+// info describes the connection in CLIENT INFO format
+func info(ctx *command.Context) string {
+	c := ctx.Client
+	fields := [][2]string{
+		{"id", itoa(c.ID)},
+		{"addr", c.Addr},
+		{"name", c.Name()},
+		{"user", c.Identity()},
+		{"db", itoa(int64(ctx.DB))},
+		{"lib-name", c.LibName},
+		{"lib-ver", c.LibVer},
+	}
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f[0] + "=" + f[1]
+	}
+	return strings.Join(parts, " ")
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+// validName reports whether s is made of printable characters without spaces
+func validName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] <= ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func ClientSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "CLIENT",
+		Handler:     command.HandlerFunc(Client),
+		MinArgs:     1,
+		MaxArgs:     3,
+		Description: "Connection management: CLIENT ID|GETNAME|SETNAME|SETINFO|INFO",
+		ReadOnly:    false,
+		Mutates:     false,
+	}
+}
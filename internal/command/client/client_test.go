@@ -0,0 +1,70 @@
+package client_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/command/client"
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/command/transaction"
+	"github.com/ElshadHu/verdis/internal/command/version"
+)
+
+// authenticated returns a session whose server has the user alice configured
+func authenticated(t *testing.T) *commandtest.Session {
+	s := commandtest.NewSession(t, standard.RegisterAll, version.RegisterAll, transaction.RegisterAll, client.RegisterAll)
+	s.Ctx.Client = command.NewClient(1, "127.0.0.1:5000")
+	s.Ctx.Authenticate = func(username, password string) bool {
+		return username == "alice" && password == "secret"
+	}
+	return s
+}
+
+// TestAuth_Required verifies that with users configured a connection runs
+// nothing but AUTH and HELLO until it authenticated, not even MULTI
+func TestAuth_Required(t *testing.T) {
+	s := authenticated(t)
+	s.Expect("-NOAUTH Authentication required.\r\n", "GET", "k")
+	s.Expect("-NOAUTH Authentication required.\r\n", "MULTI")
+	s.Expect("-NOAUTH Authentication required.\r\n", "SET", "k", "v")
+	s.Expect("-NOAUTH Authentication required.\r\n", "EXEC")
+	s.Expect("-ERR unknown command 'NOPE'\r\n", "NOPE")
+	if reply := s.Do("HELLO", "3"); !strings.HasPrefix(reply, "-NOAUTH HELLO must be called") {
+		t.Errorf("HELLO before AUTH = %q, want NOAUTH", reply)
+	}
+	s.Expect("-WRONGPASS invalid username-password pair or user is disabled.\r\n", "AUTH", "alice", "wrong")
+	s.Expect("-NOAUTH Authentication required.\r\n", "GET", "k")
+
+	s.Expect("+OK\r\n", "AUTH", "alice", "secret")
+	s.Expect("+OK\r\n", "MULTI")
+	s.Expect("+QUEUED\r\n", "SET", "k", "v")
+	s.Expect("*1\r\n+OK\r\n", "EXEC")
+	if history := s.Do("HISTORY", "k"); !strings.Contains(history, "user=alice") {
+		t.Errorf("HISTORY k = %q, want the write attributed to alice", history)
+	}
+
+	other := s.Fork()
+	other.Ctx.Authenticate = s.Ctx.Authenticate
+	other.Expect("-NOAUTH Authentication required.\r\n", "GET", "k")
+	if reply := other.Do("HELLO", "3", "AUTH", "alice", "secret"); !strings.HasPrefix(reply, "%") {
+		t.Errorf("HELLO with AUTH = %q, want the server map", reply)
+	}
+	other.Expect("$1\r\nv\r\n", "GET", "k")
+}
+
+// TestAuth_NoUsers verifies a server without users needs no AUTH and refuses it
+func TestAuth_NoUsers(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll, client.RegisterAll)
+	s.Ctx.Client = command.NewClient(1, "127.0.0.1:5000")
+	s.Expect("+OK\r\n", "SET", "k", "v")
+	if reply := s.Do("AUTH", "secret"); !strings.HasPrefix(reply, "-ERR AUTH <password> called without any password") {
+		t.Errorf("AUTH without users = %q", reply)
+	}
+	s.Expect("+OK\r\n", "CLIENT", "SETINFO", "IDENTITY", "bob")
+	s.Expect("+OK\r\n", "CLIENT", "SETNAME", "worker")
+	s.Expect("$6\r\nworker\r\n", "CLIENT", "GETNAME")
+	s.Expect(":1\r\n", "CLIENT", "ID")
+	s.Expect("-ERR Client names cannot contain spaces, newlines or special characters.\r\n", "CLIENT", "SETNAME", "a b")
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// helloCompatVersion is the Redis version whose replies clients can expect,
+// reported by HELLO
+const helloCompatVersion = "7.2.0"
+
+// Hello negotiates the RESP version of the connection, optionally
+// authenticating and naming it on the way, and replies with a map describing
+// the server in the negotiated version. Without protover the version stays.
+// Usage: HELLO [protover [AUTH username password] [SETNAME clientname]]
+func Hello(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	version := ctx.Protocol
+	if len(args) > 0 {
+		n, err := strconv.Atoi(string(args[0]))
+		if err != nil {
+			return protocol.NewError("ERR Protocol version is not an integer or out of range")
+		}
+		if n != int(protocol.RESP2) && n != int(protocol.RESP3) {
+			return protocol.NewError("NOPROTO unsupported protocol version")
+		}
+		version = protocol.Version(n)
+		args = args[1:]
+	}
+
+	var name string
+	var setName bool
+	for i := 0; i < len(args); i++ {
+		switch opt := strings.ToUpper(string(args[i])); {
+		case opt == "AUTH" && i+2 < len(args):
+			if ctx.Authenticate == nil {
+				return protocol.NewError("ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?")
+			}
+			username, password := string(args[i+1]), string(args[i+2])
+			if !ctx.Authenticate(username, password) {
+				return protocol.NewError("WRONGPASS invalid username-password pair or user is disabled.")
+			}
+			ctx.Authenticated = true
+			if ctx.Client != nil {
+				ctx.Client.SetIdentity(username)
+			}
+			i += 2
+		case opt == "SETNAME" && i+1 < len(args):
+			name, setName = string(args[i+1]), true
+			if !validName(name) {
+				return protocol.NewError("ERR Client names cannot contain spaces, newlines or special characters.")
+			}
+			i++
+		default:
+			return protocol.NewError("ERR Syntax error in HELLO option '" + string(args[i]) + "'")
+		}
+	}
+	if ctx.Authenticate != nil && !ctx.Authenticated {
+		return protocol.NewError("NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time")
+	}
+	if setName && ctx.Client != nil {
+		ctx.Client.SetName(name)
+	}
+	ctx.Protocol = version
+
+	var id int64
+	if ctx.Client != nil {
+		id = ctx.Client.ID
+	}
+	mode := "standalone"
+	if ctx.Cluster != nil {
+		mode = "cluster"
+	}
+	role := "master"
+	if ctx.Replication != nil && ctx.Replication.ReadOnly() {
+		role = "replica"
+	}
+	bulk := func(s string) protocol.RESPValue { return protocol.NewBulkString([]byte(s)) }
+	return protocol.NewMap([]protocol.MapEntry{
+		{Key: bulk("server"), Value: bulk("verdis")},
+		{Key: bulk("version"), Value: bulk(helloCompatVersion)},
+		{Key: bulk("proto"), Value: protocol.NewInteger(int64(version))},
+		{Key: bulk("id"), Value: protocol.NewInteger(id)},
+		{Key: bulk("mode"), Value: bulk(mode)},
+		{Key: bulk("role"), Value: bulk(role)},
+		{Key: bulk("modules"), Value: protocol.NewArray([]protocol.RESPValue{})},
+	})
+}
+
+func HelloSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "HELLO",
+		Handler:     command.HandlerFunc(Hello),
+		MinArgs:     0,
+		MaxArgs:     6,
+		Description: "Negotiate the RESP version: HELLO [protover [AUTH username password] [SETNAME clientname]]",
+		ReadOnly:    false,
+		Mutates:     false,
+		NoAuth:      true,
+	}
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// ReadOnly lets the connection's reads be served from this node's copy without
+// confirming with the cluster leader first. Reads get faster and keep working
+// without a leader, but may miss the latest writes.
+// Usage: READONLY
+func ReadOnly(ctx *command.Context, cmd *protocol.Command) command.Result {
+	ctx.StaleReads = true
+	return protocol.NewSimpleString("OK")
+}
+
+// ReadWrite makes the connection's reads linearizable again, the default.
+// Usage: READWRITE
+func ReadWrite(ctx *command.Context, cmd *protocol.Command) command.Result {
+	ctx.StaleReads = false
+	return protocol.NewSimpleString("OK")
+}
+
+func ReadOnlySpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "READONLY",
+		Handler:     command.HandlerFunc(ReadOnly),
+		MinArgs:     0,
+		MaxArgs:     0,
+		Description: "Allow stale reads from this node on the connection: READONLY",
+		ReadOnly:    false,
+		Mutates:     false,
+	}
+}
+
+func ReadWriteSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "READWRITE",
+		Handler:     command.HandlerFunc(ReadWrite),
+		MinArgs:     0,
+		MaxArgs:     0,
+		Description: "Make the connection's reads linearizable again: READWRITE",
+		ReadOnly:    false,
+		Mutates:     false,
+	}
+}
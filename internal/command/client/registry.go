@@ -0,0 +1,13 @@
+package client
+
+import "github.com/ElshadHu/verdis/internal/command"
+
+// RegisterAll adds all command specs into the router.
+func RegisterAll(router *command.Router) {
+	router.Register(ClientSpec())
+	router.Register(AuthSpec())
+	router.Register(ReadOnlySpec())
+	router.Register(ReadWriteSpec())
+	router.Register(AskingSpec())
+	router.Register(HelloSpec())
+}
@@ -0,0 +1,159 @@
+package command
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/cluster"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// Cluster shards the keyspace over the nodes of a Redis Cluster compatible
+// cluster, each serving the keys of the hash slots it owns
+type Cluster interface {
+	// Slot reports how this node serves slot
+	Slot(slot int) SlotRoute
+	// SlotLock returns the lock commands on slot run under, MIGRATE holds it exclusively
+	SlotLock(slot int) *sync.RWMutex
+
+	// MyID returns this node's id
+	MyID() string
+	// Nodes describes the cluster in the CLUSTER NODES format
+	Nodes() string
+	// Shards lists every known node with the slots it serves
+	Shards() []ClusterNode
+	// Info returns the CLUSTER INFO fields as name:value lines
+	Info() []string
+
+	// Meet introduces this node and the node at addr to each other
+	Meet(addr string) error
+	// Forget drops a node from this node's view of the cluster for a minute
+	Forget(id string) error
+	// AddSlots makes this node serve unassigned slots
+	AddSlots(slots []int) error
+	// DelSlots stops this node from serving slots
+	DelSlots(slots []int) error
+	// SetSlot marks slot IMPORTING from or MIGRATING to the node id, assigns
+	// it to the node id (NODE) or clears both marks (STABLE)
+	SetSlot(slot int, state, id string) error
+	// SetConfigEpoch gives a node that knows no other node its config epoch
+	SetConfigEpoch(epoch uint64) error
+	// Gossip takes in a peer's CLUSTER GOSSIP and returns this node's answer
+	Gossip(args []string) ([]string, error)
+
+	// Migrate moves keys with their histories to another node, returning how many moved
+	Migrate(ctx *Context, m *Migration) (int, error)
+	// Import installs a key with the history MIGRATE shipped in payload
+	Import(ctx *Context, key string, payload []byte, replace bool) error
+}
+
+// SlotRoute is how a node serves a slot
+type SlotRoute struct {
+	// Owner is the address of the node serving the slot, "" when unassigned
+	Owner string
+	// Mine is set when this node serves the slot
+	Mine bool
+	// Migrating is the address of the node the slot is handed over to
+	Migrating string
+	// Importing is set while this node takes the slot over
+	Importing bool
+}
+
+// ClusterNode is a node of the cluster with the slots it serves
+type ClusterNode struct {
+	ID      string
+	Addr    string
+	Slots   []cluster.Range
+	Myself  bool
+	Failing bool
+}
+
+// Migration is what MIGRATE moves where
+type Migration struct {
+	Addr    string
+	DB      int
+	Keys    []string
+	Copy    bool
+	Replace bool
+	Timeout time.Duration
+
+	// Username and Password authenticate with the target when set
+	Username string
+	Password string
+}
+
+// routeKeys runs a command on keys in a sharded cluster. All keys must hash to
+// one slot. The command runs here when this node serves the slot, or imports
+// it and the client sent ASKING. Otherwise the client is sent to the slot's
+// owner with MOVED, or with ASK to the node taking the slot over once the keys
+// have left. The command runs under the slot's lock so MIGRATE never moves a
+// key from under it; a blocking command waits outside the lock and is routed
+// again on every attempt, so it follows its slot when it moves.
+func (r *Router) routeKeys(ctx *Context, spec *CommandSpec, cmd *protocol.Command, keys [][]byte, asking bool) Result {
+	slot := cluster.KeySlot(keys[0])
+	for _, key := range keys[1:] {
+		if cluster.KeySlot(key) != slot {
+			return protocol.NewError("CROSSSLOT Keys in request don't hash to the same slot")
+		}
+	}
+
+	attempt := func() (Result, *Blocked) {
+		// taken before the slot lock, like EXEC does
+		r.txMu.RLock()
+		defer r.txMu.RUnlock()
+		lock := ctx.Cluster.SlotLock(slot)
+		lock.RLock()
+		defer lock.RUnlock()
+		if redirect := redirect(ctx, slot, keys, asking); redirect != nil {
+			return redirect, nil
+		}
+		ctx.NoWait, ctx.Blocked = true, nil
+		result := spec.Handler.Execute(ctx, cmd)
+		ctx.NoWait = false
+		return result, ctx.Blocked
+	}
+	result, blocked := attempt()
+	if blocked == nil {
+		return result
+	}
+	return waitBlocked(ctx, blocked, attempt)
+}
+
+// redirect returns the error sending a command on slot elsewhere, nil when it runs here
+func redirect(ctx *Context, slot int, keys [][]byte, asking bool) Result {
+	route := ctx.Cluster.Slot(slot)
+	switch {
+	case route.Mine && route.Migrating == "":
+		return nil
+	case route.Mine:
+		switch missing := missingKeys(ctx, keys); {
+		case missing == 0:
+			return nil
+		case missing == len(keys):
+			return protocol.NewError("ASK " + strconv.Itoa(slot) + " " + route.Migrating)
+		default:
+			return protocol.NewError("TRYAGAIN Multiple keys request during rehashing of slot")
+		}
+	case route.Importing && asking:
+		if len(keys) > 1 && missingKeys(ctx, keys) > 0 {
+			return protocol.NewError("TRYAGAIN Multiple keys request during rehashing of slot")
+		}
+		return nil
+	case route.Owner == "":
+		return protocol.NewError("CLUSTERDOWN Hash slot not served")
+	default:
+		return protocol.NewError("MOVED " + strconv.Itoa(slot) + " " + route.Owner)
+	}
+}
+
+// missingKeys counts the keys without a live value here
+func missingKeys(ctx *Context, keys [][]byte) int {
+	missing := 0
+	for _, key := range keys {
+		if !ctx.Engine.Exists(string(key)) {
+			missing++
+		}
+	}
+	return missing
+}
@@ -0,0 +1,85 @@
+// Package commandtest runs commands against an in-memory engine for handler tests
+package commandtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// Databases is how many in-memory databases a session's server holds
+const Databases = 4
+
+// Session is a client connection to fresh in-memory databases
+type Session struct {
+	t      testing.TB
+	router *command.Router
+	// Ctx is the connection's context, its Engine reaches the selected database
+	Ctx *command.Context
+}
+
+// NewSession returns a connection to new databases serving the commands that
+// the given registration functions add
+func NewSession(t testing.TB, register ...func(*command.Router)) *Session {
+	t.Helper()
+	databases, err := storage.OpenDatabases(storage.BackendMemory, Databases, func(int) storage.Config { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, _ := databases.Get(0)
+	router := command.NewRouter()
+	router.SetContext(&command.Context{Engine: engine, Databases: databases})
+	for _, fn := range register {
+		fn(router)
+	}
+	return &Session{t: t, router: router, Ctx: router.NewContext()}
+}
+
+// Fork returns another connection to the same engine
+func (s *Session) Fork() *Session {
+	return &Session{t: s.t, router: s.router, Ctx: s.router.NewContext()}
+}
+
+// Do runs a command and returns the reply serialized for the protocol the
+// connection speaks
+func (s *Session) Do(args ...string) string {
+	rest := make([][]byte, len(args)-1)
+	for i, arg := range args[1:] {
+		rest[i] = []byte(arg)
+	}
+	return string(protocol.Encode(s.router.Execute(s.Ctx, protocol.NewCommand(args[0], rest)), s.Ctx.Protocol))
+}
+
+// Expect runs a command and fails the test unless it replies want
+func (s *Session) Expect(want string, args ...string) {
+	s.t.Helper()
+	if got := s.Do(args...); got != want {
+		s.t.Errorf("%v = %q, want %q", args, got, want)
+	}
+}
+
+// Version runs a command that replies with an integer, such as SET with
+// RETURNVERSION, and returns the integer as an argument for later commands
+func (s *Session) Version(args ...string) string {
+	s.t.Helper()
+	got := s.Do(args...)
+	if !strings.HasPrefix(got, ":") {
+		s.t.Fatalf("%v = %q, want an integer reply", args, got)
+	}
+	return strings.TrimSuffix(got[1:], "\r\n")
+}
+
+// Write stores value under key with the given type, for setting up keys the
+// registered commands cannot create
+func (s *Session) Write(key string, typ storage.ValueType, value []byte) {
+	s.t.Helper()
+	_, err := s.Ctx.Engine.Update(key, func(storage.Entry) (storage.Entry, error) {
+		return storage.Entry{Value: value, Type: typ, Exists: true}, nil
+	})
+	if err != nil {
+		s.t.Fatal(err)
+	}
+}
@@ -0,0 +1,51 @@
+package command
+
+import (
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// Consensus replicates write commands through a cluster's log, so every node
+// runs them in the same order
+type Consensus interface {
+	// Submit runs cmd on every node and returns its result on this one.
+	// blocked is set when a blocking command found nothing to take.
+	Submit(ctx *Context, cmd *protocol.Command) (result Result, blocked *Blocked)
+	// ReadBarrier waits until this node applied every write committed before the call
+	ReadBarrier(ctx *Context) error
+	// AddNode adds a node to the cluster
+	AddNode(id, addr string) error
+	// RemoveNode removes a node from the cluster
+	RemoveNode(id string) error
+	// Nodes lists the members of the cluster
+	Nodes() []ConsensusNode
+	// Info returns the INFO raft fields as name:value lines
+	Info() []string
+}
+
+// ConsensusNode is one member of the cluster
+type ConsensusNode struct {
+	ID     string
+	Addr   string
+	Leader bool
+}
+
+// Blocked is what a blocking command run from the log would have waited for
+type Blocked struct {
+	Keys    []string
+	Timeout time.Duration
+}
+
+// submit runs a write through the cluster log. A blocking command runs from
+// the log without waiting, so the wait happens here: the command is submitted
+// again each time one of its keys is written, until it succeeds or times out.
+func submit(ctx *Context, cmd *protocol.Command) Result {
+	result, blocked := ctx.Consensus.Submit(ctx, cmd)
+	if blocked == nil {
+		return result
+	}
+	return waitBlocked(ctx, blocked, func() (Result, *Blocked) {
+		return ctx.Consensus.Submit(ctx, cmd)
+	})
+}
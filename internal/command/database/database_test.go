@@ -0,0 +1,135 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/database"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/command/version"
+)
+
+func newSession(t *testing.T) *commandtest.Session {
+	return commandtest.NewSession(t, standard.RegisterAll, version.RegisterAll, database.RegisterAll)
+}
+
+// TestSelect_SeparateDatabases verifies each database holds its own keys and
+// counts its own versions
+func TestSelect_SeparateDatabases(t *testing.T) {
+	s := newSession(t)
+	s.Expect(":1\r\n", "SET", "k", "zero", "RETURNVERSION")
+	s.Expect(":2\r\n", "SET", "k", "zero again", "RETURNVERSION")
+
+	s.Expect("+OK\r\n", "SELECT", "3")
+	s.Expect(":0\r\n", "EXISTS", "k")
+	s.Expect(":1\r\n", "SET", "k", "three", "RETURNVERSION")
+	s.Expect("$5\r\nthree\r\n", "GET", "k")
+
+	// another connection starts on database 0
+	s.Fork().Expect("$10\r\nzero again\r\n", "GET", "k")
+
+	s.Expect("-ERR DB index is out of range\r\n", "SELECT", "4")
+	s.Expect("-ERR value is not an integer or out of range\r\n", "SELECT", "one")
+	s.Expect("$5\r\nthree\r\n", "GET", "k")
+}
+
+// TestSwapDB_ConnectionsFollowTheIndex verifies SWAPDB exchanges the data with
+// its history under connections bound to either index
+func TestSwapDB_ConnectionsFollowTheIndex(t *testing.T) {
+	s := newSession(t)
+	first := s.Version("SET", "k", "a", "RETURNVERSION")
+	s.Expect("+OK\r\n", "SET", "k", "b")
+	other := s.Fork()
+	s.Expect("+OK\r\n", "SELECT", "1")
+	s.Expect("+OK\r\n", "SET", "only", "1")
+
+	s.Expect("+OK\r\n", "SWAPDB", "0", "1")
+	s.Expect("$1\r\nb\r\n", "GET", "k")
+	s.Expect("$1\r\na\r\n", "GETV", "k", first)
+	other.Expect("$1\r\n1\r\n", "GET", "only")
+	other.Expect(":0\r\n", "EXISTS", "k")
+
+	s.Expect("+OK\r\n", "SWAPDB", "1", "1")
+	s.Expect("-ERR invalid first DB index\r\n", "SWAPDB", "x", "1")
+	s.Expect("-ERR invalid second DB index\r\n", "SWAPDB", "1", "x")
+	s.Expect("-ERR DB index is out of range\r\n", "SWAPDB", "0", "7")
+}
+
+// TestFlushDB_RestoreDB verifies FLUSHDB empties only the selected database,
+// keeps its history readable and can be undone, or restored to any version
+func TestFlushDB_RestoreDB(t *testing.T) {
+	s := newSession(t)
+	s.Expect("+OK\r\n", "SELECT", "1")
+	s.Expect("+OK\r\n", "SET", "kept", "1")
+	s.Expect("+OK\r\n", "SELECT", "0")
+	s.Expect("+OK\r\n", "SET", "a", "1")
+	before := s.Version("SET", "b", "1", "RETURNVERSION")
+	s.Expect("+OK\r\n", "SET", "b", "2")
+	s.Expect("+OK\r\n", "SET", "c", "1")
+
+	s.Expect("-ERR no FLUSHDB to restore\r\n", "RESTOREDB")
+	s.Expect("+OK\r\n", "FLUSHDB")
+	s.Expect(":0\r\n", "EXISTS", "a", "b", "c")
+	s.Expect("$1\r\n1\r\n", "GETV", "b", before)
+	s.Expect(":3\r\n", "RESTOREDB")
+	s.Expect("$1\r\n2\r\n", "GET", "b")
+
+	// a was rewritten by the undo, c did not exist yet and is deleted
+	s.Expect(":3\r\n", "RESTOREDB", before)
+	s.Expect("$1\r\n1\r\n", "GET", "b")
+	s.Expect(":0\r\n", "EXISTS", "c")
+	s.Expect(":1\r\n", "EXISTS", "a")
+
+	s.Expect("-ERR version must be positive\r\n", "RESTOREDB", "0")
+	s.Expect("-ERR version is in the future\r\n", "RESTOREDB", "1000")
+	s.Expect("+OK\r\n", "SELECT", "1")
+	s.Expect(":1\r\n", "EXISTS", "kept")
+}
+
+// TestFlushAll_EveryDatabase verifies FLUSHALL empties every database before
+// replying, with or without ASYNC, and that each one can be restored
+func TestFlushAll_EveryDatabase(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll, database.RegisterAll)
+	s.Expect("+OK\r\n", "SET", "a", "0")
+	s.Expect("+OK\r\n", "SELECT", "1")
+	s.Expect("+OK\r\n", "SET", "b", "1")
+
+	s.Expect("+OK\r\n", "FLUSHALL", "ASYNC")
+	s.Expect(":0\r\n", "EXISTS", "b")
+	s.Expect("+OK\r\n", "SELECT", "0")
+	s.Expect(":0\r\n", "EXISTS", "a")
+
+	s.Expect(":1\r\n", "RESTOREDB")
+	s.Expect("$1\r\n0\r\n", "GET", "a")
+	s.Expect("+OK\r\n", "SELECT", "1")
+	s.Expect(":1\r\n", "RESTOREDB")
+	s.Expect("$1\r\n1\r\n", "GET", "b")
+
+	s.Expect("+OK\r\n", "FLUSHALL", "SYNC", "HARD")
+	s.Expect(":0\r\n", "EXISTS", "b")
+	s.Expect("-ERR no FLUSHDB to restore\r\n", "RESTOREDB")
+	s.Expect("-ERR syntax error\r\n", "FLUSHALL", "LATER")
+}
+
+// TestMove_History verifies MOVE takes the key's history along and leaves
+// both databases untouched when the key is live in the destination
+func TestMove_History(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll, database.RegisterAll)
+	s.Expect("+OK\r\n", "SET", "k", "a")
+	s.Expect("+OK\r\n", "SET", "k", "b")
+	s.Expect(":1\r\n", "MOVE", "k", "1")
+	s.Expect(":0\r\n", "EXISTS", "k")
+	s.Expect(":0\r\n", "MOVE", "k", "1")
+
+	s.Expect("+OK\r\n", "SET", "k", "c")
+	s.Expect(":0\r\n", "MOVE", "k", "1")
+	s.Expect("$1\r\nc\r\n", "GET", "k")
+	s.Expect("+OK\r\n", "SELECT", "1")
+	s.Expect("$1\r\nb\r\n", "GET", "k")
+	history, err := s.Ctx.Engine.History("k", 0)
+	if err != nil || len(history) != 3 {
+		t.Errorf("moved key has %d versions (%v), want the 2 moved and the move", len(history), err)
+	}
+	s.Expect("-ERR source and destination objects are the same\r\n", "MOVE", "k", "1")
+	s.Expect("-ERR DB index is out of range\r\n", "MOVE", "k", "9")
+}
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	errNotInteger = errors.New("ERR value is not an integer or out of range")
+	errRange      = errors.New("ERR DB index is out of range")
+	errSameDB     = errors.New("ERR source and destination objects are the same")
+
+	// a sharded cluster only has database 0, like Redis Cluster
+	errClusterSelect = errors.New("ERR SELECT is not allowed in cluster mode")
+	errClusterSwapDB = errors.New("ERR SWAPDB is not allowed in cluster mode")
+	errClusterMove   = errors.New("ERR MOVE is not allowed in cluster mode")
+)
+
+// parseIndex parses a database index argument
+func parseIndex(arg []byte) (int, error) {
+	db, err := strconv.Atoi(string(arg))
+	if err != nil {
+		return 0, errNotInteger
+	}
+	return db, nil
+}
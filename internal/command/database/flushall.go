@@ -0,0 +1,48 @@
+package database
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// FlushAll deletes every key of every database, each one flushed like FLUSHDB
+// so RESTOREDB can undo it per database unless HARD. ASYNC is accepted for
+// compatibility, every database is flushed before the reply.
+// Usage: FLUSHALL [ASYNC | SYNC] [HARD]
+func FlushAll(ctx *command.Context, cmd *protocol.Command) command.Result {
+	hard, err := parseFlush(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if ctx.Databases == nil {
+		if err := flush(ctx.Engine, hard); err != nil {
+			return protocol.NewError("ERR " + err.Error())
+		}
+		return protocol.NewSimpleString("OK")
+	}
+	for db := range ctx.Databases.Len() {
+		engine, _ := ctx.Databases.Get(db)
+		if db == ctx.DB {
+			// the bound view, so the flush is attributed like FLUSHDB
+			engine = ctx.Engine
+		} else {
+			engine = ctx.Clocked(engine)
+		}
+		if err := flush(engine, hard); err != nil {
+			return protocol.NewError("ERR " + err.Error())
+		}
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func FlushAllSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "FLUSHALL",
+		Handler:     command.HandlerFunc(FlushAll),
+		MinArgs:     0,
+		MaxArgs:     2,
+		Description: "Delete every key of every database, recoverably unless HARD.",
+		ReadOnly:    false,
+		Mutates:     true,
+	}
+}
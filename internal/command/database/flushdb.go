@@ -0,0 +1,69 @@
+package database
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+var errSyntax = errors.New("ERR syntax error")
+
+// FlushDB deletes every key of the selected database. By default all keys are
+// tombstoned under one version, so history stays readable and RESTOREDB can
+// undo the flush. HARD drops the keys together with their history. ASYNC is
+// accepted for compatibility: the flush takes its version before the reply,
+// so commands after it never see the old keys.
+// Usage: FLUSHDB [ASYNC | SYNC] [HARD]
+func FlushDB(ctx *command.Context, cmd *protocol.Command) command.Result {
+	hard, err := parseFlush(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if err := flush(ctx.Engine, hard); err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+// parseFlush parses the options of FLUSHDB and FLUSHALL
+func parseFlush(args [][]byte) (hard bool, err error) {
+	for _, arg := range args {
+		switch strings.ToUpper(string(arg)) {
+		case "ASYNC", "SYNC":
+		case "HARD":
+			hard = true
+		default:
+			return false, errSyntax
+		}
+	}
+	return hard, nil
+}
+
+// flush tombstones every key of engine, or drops them with their history when hard
+func flush(engine storage.Engine, hard bool) error {
+	if hard {
+		engine.Drop()
+		return nil
+	}
+	flusher, ok := engine.(storage.Flusher)
+	if !ok {
+		return storage.ErrUnsupported
+	}
+	_, _, err := flusher.Flush()
+	return err
+}
+
+func FlushDBSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "FLUSHDB",
+		Handler:     command.HandlerFunc(FlushDB),
+		MinArgs:     0,
+		MaxArgs:     2,
+		Description: "Delete every key of the selected database, recoverably unless HARD.",
+		ReadOnly:    false,
+		Mutates:     true,
+	}
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// Move transfers a key with its full history to another database. The moved
+// versions keep their numbers and timestamps and the move is recorded as a new
+// version in the destination. Replies 0 when the key does not exist here or
+// already exists in the destination.
+// Usage: MOVE key db
+func Move(ctx *command.Context, cmd *protocol.Command) command.Result {
+	if ctx.Cluster != nil {
+		return protocol.NewError(errClusterMove.Error())
+	}
+	args := cmd.Args()
+	key := string(args[0])
+	db, err := parseIndex(args[1])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if db == ctx.DB {
+		return protocol.NewError(errSameDB.Error())
+	}
+	target, ok := ctx.Databases.Get(db)
+	if !ok {
+		return protocol.NewError(errRange.Error())
+	}
+	target = ctx.Clocked(target)
+
+	mover, ok := ctx.Engine.(storage.Mover)
+	if !ok {
+		return protocol.NewError("ERR " + storage.ErrUnsupported.Error())
+	}
+	_, err = mover.Move(key, target)
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound), errors.Is(err, storage.ErrKeyExists):
+		return protocol.NewInteger(0)
+	case err != nil:
+		return protocol.NewError("ERR " + err.Error())
+	}
+	return protocol.NewInteger(1)
+}
+
+func MoveSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "MOVE",
+		Handler:     command.HandlerFunc(Move),
+		MinArgs:     2,
+		MaxArgs:     2,
+		Description: "Move a key with its history to another database.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
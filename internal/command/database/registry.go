@@ -0,0 +1,13 @@
+package database
+
+import "github.com/ElshadHu/verdis/internal/command"
+
+// RegisterAll adds all command specs into the router.
+func RegisterAll(router *command.Router) {
+	router.Register(SelectSpec())
+	router.Register(SwapDBSpec())
+	router.Register(MoveSpec())
+	router.Register(FlushDBSpec())
+	router.Register(FlushAllSpec())
+	router.Register(RestoreDBSpec())
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// RestoreDB brings every key of the selected database back to its state as of
+// version under one new version, by default undoing the last FLUSHDB. Replies
+// with the number of keys rewritten.
+// Usage: RESTOREDB [version]
+func RestoreDB(ctx *command.Context, cmd *protocol.Command) command.Result {
+	var version uint64
+	if args := cmd.Args(); len(args) == 1 {
+		var err error
+		if version, err = command.ParseVersion(args[0]); err != nil {
+			return protocol.NewError(err.Error())
+		}
+		if version == 0 {
+			return protocol.NewError("ERR version must be positive")
+		}
+	}
+
+	flusher, ok := ctx.Engine.(storage.Flusher)
+	if !ok {
+		return protocol.NewError("ERR " + storage.ErrUnsupported.Error())
+	}
+	_, restored, err := flusher.Restore(version)
+	switch {
+	case errors.Is(err, storage.ErrNoFlush):
+		return protocol.NewError("ERR no FLUSHDB to restore")
+	case errors.Is(err, storage.ErrVersionNotFound):
+		return protocol.NewError("ERR version is in the future")
+	case err != nil:
+		return protocol.NewError("ERR " + err.Error())
+	}
+	return protocol.NewInteger(int64(restored))
+}
+
+func RestoreDBSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "RESTOREDB",
+		Handler:     command.HandlerFunc(RestoreDB),
+		MinArgs:     0,
+		MaxArgs:     1,
+		Description: "Restore the selected database as of a version, by default undoing the last FLUSHDB.",
+		ReadOnly:    false,
+		Mutates:     true,
+	}
+}
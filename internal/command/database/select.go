@@ -0,0 +1,37 @@
+package database
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// Select binds the connection to a numbered database.
+// Usage: SELECT index
+func Select(ctx *command.Context, cmd *protocol.Command) command.Result {
+	db, err := parseIndex(cmd.Args()[0])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if ctx.Cluster != nil && db != 0 {
+		return protocol.NewError(errClusterSelect.Error())
+	}
+	engine, ok := ctx.Databases.Get(db)
+	if !ok {
+		return protocol.NewError(errRange.Error())
+	}
+	ctx.DB = db
+	ctx.Bind(engine)
+	return protocol.NewSimpleString("OK")
+}
+
+func SelectSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SELECT",
+		Handler:     command.HandlerFunc(Select),
+		MinArgs:     1,
+		MaxArgs:     1,
+		Description: "Change the selected database for the current connection.",
+		ReadOnly:    false,
+		Mutates:     false,
+	}
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// SwapDB atomically exchanges two databases. Connections bound to either index
+// see the other database's data, with its history, from their next command.
+// Usage: SWAPDB index1 index2
+func SwapDB(ctx *command.Context, cmd *protocol.Command) command.Result {
+	if ctx.Cluster != nil {
+		return protocol.NewError(errClusterSwapDB.Error())
+	}
+	args := cmd.Args()
+	a, err := parseIndex(args[0])
+	if err != nil {
+		return protocol.NewError("ERR invalid first DB index")
+	}
+	b, err := parseIndex(args[1])
+	if err != nil {
+		return protocol.NewError("ERR invalid second DB index")
+	}
+	if err := ctx.Databases.Swap(a, b); err != nil {
+		return protocol.NewError(errRange.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func SwapDBSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SWAPDB",
+		Handler:     command.HandlerFunc(SwapDB),
+		MinArgs:     2,
+		MaxArgs:     2,
+		Description: "Swap two databases atomically.",
+		ReadOnly:    false,
+		Mutates:     true,
+	}
+}
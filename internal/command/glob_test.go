@@ -0,0 +1,56 @@
+package command_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command"
+)
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"*TICKET-42*", "fix for TICKET-42 today", true},
+		{"*TICKET-42*", "TICKET-421", true},
+		{"TICKET-4?", "TICKET-42", true},
+		{"TICKET-4?", "TICKET-4", false},
+		{"user:[0-9]*", "user:7:name", true},
+		{"user:[^0-9]*", "user:7:name", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+	}
+	for _, tt := range tests {
+		if got := command.Glob(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("Glob(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestSplitComment(t *testing.T) {
+	args := [][]byte{[]byte("k"), []byte("v"), []byte("comment"), []byte("why")}
+	rest, comment, err := command.SplitComment(args, 2)
+	if err != nil || len(rest) != 2 || comment != "why" {
+		t.Errorf("SplitComment = %q, %q, %v", rest, comment, err)
+	}
+
+	// COMMENT in place of a fixed argument is a plain argument
+	args = [][]byte{[]byte("COMMENT"), []byte("foo")}
+	if rest, comment, _ := command.SplitComment(args, 2); len(rest) != 2 || comment != "" {
+		t.Errorf("SplitComment of SET COMMENT foo = %q, %q, want both arguments kept", rest, comment)
+	}
+	if rest, comment, _ := command.SplitComment(args, 1); len(rest) != 2 || comment != "" {
+		t.Errorf("SplitComment of DEL COMMENT foo = %q, %q, want both keys kept", rest, comment)
+	}
+
+	long := []byte(strings.Repeat("x", command.MaxCommentSize+1))
+	if _, _, err := command.SplitComment([][]byte{[]byte("k"), []byte("COMMENT"), long}, 1); err == nil {
+		t.Errorf("expected an error for a comment over the limit")
+	}
+}
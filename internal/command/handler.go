@@ -2,15 +2,135 @@ package command
 
 import (
 	"fmt"
+	"time"
 
-	"github.com/ElshadHu/verdis/internal/mvcc"
 	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
 )
 
 type Result = protocol.RESPValue
 
+// Context is the per-connection state handed to every command
 type Context struct {
-	Engine *mvcc.Engine
+	// Engine is the database the connection is bound to, resolved before each command
+	Engine storage.Engine
+
+	// Databases holds every numbered database of the server
+	Databases *storage.Databases
+
+	// DB is the index of the selected database
+	DB int
+
+	// Client is the connection's identity, nil for internal callers
+	Client *Client
+
+	// Authenticate checks credentials for AUTH, nil when no users are configured
+	Authenticate func(username, password string) bool
+
+	// Authenticated is set once the connection passed AUTH. While users are
+	// configured only NoAuth commands run before that.
+	Authenticated bool
+
+	// Replication controls the server's replication role, nil when unavailable
+	Replication Replication
+
+	// Consensus replicates writes through the cluster log, nil outside a cluster
+	Consensus Consensus
+
+	// StaleReads lets the connection read without confirming with the cluster
+	// leader first (READONLY), reads may then miss the latest writes
+	StaleReads bool
+
+	// NoWait makes blocking commands try once and record in Blocked what they
+	// would wait for, so the caller waits without holding anything: set while a
+	// command runs from the cluster log, under a slot lock or the transaction lock
+	NoWait  bool
+	Blocked *Blocked
+
+	// Cluster routes keys to the nodes of a sharded cluster, nil outside one
+	Cluster Cluster
+
+	// Asking lets the next command use a slot this node is importing (ASKING)
+	Asking bool
+
+	// Protocol is the RESP version the connection negotiated with HELLO,
+	// commands with structured replies answer RESP3 clients with maps
+	Protocol protocol.Version
+
+	// Clock returns the command's time, nil for the wall clock. Commands run
+	// from the cluster log see the time the leader logged them at.
+	Clock func() time.Time
+
+	// bound and boundAuthor are what Engine was last derived from
+	bound       storage.Engine
+	boundAuthor *storage.Author
+
+	// tx holds the commands queued since MULTI, nil outside a transaction
+	tx *transaction
+	// router made the context, EXEC holds its commands off
+	router *Router
+
+	// Done is closed when the server shuts down, blocking commands must return
+	Done <-chan struct{}
+
+	// OnBlock is called before a blocking command waits for a key, so the
+	// connection can send the replies it holds back first (nil = nothing to do)
+	OnBlock func()
+}
+
+// Bind makes engine the one commands run against, attributing writes to the
+// connection's client and judging expiry by the command's clock. The view is
+// only rebuilt when the engine or author changes.
+func (ctx *Context) Bind(engine storage.Engine) {
+	var author *storage.Author
+	if ctx.Client != nil {
+		author = ctx.Client.Author()
+	}
+	if engine == ctx.bound && author == ctx.boundAuthor && ctx.Engine != nil {
+		return
+	}
+	ctx.bound, ctx.boundAuthor = engine, author
+	ctx.Engine = ctx.Clocked(engine)
+	if author != nil {
+		ctx.Engine = storage.Attribute(ctx.Engine, author)
+	}
+}
+
+// bind binds the selected database, resolved per command so SWAPDB takes
+// effect on bound connections
+func (ctx *Context) bind() {
+	engine := ctx.bound
+	if ctx.Databases != nil {
+		engine, _ = ctx.Databases.Get(ctx.DB)
+	} else if engine == nil {
+		engine = ctx.Engine
+	}
+	ctx.Bind(engine)
+}
+
+// Clocked returns the view of engine that judges expiry at the command's time.
+// Commands reaching into another database than the bound one go through it.
+func (ctx *Context) Clocked(engine storage.Engine) storage.Engine {
+	if ctx.Clock == nil {
+		return engine
+	}
+	return storage.Clock(engine, ctx.Clock)
+}
+
+// Now returns the time a command runs at
+func (ctx *Context) Now() time.Time {
+	if ctx.Clock != nil {
+		return ctx.Clock()
+	}
+	return time.Now()
+}
+
+// Annotate returns the engine to write through so new versions record comment
+func (ctx *Context) Annotate(comment string) storage.Engine {
+	if comment == "" {
+		return ctx.Engine
+	}
+	return storage.Annotate(ctx.Engine, comment)
 }
 
 type Handler interface {
@@ -45,6 +165,17 @@ type CommandSpec struct {
 
 	// Mutates is true if this command writes data
 	Mutates bool
+
+	// Keys picks the command's keys out of its arguments, nil when it takes none
+	Keys KeyFunc
+
+	// Transaction is true for the commands that control a transaction, they
+	// run at once between MULTI and EXEC instead of being queued
+	Transaction bool
+
+	// NoAuth is true for the commands a connection may run before it
+	// authenticated, when users are configured
+	NoAuth bool
 }
 
 // Validate if command argument meet the requirements
@@ -0,0 +1,23 @@
+package hash
+
+import (
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// decode returns the hash held by entry, empty for missing keys and
+// storage.ErrWrongType when the key holds another type
+func decode(entry storage.Entry) (datastructures.Hash, error) {
+	if !entry.Exists {
+		return datastructures.Hash{}, nil
+	}
+	if entry.Type != storage.TypeHash {
+		return nil, storage.ErrWrongType
+	}
+	return datastructures.DecodeHash(entry.Value)
+}
+
+// hashEntry wraps an encoded hash as the next state of a key, keeping its expiry
+func hashEntry(current storage.Entry, h datastructures.Hash) storage.Entry {
+	return storage.Entry{Value: h.Encode(), Type: storage.TypeHash, Exists: true, ExpiresAt: current.ExpiresAt}
+}
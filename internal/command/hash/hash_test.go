@@ -0,0 +1,47 @@
+package hash_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/hash"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// TestHHistory_Entries verifies HHISTORY lists only the versions that changed
+// the field, as positional arrays to RESP2 clients and maps to RESP3 clients
+func TestHHistory_Entries(t *testing.T) {
+	s := commandtest.NewSession(t, hash.RegisterAll)
+	s.Expect(":1\r\n", "HSET", "h", "f", "a")
+	s.Expect(":1\r\n", "HSET", "h", "g", "x")
+	s.Expect(":0\r\n", "HSET", "h", "f", "bb")
+	s.Expect(":1\r\n", "HDEL", "h", "f")
+
+	history, err := s.Ctx.Engine.History("h", 0)
+	if err != nil || len(history) != 4 {
+		t.Fatalf("History = %+v, %v", history, err)
+	}
+	// newest first: the HDEL, the second HSET of f and the first one, not the HSET of g
+	changed := []struct {
+		at      int
+		removed bool
+		size    int
+	}{{0, true, 0}, {1, false, 2}, {3, false, 1}}
+
+	resp2, resp3 := "*3\r\n", "*3\r\n"
+	for _, c := range changed {
+		info := history[c.at]
+		removed, flag := 0, "f"
+		if c.removed {
+			removed, flag = 1, "t"
+		}
+		resp2 += fmt.Sprintf("*4\r\n:%d\r\n:%d\r\n:%d\r\n:%d\r\n", info.Version, info.Timestamp, removed, c.size)
+		resp3 += fmt.Sprintf("%%4\r\n$7\r\nversion\r\n:%d\r\n$9\r\ntimestamp\r\n:%d\r\n"+
+			"$7\r\nremoved\r\n#%s\r\n$4\r\nsize\r\n:%d\r\n", info.Version, info.Timestamp, flag, c.size)
+	}
+	s.Expect(resp2, "HHISTORY", "h", "f")
+
+	s.Ctx.Protocol = protocol.RESP3
+	s.Expect(resp3, "HHISTORY", "h", "f")
+}
@@ -0,0 +1,57 @@
+package hash
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// HDel removes fields from a hash as a new version. Removing the last field
+// deletes the key.
+// Usage: HDEL key field [field ...]
+func HDel(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	key := string(args[0])
+
+	var removed int64
+	_, err := ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		h, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+
+		removed = 0
+		for _, field := range args[1:] {
+			if _, exists := h[string(field)]; exists {
+				delete(h, string(field))
+				removed++
+			}
+		}
+
+		switch {
+		case removed == 0:
+			return current, storage.ErrSkipWrite
+		case len(h) == 0:
+			return storage.Entry{}, nil
+		default:
+			return hashEntry(current, h), nil
+		}
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(removed)
+}
+
+func HDelSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "HDEL",
+		Handler:     command.HandlerFunc(HDel),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Delete hash fields: HDEL key field [field ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,38 @@
+package hash
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// HGet returns the value of a hash field.
+// Usage: HGET key field
+func HGet(ctx *command.Context, cmd *protocol.Command) command.Result {
+	entry, err := ctx.Engine.Lookup(string(cmd.Args()[0]))
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	h, err := decode(entry)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	value, exists := h[string(cmd.Args()[1])]
+	if !exists {
+		return protocol.NewNullBulkString()
+	}
+	return protocol.NewBulkString(value)
+}
+
+func HGetSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "HGET",
+		Handler:     command.HandlerFunc(HGet),
+		MinArgs:     2,
+		MaxArgs:     2,
+		Description: "Get the value of a hash field.",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,38 @@
+package hash
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// HGetAll returns every field and value of a hash, fields in sorted order.
+// Usage: HGETALL key
+func HGetAll(ctx *command.Context, cmd *protocol.Command) command.Result {
+	entry, err := ctx.Engine.Lookup(string(cmd.Args()[0]))
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	h, err := decode(entry)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	result := make([]protocol.RESPValue, 0, len(h)*2)
+	for _, field := range h.Fields() {
+		result = append(result, protocol.NewBulkString([]byte(field)), protocol.NewBulkString(h[field]))
+	}
+	return protocol.NewArray(result)
+}
+
+func HGetAllSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "HGETALL",
+		Handler:     command.HandlerFunc(HGetAll),
+		MinArgs:     1,
+		MaxArgs:     1,
+		Description: "Get all fields and values of a hash.",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,45 @@
+package hash
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// HGetVersion returns the value of a hash field at a specific version.
+// Usage: HGETV key field version
+func HGetVersion(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	version, err := command.ParseVersion(args[2])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	entry, err := ctx.Engine.LookupAt(string(args[0]), version)
+	if err != nil {
+		// return nil for not found
+		return protocol.NewNullBulkString()
+	}
+	h, err := decode(entry)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	value, exists := h[string(args[1])]
+	if !exists {
+		return protocol.NewNullBulkString()
+	}
+	return protocol.NewBulkString(value)
+}
+
+func HGetVersionSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "HGETV",
+		Handler:     command.HandlerFunc(HGetVersion),
+		MinArgs:     3,
+		MaxArgs:     3,
+		Description: "Get the value of a hash field at a specific version.",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
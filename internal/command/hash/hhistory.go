@@ -0,0 +1,93 @@
+package hash
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// fieldState is the value of one hash field at one version of the key
+type fieldState struct {
+	info    storage.VersionInfo
+	present bool
+	value   []byte
+}
+
+// HHistory returns the versions at which a single hash field changed, newest
+// first, each as {version, timestamp, removed, size}: a map for RESP3 clients
+// and an array of the values in this order for RESP2 clients.
+// Usage: HHISTORY key field [count]
+func HHistory(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	key, field := string(args[0]), string(args[1])
+
+	maxEntries := 0
+	if len(args) > 2 {
+		count, err := strconv.Atoi(string(args[2]))
+		if err != nil || count < 0 {
+			return protocol.NewError("ERR invalid count")
+		}
+		maxEntries = count
+	}
+
+	var states []fieldState
+	var walkErr error
+	err := ctx.Engine.WalkHistory(key, func(info storage.VersionInfo, entry storage.Entry) bool {
+		state := fieldState{info: info}
+		// versions where the key was deleted or held another type have no field
+		if entry.Exists && entry.Type == storage.TypeHash {
+			h, err := decode(entry)
+			if err != nil {
+				walkErr = err
+				return false
+			}
+			state.value, state.present = h[field]
+		}
+		states = append(states, state)
+		return true
+	})
+	if err == nil {
+		err = walkErr
+	}
+	if err != nil {
+		return protocol.NewNullBulkString()
+	}
+
+	var result []protocol.RESPValue
+	for i, state := range states {
+		var older fieldState
+		if i+1 < len(states) {
+			older = states[i+1]
+		}
+		if state.present == older.present && bytes.Equal(state.value, older.value) {
+			continue
+		}
+
+		result = append(result, command.Entry(ctx, []command.Field{
+			{Name: "version", Value: protocol.NewInteger(int64(state.info.Version))},
+			{Name: "timestamp", Value: protocol.NewInteger(state.info.Timestamp)},
+			{Name: "removed", Value: protocol.NewBoolean(!state.present)},
+			{Name: "size", Value: protocol.NewInteger(int64(len(state.value)))},
+		}))
+		if maxEntries > 0 && len(result) == maxEntries {
+			break
+		}
+	}
+	return protocol.NewArray(result)
+}
+
+func HHistorySpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "HHISTORY",
+		Handler:     command.HandlerFunc(HHistory),
+		MinArgs:     2,
+		MaxArgs:     3,
+		Description: "Get the versions at which a hash field changed: HHISTORY key field [count]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
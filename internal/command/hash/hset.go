@@ -0,0 +1,52 @@
+package hash
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// HSet sets fields of a hash as a new version and returns how many fields were added.
+// Usage: HSET key field value [field value ...]
+func HSet(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	if len(args)%2 != 1 {
+		return protocol.NewError("ERR wrong number of arguments for 'hset' command")
+	}
+	key := string(args[0])
+
+	var added int64
+	_, err := ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		h, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+
+		added = 0
+		for i := 1; i < len(args); i += 2 {
+			field := string(args[i])
+			if _, exists := h[field]; !exists {
+				added++
+			}
+			h[field] = args[i+1]
+		}
+		return hashEntry(current, h), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(added)
+}
+
+func HSetSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "HSET",
+		Handler:     command.HandlerFunc(HSet),
+		MinArgs:     3,
+		MaxArgs:     -1,
+		Description: "Set hash fields: HSET key field value [field value ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
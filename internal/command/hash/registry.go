@@ -0,0 +1,13 @@
+package hash
+
+import "github.com/ElshadHu/verdis/internal/command"
+
+// RegisterAll adds all command specs into the router.
+func RegisterAll(router *command.Router) {
+	router.Register(HSetSpec())
+	router.Register(HGetSpec())
+	router.Register(HGetAllSpec())
+	router.Register(HDelSpec())
+	router.Register(HHistorySpec())
+	router.Register(HGetVersionSpec())
+}
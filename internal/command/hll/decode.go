@@ -0,0 +1,38 @@
+package hll
+
+import (
+	"errors"
+
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+var (
+	errNotHLL  = errors.New("WRONGTYPE Key is not a valid HyperLogLog string value.")
+	errCorrupt = errors.New("INVALIDOBJ Corrupted HLL object detected")
+)
+
+// decode returns the HyperLogLog held by entry, nil for missing keys. Like Redis,
+// HyperLogLogs are strings carrying a HYLL header, so GET and SET move them as is.
+func decode(entry storage.Entry) (*datastructures.HyperLogLog, error) {
+	if !entry.Exists {
+		return nil, nil
+	}
+	if entry.Type != storage.TypeString {
+		return nil, storage.ErrWrongType
+	}
+
+	h, err := datastructures.DecodeHyperLogLog(entry.Value)
+	switch {
+	case errors.Is(err, datastructures.ErrNotHyperLogLog):
+		return nil, errNotHLL
+	case err != nil:
+		return nil, errCorrupt
+	}
+	return h, nil
+}
+
+// hllEntry wraps an encoded HyperLogLog as the next state of a key, keeping its expiry
+func hllEntry(current storage.Entry, h *datastructures.HyperLogLog) storage.Entry {
+	return storage.Entry{Value: h.Encode(), Type: storage.TypeString, Exists: true, ExpiresAt: current.ExpiresAt}
+}
@@ -0,0 +1,93 @@
+package hll_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/hll"
+	"github.com/ElshadHu/verdis/internal/command/list"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+)
+
+func newSession(t *testing.T) *commandtest.Session {
+	return commandtest.NewSession(t, hll.RegisterAll, standard.RegisterAll, list.RegisterAll)
+}
+
+// TestPFAdd_WritesOnlyChanges verifies PFADD reports and writes a version only
+// when a register changed, so PFCOUNT AT reads every earlier estimate
+func TestPFAdd_WritesOnlyChanges(t *testing.T) {
+	s := newSession(t)
+	s.Expect(":1\r\n", "PFADD", "visitors")
+	s.Expect(":1\r\n", "PFADD", "visitors", "a", "b", "c")
+	first := strconv.FormatUint(s.Ctx.Engine.CurrentVersion(), 10)
+
+	s.Expect(":0\r\n", "PFADD", "visitors", "a", "b")
+	if got := strconv.FormatUint(s.Ctx.Engine.CurrentVersion(), 10); got != first {
+		t.Errorf("PFADD of known elements moved the version from %s to %s", first, got)
+	}
+	s.Expect(":0\r\n", "PFADD", "visitors")
+
+	s.Expect(":1\r\n", "PFADD", "visitors", "d", "e")
+	s.Expect(":5\r\n", "PFCOUNT", "visitors")
+	s.Expect(":3\r\n", "PFCOUNT", "visitors", "AT", first)
+	s.Expect(":0\r\n", "PFCOUNT", "missing")
+}
+
+// TestPFCount_Estimate verifies the estimate of a large set stays within a few
+// percent of its true size
+func TestPFCount_Estimate(t *testing.T) {
+	s := newSession(t)
+	const n = 20000
+	for i := 0; i < n; i += 100 {
+		args := []string{"PFADD", "big"}
+		for j := i; j < i+100; j++ {
+			args = append(args, "element:"+strconv.Itoa(j))
+		}
+		s.Do(args...)
+	}
+	reply := s.Do("PFCOUNT", "big")
+	count, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(reply, ":"), "\r\n"))
+	if err != nil {
+		t.Fatalf("PFCOUNT = %q", reply)
+	}
+	if count < n*97/100 || count > n*103/100 {
+		t.Errorf("PFCOUNT = %d, want about %d", count, n)
+	}
+}
+
+// TestPFMerge_Union verifies PFMERGE and a multi-key PFCOUNT both count the
+// union, the destination's own elements included
+func TestPFMerge_Union(t *testing.T) {
+	s := newSession(t)
+	s.Expect(":1\r\n", "PFADD", "monday", "a", "b", "c")
+	s.Expect(":1\r\n", "PFADD", "tuesday", "c", "d")
+	s.Expect(":1\r\n", "PFADD", "week", "e")
+
+	s.Expect(":4\r\n", "PFCOUNT", "monday", "tuesday")
+	s.Expect("+OK\r\n", "PFMERGE", "week", "monday", "tuesday", "monday")
+	s.Expect(":5\r\n", "PFCOUNT", "week")
+	s.Expect(":3\r\n", "PFCOUNT", "monday")
+
+	s.Expect("+OK\r\n", "PFMERGE", "empty")
+	s.Expect(":0\r\n", "PFCOUNT", "empty")
+
+	// HyperLogLogs are strings, so they copy through GET and SET
+	header, value, _ := strings.Cut(s.Do("GET", "week"), "\r\n")
+	size, _ := strconv.Atoi(strings.TrimPrefix(header, "$"))
+	s.Expect("+OK\r\n", "SET", "copy", value[:size])
+	s.Expect(":5\r\n", "PFCOUNT", "copy")
+}
+
+// TestPFAdd_WrongType verifies the HyperLogLog commands refuse lists and
+// strings without the HyperLogLog header
+func TestPFAdd_WrongType(t *testing.T) {
+	s := newSession(t)
+	s.Expect("+OK\r\n", "SET", "s", "plain")
+	s.Expect(":1\r\n", "RPUSH", "l", "a")
+	s.Expect("-WRONGTYPE Key is not a valid HyperLogLog string value.\r\n", "PFADD", "s", "a")
+	s.Expect("-WRONGTYPE Key is not a valid HyperLogLog string value.\r\n", "PFCOUNT", "s")
+	s.Expect("-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", "PFMERGE", "dst", "l")
+	s.Expect(":0\r\n", "EXISTS", "dst")
+}
@@ -0,0 +1,57 @@
+package hll
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// PFAdd adds elements to a HyperLogLog and returns 1 if any register changed.
+// Only changing adds are written, so every version of the key is a distinct
+// cardinality estimate.
+// Usage: PFADD key [element ...]
+func PFAdd(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+
+	version, err := ctx.Engine.Update(string(args[0]), func(current storage.Entry) (storage.Entry, error) {
+		h, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+
+		changed := h == nil
+		if h == nil {
+			h = datastructures.NewHyperLogLog()
+		}
+		for _, elem := range args[1:] {
+			if h.Add(elem) {
+				changed = true
+			}
+		}
+		if !changed {
+			return current, storage.ErrSkipWrite
+		}
+		return hllEntry(current, h), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if version == 0 {
+		return protocol.NewInteger(0)
+	}
+	return protocol.NewInteger(1)
+}
+
+func PFAddSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "PFADD",
+		Handler:     command.HandlerFunc(PFAdd),
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Description: "Add elements to a HyperLogLog: PFADD key [element ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,69 @@
+package hll
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// PFCount returns the estimated number of unique elements added to the given
+// HyperLogLogs, counting the union when several keys are given. With AT every
+// key is read as of that version.
+// Usage: PFCOUNT key [key ...] [AT version]
+func PFCount(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) == 0 {
+		return protocol.NewError("ERR wrong number of arguments for 'pfcount' command")
+	}
+
+	keys := make([]string, len(args))
+	for i, arg := range args {
+		keys[i] = string(arg)
+	}
+
+	var entries []storage.Entry
+	if atVersion {
+		entries = make([]storage.Entry, len(keys))
+		for i, key := range keys {
+			// missing keys, deleted keys and unknown versions count as empty
+			entries[i], _ = ctx.Engine.LookupAt(key, version)
+		}
+	} else {
+		entries, _ = ctx.Engine.GetMany(keys)
+	}
+
+	union := datastructures.NewHyperLogLog()
+	for _, entry := range entries {
+		h, err := decode(entry)
+		if err != nil {
+			return protocol.NewError(err.Error())
+		}
+		if h != nil {
+			union.Merge(h)
+		}
+	}
+	return protocol.NewInteger(int64(union.Count()))
+}
+
+// pfcountKeys leaves out the AT clause
+func pfcountKeys(args [][]byte) [][]byte {
+	keys, _, _, _ := command.SplitAtVersion(args)
+	return keys
+}
+
+func PFCountSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "PFCOUNT",
+		Handler:     command.HandlerFunc(PFCount),
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Description: "Estimate the unique elements of HyperLogLogs: PFCOUNT key [key ...] [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        pfcountKeys,
+	}
+}
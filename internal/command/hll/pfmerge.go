@@ -0,0 +1,59 @@
+package hll
+
+import (
+	"slices"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// PFMerge merges HyperLogLogs into destination as a new version. The destination
+// takes part in the union when it already exists.
+// Usage: PFMERGE destination [source ...]
+func PFMerge(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+
+	// UpdateMany takes distinct keys: destination first, then each source once
+	keys := []string{string(args[0])}
+	for _, arg := range args[1:] {
+		if !slices.Contains(keys, string(arg)) {
+			keys = append(keys, string(arg))
+		}
+	}
+
+	_, err := ctx.Engine.UpdateMany(keys, func(current []storage.Entry) ([]storage.Entry, error) {
+		merged := datastructures.NewHyperLogLog()
+		for _, entry := range current {
+			h, err := decode(entry)
+			if err != nil {
+				return nil, err
+			}
+			if h != nil {
+				merged.Merge(h)
+			}
+		}
+
+		next := slices.Clone(current)
+		next[0] = hllEntry(current[0], merged)
+		return next, nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func PFMergeSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "PFMERGE",
+		Handler:     command.HandlerFunc(PFMerge),
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Description: "Merge HyperLogLogs into a key: PFMERGE destination [source ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.AllKeys,
+	}
+}
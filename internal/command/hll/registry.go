@@ -0,0 +1,10 @@
+package hll
+
+import "github.com/ElshadHu/verdis/internal/command"
+
+// RegisterAll adds all command specs into the router.
+func RegisterAll(router *command.Router) {
+	router.Register(PFAddSpec())
+	router.Register(PFCountSpec())
+	router.Register(PFMergeSpec())
+}
@@ -0,0 +1,50 @@
+package command
+
+// KeyFunc picks the keys out of a command's arguments, so a sharded cluster
+// can send the command to the node serving them
+type KeyFunc func(args [][]byte) [][]byte
+
+// FirstKey is the KeyFunc of commands whose first argument is their only key
+func FirstKey(args [][]byte) [][]byte {
+	return KeyAt(0)(args)
+}
+
+// AllKeys is the KeyFunc of commands taking nothing but keys
+func AllKeys(args [][]byte) [][]byte {
+	return args
+}
+
+// KeyAt returns the KeyFunc of commands whose only key is argument i
+func KeyAt(i int) KeyFunc {
+	return func(args [][]byte) [][]byte {
+		if i >= len(args) {
+			return nil
+		}
+		return args[i : i+1]
+	}
+}
+
+// FirstKeys returns the KeyFunc of commands whose first n arguments are keys
+func FirstKeys(n int) KeyFunc {
+	return func(args [][]byte) [][]byte {
+		return args[:min(n, len(args))]
+	}
+}
+
+// KeysBeforeLast is the KeyFunc of commands taking keys followed by one more
+// argument, like BLPOP's timeout
+func KeysBeforeLast(args [][]byte) [][]byte {
+	if len(args) == 0 {
+		return nil
+	}
+	return args[:len(args)-1]
+}
+
+// KeyValuePairs is the KeyFunc of commands taking key value pairs
+func KeyValuePairs(args [][]byte) [][]byte {
+	keys := make([][]byte, 0, (len(args)+1)/2)
+	for i := 0; i < len(args); i += 2 {
+		keys = append(keys, args[i])
+	}
+	return keys
+}
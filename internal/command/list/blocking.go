@@ -0,0 +1,147 @@
+package list
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+var (
+	errTimeoutNegative = errors.New("ERR timeout is negative")
+	errTimeoutInvalid  = errors.New("ERR timeout is not a float or out of range")
+)
+
+// BLPop pops the first element of the first non-empty list, waiting up to
+// timeout seconds (0 waits forever) for one of them to be pushed to.
+// Usage: BLPOP key [key ...] timeout
+func BLPop(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return blockingPop(ctx, cmd, true)
+}
+
+// BRPop pops the last element of the first non-empty list, waiting up to
+// timeout seconds (0 waits forever) for one of them to be pushed to.
+// Usage: BRPOP key [key ...] timeout
+func BRPop(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return blockingPop(ctx, cmd, false)
+}
+
+func blockingPop(ctx *command.Context, cmd *protocol.Command, left bool) command.Result {
+	args := cmd.Args()
+	timeout, err := parseTimeout(args[len(args)-1])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	keys := make([]string, len(args)-1)
+	for i, arg := range args[:len(args)-1] {
+		keys[i] = string(arg)
+	}
+
+	result, ok := command.Block(ctx, keys, timeout, func() (command.Result, bool) {
+		for _, key := range keys {
+			popped, existed, err := pop(ctx, key, left, 1)
+			if err != nil {
+				return protocol.NewError(err.Error()), true
+			}
+			if existed {
+				return protocol.NewArray([]protocol.RESPValue{
+					protocol.NewBulkString([]byte(key)),
+					protocol.NewBulkString(popped[0]),
+				}), true
+			}
+		}
+		return nil, false
+	})
+	if !ok {
+		return protocol.NewNullArray()
+	}
+	return result
+}
+
+// BLMove is the blocking form of LMOVE, waiting up to timeout seconds (0 waits
+// forever) for source to be pushed to.
+// Usage: BLMOVE source destination LEFT|RIGHT LEFT|RIGHT timeout
+func BLMove(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	fromLeft, toLeft, err := parseDirections(args[2], args[3])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	timeout, err := parseTimeout(args[4])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	source, destination := string(args[0]), string(args[1])
+
+	result, ok := command.Block(ctx, []string{source}, timeout, func() (command.Result, bool) {
+		elem, moved, err := move(ctx, source, destination, fromLeft, toLeft)
+		if err != nil {
+			return protocol.NewError(err.Error()), true
+		}
+		if !moved {
+			return nil, false
+		}
+		return protocol.NewBulkString(elem), true
+	})
+	if !ok {
+		return protocol.NewNullBulkString()
+	}
+	return result
+}
+
+// parseTimeout parses a blocking timeout in seconds, 0 meaning no timeout
+func parseTimeout(arg []byte) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(string(arg), 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return 0, errTimeoutInvalid
+	}
+	if seconds < 0 {
+		return 0, errTimeoutNegative
+	}
+	if seconds > math.MaxInt64/float64(time.Second) {
+		return 0, errTimeoutInvalid
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func BLPopSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "BLPOP",
+		Handler:     command.HandlerFunc(BLPop),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Pop the first element of a list, blocking until one is available: BLPOP key [key ...] timeout",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.KeysBeforeLast,
+	}
+}
+
+func BRPopSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "BRPOP",
+		Handler:     command.HandlerFunc(BRPop),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Pop the last element of a list, blocking until one is available: BRPOP key [key ...] timeout",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.KeysBeforeLast,
+	}
+}
+
+func BLMoveSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "BLMOVE",
+		Handler:     command.HandlerFunc(BLMove),
+		MinArgs:     5,
+		MaxArgs:     5,
+		Description: "Move an element between lists, blocking until one is available: BLMOVE source destination LEFT|RIGHT LEFT|RIGHT timeout",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKeys(2),
+	}
+}
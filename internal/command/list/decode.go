@@ -0,0 +1,51 @@
+package list
+
+import (
+	"errors"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+var (
+	errNoSuchKey  = errors.New("ERR no such key")
+	errOutOfRange = errors.New("ERR index out of range")
+	errSyntax     = errors.New("ERR syntax error")
+)
+
+// decode returns the list held by entry, empty for missing keys and
+// storage.ErrWrongType when the key holds another type
+func decode(entry storage.Entry) (datastructures.List, error) {
+	if !entry.Exists {
+		return nil, nil
+	}
+	if entry.Type != storage.TypeList {
+		return nil, storage.ErrWrongType
+	}
+	return datastructures.DecodeList(entry.Value)
+}
+
+// listEntry wraps an encoded list as the next state of a key, keeping its expiry.
+// An empty list deletes the key, as in Redis.
+func listEntry(current storage.Entry, l datastructures.List) storage.Entry {
+	if len(l) == 0 {
+		return storage.Entry{}
+	}
+	return storage.Entry{Value: l.Encode(), Type: storage.TypeList, Exists: true, ExpiresAt: current.ExpiresAt}
+}
+
+// readList returns a key's latest list, or the list as of version when atVersion
+// is set. Missing keys, deleted keys and unknown versions read as empty.
+func readList(ctx *command.Context, key string, version uint64, atVersion bool) (datastructures.List, error) {
+	var entry storage.Entry
+	var err error
+	if atVersion {
+		if entry, err = ctx.Engine.LookupAt(key, version); err != nil {
+			return nil, nil
+		}
+	} else if entry, err = ctx.Engine.Lookup(key); err != nil {
+		return nil, errors.New("ERR " + err.Error())
+	}
+	return decode(entry)
+}
@@ -0,0 +1,47 @@
+package list
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// LIndex returns the list element at an index, optionally as of a past version.
+// Negative indexes count from the tail.
+// Usage: LINDEX key index [AT version]
+func LIndex(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 2 {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	index, err := command.ParseInt(args[1])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	l, err := readList(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	i, ok := l.Index(index)
+	if !ok {
+		return protocol.NewNullBulkString()
+	}
+	return protocol.NewBulkString(l[i])
+}
+
+func LIndexSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "LINDEX",
+		Handler:     command.HandlerFunc(LIndex),
+		MinArgs:     2,
+		MaxArgs:     4,
+		Description: "Get a list element by index: LINDEX key index [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,110 @@
+package list_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/list"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+)
+
+const wrongType = "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+
+func newSession(t *testing.T) *commandtest.Session {
+	return commandtest.NewSession(t, list.RegisterAll, standard.RegisterAll)
+}
+
+// current returns the session's current version as an argument for AT
+func current(s *commandtest.Session) string {
+	return strconv.FormatUint(s.Ctx.Engine.CurrentVersion(), 10)
+}
+
+// TestList_PushPopAndPastVersions verifies every list write is a new version
+// that LRANGE, LLEN and LINDEX can still read with AT
+func TestList_PushPopAndPastVersions(t *testing.T) {
+	s := newSession(t)
+	s.Expect(":2\r\n", "RPUSH", "l", "b", "c")
+	s.Expect(":3\r\n", "LPUSH", "l", "a")
+	pushed := current(s)
+
+	s.Expect("*3\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n", "LRANGE", "l", "0", "-1")
+	s.Expect("$1\r\nc\r\n", "LINDEX", "l", "-1")
+	s.Expect("+OK\r\n", "LSET", "l", "1", "B")
+	s.Expect("$1\r\na\r\n", "LPOP", "l")
+	s.Expect("*2\r\n$1\r\nc\r\n$1\r\nB\r\n", "RPOP", "l", "5")
+	s.Expect(":0\r\n", "EXISTS", "l")
+
+	s.Expect(":3\r\n", "LLEN", "l", "AT", pushed)
+	s.Expect("*2\r\n$1\r\nb\r\n$1\r\nc\r\n", "LRANGE", "l", "1", "2", "AT", pushed)
+	s.Expect("$1\r\nb\r\n", "LINDEX", "l", "1", "AT", pushed)
+
+	s.Expect("$-1\r\n", "LPOP", "l")
+	s.Expect("*-1\r\n", "LPOP", "l", "2")
+	s.Expect("-ERR no such key\r\n", "LSET", "l", "0", "x")
+}
+
+// TestList_Trim verifies LTRIM keeps the range and deletes a list it empties
+func TestList_Trim(t *testing.T) {
+	s := newSession(t)
+	s.Expect(":5\r\n", "RPUSH", "l", "a", "b", "c", "d", "e")
+	s.Expect("+OK\r\n", "LTRIM", "l", "1", "-2")
+	s.Expect("*3\r\n$1\r\nb\r\n$1\r\nc\r\n$1\r\nd\r\n", "LRANGE", "l", "0", "-1")
+	s.Expect("-ERR index out of range\r\n", "LSET", "l", "5", "x")
+	s.Expect("+OK\r\n", "LTRIM", "l", "5", "10")
+	s.Expect(":0\r\n", "EXISTS", "l")
+}
+
+// TestList_MoveIsOneVersion verifies LMOVE changes both lists under the same
+// version, so no past version shows the element in both or in neither
+func TestList_MoveIsOneVersion(t *testing.T) {
+	s := newSession(t)
+	s.Expect(":2\r\n", "RPUSH", "src", "a", "b")
+	s.Expect(":1\r\n", "RPUSH", "dst", "x")
+	version := s.Ctx.Engine.CurrentVersion()
+	s.Expect("$1\r\nb\r\n", "LMOVE", "src", "dst", "RIGHT", "LEFT")
+	if got := s.Ctx.Engine.CurrentVersion(); got != version+1 {
+		t.Fatalf("LMOVE moved the version from %d to %d, want one step", version, got)
+	}
+	before, moved := strconv.FormatUint(version, 10), current(s)
+	s.Expect("*2\r\n$1\r\na\r\n$1\r\nb\r\n", "LRANGE", "src", "0", "-1", "AT", before)
+	s.Expect("*1\r\n$1\r\nx\r\n", "LRANGE", "dst", "0", "-1", "AT", before)
+	s.Expect("*1\r\n$1\r\na\r\n", "LRANGE", "src", "0", "-1", "AT", moved)
+	s.Expect("*2\r\n$1\r\nb\r\n$1\r\nx\r\n", "LRANGE", "dst", "0", "-1", "AT", moved)
+
+	// a list moved onto itself rotates
+	s.Expect("$1\r\nb\r\n", "LMOVE", "dst", "dst", "LEFT", "RIGHT")
+	s.Expect("*2\r\n$1\r\nx\r\n$1\r\nb\r\n", "LRANGE", "dst", "0", "-1")
+	s.Expect("$-1\r\n", "LMOVE", "missing", "dst", "LEFT", "LEFT")
+	s.Expect("-ERR syntax error\r\n", "LMOVE", "src", "dst", "UP", "LEFT")
+}
+
+// TestList_WrongType verifies list commands refuse keys of another type
+// without changing them
+func TestList_WrongType(t *testing.T) {
+	s := newSession(t)
+	s.Expect("+OK\r\n", "SET", "s", "v")
+	s.Expect(":1\r\n", "RPUSH", "l", "a")
+	s.Expect(wrongType, "LPUSH", "s", "a")
+	s.Expect(wrongType, "LRANGE", "s", "0", "-1")
+	s.Expect(wrongType, "LMOVE", "l", "s", "LEFT", "LEFT")
+	s.Expect("*1\r\n$1\r\na\r\n", "LRANGE", "l", "0", "-1")
+	s.Expect("$1\r\nv\r\n", "GET", "s")
+}
+
+// TestList_BlockingPop verifies BRPOP waits for a push from another connection
+// and BLMOVE gives up after its timeout
+func TestList_BlockingPop(t *testing.T) {
+	s := newSession(t)
+	other := s.Fork()
+	done := make(chan string)
+	go func() { done <- other.Do("BRPOP", "empty", "q", "0") }()
+	s.Expect(":2\r\n", "RPUSH", "q", "a", "b")
+	if got := <-done; got != "*2\r\n$1\r\nq\r\n$1\r\nb\r\n" {
+		t.Errorf("BRPOP = %q after a push", got)
+	}
+
+	s.Expect("*2\r\n$1\r\nq\r\n$1\r\na\r\n", "BLPOP", "q", "0")
+	s.Expect("$-1\r\n", "BLMOVE", "q", "dst", "LEFT", "LEFT", "0.01")
+	s.Expect("-ERR timeout is negative\r\n", "BLPOP", "q", "-1")
+}
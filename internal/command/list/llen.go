@@ -0,0 +1,37 @@
+package list
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// LLen returns the length of a list, optionally as of a past version.
+// Usage: LLEN key [AT version]
+func LLen(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 1 {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	l, err := readList(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(int64(len(l)))
+}
+
+func LLenSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "LLEN",
+		Handler:     command.HandlerFunc(LLen),
+		MinArgs:     1,
+		MaxArgs:     3,
+		Description: "Get the length of a list: LLEN key [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,124 @@
+package list
+
+import (
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// LMove pops an element from one end of source and pushes it onto one end of
+// destination. Both lists change under the same version.
+// Usage: LMOVE source destination LEFT|RIGHT LEFT|RIGHT
+func LMove(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	fromLeft, toLeft, err := parseDirections(args[2], args[3])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	elem, ok, err := move(ctx, string(args[0]), string(args[1]), fromLeft, toLeft)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if !ok {
+		return protocol.NewNullBulkString()
+	}
+	return protocol.NewBulkString(elem)
+}
+
+// parseDirections parses the LEFT|RIGHT pair of LMOVE and BLMOVE
+func parseDirections(from, to []byte) (fromLeft, toLeft bool, err error) {
+	if fromLeft, err = parseDirection(from); err != nil {
+		return false, false, err
+	}
+	if toLeft, err = parseDirection(to); err != nil {
+		return false, false, err
+	}
+	return fromLeft, toLeft, nil
+}
+
+func parseDirection(arg []byte) (bool, error) {
+	switch strings.ToUpper(string(arg)) {
+	case "LEFT":
+		return true, nil
+	case "RIGHT":
+		return false, nil
+	default:
+		return false, errSyntax
+	}
+}
+
+// move pops from source and pushes onto destination in a single version, so the
+// element is never visible in both lists or in neither. ok is false when source
+// is empty.
+func move(ctx *command.Context, source, destination string, fromLeft, toLeft bool) ([]byte, bool, error) {
+	var elem []byte
+	var ok bool
+
+	// rotating a list in place is a plain single-key update
+	if source == destination {
+		_, err := ctx.Engine.Update(source, func(current storage.Entry) (storage.Entry, error) {
+			l, err := decode(current)
+			if err != nil {
+				return current, err
+			}
+			if ok = current.Exists; !ok {
+				return current, storage.ErrSkipWrite
+			}
+
+			popped, rest := popEnd(l, fromLeft, 1)
+			elem = popped[0]
+			return listEntry(current, pushEnd(rest, toLeft, elem)), nil
+		})
+		return elem, ok, err
+	}
+
+	_, err := ctx.Engine.UpdateMany([]string{source, destination}, func(current []storage.Entry) ([]storage.Entry, error) {
+		src, err := decode(current[0])
+		if err != nil {
+			return nil, err
+		}
+		dst, err := decode(current[1])
+		if err != nil {
+			return nil, err
+		}
+		if ok = current[0].Exists; !ok {
+			return nil, storage.ErrSkipWrite
+		}
+
+		popped, rest := popEnd(src, fromLeft, 1)
+		elem = popped[0]
+		return []storage.Entry{
+			listEntry(current[0], rest),
+			listEntry(current[1], pushEnd(dst, toLeft, elem)),
+		}, nil
+	})
+	return elem, ok, err
+}
+
+// pushEnd returns a copy of l with elem added at one end
+func pushEnd(l datastructures.List, left bool, elem []byte) datastructures.List {
+	next := make(datastructures.List, 0, len(l)+1)
+	if left {
+		next = append(next, elem)
+		return append(next, l...)
+	}
+	next = append(next, l...)
+	return append(next, elem)
+}
+
+func LMoveSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "LMOVE",
+		Handler:     command.HandlerFunc(LMove),
+		MinArgs:     4,
+		MaxArgs:     4,
+		Description: "Move an element between lists: LMOVE source destination LEFT|RIGHT LEFT|RIGHT",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKeys(2),
+	}
+}
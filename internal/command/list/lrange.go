@@ -0,0 +1,55 @@
+package list
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// LRange returns a range of list elements, optionally as of a past version.
+// Usage: LRANGE key start stop [AT version]
+func LRange(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 3 {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	start, err := command.ParseInt(args[1])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	stop, err := command.ParseInt(args[2])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	l, err := readList(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	from, to, ok := l.Range(start, stop)
+	if !ok {
+		return protocol.NewArray([]protocol.RESPValue{})
+	}
+	values := make([]protocol.RESPValue, 0, to-from)
+	for _, elem := range l[from:to] {
+		values = append(values, protocol.NewBulkString(elem))
+	}
+	return protocol.NewArray(values)
+}
+
+func LRangeSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "LRANGE",
+		Handler:     command.HandlerFunc(LRange),
+		MinArgs:     3,
+		MaxArgs:     5,
+		Description: "Get a range of list elements: LRANGE key start stop [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,52 @@
+package list
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// LSet replaces the list element at an index as a new version.
+// Usage: LSET key index element
+func LSet(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	index, err := command.ParseInt(args[1])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	_, err = ctx.Engine.Update(string(args[0]), func(current storage.Entry) (storage.Entry, error) {
+		l, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+		if !current.Exists {
+			return current, errNoSuchKey
+		}
+
+		i, ok := l.Index(index)
+		if !ok {
+			return current, errOutOfRange
+		}
+		l = l.Clone()
+		l[i] = args[2]
+		return listEntry(current, l), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func LSetSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "LSET",
+		Handler:     command.HandlerFunc(LSet),
+		MinArgs:     3,
+		MaxArgs:     3,
+		Description: "Set a list element by index: LSET key index element",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,58 @@
+package list
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// LTrim keeps only the given range of a list as a new version. Trimming
+// everything deletes the key, a trim that removes nothing writes no version.
+// Usage: LTRIM key start stop
+func LTrim(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	start, err := command.ParseInt(args[1])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	stop, err := command.ParseInt(args[2])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	_, err = ctx.Engine.Update(string(args[0]), func(current storage.Entry) (storage.Entry, error) {
+		l, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+		if !current.Exists {
+			return current, storage.ErrSkipWrite
+		}
+
+		from, to, ok := l.Range(start, stop)
+		if !ok {
+			return storage.Entry{}, nil
+		}
+		if from == 0 && to == len(l) {
+			return current, storage.ErrSkipWrite
+		}
+		return listEntry(current, l[from:to]), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func LTrimSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "LTRIM",
+		Handler:     command.HandlerFunc(LTrim),
+		MinArgs:     3,
+		MaxArgs:     3,
+		Description: "Trim a list to a range: LTRIM key start stop",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,117 @@
+package list
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// LPop removes and returns the first elements of a list as a new version.
+// Usage: LPOP key [count]
+func LPop(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return popCommand(ctx, cmd, true)
+}
+
+// RPop removes and returns the last elements of a list as a new version.
+// Usage: RPOP key [count]
+func RPop(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return popCommand(ctx, cmd, false)
+}
+
+func popCommand(ctx *command.Context, cmd *protocol.Command, left bool) command.Result {
+	args := cmd.Args()
+	count := int64(1)
+	if len(args) == 2 {
+		var err error
+		if count, err = command.ParseInt(args[1]); err != nil || count < 0 {
+			return protocol.NewError("ERR value is out of range, must be positive")
+		}
+	}
+
+	popped, existed, err := pop(ctx, string(args[0]), left, int(count))
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	if len(args) == 1 {
+		if !existed {
+			return protocol.NewNullBulkString()
+		}
+		return protocol.NewBulkString(popped[0])
+	}
+	if !existed {
+		return protocol.NewNullArray()
+	}
+	values := make([]protocol.RESPValue, len(popped))
+	for i, elem := range popped {
+		values[i] = protocol.NewBulkString(elem)
+	}
+	return protocol.NewArray(values)
+}
+
+// pop removes up to count elements from one end of a list, in the order they were
+// popped. Popping the last element deletes the key. existed is false for missing keys.
+func pop(ctx *command.Context, key string, left bool, count int) (datastructures.List, bool, error) {
+	var popped datastructures.List
+	var existed bool
+	_, err := ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		l, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+
+		existed = current.Exists
+		popped = nil
+		if !existed || count == 0 {
+			return current, storage.ErrSkipWrite
+		}
+
+		popped, l = popEnd(l, left, count)
+		return listEntry(current, l), nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return popped, existed, nil
+}
+
+// popEnd splits up to count elements off one end of l, returning them in pop
+// order and the remaining list
+func popEnd(l datastructures.List, left bool, count int) (popped, rest datastructures.List) {
+	count = min(count, len(l))
+	if left {
+		return l[:count], l[count:]
+	}
+	popped = make(datastructures.List, count)
+	for i := range popped {
+		popped[i] = l[len(l)-1-i]
+	}
+	return popped, l[:len(l)-count]
+}
+
+func LPopSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "LPOP",
+		Handler:     command.HandlerFunc(LPop),
+		MinArgs:     1,
+		MaxArgs:     2,
+		Description: "Remove and return the first elements of a list: LPOP key [count]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
+
+func RPopSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "RPOP",
+		Handler:     command.HandlerFunc(RPop),
+		MinArgs:     1,
+		MaxArgs:     2,
+		Description: "Remove and return the last elements of a list: RPOP key [count]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
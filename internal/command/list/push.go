@@ -0,0 +1,75 @@
+package list
+
+import (
+	"slices"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// LPush prepends elements to a list as a new version and returns its length.
+// Elements are inserted one after another, so the last one ends up first.
+// Usage: LPUSH key element [element ...]
+func LPush(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return push(ctx, cmd, true)
+}
+
+// RPush appends elements to a list as a new version and returns its length.
+// Usage: RPUSH key element [element ...]
+func RPush(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return push(ctx, cmd, false)
+}
+
+func push(ctx *command.Context, cmd *protocol.Command, left bool) command.Result {
+	args := cmd.Args()
+	elements := args[1:]
+
+	var length int64
+	_, err := ctx.Engine.Update(string(args[0]), func(current storage.Entry) (storage.Entry, error) {
+		l, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+
+		if left {
+			head := slices.Clone(elements)
+			slices.Reverse(head)
+			l = append(head, l...)
+		} else {
+			l = append(l.Clone(), elements...)
+		}
+		length = int64(len(l))
+		return listEntry(current, l), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(length)
+}
+
+func LPushSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "LPUSH",
+		Handler:     command.HandlerFunc(LPush),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Prepend elements to a list: LPUSH key element [element ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
+
+func RPushSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "RPUSH",
+		Handler:     command.HandlerFunc(RPush),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Append elements to a list: RPUSH key element [element ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
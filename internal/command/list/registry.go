@@ -0,0 +1,20 @@
+package list
+
+import "github.com/ElshadHu/verdis/internal/command"
+
+// RegisterAll adds all command specs into the router.
+func RegisterAll(router *command.Router) {
+	router.Register(LPushSpec())
+	router.Register(RPushSpec())
+	router.Register(LPopSpec())
+	router.Register(RPopSpec())
+	router.Register(LRangeSpec())
+	router.Register(LLenSpec())
+	router.Register(LIndexSpec())
+	router.Register(LSetSpec())
+	router.Register(LTrimSpec())
+	router.Register(LMoveSpec())
+	router.Register(BLPopSpec())
+	router.Register(BRPopSpec())
+	router.Register(BLMoveSpec())
+}
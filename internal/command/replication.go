@@ -0,0 +1,11 @@
+package command
+
+// Replication is the server's replication role as commands see it
+type Replication interface {
+	// ReplicaOf follows the leader at addr, an empty addr makes the server a leader again
+	ReplicaOf(addr string) error
+	// ReadOnly reports whether client writes are refused because the server is a replica
+	ReadOnly() bool
+	// Info returns the INFO replication fields as name:value lines
+	Info() []string
+}
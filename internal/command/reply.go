@@ -0,0 +1,32 @@
+package command
+
+import "github.com/ElshadHu/verdis/internal/protocol"
+
+// Field is one named value of a reply entry
+type Field struct {
+	Name  string
+	Value protocol.RESPValue
+}
+
+// Entry renders fields as a map to RESP3 clients and as a positional array of
+// the values to RESP2 clients
+func Entry(ctx *Context, fields []Field) protocol.RESPValue {
+	if ctx.Protocol != protocol.RESP3 {
+		values := make([]protocol.RESPValue, len(fields))
+		for i, f := range fields {
+			values[i] = f.Value
+		}
+		return protocol.NewArray(values)
+	}
+	return Pairs(fields)
+}
+
+// Pairs renders fields as a map, which RESP2 clients get as a flat array of
+// names and values
+func Pairs(fields []Field) *protocol.Map {
+	entries := make([]protocol.MapEntry, len(fields))
+	for i, f := range fields {
+		entries[i] = protocol.MapEntry{Key: protocol.NewBulkString([]byte(f.Name)), Value: f.Value}
+	}
+	return protocol.NewMap(entries)
+}
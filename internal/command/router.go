@@ -12,7 +12,11 @@ import (
 type Router struct {
 	mu       sync.RWMutex            // protects commands map
 	commands map[string]*CommandSpec // command name -> spec
-	ctx      *Context                // shared context
+	ctx      *Context                // template copied into each connection
+
+	// txMu keeps commands out of a running transaction: commands that read or
+	// write data hold the read side while they run, EXEC the write side
+	txMu sync.RWMutex
 }
 
 func NewRouter() *Router {
@@ -29,6 +33,23 @@ func (r *Router) SetContext(ctx *Context) {
 	r.mu.Unlock()
 }
 
+// NewContext returns a fresh per-connection context bound to database 0
+func (r *Router) NewContext() *Context {
+	r.mu.RLock()
+	ctx := *r.ctx
+	r.mu.RUnlock()
+	ctx.DB = 0
+	ctx.Client = nil
+	ctx.StaleReads = false
+	ctx.Asking = false
+	ctx.Authenticated = false
+	ctx.Protocol = protocol.RESP2
+	ctx.bound, ctx.boundAuthor = nil, nil
+	ctx.tx = nil
+	ctx.router = r
+	return &ctx
+}
+
 // Register adds a command spec (panics on duplicates)
 func (r *Router) Register(spec *CommandSpec) {
 	if spec == nil || spec.Name == "" || spec.Handler == nil {
@@ -46,24 +67,83 @@ func (r *Router) Register(spec *CommandSpec) {
 	r.commands[name] = spec
 }
 
-// Execute routes a command to its handlers and returns the response
-func (r *Router) Execute(cmd *protocol.Command) protocol.RESPValue {
+// Execute routes a command to its handlers with the connection's context and
+// returns the response
+func (r *Router) Execute(ctx *Context, cmd *protocol.Command) protocol.RESPValue {
 	if cmd == nil {
 		return protocol.NewError("ERR empty command")
 	}
 
 	name := strings.ToUpper(cmd.Name())
+	// ASKING only holds for the command right after it
+	asking := ctx.Asking
+	ctx.Asking = false
 
 	r.mu.RLock()
 	spec, exists := r.commands[name]
-	ctx := r.ctx
 	r.mu.RUnlock()
 
 	if !exists {
+		if ctx.tx != nil {
+			ctx.tx.failed = true
+		}
 		return protocol.NewError("ERR unknown command '" + cmd.Name() + "'")
 	}
+	if ctx.Authenticate != nil && !ctx.Authenticated && !spec.NoAuth {
+		return protocol.NewError("NOAUTH Authentication required.")
+	}
+	if ctx.tx != nil && !spec.Transaction {
+		return ctx.tx.queue(spec, cmd)
+	}
 	if err := spec.Validate(cmd); err != nil {
 		return protocol.NewError(err.Error())
 	}
-	return spec.Handler.Execute(ctx, cmd)
+	if spec.Mutates && ctx.Replication != nil && ctx.Replication.ReadOnly() {
+		return protocol.NewError("READONLY You can't write against a read only replica.")
+	}
+	ctx.bind()
+	if ctx.Cluster != nil && spec.Keys != nil {
+		if keys := spec.Keys(cmd.Args()); len(keys) > 0 {
+			return r.routeKeys(ctx, spec, cmd, keys, asking)
+		}
+	}
+	if ctx.Consensus != nil {
+		switch {
+		case spec.Mutates:
+			return submit(ctx, cmd)
+		case spec.ReadOnly && !ctx.StaleReads:
+			if err := ctx.Consensus.ReadBarrier(ctx); err != nil {
+				return protocol.NewError(err.Error())
+			}
+		}
+	}
+	return r.run(ctx, spec, cmd)
+}
+
+// run executes a command. Commands that read or write data run under the read
+// side of the transaction lock, so they never land inside a transaction; a
+// blocking one waits outside it, the way it does under a slot lock.
+func (r *Router) run(ctx *Context, spec *CommandSpec, cmd *protocol.Command) Result {
+	if !spec.ReadOnly && !spec.Mutates {
+		return spec.Handler.Execute(ctx, cmd)
+	}
+	if ctx.NoWait {
+		r.txMu.RLock()
+		defer r.txMu.RUnlock()
+		return spec.Handler.Execute(ctx, cmd)
+	}
+
+	attempt := func() (Result, *Blocked) {
+		r.txMu.RLock()
+		defer r.txMu.RUnlock()
+		ctx.NoWait, ctx.Blocked = true, nil
+		result := spec.Handler.Execute(ctx, cmd)
+		ctx.NoWait = false
+		return result, ctx.Blocked
+	}
+	result, blocked := attempt()
+	if blocked == nil {
+		return result
+	}
+	return waitBlocked(ctx, blocked, attempt)
 }
@@ -0,0 +1,201 @@
+package set
+
+import (
+	"slices"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// setOp combines the sets read from the source keys, in argument order
+type setOp func(sets []datastructures.Set) datastructures.Set
+
+func intersect(sets []datastructures.Set) datastructures.Set {
+	return datastructures.Intersect(sets...)
+}
+
+func union(sets []datastructures.Set) datastructures.Set {
+	return datastructures.Union(sets...)
+}
+
+func diff(sets []datastructures.Set) datastructures.Set {
+	return datastructures.Diff(sets[0], sets[1:]...)
+}
+
+// SInter returns the members present in every given set.
+// Usage: SINTER key [key ...]
+func SInter(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return combine(ctx, cmd.Args(), intersect)
+}
+
+// SUnion returns the members present in any given set.
+// Usage: SUNION key [key ...]
+func SUnion(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return combine(ctx, cmd.Args(), union)
+}
+
+// SDiff returns the members of the first set that are in none of the others.
+// Usage: SDIFF key [key ...]
+func SDiff(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return combine(ctx, cmd.Args(), diff)
+}
+
+// SInterStore stores the intersection of the given sets in destination as a new
+// version and returns its size.
+// Usage: SINTERSTORE destination key [key ...]
+func SInterStore(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return store(ctx, cmd.Args(), intersect)
+}
+
+// SUnionStore stores the union of the given sets in destination as a new
+// version and returns its size.
+// Usage: SUNIONSTORE destination key [key ...]
+func SUnionStore(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return store(ctx, cmd.Args(), union)
+}
+
+// SDiffStore stores the difference of the given sets in destination as a new
+// version and returns its size.
+// Usage: SDIFFSTORE destination key [key ...]
+func SDiffStore(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return store(ctx, cmd.Args(), diff)
+}
+
+// combine reads every source at one snapshot version, so a concurrent batch is
+// either fully reflected in the result or not at all
+func combine(ctx *command.Context, args [][]byte, op setOp) command.Result {
+	keys := make([]string, len(args))
+	for i, arg := range args {
+		keys[i] = string(arg)
+	}
+
+	entries, _ := ctx.Engine.GetMany(keys)
+	sets := make([]datastructures.Set, len(entries))
+	for i, entry := range entries {
+		var err error
+		if sets[i], err = decode(entry); err != nil {
+			return protocol.NewError(err.Error())
+		}
+	}
+	return membersReply(op(sets))
+}
+
+// store computes op over the sources and writes the result to the destination
+// in one multi-key update. The destination is overwritten whatever its type, an
+// empty result deletes it.
+func store(ctx *command.Context, args [][]byte, op setOp) command.Result {
+	destination := string(args[0])
+
+	// UpdateMany takes distinct keys: destination first, then each source once
+	keys := []string{destination}
+	sources := make([]int, len(args)-1)
+	for i, arg := range args[1:] {
+		pos := slices.Index(keys, string(arg))
+		if pos < 0 {
+			pos = len(keys)
+			keys = append(keys, string(arg))
+		}
+		sources[i] = pos
+	}
+
+	var size int64
+	_, err := ctx.Engine.UpdateMany(keys, func(current []storage.Entry) ([]storage.Entry, error) {
+		sets := make([]datastructures.Set, len(sources))
+		for i, pos := range sources {
+			var err error
+			if sets[i], err = decode(current[pos]); err != nil {
+				return nil, err
+			}
+		}
+
+		result := op(sets)
+		size = int64(len(result))
+		next := slices.Clone(current)
+		next[0] = setEntry(storage.Entry{}, result)
+		return next, nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(size)
+}
+
+func SInterSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SINTER",
+		Handler:     command.HandlerFunc(SInter),
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Description: "Intersect sets: SINTER key [key ...]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.AllKeys,
+	}
+}
+
+func SUnionSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SUNION",
+		Handler:     command.HandlerFunc(SUnion),
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Description: "Union sets: SUNION key [key ...]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.AllKeys,
+	}
+}
+
+func SDiffSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SDIFF",
+		Handler:     command.HandlerFunc(SDiff),
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Description: "Subtract sets: SDIFF key [key ...]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.AllKeys,
+	}
+}
+
+func SInterStoreSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SINTERSTORE",
+		Handler:     command.HandlerFunc(SInterStore),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Intersect sets into a key: SINTERSTORE destination key [key ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.AllKeys,
+	}
+}
+
+func SUnionStoreSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SUNIONSTORE",
+		Handler:     command.HandlerFunc(SUnionStore),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Union sets into a key: SUNIONSTORE destination key [key ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.AllKeys,
+	}
+}
+
+func SDiffStoreSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SDIFFSTORE",
+		Handler:     command.HandlerFunc(SDiffStore),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Subtract sets into a key: SDIFFSTORE destination key [key ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.AllKeys,
+	}
+}
@@ -0,0 +1,58 @@
+package set
+
+import (
+	"errors"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+var errSyntax = errors.New("ERR syntax error")
+
+// decode returns the set held by entry, empty for missing keys and
+// storage.ErrWrongType when the key holds another type
+func decode(entry storage.Entry) (datastructures.Set, error) {
+	if !entry.Exists {
+		return datastructures.Set{}, nil
+	}
+	if entry.Type != storage.TypeSet {
+		return nil, storage.ErrWrongType
+	}
+	return datastructures.DecodeSet(entry.Value)
+}
+
+// setEntry wraps an encoded set as the next state of a key, keeping its expiry.
+// An empty set deletes the key.
+func setEntry(current storage.Entry, s datastructures.Set) storage.Entry {
+	if len(s) == 0 {
+		return storage.Entry{}
+	}
+	return storage.Entry{Value: s.Encode(), Type: storage.TypeSet, Exists: true, ExpiresAt: current.ExpiresAt}
+}
+
+// readSet returns a key's latest set, or the set as of version when atVersion
+// is set. Missing keys, deleted keys and unknown versions read as empty.
+func readSet(ctx *command.Context, key string, version uint64, atVersion bool) (datastructures.Set, error) {
+	var entry storage.Entry
+	var err error
+	if atVersion {
+		if entry, err = ctx.Engine.LookupAt(key, version); err != nil {
+			return datastructures.Set{}, nil
+		}
+	} else if entry, err = ctx.Engine.Lookup(key); err != nil {
+		return nil, errors.New("ERR " + err.Error())
+	}
+	return decode(entry)
+}
+
+// membersReply renders members in sorted order
+func membersReply(s datastructures.Set) command.Result {
+	members := s.Members()
+	values := make([]protocol.RESPValue, len(members))
+	for i, member := range members {
+		values[i] = protocol.NewBulkString([]byte(member))
+	}
+	return protocol.NewArray(values)
+}
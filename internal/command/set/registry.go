@@ -0,0 +1,19 @@
+package set
+
+import "github.com/ElshadHu/verdis/internal/command"
+
+// RegisterAll adds all command specs into the router.
+func RegisterAll(router *command.Router) {
+	router.Register(SAddSpec())
+	router.Register(SRemSpec())
+	router.Register(SIsMemberSpec())
+	router.Register(SMembersSpec())
+	router.Register(SCardSpec())
+	router.Register(SInterSpec())
+	router.Register(SUnionSpec())
+	router.Register(SDiffSpec())
+	router.Register(SInterStoreSpec())
+	router.Register(SUnionStoreSpec())
+	router.Register(SDiffStoreSpec())
+	router.Register(SDiffVersionsSpec())
+}
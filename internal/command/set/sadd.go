@@ -0,0 +1,51 @@
+package set
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// SAdd adds members to a set as a new version and returns how many were added.
+// Adding only existing members writes no version.
+// Usage: SADD key member [member ...]
+func SAdd(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+
+	var added int64
+	_, err := ctx.Engine.Update(string(args[0]), func(current storage.Entry) (storage.Entry, error) {
+		s, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+
+		added = 0
+		for _, member := range args[1:] {
+			if _, exists := s[string(member)]; !exists {
+				s[string(member)] = struct{}{}
+				added++
+			}
+		}
+		if added == 0 {
+			return current, storage.ErrSkipWrite
+		}
+		return setEntry(current, s), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(added)
+}
+
+func SAddSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SADD",
+		Handler:     command.HandlerFunc(SAdd),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Add members to a set: SADD key member [member ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,37 @@
+package set
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// SCard returns the number of members of a set, optionally as of a past version.
+// Usage: SCARD key [AT version]
+func SCard(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 1 {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	s, err := readSet(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(int64(len(s)))
+}
+
+func SCardSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SCARD",
+		Handler:     command.HandlerFunc(SCard),
+		MinArgs:     1,
+		MaxArgs:     3,
+		Description: "Get the number of members of a set: SCARD key [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
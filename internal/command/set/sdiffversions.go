@@ -0,0 +1,55 @@
+package set
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// SDiffVersions reports how a set's membership changed between two versions as
+// [added, removed], each sorted. A key missing at a version reads as empty, a
+// version not taken yet is an error.
+// Usage: SDIFFVERSIONS key v1 v2
+func SDiffVersions(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	key := string(args[0])
+
+	v1, err := command.ParseVersion(args[1])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	v2, err := command.ParseVersion(args[2])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if max(v1, v2) > ctx.Engine.CurrentVersion() {
+		return protocol.NewError("ERR version is in the future")
+	}
+
+	before, err := readSet(ctx, key, v1, true)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	after, err := readSet(ctx, key, v2, true)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	return protocol.NewArray([]protocol.RESPValue{
+		membersReply(datastructures.Diff(after, before)),
+		membersReply(datastructures.Diff(before, after)),
+	})
+}
+
+func SDiffVersionsSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SDIFFVERSIONS",
+		Handler:     command.HandlerFunc(SDiffVersions),
+		MinArgs:     3,
+		MaxArgs:     3,
+		Description: "Members added and removed between two versions of a set: SDIFFVERSIONS key v1 v2",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,101 @@
+package set_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/set"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// current returns the engine's latest version as a command argument
+func current(s *commandtest.Session) string {
+	return strconv.FormatUint(s.Ctx.Engine.CurrentVersion(), 10)
+}
+
+func TestSet_Membership(t *testing.T) {
+	s := commandtest.NewSession(t, set.RegisterAll)
+
+	s.Expect(":3\r\n", "SADD", "s", "b", "a", "c")
+	s.Expect(":1\r\n", "SADD", "s", "a", "d")
+	v1 := current(s)
+	s.Expect(":0\r\n", "SADD", "s", "a")
+	if current(s) != v1 {
+		t.Errorf("SADD of existing members wrote a version")
+	}
+
+	s.Expect(":2\r\n", "SREM", "s", "a", "x", "b")
+	s.Expect(":0\r\n", "SREM", "s", "x")
+	s.Expect("*2\r\n$1\r\nc\r\n$1\r\nd\r\n", "SMEMBERS", "s")
+	s.Expect(":2\r\n", "SCARD", "s")
+	s.Expect(":0\r\n", "SISMEMBER", "s", "a")
+
+	s.Expect("*4\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n$1\r\nd\r\n", "SMEMBERS", "s", "AT", v1)
+	s.Expect(":4\r\n", "SCARD", "s", "AT", v1)
+	s.Expect(":1\r\n", "SISMEMBER", "s", "a", "AT", v1)
+
+	s.Expect(":2\r\n", "SREM", "s", "c", "d")
+	s.Expect("*0\r\n", "SMEMBERS", "s")
+	if entry, _ := s.Ctx.Engine.Lookup("s"); entry.Exists {
+		t.Errorf("removing the last member left the key")
+	}
+	s.Expect(":0\r\n", "SCARD", "missing")
+}
+
+func TestSet_WrongType(t *testing.T) {
+	s := commandtest.NewSession(t, set.RegisterAll)
+	s.Write("str", storage.TypeString, []byte("v"))
+	const wrongType = "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+
+	s.Expect(wrongType, "SADD", "str", "a")
+	s.Expect(wrongType, "SMEMBERS", "str")
+	s.Expect(wrongType, "SINTER", "str")
+	s.Expect(wrongType, "SUNIONSTORE", "dst", "str")
+}
+
+func TestSet_Algebra(t *testing.T) {
+	s := commandtest.NewSession(t, set.RegisterAll)
+	s.Expect(":4\r\n", "SADD", "a", "1", "2", "3", "4")
+	s.Expect(":3\r\n", "SADD", "b", "3", "4", "5")
+	s.Expect(":2\r\n", "SADD", "c", "4", "6")
+
+	s.Expect("*1\r\n$1\r\n4\r\n", "SINTER", "a", "b", "c")
+	s.Expect("*0\r\n", "SINTER", "a", "missing")
+	s.Expect("*6\r\n$1\r\n1\r\n$1\r\n2\r\n$1\r\n3\r\n$1\r\n4\r\n$1\r\n5\r\n$1\r\n6\r\n", "SUNION", "a", "b", "c")
+	s.Expect("*2\r\n$1\r\n1\r\n$1\r\n2\r\n", "SDIFF", "a", "b", "c")
+	s.Expect("*4\r\n$1\r\n1\r\n$1\r\n2\r\n$1\r\n3\r\n$1\r\n4\r\n", "SDIFF", "a", "missing")
+
+	s.Expect(":2\r\n", "SINTERSTORE", "dst", "a", "b")
+	s.Expect("*2\r\n$1\r\n3\r\n$1\r\n4\r\n", "SMEMBERS", "dst")
+
+	// the destination may also be a source and is read before it is overwritten
+	s.Expect(":3\r\n", "SUNIONSTORE", "dst", "dst", "c")
+	s.Expect("*3\r\n$1\r\n3\r\n$1\r\n4\r\n$1\r\n6\r\n", "SMEMBERS", "dst")
+
+	// an empty result deletes the destination whatever it held
+	s.Write("str", storage.TypeString, []byte("v"))
+	s.Expect(":0\r\n", "SDIFFSTORE", "str", "c", "c")
+	if entry, _ := s.Ctx.Engine.Lookup("str"); entry.Exists {
+		t.Errorf("empty SDIFFSTORE result left the destination")
+	}
+}
+
+func TestSDiffVersions(t *testing.T) {
+	s := commandtest.NewSession(t, set.RegisterAll)
+	s.Expect(":3\r\n", "SADD", "s", "a", "b", "c")
+	v1 := current(s)
+	s.Expect(":1\r\n", "SREM", "s", "b")
+	s.Expect(":2\r\n", "SADD", "s", "d", "e")
+	v2 := current(s)
+
+	s.Expect("*2\r\n*2\r\n$1\r\nd\r\n$1\r\ne\r\n*1\r\n$1\r\nb\r\n", "SDIFFVERSIONS", "s", v1, v2)
+	s.Expect("*2\r\n*1\r\n$1\r\nb\r\n*2\r\n$1\r\nd\r\n$1\r\ne\r\n", "SDIFFVERSIONS", "s", v2, v1)
+	s.Expect("*2\r\n*3\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n*0\r\n", "SDIFFVERSIONS", "s", "0", v1)
+	s.Expect("*2\r\n*0\r\n*0\r\n", "SDIFFVERSIONS", "missing", v1, v2)
+
+	future := strconv.FormatUint(s.Ctx.Engine.CurrentVersion()+1, 10)
+	s.Expect("-ERR version is in the future\r\n", "SDIFFVERSIONS", "s", v1, future)
+	s.Expect("-ERR version is in the future\r\n", "SDIFFVERSIONS", "s", future, v1)
+	s.Expect("-ERR invalid version number: x\r\n", "SDIFFVERSIONS", "s", "x", v1)
+}
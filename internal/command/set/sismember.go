@@ -0,0 +1,40 @@
+package set
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// SIsMember reports whether a member belongs to a set, optionally as of a past version.
+// Usage: SISMEMBER key member [AT version]
+func SIsMember(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 2 {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	s, err := readSet(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if _, ok := s[string(args[1])]; ok {
+		return protocol.NewInteger(1)
+	}
+	return protocol.NewInteger(0)
+}
+
+func SIsMemberSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SISMEMBER",
+		Handler:     command.HandlerFunc(SIsMember),
+		MinArgs:     2,
+		MaxArgs:     4,
+		Description: "Check set membership: SISMEMBER key member [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,37 @@
+package set
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// SMembers returns the members of a set in sorted order, optionally as of a past version.
+// Usage: SMEMBERS key [AT version]
+func SMembers(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 1 {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	s, err := readSet(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return membersReply(s)
+}
+
+func SMembersSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SMEMBERS",
+		Handler:     command.HandlerFunc(SMembers),
+		MinArgs:     1,
+		MaxArgs:     3,
+		Description: "Get all members of a set: SMEMBERS key [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
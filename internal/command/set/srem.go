@@ -0,0 +1,51 @@
+package set
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// SRem removes members from a set as a new version. Removing the last member
+// deletes the key.
+// Usage: SREM key member [member ...]
+func SRem(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+
+	var removed int64
+	_, err := ctx.Engine.Update(string(args[0]), func(current storage.Entry) (storage.Entry, error) {
+		s, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+
+		removed = 0
+		for _, member := range args[1:] {
+			if _, exists := s[string(member)]; exists {
+				delete(s, string(member))
+				removed++
+			}
+		}
+		if removed == 0 {
+			return current, storage.ErrSkipWrite
+		}
+		return setEntry(current, s), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(removed)
+}
+
+func SRemSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SREM",
+		Handler:     command.HandlerFunc(SRem),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Remove members from a set: SREM key member [member ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
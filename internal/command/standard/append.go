@@ -0,0 +1,44 @@
+package standard
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// Append appends a value to a key as a new version, creating the key if needed.
+// Usage: APPEND key value
+func Append(ctx *command.Context, cmd *protocol.Command) command.Result {
+	key := string(cmd.Args()[0])
+	suffix := cmd.Args()[1]
+
+	var length int
+	_, err := ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		if !current.Is(storage.TypeString) {
+			return current, storage.ErrWrongType
+		}
+		// never append in place, the current value is shared with older readers
+		next := make([]byte, 0, len(current.Value)+len(suffix))
+		next = append(next, current.Value...)
+		next = append(next, suffix...)
+		length = len(next)
+		return current.WithValue(next), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(int64(length))
+}
+
+func AppendSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "APPEND",
+		Handler:     command.HandlerFunc(Append),
+		MinArgs:     2,
+		MaxArgs:     2,
+		Description: "Append a value to a key.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
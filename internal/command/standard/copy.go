@@ -0,0 +1,60 @@
+package standard
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// Copy copies the value of a key to another key, with its history when
+// WITHHISTORY is given. Replies 1 if copied, 0 if the source is missing or
+// the destination exists and REPLACE was not given.
+// Usage: COPY source destination [REPLACE] [WITHHISTORY]
+func Copy(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	src, dst := string(args[0]), string(args[1])
+
+	var replace, withHistory bool
+	for _, arg := range args[2:] {
+		switch strings.ToUpper(string(arg)) {
+		case "REPLACE":
+			replace = true
+		case "WITHHISTORY":
+			withHistory = true
+		default:
+			return protocol.NewError(errSyntax.Error())
+		}
+	}
+	if src == dst {
+		return protocol.NewError(errSameObject.Error())
+	}
+
+	renamer, ok := ctx.Engine.(storage.Renamer)
+	if !ok {
+		return protocol.NewError("ERR " + storage.ErrUnsupported.Error())
+	}
+	_, err := renamer.Copy(src, dst, replace, withHistory)
+	if errors.Is(err, storage.ErrKeyNotFound) || errors.Is(err, storage.ErrKeyExists) {
+		return protocol.NewInteger(0)
+	}
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	return protocol.NewInteger(1)
+}
+
+func CopySpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "COPY",
+		Handler:     command.HandlerFunc(Copy),
+		MinArgs:     2,
+		MaxArgs:     4,
+		Description: "Copy a key, optionally with its version history.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKeys(2),
+	}
+}
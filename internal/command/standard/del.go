@@ -6,12 +6,20 @@ import (
 )
 
 // Del deletes one or more keys.
-// Usage: DEL key [key ...]
+// Usage: DEL key [key ...] [COMMENT text]
 func Del(ctx *command.Context, cmd *protocol.Command) command.Result {
-	keys := cmd.Args()
+	keys, comment, err := command.SplitComment(cmd.Args(), 1)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(keys) == 0 {
+		return protocol.NewError(errSyntax.Error())
+	}
+	engine := ctx.Annotate(comment)
+
 	deleted := int64(0)
 	for _, key := range keys {
-		existed := ctx.Engine.Del(string(key))
+		existed := engine.Del(string(key))
 		if existed {
 			deleted++
 		}
@@ -19,6 +27,12 @@ func Del(ctx *command.Context, cmd *protocol.Command) command.Result {
 	return protocol.NewInteger(deleted)
 }
 
+// delKeys leaves out the COMMENT clause
+func delKeys(args [][]byte) [][]byte {
+	keys, _, _ := command.SplitComment(args, 1)
+	return keys
+}
+
 func DelSpec() *command.CommandSpec {
 	return &command.CommandSpec{
 		Name:        "DEL",
@@ -28,5 +42,6 @@ func DelSpec() *command.CommandSpec {
 		Description: "Deletes one or more keys.",
 		ReadOnly:    false,
 		Mutates:     true,
+		Keys:        delKeys,
 	}
 }
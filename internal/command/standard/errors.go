@@ -0,0 +1,14 @@
+package standard
+
+import "errors"
+
+// Replies shared by several commands, worded like Redis so client libraries recognise them
+var (
+	errSyntax     = errors.New("ERR syntax error")
+	errNotInteger = errors.New("ERR value is not an integer or out of range")
+	errOverflow   = errors.New("ERR increment or decrement would overflow")
+	errNotFloat   = errors.New("ERR value is not a valid float")
+	errNaNOrInf   = errors.New("ERR increment would produce NaN or Infinity")
+	errNoSuchKey  = errors.New("ERR no such key")
+	errSameObject = errors.New("ERR source and destination objects are the same")
+)
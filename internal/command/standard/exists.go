@@ -28,5 +28,6 @@ func ExistsSpec() *command.CommandSpec {
 		Description: "Check if keys exists",
 		ReadOnly:    true,
 		Mutates:     false,
+		Keys:        command.AllKeys,
 	}
 }
@@ -0,0 +1,52 @@
+package standard
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/command"
+)
+
+// isExpiryOption reports whether opt is one of EX, PX, EXAT or PXAT
+func isExpiryOption(opt string) bool {
+	switch opt {
+	case "EX", "PX", "EXAT", "PXAT":
+		return true
+	}
+	return false
+}
+
+// parseExpiry converts an EX/PX/EXAT/PXAT option and its argument into a Unix nano deadline
+func parseExpiry(cmdName, opt string, arg []byte, now time.Time) (int64, error) {
+	invalid := errors.New("ERR invalid expire time in '" + strings.ToLower(cmdName) + "' command")
+
+	n, err := command.ParseInt(arg)
+	if err != nil {
+		return 0, errNotInteger
+	}
+	if n <= 0 {
+		return 0, invalid
+	}
+
+	var unit int64
+	switch opt {
+	case "EX", "EXAT":
+		unit = int64(time.Second)
+	case "PX", "PXAT":
+		unit = int64(time.Millisecond)
+	}
+	if n > math.MaxInt64/unit {
+		return 0, invalid
+	}
+
+	deadline := n * unit
+	if opt == "EX" || opt == "PX" {
+		if deadline > math.MaxInt64-now.UnixNano() {
+			return 0, invalid
+		}
+		deadline += now.UnixNano()
+	}
+	return deadline, nil
+}
@@ -9,7 +9,10 @@ import (
 // Usage: GET [key]
 func Get(ctx *command.Context, cmd *protocol.Command) command.Result {
 	key := string(cmd.Args()[0])
-	value, exists := ctx.Engine.Get(key)
+	value, exists, err := getString(ctx, key)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
 	if !exists {
 		return protocol.NewNullBulkString()
 	}
@@ -26,5 +29,6 @@ func GetSpec() *command.CommandSpec {
 		Description: "Get the value of a key.",
 		ReadOnly:    true,
 		Mutates:     false,
+		Keys:        command.FirstKey,
 	}
 }
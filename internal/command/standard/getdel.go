@@ -0,0 +1,46 @@
+package standard
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// GetDel returns a key's value and deletes it with a tombstone version.
+// Usage: GETDEL key
+func GetDel(ctx *command.Context, cmd *protocol.Command) command.Result {
+	key := string(cmd.Args()[0])
+
+	var old []byte
+	var existed bool
+	_, err := ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		if !current.Is(storage.TypeString) {
+			return current, storage.ErrWrongType
+		}
+		old, existed = current.Value, current.Exists
+		if !current.Exists {
+			return current, storage.ErrSkipWrite
+		}
+		return storage.Entry{}, nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if !existed {
+		return protocol.NewNullBulkString()
+	}
+	return protocol.NewBulkString(old)
+}
+
+func GetDelSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "GETDEL",
+		Handler:     command.HandlerFunc(GetDel),
+		MinArgs:     1,
+		MaxArgs:     1,
+		Description: "Get the value of a key and delete it.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,76 @@
+package standard
+
+import (
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// GetEx returns a key's value and optionally changes its expiry. Changing the
+// expiry writes a new version holding the same value.
+// Usage: GETEX key [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds | PERSIST]
+func GetEx(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	key := string(args[0])
+
+	if len(args) == 1 {
+		value, exists, err := getString(ctx, key)
+		if err != nil {
+			return protocol.NewError(err.Error())
+		}
+		if !exists {
+			return protocol.NewNullBulkString()
+		}
+		return protocol.NewBulkString(value)
+	}
+
+	var expiresAt int64
+	opt := strings.ToUpper(string(args[1]))
+	switch {
+	case opt == "PERSIST" && len(args) == 2:
+		expiresAt = 0
+	case isExpiryOption(opt) && len(args) == 3:
+		var err error
+		expiresAt, err = parseExpiry("getex", opt, args[2], ctx.Now())
+		if err != nil {
+			return protocol.NewError(err.Error())
+		}
+	default:
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	var value []byte
+	var exists bool
+	_, err := ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		if !current.Is(storage.TypeString) {
+			return current, storage.ErrWrongType
+		}
+		value, exists = current.Value, current.Exists
+		if !current.Exists || current.ExpiresAt == expiresAt {
+			return current, storage.ErrSkipWrite
+		}
+		return storage.Entry{Value: current.Value, Exists: true, ExpiresAt: expiresAt}, nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if !exists {
+		return protocol.NewNullBulkString()
+	}
+	return protocol.NewBulkString(value)
+}
+
+func GetExSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "GETEX",
+		Handler:     command.HandlerFunc(GetEx),
+		MinArgs:     1,
+		MaxArgs:     3,
+		Description: "Get the value of a key and optionally set its expiration.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,68 @@
+package standard
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// GetRange returns a substring of a key's value, optionally at a historical version.
+// Usage: GETRANGE key start end [AT version]
+func GetRange(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 3 {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	start, err := command.ParseInt(args[1])
+	if err != nil {
+		return protocol.NewError(errNotInteger.Error())
+	}
+	end, err := command.ParseInt(args[2])
+	if err != nil {
+		return protocol.NewError(errNotInteger.Error())
+	}
+
+	value, _, err := readValue(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewBulkString(substring(value, start, end))
+}
+
+// substring applies Redis GETRANGE index rules: inclusive bounds, negative
+// offsets count from the end and out of range bounds are clamped
+func substring(value []byte, start, end int64) []byte {
+	length := int64(len(value))
+	if length == 0 || (start < 0 && end < 0 && start > end) {
+		return []byte{}
+	}
+	if start < 0 {
+		start = length + start
+	}
+	if end < 0 {
+		end = length + end
+	}
+	start = max(start, 0)
+	end = max(end, 0)
+	end = min(end, length-1)
+	if start > end {
+		return []byte{}
+	}
+	return value[start : end+1]
+}
+
+func GetRangeSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "GETRANGE",
+		Handler:     command.HandlerFunc(GetRange),
+		MinArgs:     3,
+		MaxArgs:     5,
+		Description: "Get a substring of a key's value: GETRANGE key start end [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
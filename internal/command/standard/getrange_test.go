@@ -0,0 +1,47 @@
+package standard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+)
+
+func TestGetRange_AtVersion(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	v1 := s.Version("SET", "k", "Hello World", "RETURNVERSION")
+	s.Expect(":14\r\n", "APPEND", "k", "!!!")
+
+	s.Expect("$5\r\nHello\r\n", "GETRANGE", "k", "0", "4", "AT", v1)
+	s.Expect("$3\r\nrld\r\n", "GETRANGE", "k", "-3", "-1", "AT", v1)
+	s.Expect("$3\r\n!!!\r\n", "GETRANGE", "k", "-3", "-1")
+	s.Expect("$0\r\n\r\n", "GETRANGE", "k", "20", "30", "AT", v1)
+	s.Expect("$0\r\n\r\n", "GETRANGE", "missing", "0", "-1", "AT", v1)
+	s.Expect("-ERR syntax error\r\n", "GETRANGE", "k", "0", "AT", v1)
+}
+
+func TestStrLen_AtVersion(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	v1 := s.Version("SET", "k", "abc", "RETURNVERSION")
+	v2 := s.Version("SET", "k", "abcdef", "RETURNVERSION")
+	s.Expect(":1\r\n", "DEL", "k")
+
+	s.Expect(":3\r\n", "STRLEN", "k", "AT", v1)
+	s.Expect(":6\r\n", "STRLEN", "k", "AT", v2)
+	s.Expect(":0\r\n", "STRLEN", "k")
+	s.Expect(":0\r\n", "STRLEN", "k", "AT", "0")
+}
+
+// TestStrLen_AtVersionExpired verifies a value that had expired when the
+// requested version was taken reads as absent
+func TestStrLen_AtVersionExpired(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	live := s.Version("SET", "k", "abc", "PX", "50", "RETURNVERSION")
+	time.Sleep(60 * time.Millisecond)
+	later := s.Version("SET", "other", "x", "RETURNVERSION")
+
+	s.Expect(":3\r\n", "STRLEN", "k", "AT", live)
+	s.Expect(":0\r\n", "STRLEN", "k", "AT", later)
+	s.Expect("$0\r\n\r\n", "GETRANGE", "k", "0", "-1", "AT", later)
+}
@@ -0,0 +1,45 @@
+package standard
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// GetSet sets a key to a new value and returns the old one.
+// Usage: GETSET key value
+func GetSet(ctx *command.Context, cmd *protocol.Command) command.Result {
+	key := string(cmd.Args()[0])
+	value := cmd.Args()[1]
+
+	var old []byte
+	var existed bool
+	_, err := ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		if !current.Is(storage.TypeString) {
+			return current, storage.ErrWrongType
+		}
+		old, existed = current.Value, current.Exists
+		// like SET, the new value does not keep the old expiry
+		return storage.Entry{Value: value, Exists: true}, nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if !existed {
+		return protocol.NewNullBulkString()
+	}
+	return protocol.NewBulkString(old)
+}
+
+func GetSetSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "GETSET",
+		Handler:     command.HandlerFunc(GetSet),
+		MinArgs:     2,
+		MaxArgs:     2,
+		Description: "Set a key to a value and return the old value.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
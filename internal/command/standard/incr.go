@@ -0,0 +1,185 @@
+package standard
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// Incr increments the integer value of a key by one.
+// Usage: INCR key
+func Incr(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return incrBy(ctx, string(cmd.Args()[0]), 1)
+}
+
+// Decr decrements the integer value of a key by one.
+// Usage: DECR key
+func Decr(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return incrBy(ctx, string(cmd.Args()[0]), -1)
+}
+
+// IncrBy increments the integer value of a key by the given amount.
+// Usage: INCRBY key increment
+func IncrBy(ctx *command.Context, cmd *protocol.Command) command.Result {
+	delta, err := command.ParseInt(cmd.Args()[1])
+	if err != nil {
+		return protocol.NewError(errNotInteger.Error())
+	}
+	return incrBy(ctx, string(cmd.Args()[0]), delta)
+}
+
+// DecrBy decrements the integer value of a key by the given amount.
+// Usage: DECRBY key decrement
+func DecrBy(ctx *command.Context, cmd *protocol.Command) command.Result {
+	delta, err := command.ParseInt(cmd.Args()[1])
+	if err != nil {
+		return protocol.NewError(errNotInteger.Error())
+	}
+	if delta == math.MinInt64 {
+		return protocol.NewError("ERR decrement would overflow")
+	}
+	return incrBy(ctx, string(cmd.Args()[0]), -delta)
+}
+
+// IncrByFloat increments the float value of a key by the given amount.
+// Usage: INCRBYFLOAT key increment
+func IncrByFloat(ctx *command.Context, cmd *protocol.Command) command.Result {
+	key := string(cmd.Args()[0])
+	delta, err := parseFloat(cmd.Args()[1])
+	if err != nil {
+		return protocol.NewError(errNotFloat.Error())
+	}
+
+	var result []byte
+	_, err = ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		if !current.Is(storage.TypeString) {
+			return current, storage.ErrWrongType
+		}
+		value := 0.0
+		if current.Exists {
+			var err error
+			value, err = parseFloat(current.Value)
+			if err != nil {
+				return current, errNotFloat
+			}
+		}
+		sum := value + delta
+		if math.IsNaN(sum) || math.IsInf(sum, 0) {
+			return current, errNaNOrInf
+		}
+		result = strconv.AppendFloat(nil, sum, 'f', -1, 64)
+		return current.WithValue(result), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewBulkString(result)
+}
+
+// incrBy adds delta to the integer stored at key inside the engine's CAS loop,
+// so concurrent increments never lose an update and each one becomes a version
+func incrBy(ctx *command.Context, key string, delta int64) command.Result {
+	var result int64
+	_, err := ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		if !current.Is(storage.TypeString) {
+			return current, storage.ErrWrongType
+		}
+		value := int64(0)
+		if current.Exists {
+			var err error
+			value, err = command.ParseInt(current.Value)
+			if err != nil {
+				return current, errNotInteger
+			}
+		}
+		if (delta > 0 && value > math.MaxInt64-delta) || (delta < 0 && value < math.MinInt64-delta) {
+			return current, errOverflow
+		}
+		result = value + delta
+		return current.WithValue(strconv.AppendInt(nil, result, 10)), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(result)
+}
+
+// parseFloat parses a finite float, rejecting whitespace, NaN and infinities
+func parseFloat(b []byte) (float64, error) {
+	if len(b) == 0 || b[0] == ' ' || b[len(b)-1] == ' ' {
+		return 0, errNotFloat
+	}
+	f, err := strconv.ParseFloat(string(b), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, errNotFloat
+	}
+	return f, nil
+}
+
+func IncrSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "INCR",
+		Handler:     command.HandlerFunc(Incr),
+		MinArgs:     1,
+		MaxArgs:     1,
+		Description: "Increment the integer value of a key by one.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
+
+func DecrSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "DECR",
+		Handler:     command.HandlerFunc(Decr),
+		MinArgs:     1,
+		MaxArgs:     1,
+		Description: "Decrement the integer value of a key by one.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
+
+func IncrBySpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "INCRBY",
+		Handler:     command.HandlerFunc(IncrBy),
+		MinArgs:     2,
+		MaxArgs:     2,
+		Description: "Increment the integer value of a key by the given amount.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
+
+func DecrBySpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "DECRBY",
+		Handler:     command.HandlerFunc(DecrBy),
+		MinArgs:     2,
+		MaxArgs:     2,
+		Description: "Decrement the integer value of a key by the given amount.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
+
+func IncrByFloatSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "INCRBYFLOAT",
+		Handler:     command.HandlerFunc(IncrByFloat),
+		MinArgs:     2,
+		MaxArgs:     2,
+		Description: "Increment the float value of a key by the given amount.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
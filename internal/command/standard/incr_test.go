@@ -0,0 +1,43 @@
+package standard_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+func TestIncr_Errors(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	const notInteger = "-ERR value is not an integer or out of range\r\n"
+	const overflow = "-ERR increment or decrement would overflow\r\n"
+
+	s.Expect("+OK\r\n", "SET", "text", "abc")
+	s.Expect(notInteger, "INCR", "text")
+	s.Expect(notInteger, "DECRBY", "text", "1")
+	s.Expect("-ERR value is not a valid float\r\n", "INCRBYFLOAT", "text", "1.5")
+
+	for _, bad := range []string{"", " 1", "+1", "01", "-0", "1.0", "99999999999999999999"} {
+		s.Expect("+OK\r\n", "SET", "bad", bad)
+		s.Expect(notInteger, "INCR", "bad")
+	}
+	s.Expect(notInteger, "INCRBY", "n", "x")
+
+	s.Expect("+OK\r\n", "SET", "max", strconv.FormatInt(1<<63-1, 10))
+	s.Expect(overflow, "INCR", "max")
+	s.Expect(overflow, "INCRBY", "max", "1")
+	s.Expect("+OK\r\n", "SET", "min", strconv.FormatInt(-1<<63, 10))
+	s.Expect(overflow, "DECR", "min")
+	s.Expect("-ERR decrement would overflow\r\n", "DECRBY", "n", strconv.FormatInt(-1<<63, 10))
+	s.Expect("+OK\r\n", "SET", "f", "1e308")
+	s.Expect("-ERR increment would produce NaN or Infinity\r\n", "INCRBYFLOAT", "f", "1e308")
+}
+
+func TestIncr_WrongType(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	s.Write("list", storage.TypeList, []byte{})
+	s.Expect("-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", "INCR", "list")
+	s.Expect("-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", "INCRBYFLOAT", "list", "1")
+}
@@ -0,0 +1,42 @@
+package standard
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// MGet returns the values of several keys read at one consistent version.
+// Usage: MGET key [key ...]
+func MGet(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	keys := make([]string, len(args))
+	for i, arg := range args {
+		keys[i] = string(arg)
+	}
+
+	entries, _ := ctx.Engine.GetMany(keys)
+	result := make([]protocol.RESPValue, len(entries))
+	for i, entry := range entries {
+		// like Redis, keys holding other types read as missing
+		if !entry.Exists || entry.Type != storage.TypeString {
+			result[i] = protocol.NewNullBulkString()
+			continue
+		}
+		result[i] = protocol.NewBulkString(entry.Value)
+	}
+	return protocol.NewArray(result)
+}
+
+func MGetSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "MGET",
+		Handler:     command.HandlerFunc(MGet),
+		MinArgs:     1,
+		MaxArgs:     -1,
+		Description: "Get the values of multiple keys at one consistent version.",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.AllKeys,
+	}
+}
@@ -0,0 +1,79 @@
+package standard
+
+import (
+	"errors"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// MSet atomically sets several keys under one shared version.
+// Usage: MSET key value [key value ...]
+func MSet(ctx *command.Context, cmd *protocol.Command) command.Result {
+	batch, errResult := pairsBatch(cmd, "mset")
+	if errResult != nil {
+		return errResult
+	}
+	if _, err := ctx.Engine.Write(batch); err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+// MSetNX atomically sets several keys under one shared version, only if none of them exist.
+// Usage: MSETNX key value [key value ...]
+func MSetNX(ctx *command.Context, cmd *protocol.Command) command.Result {
+	batch, errResult := pairsBatch(cmd, "msetnx")
+	if errResult != nil {
+		return errResult
+	}
+	_, err := ctx.Engine.Write(batch.IfAbsent())
+	if errors.Is(err, storage.ErrBatchConflict) {
+		return protocol.NewInteger(0)
+	}
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	return protocol.NewInteger(1)
+}
+
+// pairsBatch builds a batch from key value argument pairs
+func pairsBatch(cmd *protocol.Command, name string) (*storage.Batch, command.Result) {
+	args := cmd.Args()
+	if len(args)%2 != 0 {
+		return nil, protocol.NewError("ERR wrong number of arguments for '" + name + "' command")
+	}
+
+	batch := storage.NewBatch()
+	for i := 0; i < len(args); i += 2 {
+		batch.Set(string(args[i]), args[i+1])
+	}
+	return batch, nil
+}
+
+func MSetSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "MSET",
+		Handler:     command.HandlerFunc(MSet),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Atomically set multiple keys under one version.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.KeyValuePairs,
+	}
+}
+
+func MSetNXSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "MSETNX",
+		Handler:     command.HandlerFunc(MSetNX),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Atomically set multiple keys under one version if none exist.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.KeyValuePairs,
+	}
+}
@@ -0,0 +1,71 @@
+package standard
+
+import (
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// embstrLimit is the longest string Redis reports as embstr
+const embstrLimit = 44
+
+// Object inspects the internals of a key without counting as an access to it.
+// Usage: OBJECT ENCODING|IDLETIME|FREQ key
+func Object(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	sub := strings.ToUpper(string(args[0]))
+	switch sub {
+	case "ENCODING", "IDLETIME", "FREQ":
+	default:
+		return protocol.NewError("ERR unknown subcommand '" + string(args[0]) + "'. Try OBJECT ENCODING|IDLETIME|FREQ.")
+	}
+	if len(args) != 2 {
+		return protocol.NewError("ERR wrong number of arguments for 'object|" + strings.ToLower(sub) + "' command")
+	}
+
+	info, err := ctx.Engine.KeyInfo(string(args[1]))
+	if err != nil || !info.Exists {
+		return protocol.NewNullBulkString()
+	}
+	switch sub {
+	case "ENCODING":
+		return protocol.NewBulkString([]byte(encoding(info)))
+	case "IDLETIME":
+		return protocol.NewInteger(max(ctx.Now().Unix()-info.LastAccess, 0))
+	default:
+		return protocol.NewInteger(int64(info.Frequency))
+	}
+}
+
+// encoding names the representation of a key's head the way Redis does
+func encoding(info storage.KeyInfo) string {
+	switch info.Type {
+	case storage.TypeHash, storage.TypeSet:
+		return "hashtable"
+	case storage.TypeList:
+		return "quicklist"
+	case storage.TypeZSet:
+		return "skiplist"
+	case storage.TypeStream:
+		return "stream"
+	}
+	if info.Codec == storage.CodecNone && info.HeadBytes <= embstrLimit {
+		return "embstr"
+	}
+	return "raw"
+}
+
+func ObjectSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "OBJECT",
+		Handler:     command.HandlerFunc(Object),
+		MinArgs:     1,
+		MaxArgs:     2,
+		Description: "Inspect the internals of a key: OBJECT ENCODING|IDLETIME|FREQ key",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.KeyAt(1),
+	}
+}
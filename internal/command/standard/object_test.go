@@ -0,0 +1,24 @@
+package standard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+)
+
+// TestObject_IdleTimeFollowsClock verifies IDLETIME is measured on the clock
+// the accesses were recorded with
+func TestObject_IdleTimeFollowsClock(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	now := time.Now().Add(time.Hour)
+	s.Ctx.Clock = func() time.Time { return now }
+
+	s.Expect("+OK\r\n", "SET", "k", "v")
+	s.Expect("$1\r\nv\r\n", "GET", "k")
+	now = now.Add(100 * time.Second)
+	s.Expect(":100\r\n", "OBJECT", "IDLETIME", "k")
+	s.Expect("$6\r\nembstr\r\n", "OBJECT", "ENCODING", "k")
+	s.Expect("$-1\r\n", "OBJECT", "IDLETIME", "missing")
+}
@@ -22,7 +22,7 @@ func PingSpec() *command.CommandSpec {
 		MinArgs:     0,
 		MaxArgs:     1,
 		Description: "Simple health check. Returns PONG or echoes the argument.",
-		ReadOnly:    true,
+		ReadOnly:    false,
 		Mutates:     false,
 	}
 }
@@ -0,0 +1,34 @@
+package standard
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// getString returns a key's latest value, failing with storage.ErrWrongType for non-strings
+func getString(ctx *command.Context, key string) ([]byte, bool, error) {
+	entry, err := ctx.Engine.Lookup(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if !entry.Is(storage.TypeString) {
+		return nil, false, storage.ErrWrongType
+	}
+	return entry.Value, entry.Exists, nil
+}
+
+// readValue returns a key's latest string value, or its value at version when
+// atVersion is set. Missing keys, deleted keys and unknown versions read as absent.
+func readValue(ctx *command.Context, key string, version uint64, atVersion bool) ([]byte, bool, error) {
+	if !atVersion {
+		return getString(ctx, key)
+	}
+	entry, err := ctx.Engine.LookupAt(key, version)
+	if err != nil {
+		return nil, false, nil
+	}
+	if !entry.Is(storage.TypeString) {
+		return nil, false, storage.ErrWrongType
+	}
+	return entry.Value, entry.Exists, nil
+}
@@ -9,4 +9,24 @@ func RegisterAll(router *command.Router) {
 	router.Register(SetSpec())
 	router.Register(DelSpec())
 	router.Register(ExistsSpec())
+	router.Register(IncrSpec())
+	router.Register(DecrSpec())
+	router.Register(IncrBySpec())
+	router.Register(DecrBySpec())
+	router.Register(IncrByFloatSpec())
+	router.Register(AppendSpec())
+	router.Register(GetRangeSpec())
+	router.Register(SetRangeSpec())
+	router.Register(StrLenSpec())
+	router.Register(GetDelSpec())
+	router.Register(GetSetSpec())
+	router.Register(GetExSpec())
+	router.Register(MSetSpec())
+	router.Register(MSetNXSpec())
+	router.Register(MGetSpec())
+	router.Register(TypeSpec())
+	router.Register(RenameSpec())
+	router.Register(RenameNXSpec())
+	router.Register(CopySpec())
+	router.Register(ObjectSpec())
 }
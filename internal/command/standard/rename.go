@@ -0,0 +1,76 @@
+package standard
+
+import (
+	"errors"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// Rename renames a key, overwriting the destination. The version chain moves
+// with the key and the old name keeps a tombstone pointing at the new one.
+// Usage: RENAME key newkey
+func Rename(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	if _, err := rename(ctx, string(args[0]), string(args[1]), false); err != nil {
+		return renameError(err)
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+// RenameNX renames a key only if the new name does not exist.
+// Usage: RENAMENX key newkey
+func RenameNX(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	_, err := rename(ctx, string(args[0]), string(args[1]), true)
+	if errors.Is(err, storage.ErrKeyExists) {
+		return protocol.NewInteger(0)
+	}
+	if err != nil {
+		return renameError(err)
+	}
+	return protocol.NewInteger(1)
+}
+
+// rename renames a key on engines that can carry its history along
+func rename(ctx *command.Context, src, dst string, nx bool) (uint64, error) {
+	renamer, ok := ctx.Engine.(storage.Renamer)
+	if !ok {
+		return 0, storage.ErrUnsupported
+	}
+	return renamer.Rename(src, dst, nx)
+}
+
+func renameError(err error) command.Result {
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		return protocol.NewError(errNoSuchKey.Error())
+	}
+	return protocol.NewError("ERR " + err.Error())
+}
+
+func RenameSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "RENAME",
+		Handler:     command.HandlerFunc(Rename),
+		MinArgs:     2,
+		MaxArgs:     2,
+		Description: "Rename a key together with its version history.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKeys(2),
+	}
+}
+
+func RenameNXSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "RENAMENX",
+		Handler:     command.HandlerFunc(RenameNX),
+		MinArgs:     2,
+		MaxArgs:     2,
+		Description: "Rename a key with its history only if the new key does not exist.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKeys(2),
+	}
+}
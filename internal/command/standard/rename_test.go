@@ -0,0 +1,35 @@
+package standard_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/command/version"
+)
+
+// TestRename_ReplacesHistory verifies a key renamed onto an existing one takes
+// its history along in place of the destination's own, and that a refused
+// rename leaves no trace of the destination
+func TestRename_ReplacesHistory(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll, version.RegisterAll)
+	s.Expect("+OK\r\n", "SET", "src", "a")
+	s.Expect("+OK\r\n", "SET", "src", "b")
+	s.Expect("+OK\r\n", "SET", "dst", "x")
+	own := s.Version("SET", "dst", "y", "RETURNVERSION")
+	s.Expect("+OK\r\n", "RENAME", "src", "dst")
+
+	history := s.Do("HISTORY", "dst")
+	if !strings.HasPrefix(history, "*3\r\n") || !strings.Contains(history, "renamed_from:src") {
+		t.Errorf("HISTORY dst = %q, want the rename on top of src's 2 versions", history)
+	}
+	s.Expect("$1\r\nb\r\n", "GET", "dst")
+	s.Expect("$1\r\nb\r\n", "GETV", "dst", own)
+
+	s.Expect("-ERR no such key\r\n", "RENAME", "missing", "fresh")
+	s.Expect(":0\r\n", "RENAMENX", "dst", "dst")
+	s.Expect("$-1\r\n", "HISTORY", "fresh")
+	s.Expect(":0\r\n", "COPY", "missing", "fresh")
+	s.Expect("$-1\r\n", "HISTORY", "fresh")
+}
@@ -1,20 +1,136 @@
 package standard
 
 import (
+	"strings"
+	"time"
+
 	"github.com/ElshadHu/verdis/internal/command"
 	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
 )
 
+// setOptions holds the parsed SET flags
+type setOptions struct {
+	nx, xx        bool
+	get           bool
+	keepTTL       bool
+	expiresAt     int64
+	returnVersion bool
+}
+
 // Set stores a value under the given key
-// Usage: SET [key] [value]
+// Usage: SET key value [NX | XX] [GET] [EX seconds | PX milliseconds |
+// EXAT unix-time-seconds | PXAT unix-time-milliseconds | KEEPTTL] [RETURNVERSION]
+// [COMMENT text]
 func Set(ctx *command.Context, cmd *protocol.Command) command.Result {
-	key := string(cmd.Args()[0])
-	value := cmd.Args()[1]
+	args, comment, err := command.SplitComment(cmd.Args(), 2)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) < 2 {
+		return protocol.NewError(errSyntax.Error())
+	}
+	key := string(args[0])
+	value := args[1]
+	engine := ctx.Annotate(comment)
+
+	opts, err := parseSetOptions(args[2:], ctx.Now())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	// plain SET is a blind write and skips the read-modify-write loop
+	if !opts.nx && !opts.xx && !opts.get && !opts.keepTTL && opts.expiresAt == 0 {
+		version := engine.Set(key, value)
+		if opts.returnVersion {
+			return protocol.NewInteger(int64(version))
+		}
+		return protocol.NewSimpleString("OK")
+	}
 
-	ctx.Engine.Set(key, value)
-	// Elshad: we can come back here boi
-	// Dan: alright G
-	return protocol.NewSimpleString("OK")
+	var old []byte
+	var existed bool
+	version, err := engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		if opts.get && !current.Is(storage.TypeString) {
+			return current, storage.ErrWrongType
+		}
+		old, existed = current.Value, current.Exists
+
+		// NX/XX are checked against the head inside the CAS loop, so a
+		// concurrent writer cannot slip in between the check and the write
+		if (opts.nx && current.Exists) || (opts.xx && !current.Exists) {
+			return current, storage.ErrSkipWrite
+		}
+
+		next := storage.Entry{Value: value, Exists: true, ExpiresAt: opts.expiresAt}
+		if opts.keepTTL {
+			next.ExpiresAt = current.ExpiresAt
+		}
+		return next, nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	return setReply(opts, version, old, existed)
+}
+
+// setReply builds the SET response. GET replies with the old value, RETURNVERSION
+// with the version the write was assigned and both together with [old, version].
+// Writes skipped by NX/XX reply with null in place of OK or the version.
+func setReply(opts setOptions, version uint64, old []byte, existed bool) command.Result {
+	var oldValue, written protocol.RESPValue = protocol.NewNullBulkString(), protocol.NewNullBulkString()
+	if existed {
+		oldValue = protocol.NewBulkString(old)
+	}
+	if version != 0 {
+		written = protocol.NewSimpleString("OK")
+		if opts.returnVersion {
+			written = protocol.NewInteger(int64(version))
+		}
+	}
+
+	switch {
+	case opts.get && opts.returnVersion:
+		return protocol.NewArray([]protocol.RESPValue{oldValue, written})
+	case opts.get:
+		return oldValue
+	default:
+		return written
+	}
+}
+
+// parseSetOptions parses the flags following SET key value
+func parseSetOptions(args [][]byte, now time.Time) (setOptions, error) {
+	var opts setOptions
+	hasExpiry := false
+
+	for i := 0; i < len(args); i++ {
+		opt := strings.ToUpper(string(args[i]))
+		switch {
+		case opt == "NX" && !opts.xx:
+			opts.nx = true
+		case opt == "XX" && !opts.nx:
+			opts.xx = true
+		case opt == "GET":
+			opts.get = true
+		case opt == "RETURNVERSION":
+			opts.returnVersion = true
+		case opt == "KEEPTTL" && !hasExpiry:
+			opts.keepTTL = true
+		case isExpiryOption(opt) && !hasExpiry && !opts.keepTTL && i+1 < len(args):
+			expiresAt, err := parseExpiry("set", opt, args[i+1], now)
+			if err != nil {
+				return opts, err
+			}
+			opts.expiresAt = expiresAt
+			hasExpiry = true
+			i++
+		default:
+			return opts, errSyntax
+		}
+	}
+	return opts, nil
 }
 
 func SetSpec() *command.CommandSpec {
@@ -22,9 +138,10 @@ func SetSpec() *command.CommandSpec {
 		Name:        "SET",
 		Handler:     command.HandlerFunc(Set),
 		MinArgs:     2,
-		MaxArgs:     2,
-		Description: "Set key to value.",
+		MaxArgs:     -1,
+		Description: "Set key to value: SET key value [NX|XX] [GET] [EX|PX|EXAT|PXAT n|KEEPTTL] [RETURNVERSION]",
 		ReadOnly:    false,
 		Mutates:     true,
+		Keys:        command.FirstKey,
 	}
 }
@@ -0,0 +1,180 @@
+package standard_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+func TestSet_Options(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+
+	s.Expect("$-1\r\n", "SET", "k", "a", "XX")
+	s.Expect("+OK\r\n", "SET", "k", "a", "NX")
+	s.Expect("$-1\r\n", "SET", "k", "b", "NX")
+	s.Expect("+OK\r\n", "SET", "k", "b", "XX")
+
+	s.Expect("$1\r\nb\r\n", "SET", "k", "c", "GET")
+	s.Expect("$-1\r\n", "SET", "fresh", "x", "GET")
+	s.Expect("$1\r\nc\r\n", "SET", "k", "d", "NX", "GET")
+	s.Expect("$1\r\nc\r\n", "GET", "k")
+
+	v := s.Version("SET", "k", "e", "RETURNVERSION")
+	s.Expect("*2\r\n$1\r\ne\r\n:"+strconv.Itoa(mustAtoi(t, v)+1)+"\r\n", "SET", "k", "f", "GET", "RETURNVERSION")
+	s.Expect("*2\r\n$1\r\nf\r\n$-1\r\n", "SET", "k", "g", "NX", "GET", "RETURNVERSION")
+}
+
+func TestSet_KeepTTL(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	s.Expect("+OK\r\n", "SET", "k", "a", "EX", "100")
+	before, _ := s.Ctx.Engine.Lookup("k")
+	if before.ExpiresAt == 0 {
+		t.Fatalf("SET EX left no expiry")
+	}
+
+	s.Expect("+OK\r\n", "SET", "k", "b", "KEEPTTL")
+	after, _ := s.Ctx.Engine.Lookup("k")
+	if string(after.Value) != "b" || after.ExpiresAt != before.ExpiresAt {
+		t.Errorf("after KEEPTTL = %q expiring at %d, want %q expiring at %d",
+			after.Value, after.ExpiresAt, "b", before.ExpiresAt)
+	}
+
+	s.Expect("+OK\r\n", "SET", "k", "c")
+	if cleared, _ := s.Ctx.Engine.Lookup("k"); cleared.ExpiresAt != 0 {
+		t.Errorf("plain SET kept expiry %d", cleared.ExpiresAt)
+	}
+}
+
+func TestSet_Expiry(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	s.Expect("+OK\r\n", "SET", "k", "a", "PX", "30")
+	s.Expect("$1\r\na\r\n", "GET", "k")
+	time.Sleep(40 * time.Millisecond)
+	s.Expect("$-1\r\n", "GET", "k")
+	s.Expect("+OK\r\n", "SET", "k", "b", "NX")
+
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).UnixMilli(), 10)
+	s.Expect("+OK\r\n", "SET", "gone", "a", "PXAT", past)
+	s.Expect("$-1\r\n", "GET", "gone")
+}
+
+func TestSet_OptionErrors(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	const syntax = "-ERR syntax error\r\n"
+
+	for _, args := range [][]string{
+		{"NX", "XX"},
+		{"XX", "NX"},
+		{"EX", "10", "PX", "100"},
+		{"EX", "10", "KEEPTTL"},
+		{"KEEPTTL", "PXAT", "100"},
+		{"EX"},
+		{"BOGUS"},
+	} {
+		s.Expect(syntax, append([]string{"SET", "k", "v"}, args...)...)
+	}
+	s.Expect("-ERR value is not an integer or out of range\r\n", "SET", "k", "v", "EX", "ten")
+	s.Expect("-ERR invalid expire time in 'set' command\r\n", "SET", "k", "v", "EX", "0")
+	s.Expect("-ERR invalid expire time in 'set' command\r\n", "SET", "k", "v", "PX", "-5")
+	s.Expect("$-1\r\n", "GET", "k")
+
+	s.Write("list", storage.TypeList, []byte{})
+	s.Expect("-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", "SET", "list", "v", "GET")
+	s.Expect("+OK\r\n", "SET", "list", "v")
+}
+
+// TestSet_ConcurrentNX verifies exactly one of many racing SET NX wins
+func TestSet_ConcurrentNX(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	const writers = 32
+
+	var wg sync.WaitGroup
+	replies := make([]string, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func(conn *commandtest.Session) {
+			defer wg.Done()
+			replies[i] = conn.Do("SET", "k", strconv.Itoa(i), "NX")
+		}(s.Fork())
+	}
+	wg.Wait()
+
+	winner := -1
+	for i, reply := range replies {
+		if reply != "+OK\r\n" {
+			continue
+		}
+		if winner >= 0 {
+			t.Fatalf("writers %d and %d both won SET NX", winner, i)
+		}
+		winner = i
+	}
+	if winner < 0 {
+		t.Fatalf("no writer won SET NX")
+	}
+	want := strconv.Itoa(winner)
+	s.Expect("$"+strconv.Itoa(len(want))+"\r\n"+want+"\r\n", "GET", "k")
+}
+
+// TestSet_ConcurrentXX verifies racing SET XX GET writers each replace a
+// different value, so no write is lost between the check and the swap
+func TestSet_ConcurrentXX(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	const writers = 32
+	s.Expect("+OK\r\n", "SET", "k", "init")
+
+	var wg sync.WaitGroup
+	replies := make([]string, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func(conn *commandtest.Session) {
+			defer wg.Done()
+			replies[i] = conn.Do("SET", "k", strconv.Itoa(i), "XX", "GET")
+		}(s.Fork())
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for i, reply := range replies {
+		if reply == "$-1\r\n" {
+			t.Fatalf("writer %d saw the key missing", i)
+		}
+		if seen[reply] {
+			t.Fatalf("two writers replaced the same value %q", reply)
+		}
+		seen[reply] = true
+	}
+	if last := s.Do("GET", "k"); seen[last] {
+		t.Errorf("final value %q was also replaced by a writer", last)
+	}
+}
+
+func mustAtoi(t *testing.T, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// TestComment_OnlyAfterFixedArgs verifies COMMENT is a keyword only after a
+// command's fixed arguments, so keys and values may be named COMMENT
+func TestComment_OnlyAfterFixedArgs(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll)
+	s.Expect("+OK\r\n", "SET", "COMMENT", "foo")
+	s.Expect("$3\r\nfoo\r\n", "GET", "COMMENT")
+	s.Expect("+OK\r\n", "SET", "x", "1")
+	s.Expect(":2\r\n", "DEL", "COMMENT", "x")
+
+	s.Expect("+OK\r\n", "SET", "k", "v", "COMMENT", "why")
+	history, err := s.Ctx.Engine.History("k", 0)
+	if err != nil || history[0].Comment != "why" {
+		t.Errorf("History = %+v, %v, want the comment recorded", history, err)
+	}
+}
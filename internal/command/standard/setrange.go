@@ -0,0 +1,60 @@
+package standard
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// maxStringSize is the largest value SETRANGE may produce (same as Redis)
+const maxStringSize = 512 * 1024 * 1024
+
+// SetRange overwrites part of a key's value starting at offset as a new version,
+// zero padding the value if it is shorter than offset.
+// Usage: SETRANGE key offset value
+func SetRange(ctx *command.Context, cmd *protocol.Command) command.Result {
+	key := string(cmd.Args()[0])
+	offset, err := command.ParseInt(cmd.Args()[1])
+	if err != nil {
+		return protocol.NewError(errNotInteger.Error())
+	}
+	patch := cmd.Args()[2]
+	if offset < 0 || offset+int64(len(patch)) > maxStringSize {
+		return protocol.NewError("ERR offset is out of range")
+	}
+
+	var length int
+	_, err = ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		if !current.Is(storage.TypeString) {
+			return current, storage.ErrWrongType
+		}
+		length = len(current.Value)
+		// an empty patch never creates or changes the key
+		if len(patch) == 0 {
+			return current, storage.ErrSkipWrite
+		}
+
+		next := make([]byte, max(len(current.Value), int(offset)+len(patch)))
+		copy(next, current.Value)
+		copy(next[offset:], patch)
+		length = len(next)
+		return current.WithValue(next), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(int64(length))
+}
+
+func SetRangeSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "SETRANGE",
+		Handler:     command.HandlerFunc(SetRange),
+		MinArgs:     3,
+		MaxArgs:     3,
+		Description: "Overwrite part of a key's value starting at offset.",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,37 @@
+package standard
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// StrLen returns the length of a key's value, optionally at a historical version.
+// Usage: STRLEN key [AT version]
+func StrLen(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 1 {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	value, _, err := readValue(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(int64(len(value)))
+}
+
+func StrLenSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "STRLEN",
+		Handler:     command.HandlerFunc(StrLen),
+		MinArgs:     1,
+		MaxArgs:     3,
+		Description: "Get the length of a key's value: STRLEN key [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,32 @@
+package standard
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// Type returns the type of the value stored at key, or "none".
+// Usage: TYPE key
+func Type(ctx *command.Context, cmd *protocol.Command) command.Result {
+	entry, err := ctx.Engine.Lookup(string(cmd.Args()[0]))
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	if !entry.Exists {
+		return protocol.NewSimpleString("none")
+	}
+	return protocol.NewSimpleString(entry.Type.String())
+}
+
+func TypeSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "TYPE",
+		Handler:     command.HandlerFunc(Type),
+		MinArgs:     1,
+		MaxArgs:     1,
+		Description: "Get the type of the value stored at a key.",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,131 @@
+package stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+var (
+	errSyntax     = errors.New("ERR syntax error")
+	errNotInteger = errors.New("ERR value is not an integer or out of range")
+	errInvalidID  = errors.New("ERR Invalid stream ID specified as stream command argument")
+	errExplicitID = errors.New("ERR explicit stream IDs are not supported, entry IDs are assigned from the global version: use *")
+)
+
+// decode returns the stream held by entry, empty for missing keys and
+// storage.ErrWrongType when the key holds another type
+func decode(entry storage.Entry) (*datastructures.Stream, error) {
+	if !entry.Exists {
+		return datastructures.NewStream(), nil
+	}
+	if entry.Type != storage.TypeStream {
+		return nil, storage.ErrWrongType
+	}
+	return datastructures.DecodeStream(entry.Value)
+}
+
+// streamEntry wraps an encoded stream as the next state of a key, keeping its
+// expiry. Unlike other collections an empty stream keeps its key.
+func streamEntry(current storage.Entry, s *datastructures.Stream) storage.Entry {
+	return storage.Entry{Value: s.Encode(), Type: storage.TypeStream, Exists: true, ExpiresAt: current.ExpiresAt}
+}
+
+// readStream returns a key's latest stream, or the stream as of version when
+// atVersion is set. Missing keys, deleted keys and unknown versions read as empty.
+func readStream(ctx *command.Context, key string, version uint64, atVersion bool) (*datastructures.Stream, error) {
+	var entry storage.Entry
+	var err error
+	if atVersion {
+		if entry, err = ctx.Engine.LookupAt(key, version); err != nil {
+			return datastructures.NewStream(), nil
+		}
+	} else if entry, err = ctx.Engine.Lookup(key); err != nil {
+		return nil, errors.New("ERR " + err.Error())
+	}
+	return decode(entry)
+}
+
+// parseRangeID parses an XRANGE bound: - and + for the extremes, ( for an
+// exclusive bound, and ms or ms-seq. ok is false when an exclusive bound leaves
+// nothing to return.
+func parseRangeID(arg []byte, end bool) (id datastructures.StreamID, ok bool, err error) {
+	s := string(arg)
+	switch s {
+	case "-":
+		return datastructures.StreamID{}, true, nil
+	case "+":
+		return datastructures.MaxStreamID, true, nil
+	}
+
+	exclusive := strings.HasPrefix(s, "(")
+	s = strings.TrimPrefix(s, "(")
+	defaultSeq := uint64(0)
+	if end {
+		defaultSeq = datastructures.MaxStreamID.Seq
+	}
+	if id, err = datastructures.ParseStreamID(s, defaultSeq); err != nil {
+		return id, false, errInvalidID
+	}
+
+	if !exclusive {
+		return id, true, nil
+	}
+	if end {
+		id, ok = id.Prev()
+	} else {
+		id, ok = id.Next()
+	}
+	return id, ok, nil
+}
+
+// parseID parses an exact ID argument such as a XACK or XREAD position
+func parseID(arg []byte) (datastructures.StreamID, error) {
+	id, err := datastructures.ParseStreamID(string(arg), 0)
+	if err != nil {
+		return id, errInvalidID
+	}
+	return id, nil
+}
+
+func nowMillis(ctx *command.Context) int64 {
+	return ctx.Now().UnixMilli()
+}
+
+func parseCount(arg []byte) (int, error) {
+	n, err := strconv.ParseInt(string(arg), 10, 64)
+	if err != nil || n < 0 {
+		return 0, errNotInteger
+	}
+	return int(n), nil
+}
+
+// entryReply renders an entry as [id, [field, value, ...]]
+func entryReply(e datastructures.StreamEntry) protocol.RESPValue {
+	fields := make([]protocol.RESPValue, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = protocol.NewBulkString(f)
+	}
+	return protocol.NewArray([]protocol.RESPValue{
+		protocol.NewBulkString([]byte(e.ID.String())),
+		protocol.NewArray(fields),
+	})
+}
+
+func entriesReply(entries []datastructures.StreamEntry) protocol.RESPValue {
+	values := make([]protocol.RESPValue, len(entries))
+	for i, e := range entries {
+		values[i] = entryReply(e)
+	}
+	return protocol.NewArray(values)
+}
+
+// streamReply renders one [key, entries] element of an XREAD or XREADGROUP reply
+func streamReply(key string, entries protocol.RESPValue) protocol.RESPValue {
+	return protocol.NewArray([]protocol.RESPValue{protocol.NewBulkString([]byte(key)), entries})
+}
@@ -0,0 +1,17 @@
+package stream
+
+import "github.com/ElshadHu/verdis/internal/command"
+
+// RegisterAll adds all command specs into the router.
+func RegisterAll(router *command.Router) {
+	router.Register(XAddSpec())
+	router.Register(XRangeSpec())
+	router.Register(XRevRangeSpec())
+	router.Register(XLenSpec())
+	router.Register(XTrimSpec())
+	router.Register(XReadSpec())
+	router.Register(XGroupSpec())
+	router.Register(XReadGroupSpec())
+	router.Register(XAckSpec())
+	router.Register(XPendingSpec())
+}
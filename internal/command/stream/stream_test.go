@@ -0,0 +1,81 @@
+package stream_test
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/database"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/command/stream"
+)
+
+func newSession(t *testing.T) *commandtest.Session {
+	return commandtest.NewSession(t, stream.RegisterAll, standard.RegisterAll, database.RegisterAll)
+}
+
+// xadd appends an entry and returns its ID
+func xadd(t *testing.T, s *commandtest.Session, key string, fields ...string) string {
+	t.Helper()
+	reply := s.Do(append([]string{"XADD", key, "*"}, fields...)...)
+	_, id, ok := strings.Cut(strings.TrimSuffix(reply, "\r\n"), "\r\n")
+	if !strings.HasPrefix(reply, "$") || !ok {
+		t.Fatalf("XADD %s = %q, want an ID", key, reply)
+	}
+	return id
+}
+
+// TestXAdd_IDsFollowTheStream verifies entry IDs are stored with the entries, so
+// rewriting the key under a new version keeps them and later IDs keep increasing
+func TestXAdd_IDsFollowTheStream(t *testing.T) {
+	s := newSession(t)
+	xadd(t, s, "events", "n", "1")
+	last := xadd(t, s, "events", "n", "2")
+	entries := s.Do("XRANGE", "events", "-", "+")
+
+	s.Expect("+OK\r\n", "RENAME", "events", "renamed")
+	s.Expect(entries, "XRANGE", "renamed", "-", "+")
+
+	s.Expect(":1\r\n", "COPY", "renamed", "copied")
+	s.Expect(entries, "XRANGE", "copied", "-", "+")
+
+	s.Expect("+OK\r\n", "FLUSHDB")
+	s.Expect(":2\r\n", "RESTOREDB")
+	s.Expect(entries, "XRANGE", "renamed", "-", "+")
+	s.Expect(entries, "XRANGE", "copied", "-", "+")
+
+	for _, key := range []string{"renamed", "copied"} {
+		if id := xadd(t, s, key, "n", "3"); compareIDs(id, last) <= 0 {
+			t.Errorf("XADD %s after rewrites = %s, want past %s", key, id, last)
+		}
+	}
+}
+
+func TestXAdd_IDsIncrease(t *testing.T) {
+	s := newSession(t)
+	prev := xadd(t, s, "events", "n", "0")
+	for range 20 {
+		s.Expect("+OK\r\n", "SET", "other", "x")
+		id := xadd(t, s, "events", "n", "1")
+		if compareIDs(id, prev) <= 0 {
+			t.Fatalf("XADD = %s after %s", id, prev)
+		}
+		prev = id
+	}
+	s.Expect(":21\r\n", "XLEN", "events")
+}
+
+// compareIDs compares two ms-seq stream IDs
+func compareIDs(a, b string) int {
+	split := func(id string) (uint64, uint64) {
+		ms, seq, _ := strings.Cut(id, "-")
+		msN, _ := strconv.ParseUint(ms, 10, 64)
+		seqN, _ := strconv.ParseUint(seq, 10, 64)
+		return msN, seqN
+	}
+	aMs, aSeq := split(a)
+	bMs, bSeq := split(b)
+	return cmp.Or(cmp.Compare(aMs, bMs), cmp.Compare(aSeq, bSeq))
+}
@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/datastructures"
+)
+
+// trimStrategy selects how XADD and XTRIM cap a stream
+type trimStrategy int
+
+const (
+	trimNone trimStrategy = iota
+	trimMaxLen
+	trimMinID
+)
+
+// trimOptions holds a parsed MAXLEN|MINID [=|~] threshold [LIMIT count] clause.
+// Trimming is always exact, so ~ and LIMIT are accepted for compatibility only.
+type trimOptions struct {
+	strategy trimStrategy
+	maxLen   int
+	minID    datastructures.StreamID
+}
+
+// parseTrim parses a trim clause starting at args[i], which holds MAXLEN or MINID,
+// and returns the index of the first argument after it
+func parseTrim(args [][]byte, i int) (trimOptions, int, error) {
+	var opts trimOptions
+	switch strings.ToUpper(string(args[i])) {
+	case "MAXLEN":
+		opts.strategy = trimMaxLen
+	case "MINID":
+		opts.strategy = trimMinID
+	default:
+		return opts, i, errSyntax
+	}
+	i++
+
+	approximate := false
+	if i < len(args) && (string(args[i]) == "=" || string(args[i]) == "~") {
+		approximate = string(args[i]) == "~"
+		i++
+	}
+	if i >= len(args) {
+		return opts, i, errSyntax
+	}
+
+	var err error
+	if opts.strategy == trimMaxLen {
+		opts.maxLen, err = parseCount(args[i])
+	} else {
+		opts.minID, err = parseID(args[i])
+	}
+	if err != nil {
+		return opts, i, err
+	}
+	i++
+
+	if i+1 < len(args) && strings.EqualFold(string(args[i]), "LIMIT") {
+		if !approximate {
+			return opts, i, errSyntax
+		}
+		if _, err := parseCount(args[i+1]); err != nil {
+			return opts, i, err
+		}
+		i += 2
+	}
+	return opts, i, nil
+}
+
+// apply trims s and returns how many entries were removed
+func (t trimOptions) apply(s *datastructures.Stream) int {
+	switch t.strategy {
+	case trimMaxLen:
+		return s.TrimLen(t.maxLen)
+	case trimMinID:
+		return s.TrimMinID(t.minID)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,63 @@
+package stream
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// XAck removes entries from a group's pending entries list as a new version and
+// returns how many were pending.
+// Usage: XACK key group id [id ...]
+func XAck(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	ids := make([]datastructures.StreamID, len(args)-2)
+	for i, arg := range args[2:] {
+		var err error
+		if ids[i], err = parseID(arg); err != nil {
+			return protocol.NewError(err.Error())
+		}
+	}
+
+	var acked int64
+	_, err := ctx.Engine.Update(string(args[0]), func(current storage.Entry) (storage.Entry, error) {
+		s, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+
+		acked = 0
+		group, ok := s.Groups[string(args[1])]
+		if !ok {
+			return current, storage.ErrSkipWrite
+		}
+		for _, id := range ids {
+			if i, pending := group.PendingIndex(id); pending {
+				group.Pending = append(group.Pending[:i], group.Pending[i+1:]...)
+				acked++
+			}
+		}
+		if acked == 0 {
+			return current, storage.ErrSkipWrite
+		}
+		return streamEntry(current, s), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(acked)
+}
+
+func XAckSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "XACK",
+		Handler:     command.HandlerFunc(XAck),
+		MinArgs:     3,
+		MaxArgs:     -1,
+		Description: "Acknowledge stream entries of a consumer group: XACK key group id [id ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
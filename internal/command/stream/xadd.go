@@ -0,0 +1,91 @@
+package stream
+
+import (
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// XAdd appends an entry to a stream as a new version. The entry ID is taken from
+// the global version and stored with the entry, so stream order matches the order
+// of every other write and the ID survives RENAME, COPY and restores.
+// Usage: XADD key [NOMKSTREAM] [MAXLEN|MINID [=|~] threshold [LIMIT count]] * field value [field value ...]
+func XAdd(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	key := string(args[0])
+
+	noMkStream := false
+	var trim trimOptions
+	i := 1
+loop:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(string(args[i])) {
+		case "NOMKSTREAM":
+			noMkStream = true
+		case "MAXLEN", "MINID":
+			var err error
+			if trim, i, err = parseTrim(args, i); err != nil {
+				return protocol.NewError(err.Error())
+			}
+			i--
+		default:
+			break loop
+		}
+	}
+
+	if i >= len(args) {
+		return protocol.NewError(errSyntax.Error())
+	}
+	if string(args[i]) != "*" {
+		return protocol.NewError(errExplicitID.Error())
+	}
+	fields := args[i+1:]
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		return protocol.NewError("ERR wrong number of arguments for 'xadd' command")
+	}
+
+	var id datastructures.StreamID
+	version, err := ctx.Engine.UpdateVersioned(key, func(current storage.Entry, version uint64) (storage.Entry, error) {
+		s, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+		if !current.Exists && noMkStream {
+			return current, storage.ErrSkipWrite
+		}
+
+		// MINID is applied before the append so the new entry always survives it
+		if trim.strategy == trimMinID {
+			trim.apply(s)
+		}
+		id = s.NextID(version)
+		s.Append(id, fields)
+		if trim.strategy == trimMaxLen {
+			trim.apply(s)
+		}
+		return streamEntry(current, s), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if version == 0 {
+		return protocol.NewNullBulkString()
+	}
+	return protocol.NewBulkString([]byte(id.String()))
+}
+
+func XAddSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "XADD",
+		Handler:     command.HandlerFunc(XAdd),
+		MinArgs:     4,
+		MaxArgs:     -1,
+		Description: "Append an entry to a stream: XADD key [NOMKSTREAM] [MAXLEN|MINID [=|~] threshold] * field value [field value ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
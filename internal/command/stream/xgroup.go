@@ -0,0 +1,147 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+var errNoKey = errors.New("ERR The XGROUP subcommand requires the key to exist. " +
+	"Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically.")
+
+func errNoGroup(key, group string) error {
+	return fmt.Errorf("NOGROUP No such consumer group '%s' for key name '%s'", group, key)
+}
+
+// XGroup manages consumer groups. Group state is part of the stream value, so
+// every change is a version of the key.
+// Usage: XGROUP CREATE key group id|$ [MKSTREAM]
+//
+//	XGROUP SETID key group id|$
+//	XGROUP DESTROY key group
+//	XGROUP CREATECONSUMER key group consumer
+//	XGROUP DELCONSUMER key group consumer
+func XGroup(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	sub := strings.ToUpper(string(args[0]))
+
+	var arity int
+	switch sub {
+	case "CREATE", "SETID", "CREATECONSUMER", "DELCONSUMER":
+		arity = 4
+	case "DESTROY":
+		arity = 3
+	default:
+		return protocol.NewError(fmt.Sprintf("ERR unknown subcommand '%s'. Try XGROUP HELP.", args[0]))
+	}
+	mkStream := sub == "CREATE" && len(args) == 5 && strings.EqualFold(string(args[4]), "MKSTREAM")
+	if len(args) != arity && !mkStream {
+		return protocol.NewError(fmt.Sprintf("ERR wrong number of arguments for 'xgroup|%s' command", strings.ToLower(sub)))
+	}
+	key, name := string(args[1]), string(args[2])
+
+	var reply int64
+	_, err := ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		s, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+		if !current.Exists && !mkStream {
+			return current, errNoKey
+		}
+
+		group, exists := s.Groups[name]
+		if !exists && sub != "CREATE" && sub != "DESTROY" {
+			return current, errNoGroup(key, name)
+		}
+
+		switch sub {
+		case "CREATE":
+			if exists {
+				return current, errors.New("BUSYGROUP Consumer Group name already exists")
+			}
+			id, err := groupID(s, args[3])
+			if err != nil {
+				return current, err
+			}
+			s.Groups[name] = &datastructures.ConsumerGroup{Name: name, LastDelivered: id, Consumers: make(map[string]int64)}
+
+		case "SETID":
+			id, err := groupID(s, args[3])
+			if err != nil {
+				return current, err
+			}
+			group.LastDelivered = id
+
+		case "DESTROY":
+			if !exists {
+				reply = 0
+				return current, storage.ErrSkipWrite
+			}
+			delete(s.Groups, name)
+			reply = 1
+
+		case "CREATECONSUMER":
+			consumer := string(args[3])
+			if _, ok := group.Consumers[consumer]; ok {
+				reply = 0
+				return current, storage.ErrSkipWrite
+			}
+			group.Consumers[consumer] = nowMillis(ctx)
+			reply = 1
+
+		case "DELCONSUMER":
+			consumer := string(args[3])
+			if _, ok := group.Consumers[consumer]; !ok {
+				reply = 0
+				return current, storage.ErrSkipWrite
+			}
+			delete(group.Consumers, consumer)
+			kept := group.Pending[:0:0]
+			for _, p := range group.Pending {
+				if p.Consumer != consumer {
+					kept = append(kept, p)
+				}
+			}
+			reply = int64(len(group.Pending) - len(kept))
+			group.Pending = kept
+		}
+		return streamEntry(current, s), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	switch sub {
+	case "CREATE", "SETID":
+		return protocol.NewSimpleString("OK")
+	default:
+		return protocol.NewInteger(reply)
+	}
+}
+
+// groupID parses the starting ID of a group, $ meaning the stream's last entry
+func groupID(s *datastructures.Stream, arg []byte) (datastructures.StreamID, error) {
+	if string(arg) == "$" {
+		return s.LastID, nil
+	}
+	return parseID(arg)
+}
+
+func XGroupSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "XGROUP",
+		Handler:     command.HandlerFunc(XGroup),
+		MinArgs:     3,
+		MaxArgs:     5,
+		Description: "Manage stream consumer groups: XGROUP CREATE|SETID|DESTROY|CREATECONSUMER|DELCONSUMER key group ...",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.KeyAt(1),
+	}
+}
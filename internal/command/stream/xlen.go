@@ -0,0 +1,37 @@
+package stream
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// XLen returns the number of entries in a stream, optionally as of a past version.
+// Usage: XLEN key [AT version]
+func XLen(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 1 {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	s, err := readStream(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(int64(len(s.Entries)))
+}
+
+func XLenSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "XLEN",
+		Handler:     command.HandlerFunc(XLen),
+		MinArgs:     1,
+		MaxArgs:     3,
+		Description: "Get the number of entries in a stream: XLEN key [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
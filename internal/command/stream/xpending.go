@@ -0,0 +1,124 @@
+package stream
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// XPending inspects a group's pending entries list. Without a range it returns
+// [count, smallest id, greatest id, [[consumer, count] ...]], with one it returns
+// [id, consumer, idle milliseconds, deliveries] for each matching entry.
+// Usage: XPENDING key group [[IDLE min-idle-time] start end count [consumer]]
+func XPending(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	key, name := string(args[0]), string(args[1])
+
+	s, err := readStream(ctx, key, 0, false)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	group, ok := s.Groups[name]
+	if !ok {
+		return protocol.NewError(fmt.Sprintf("NOGROUP No such key '%s' or consumer group '%s'", key, name))
+	}
+
+	if len(args) == 2 {
+		if len(group.Pending) == 0 {
+			return protocol.NewArray([]protocol.RESPValue{
+				protocol.NewInteger(0), protocol.NewNullBulkString(), protocol.NewNullBulkString(), protocol.NewNullArray(),
+			})
+		}
+
+		perConsumer := make(map[string]int)
+		var order []string
+		for _, p := range group.Pending {
+			if perConsumer[p.Consumer] == 0 {
+				order = append(order, p.Consumer)
+			}
+			perConsumer[p.Consumer]++
+		}
+		consumers := make([]protocol.RESPValue, len(order))
+		for i, c := range order {
+			consumers[i] = protocol.NewArray([]protocol.RESPValue{
+				protocol.NewBulkString([]byte(c)),
+				protocol.NewBulkString([]byte(strconv.Itoa(perConsumer[c]))),
+			})
+		}
+		return protocol.NewArray([]protocol.RESPValue{
+			protocol.NewInteger(int64(len(group.Pending))),
+			protocol.NewBulkString([]byte(group.Pending[0].ID.String())),
+			protocol.NewBulkString([]byte(group.Pending[len(group.Pending)-1].ID.String())),
+			protocol.NewArray(consumers),
+		})
+	}
+
+	rest := args[2:]
+	var minIdle int64
+	if strings.EqualFold(string(rest[0]), "IDLE") {
+		if len(rest) < 2 {
+			return protocol.NewError(errSyntax.Error())
+		}
+		if minIdle, err = strconv.ParseInt(string(rest[1]), 10, 64); err != nil {
+			return protocol.NewError(errNotInteger.Error())
+		}
+		rest = rest[2:]
+	}
+	if len(rest) != 3 && len(rest) != 4 {
+		return protocol.NewError(errSyntax.Error())
+	}
+	start, startOK, err := parseRangeID(rest[0], false)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	end, endOK, err := parseRangeID(rest[1], true)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	count, err := parseCount(rest[2])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	consumer := ""
+	if len(rest) == 4 {
+		consumer = string(rest[3])
+	}
+
+	values := []protocol.RESPValue{}
+	if !startOK || !endOK {
+		return protocol.NewArray(values)
+	}
+	now := nowMillis(ctx)
+	for _, p := range group.Pending {
+		if len(values) == count || p.ID.Compare(end) > 0 {
+			break
+		}
+		idle := now - p.DeliveredAt
+		if p.ID.Compare(start) < 0 || (consumer != "" && p.Consumer != consumer) || idle < minIdle {
+			continue
+		}
+		values = append(values, protocol.NewArray([]protocol.RESPValue{
+			protocol.NewBulkString([]byte(p.ID.String())),
+			protocol.NewBulkString([]byte(p.Consumer)),
+			protocol.NewInteger(idle),
+			protocol.NewInteger(int64(p.Deliveries)),
+		}))
+	}
+	return protocol.NewArray(values)
+}
+
+func XPendingSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "XPENDING",
+		Handler:     command.HandlerFunc(XPending),
+		MinArgs:     2,
+		MaxArgs:     8,
+		Description: "Inspect the pending entries list of a consumer group: XPENDING key group [[IDLE min-idle-time] start end count [consumer]]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,97 @@
+package stream
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// XRange returns stream entries with IDs between start and end, optionally as of
+// a past version.
+// Usage: XRANGE key start end [COUNT count] [AT version]
+func XRange(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return xrange(ctx, cmd, false)
+}
+
+// XRevRange returns stream entries with IDs between end and start, newest first,
+// optionally as of a past version.
+// Usage: XREVRANGE key end start [COUNT count] [AT version]
+func XRevRange(ctx *command.Context, cmd *protocol.Command) command.Result {
+	return xrange(ctx, cmd, true)
+}
+
+func xrange(ctx *command.Context, cmd *protocol.Command, rev bool) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	count := -1
+	switch {
+	case len(args) == 5 && strings.EqualFold(string(args[3]), "COUNT"):
+		if count, err = parseCount(args[4]); err != nil {
+			return protocol.NewError(err.Error())
+		}
+	case len(args) != 3:
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	startArg, endArg := args[1], args[2]
+	if rev {
+		startArg, endArg = endArg, startArg
+	}
+	start, startOK, err := parseRangeID(startArg, false)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	end, endOK, err := parseRangeID(endArg, true)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	s, err := readStream(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if !startOK || !endOK {
+		return protocol.NewArray([]protocol.RESPValue{})
+	}
+
+	if !rev {
+		return entriesReply(s.Range(start, end, count))
+	}
+	entries := slices.Clone(s.Range(start, end, -1))
+	slices.Reverse(entries)
+	if count >= 0 && count < len(entries) {
+		entries = entries[:count]
+	}
+	return entriesReply(entries)
+}
+
+func XRangeSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "XRANGE",
+		Handler:     command.HandlerFunc(XRange),
+		MinArgs:     3,
+		MaxArgs:     7,
+		Description: "Get stream entries in an ID range: XRANGE key start end [COUNT count] [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
+
+func XRevRangeSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "XREVRANGE",
+		Handler:     command.HandlerFunc(XRevRange),
+		MinArgs:     3,
+		MaxArgs:     7,
+		Description: "Get stream entries in an ID range, newest first: XREVRANGE key end start [COUNT count] [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
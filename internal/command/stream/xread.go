@@ -0,0 +1,149 @@
+package stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// readOptions holds the parsed XREAD and XREADGROUP arguments
+type readOptions struct {
+	count   int
+	block   bool
+	timeout time.Duration
+	noAck   bool
+	keys    []string
+	ids     [][]byte
+}
+
+// parseReadOptions parses [COUNT count] [BLOCK milliseconds] [NOACK] STREAMS key
+// [key ...] id [id ...]. NOACK is only accepted for XREADGROUP.
+func parseReadOptions(args [][]byte, name string, group bool) (readOptions, error) {
+	opts := readOptions{count: -1}
+	for i := 0; i < len(args); i++ {
+		switch opt := strings.ToUpper(string(args[i])); {
+		case opt == "COUNT" && i+1 < len(args):
+			count, err := parseCount(args[i+1])
+			if err != nil {
+				return opts, err
+			}
+			opts.count = count
+			i++
+		case opt == "BLOCK" && i+1 < len(args):
+			ms, err := strconv.ParseInt(string(args[i+1]), 10, 64)
+			if err != nil {
+				return opts, errors.New("ERR timeout is not an integer or out of range")
+			}
+			if ms < 0 {
+				return opts, errors.New("ERR timeout is negative")
+			}
+			opts.block, opts.timeout = true, time.Duration(ms)*time.Millisecond
+			i++
+		case opt == "NOACK" && group:
+			opts.noAck = true
+		case opt == "STREAMS":
+			rest := args[i+1:]
+			if len(rest) == 0 || len(rest)%2 != 0 {
+				return opts, errors.New("ERR Unbalanced '" + name + "' list of streams: for each stream key an ID or '$' must be specified.")
+			}
+			half := len(rest) / 2
+			for _, key := range rest[:half] {
+				opts.keys = append(opts.keys, string(key))
+			}
+			opts.ids = rest[half:]
+			return opts, nil
+		default:
+			return opts, errSyntax
+		}
+	}
+	return opts, errSyntax
+}
+
+// streamKeys returns the keys following STREAMS
+func streamKeys(args [][]byte) [][]byte {
+	for i, arg := range args {
+		if strings.EqualFold(string(arg), "STREAMS") {
+			rest := args[i+1:]
+			return rest[:len(rest)/2]
+		}
+	}
+	return nil
+}
+
+// XRead returns entries added after the given IDs from one or more streams. With
+// BLOCK it waits up to the timeout (0 waits forever) for an entry to arrive.
+// $ stands for the last ID of the stream when the command was issued.
+// Usage: XREAD [COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id [id ...]
+func XRead(ctx *command.Context, cmd *protocol.Command) command.Result {
+	opts, err := parseReadOptions(cmd.Args(), "xread", false)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	// resolve positions once, so $ keeps meaning "after what existed when we started"
+	after := make([]datastructures.StreamID, len(opts.keys))
+	for i, key := range opts.keys {
+		if string(opts.ids[i]) == "$" {
+			s, err := readStream(ctx, key, 0, false)
+			if err != nil {
+				return protocol.NewError(err.Error())
+			}
+			after[i] = s.LastID
+			continue
+		}
+		if after[i], err = parseID(opts.ids[i]); err != nil {
+			return protocol.NewError(err.Error())
+		}
+	}
+
+	try := func() (command.Result, bool) {
+		var streams []protocol.RESPValue
+		for i, key := range opts.keys {
+			s, err := readStream(ctx, key, 0, false)
+			if err != nil {
+				return protocol.NewError(err.Error()), true
+			}
+			start, ok := after[i].Next()
+			if !ok {
+				continue
+			}
+			if entries := s.Range(start, datastructures.MaxStreamID, opts.count); len(entries) > 0 {
+				streams = append(streams, streamReply(key, entriesReply(entries)))
+			}
+		}
+		if len(streams) == 0 {
+			return nil, false
+		}
+		return protocol.NewArray(streams), true
+	}
+
+	if !opts.block {
+		if result, ok := try(); ok {
+			return result
+		}
+		return protocol.NewNullArray()
+	}
+	result, ok := command.Block(ctx, opts.keys, opts.timeout, try)
+	if !ok {
+		return protocol.NewNullArray()
+	}
+	return result
+}
+
+func XReadSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "XREAD",
+		Handler:     command.HandlerFunc(XRead),
+		MinArgs:     3,
+		MaxArgs:     -1,
+		Description: "Read new entries from streams: XREAD [COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id [id ...]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        streamKeys,
+	}
+}
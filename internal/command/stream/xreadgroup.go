@@ -0,0 +1,168 @@
+package stream
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// XReadGroup reads from streams on behalf of a consumer of a group. The ID >
+// delivers entries the group has not seen yet, adding them to the pending entries
+// list unless NOACK is given. Any other ID replays the consumer's own pending
+// entries after it. Deliveries change the group state, so they are versions too.
+// Usage: XREADGROUP GROUP group consumer [COUNT count] [BLOCK milliseconds] [NOACK] STREAMS key [key ...] id [id ...]
+func XReadGroup(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	if len(args) < 3 || !strings.EqualFold(string(args[0]), "GROUP") {
+		return protocol.NewError(errSyntax.Error())
+	}
+	group, consumer := string(args[1]), string(args[2])
+	opts, err := parseReadOptions(args[3:], "xreadgroup", true)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	newOnly := true
+	for _, id := range opts.ids {
+		if string(id) != ">" {
+			if _, err := parseID(id); err != nil {
+				return protocol.NewError(err.Error())
+			}
+			newOnly = false
+		}
+	}
+
+	try := func() (command.Result, bool) {
+		var streams []protocol.RESPValue
+		for i, key := range opts.keys {
+			reply, delivered, err := readGroup(ctx, key, group, consumer, opts, opts.ids[i])
+			if err != nil {
+				return protocol.NewError(err.Error()), true
+			}
+			if delivered {
+				streams = append(streams, streamReply(key, reply))
+			}
+		}
+		if len(streams) == 0 {
+			return nil, false
+		}
+		return protocol.NewArray(streams), true
+	}
+
+	// only new entries are worth waiting for, pending history is answered at once
+	if !opts.block || !newOnly {
+		if result, ok := try(); ok {
+			return result
+		}
+		return protocol.NewNullArray()
+	}
+	result, ok := command.Block(ctx, opts.keys, opts.timeout, try)
+	if !ok {
+		return protocol.NewNullArray()
+	}
+	return result
+}
+
+// readGroup serves one stream of an XREADGROUP. delivered is false when > found
+// nothing new, history reads always report the stream.
+func readGroup(ctx *command.Context, key, name, consumer string, opts readOptions, idArg []byte) (protocol.RESPValue, bool, error) {
+	var reply protocol.RESPValue
+	var delivered bool
+
+	_, err := ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		s, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+		group, ok := s.Groups[name]
+		if !current.Exists || !ok {
+			return current, fmt.Errorf("NOGROUP No such key '%s' or consumer group '%s' in XREADGROUP with GROUP option", key, name)
+		}
+
+		now := nowMillis(ctx)
+		_, known := group.Consumers[consumer]
+		group.Consumers[consumer] = now
+
+		if string(idArg) != ">" {
+			after, _ := parseID(idArg)
+			reply, delivered = pendingHistory(s, group, consumer, after, opts.count), true
+			if known {
+				return current, storage.ErrSkipWrite
+			}
+			return streamEntry(current, s), nil
+		}
+
+		var entries []datastructures.StreamEntry
+		if start, ok := group.LastDelivered.Next(); ok {
+			entries = s.Range(start, datastructures.MaxStreamID, opts.count)
+		}
+		reply, delivered = entriesReply(entries), len(entries) > 0
+		if !delivered && known {
+			return current, storage.ErrSkipWrite
+		}
+
+		for _, e := range entries {
+			group.LastDelivered = e.ID
+			if opts.noAck {
+				continue
+			}
+			i, pending := group.PendingIndex(e.ID)
+			if pending {
+				group.Pending[i].Consumer = consumer
+				group.Pending[i].DeliveredAt = now
+				group.Pending[i].Deliveries++
+				continue
+			}
+			group.Pending = append(group.Pending, datastructures.PendingEntry{})
+			copy(group.Pending[i+1:], group.Pending[i:])
+			group.Pending[i] = datastructures.PendingEntry{ID: e.ID, Consumer: consumer, DeliveredAt: now, Deliveries: 1}
+		}
+		return streamEntry(current, s), nil
+	})
+	return reply, delivered, err
+}
+
+// pendingHistory replays a consumer's pending entries after an ID. Entries
+// trimmed from the stream since delivery are returned as [id, nil].
+func pendingHistory(s *datastructures.Stream, group *datastructures.ConsumerGroup, consumer string, after datastructures.StreamID, count int) protocol.RESPValue {
+	var values []protocol.RESPValue
+	for _, p := range group.Pending {
+		if p.Consumer != consumer || p.ID.Compare(after) <= 0 {
+			continue
+		}
+		if count >= 0 && len(values) == count {
+			break
+		}
+		if e, ok := s.Entry(p.ID); ok {
+			values = append(values, entryReply(e))
+			continue
+		}
+		values = append(values, protocol.NewArray([]protocol.RESPValue{
+			protocol.NewBulkString([]byte(p.ID.String())),
+			protocol.NewNullArray(),
+		}))
+	}
+	return protocol.NewArray(values)
+}
+
+// groupStreamKeys skips GROUP group consumer, which could read STREAMS
+func groupStreamKeys(args [][]byte) [][]byte {
+	return streamKeys(args[min(3, len(args)):])
+}
+
+func XReadGroupSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "XREADGROUP",
+		Handler:     command.HandlerFunc(XReadGroup),
+		MinArgs:     6,
+		MaxArgs:     -1,
+		Description: "Read from streams as a consumer group member: XREADGROUP GROUP group consumer [COUNT count] [BLOCK milliseconds] [NOACK] STREAMS key [key ...] id [id ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        groupStreamKeys,
+	}
+}
@@ -0,0 +1,50 @@
+package stream
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// XTrim drops the oldest stream entries as a new version and returns how many
+// were removed. A trim that removes nothing writes no version.
+// Usage: XTRIM key MAXLEN|MINID [=|~] threshold [LIMIT count]
+func XTrim(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	trim, next, err := parseTrim(args, 1)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if next != len(args) {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	var removed int
+	_, err = ctx.Engine.Update(string(args[0]), func(current storage.Entry) (storage.Entry, error) {
+		s, err := decode(current)
+		if err != nil {
+			return current, err
+		}
+		if removed = trim.apply(s); removed == 0 {
+			return current, storage.ErrSkipWrite
+		}
+		return streamEntry(current, s), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(int64(removed))
+}
+
+func XTrimSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "XTRIM",
+		Handler:     command.HandlerFunc(XTrim),
+		MinArgs:     3,
+		MaxArgs:     7,
+		Description: "Trim a stream: XTRIM key MAXLEN|MINID [=|~] threshold [LIMIT count]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
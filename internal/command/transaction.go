@@ -0,0 +1,173 @@
+package command
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/cluster"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+var (
+	errNestedMulti         = errors.New("ERR MULTI calls can not be nested")
+	errExecWithoutMulti    = errors.New("ERR EXEC without MULTI")
+	errDiscardWithoutMulti = errors.New("ERR DISCARD without MULTI")
+	errExecAbort           = errors.New("EXECABORT Transaction discarded because of previous errors.")
+)
+
+// transaction is what a connection queued since MULTI
+type transaction struct {
+	commands []*protocol.Command
+	specs    []*CommandSpec
+
+	// failed is set when a command could not be queued, EXEC then discards the transaction
+	failed bool
+}
+
+// queue adds a command to the connection's transaction and replies QUEUED. A
+// command that could not run marks the transaction failed.
+func (tx *transaction) queue(spec *CommandSpec, cmd *protocol.Command) Result {
+	if err := spec.Validate(cmd); err != nil {
+		tx.failed = true
+		return protocol.NewError(err.Error())
+	}
+	tx.commands = append(tx.commands, cmd)
+	tx.specs = append(tx.specs, spec)
+	return protocol.NewSimpleString("QUEUED")
+}
+
+// Multi starts queueing the connection's commands until Exec or Discard
+func (ctx *Context) Multi() error {
+	if ctx.tx != nil {
+		return errNestedMulti
+	}
+	ctx.tx = &transaction{}
+	return nil
+}
+
+// Discard drops the commands queued since MULTI
+func (ctx *Context) Discard() error {
+	if ctx.tx == nil {
+		return errDiscardWithoutMulti
+	}
+	ctx.tx = nil
+	return nil
+}
+
+// Queued returns the commands queued since MULTI, so a transaction sent
+// through the cluster log can carry them
+func (ctx *Context) Queued() []*protocol.Command {
+	if ctx.tx == nil {
+		return nil
+	}
+	return ctx.tx.commands
+}
+
+// Exec runs the commands queued since MULTI and replies with their replies.
+// No other command runs in between and they all see the same time, so the
+// transaction's expiry decisions agree. Blocking commands do not wait. With a
+// comment every version the transaction writes records it.
+func (ctx *Context) Exec(comment string) Result {
+	tx := ctx.tx
+	if tx == nil {
+		return protocol.NewError(errExecWithoutMulti.Error())
+	}
+	if tx.failed {
+		ctx.tx = nil
+		return protocol.NewError(errExecAbort.Error())
+	}
+
+	var mutates, reads bool
+	for _, spec := range tx.specs {
+		mutates = mutates || spec.Mutates
+		reads = reads || spec.ReadOnly
+	}
+	if mutates && ctx.Replication != nil && ctx.Replication.ReadOnly() {
+		ctx.tx = nil
+		return protocol.NewError("READONLY You can't write against a read only replica.")
+	}
+	if ctx.Consensus != nil {
+		switch {
+		case mutates:
+			// the queued commands travel with EXEC as one entry of the log
+			args := [][]byte{}
+			if comment != "" {
+				args = append(args, []byte("COMMENT"), []byte(comment))
+			}
+			result := submit(ctx, protocol.NewCommand("EXEC", args))
+			ctx.tx = nil
+			return result
+		case reads && !ctx.StaleReads:
+			if err := ctx.Consensus.ReadBarrier(ctx); err != nil {
+				ctx.tx = nil
+				return protocol.NewError(err.Error())
+			}
+		}
+	}
+	ctx.tx = nil
+
+	ctx.router.txMu.Lock()
+	defer ctx.router.txMu.Unlock()
+	if ctx.Cluster != nil {
+		return ctx.execInSlot(tx, comment)
+	}
+	return ctx.execQueued(tx, comment)
+}
+
+// execInSlot runs a transaction in a sharded cluster. Every key it touches must
+// hash to one slot, which it holds like a single command does.
+func (ctx *Context) execInSlot(tx *transaction, comment string) Result {
+	var keys [][]byte
+	for i, spec := range tx.specs {
+		if spec.Keys != nil {
+			keys = append(keys, spec.Keys(tx.commands[i].Args())...)
+		}
+	}
+	if len(keys) == 0 {
+		return ctx.execQueued(tx, comment)
+	}
+	slot := cluster.KeySlot(keys[0])
+	for _, key := range keys[1:] {
+		if cluster.KeySlot(key) != slot {
+			return protocol.NewError("CROSSSLOT Keys in request don't hash to the same slot")
+		}
+	}
+
+	lock := ctx.Cluster.SlotLock(slot)
+	lock.RLock()
+	defer lock.RUnlock()
+	if redirect := redirect(ctx, slot, keys, false); redirect != nil {
+		return redirect
+	}
+	return ctx.execQueued(tx, comment)
+}
+
+// execQueued runs the queued commands, with every other command held off
+func (ctx *Context) execQueued(tx *transaction, comment string) Result {
+	clock, noWait := ctx.Clock, ctx.NoWait
+	at := ctx.Now()
+	ctx.Clock = func() time.Time { return at }
+	ctx.NoWait = true
+	// the bound view is rebuilt on the frozen clock, and again after
+	ctx.Engine = nil
+	defer func() {
+		ctx.Clock, ctx.NoWait, ctx.Blocked = clock, noWait, nil
+		ctx.Engine = nil
+		ctx.bind()
+	}()
+
+	results := make([]protocol.RESPValue, len(tx.commands))
+	for i, cmd := range tx.commands {
+		// SELECT and SWAPDB in the transaction take effect on the next command
+		ctx.bind()
+		engine := ctx.Engine
+		if comment != "" {
+			ctx.Engine = storage.Annotate(engine, comment)
+		}
+		ctx.Blocked = nil
+		results[i] = tx.specs[i].Handler.Execute(ctx, cmd)
+		ctx.Engine = engine
+	}
+	return protocol.NewArray(results)
+}
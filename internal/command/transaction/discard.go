@@ -0,0 +1,28 @@
+package transaction
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// Discard drops the commands queued since MULTI and ends the transaction.
+// Usage: DISCARD
+func Discard(ctx *command.Context, cmd *protocol.Command) command.Result {
+	if err := ctx.Discard(); err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func DiscardSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "DISCARD",
+		Handler:     command.HandlerFunc(Discard),
+		MinArgs:     0,
+		MaxArgs:     0,
+		Description: "Drop the commands queued since MULTI.",
+		ReadOnly:    false,
+		Mutates:     false,
+		Transaction: true,
+	}
+}
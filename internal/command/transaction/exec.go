@@ -0,0 +1,38 @@
+package transaction
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// Exec runs the commands queued since MULTI with no other command in between
+// and replies with an array of their replies. COMMENT records the text on
+// every version the transaction writes, unless a command brings its own.
+// Replies EXECABORT when a command could not be queued.
+// Usage: EXEC [COMMENT text]
+func Exec(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, comment, err := command.SplitComment(cmd.Args(), 0)
+	if err != nil {
+		ctx.Discard()
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 0 {
+		ctx.Discard()
+		return protocol.NewError("ERR syntax error")
+	}
+	return ctx.Exec(comment)
+}
+
+func ExecSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "EXEC",
+		Handler:     command.HandlerFunc(Exec),
+		MinArgs:     0,
+		MaxArgs:     2,
+		Description: "Run the commands queued since MULTI as one transaction.",
+		// the queued commands decide whether the transaction reads or writes
+		ReadOnly:    false,
+		Mutates:     false,
+		Transaction: true,
+	}
+}
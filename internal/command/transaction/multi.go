@@ -0,0 +1,29 @@
+package transaction
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// Multi starts a transaction: the connection's commands are queued and replied
+// QUEUED until EXEC runs them together or DISCARD drops them.
+// Usage: MULTI
+func Multi(ctx *command.Context, cmd *protocol.Command) command.Result {
+	if err := ctx.Multi(); err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewSimpleString("OK")
+}
+
+func MultiSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "MULTI",
+		Handler:     command.HandlerFunc(Multi),
+		MinArgs:     0,
+		MaxArgs:     0,
+		Description: "Start queueing commands for EXEC.",
+		ReadOnly:    false,
+		Mutates:     false,
+		Transaction: true,
+	}
+}
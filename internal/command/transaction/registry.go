@@ -0,0 +1,10 @@
+package transaction
+
+import "github.com/ElshadHu/verdis/internal/command"
+
+// RegisterAll adds all command specs into the router.
+func RegisterAll(router *command.Router) {
+	router.Register(MultiSpec())
+	router.Register(ExecSpec())
+	router.Register(DiscardSpec())
+}
@@ -0,0 +1,128 @@
+package transaction_test
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/list"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/command/transaction"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+func newSession(t *testing.T) *commandtest.Session {
+	return commandtest.NewSession(t, standard.RegisterAll, list.RegisterAll, transaction.RegisterAll)
+}
+
+// TestExec_Comment verifies EXEC runs the queued commands in order and records
+// its comment on every version they write, unless a command brings its own
+func TestExec_Comment(t *testing.T) {
+	s := newSession(t)
+	s.Expect("+OK\r\n", "SET", "n", "1")
+	s.Expect("+OK\r\n", "MULTI")
+	s.Expect("+QUEUED\r\n", "INCR", "n")
+	s.Expect("+QUEUED\r\n", "SET", "k", "v", "COMMENT", "own")
+	s.Expect("+QUEUED\r\n", "DEL", "n")
+	s.Expect("+QUEUED\r\n", "GET", "n")
+	s.Expect("*4\r\n:2\r\n+OK\r\n:1\r\n$-1\r\n", "EXEC", "COMMENT", "TICKET-7")
+
+	for key, want := range map[string][]string{"n": {"TICKET-7", "TICKET-7", ""}, "k": {"own"}} {
+		history, err := s.Ctx.Engine.History(key, 0)
+		if err != nil || len(history) != len(want) {
+			t.Fatalf("History(%s) = %+v, %v", key, history, err)
+		}
+		for i, comment := range want {
+			if history[i].Comment != comment {
+				t.Errorf("%s version %d has comment %q, want %q", key, history[i].Version, history[i].Comment, comment)
+			}
+		}
+	}
+}
+
+// TestExec_Errors verifies queueing errors abort the transaction, DISCARD
+// drops it and transaction commands are not nested
+func TestExec_Errors(t *testing.T) {
+	s := newSession(t)
+	s.Expect("-ERR EXEC without MULTI\r\n", "EXEC")
+	s.Expect("-ERR DISCARD without MULTI\r\n", "DISCARD")
+
+	s.Expect("+OK\r\n", "MULTI")
+	s.Expect("-ERR MULTI calls can not be nested\r\n", "MULTI")
+	s.Expect("+QUEUED\r\n", "SET", "k", "v")
+	s.Expect("+OK\r\n", "DISCARD")
+	s.Expect("$-1\r\n", "GET", "k")
+
+	s.Expect("+OK\r\n", "MULTI")
+	s.Expect("+QUEUED\r\n", "SET", "k", "v")
+	s.Expect("-ERR unknown command 'NOPE'\r\n", "NOPE")
+	s.Expect("-EXECABORT Transaction discarded because of previous errors.\r\n", "EXEC")
+	s.Expect("$-1\r\n", "GET", "k")
+
+	s.Expect("+OK\r\n", "MULTI")
+	if got := s.Do("GET"); got[0] != '-' {
+		t.Errorf("GET without a key queued: %q", got)
+	}
+	s.Expect("-EXECABORT Transaction discarded because of previous errors.\r\n", "EXEC")
+}
+
+// TestExec_BlockingDoesNotWait verifies a blocking command in a transaction
+// replies at once when there is nothing to take
+func TestExec_BlockingDoesNotWait(t *testing.T) {
+	s := newSession(t)
+	s.Expect("+OK\r\n", "MULTI")
+	s.Expect("+QUEUED\r\n", "BLPOP", "empty", "0")
+	s.Expect("+QUEUED\r\n", "RPUSH", "l", "a")
+	s.Expect("+QUEUED\r\n", "BLPOP", "l", "0")
+	s.Expect("*3\r\n*-1\r\n:1\r\n*2\r\n$1\r\nl\r\n$1\r\na\r\n", "EXEC")
+
+	// commands outside a transaction still wait
+	other := s.Fork()
+	done := make(chan string)
+	go func() { done <- other.Do("BLPOP", "l", "0") }()
+	s.Expect(":1\r\n", "RPUSH", "l", "b")
+	if got := <-done; got != "*2\r\n$1\r\nl\r\n$1\r\nb\r\n" {
+		t.Errorf("BLPOP = %q after a push", got)
+	}
+}
+
+// TestExec_Isolated verifies no command of another connection runs between the
+// commands of a transaction
+func TestExec_Isolated(t *testing.T) {
+	s := newSession(t)
+	other := s.Fork()
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				other.Do("INCR", "n")
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	const reads = 50
+	for range 100 {
+		s.Expect("+OK\r\n", "MULTI")
+		for range reads {
+			s.Expect("+QUEUED\r\n", "GET", "n")
+		}
+		reply := s.Do("EXEC")
+		value, err := protocol.NewRESPParser(bufio.NewReader(strings.NewReader(reply))).ParseValue()
+		if err != nil {
+			t.Fatalf("EXEC = %q: %v", reply, err)
+		}
+		replies := value.(*protocol.Array).Elements()
+		if first, last := string(replies[0].Serialize()), string(replies[reads-1].Serialize()); first != last {
+			t.Fatalf("GET in one transaction read %q, then %q", first, last)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package version
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+const (
+	defaultChangesCount = 100
+
+	// changesScanFactor bounds how many versions one call visits per requested
+	// change, so a MATCH that hits rarely still returns promptly with a cursor
+	changesScanFactor = 100
+)
+
+// Changes lists writes across all keys in version order. The reply is [cursor,
+// [[version, timestamp, key, op, size], ...]], with the rows as maps for RESP3
+// clients: pass the cursor as the next SINCE to continue. Versions are never
+// split across calls, so COUNT may be exceeded by the keys of one batch. A
+// cluster node only lists its own writes, ordered by its own counter, and not
+// those of other shards.
+// Usage: CHANGES SINCE version [UNTIL version] [MATCH pattern] [COUNT n]
+func Changes(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	if !strings.EqualFold(string(args[0]), "SINCE") {
+		return protocol.NewError("ERR syntax error")
+	}
+	since, err := command.ParseVersion(args[1])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	until := uint64(math.MaxUint64)
+	count := defaultChangesCount
+	var match string
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return protocol.NewError("ERR syntax error")
+		}
+		value := args[i+1]
+		switch strings.ToUpper(string(args[i])) {
+		case "UNTIL":
+			if until, err = command.ParseVersion(value); err != nil {
+				return protocol.NewError(err.Error())
+			}
+		case "MATCH":
+			match = string(value)
+		case "COUNT":
+			if count, err = strconv.Atoi(string(value)); err != nil || count <= 0 {
+				return protocol.NewError("ERR value is out of range, must be positive")
+			}
+		default:
+			return protocol.NewError("ERR syntax error")
+		}
+	}
+
+	changeLog, ok := ctx.Engine.(storage.ChangeLogger)
+	if !ok {
+		return protocol.NewError("ERR " + storage.ErrUnsupported.Error())
+	}
+	var rows []protocol.RESPValue
+	cursor, err := changeLog.Changes(since, until, count*changesScanFactor, func(_ uint64, changes []storage.Change) bool {
+		for _, change := range changes {
+			if match != "" && !command.Glob(match, change.Key) {
+				continue
+			}
+			rows = append(rows, command.Entry(ctx, []command.Field{
+				{Name: "version", Value: protocol.NewInteger(int64(change.Version))},
+				{Name: "timestamp", Value: protocol.NewInteger(change.Timestamp)},
+				{Name: "key", Value: protocol.NewBulkString([]byte(change.Key))},
+				{Name: "op", Value: protocol.NewBulkString([]byte(change.Op))},
+				{Name: "size", Value: protocol.NewInteger(int64(change.Size))},
+			}))
+		}
+		return len(rows) < count
+	})
+	if errors.Is(err, storage.ErrChangesPruned) {
+		return protocol.NewError("ERR changes before version " +
+			strconv.FormatUint(changeLog.OldestChange(), 10) + " have been pruned")
+	}
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+
+	return protocol.NewArray([]protocol.RESPValue{
+		protocol.NewInteger(int64(cursor)),
+		protocol.NewArray(rows),
+	})
+}
+
+func ChangesSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "CHANGES",
+		Handler:     command.HandlerFunc(Changes),
+		MinArgs:     2,
+		MaxArgs:     8,
+		Description: "List writes across all keys: CHANGES SINCE version [UNTIL version] [MATCH pattern] [COUNT n]",
+		ReadOnly:    true,
+		Mutates:     false,
+	}
+}
@@ -0,0 +1,61 @@
+package version_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/command/version"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// TestChanges_VersionOrder verifies CHANGES lists writes to every key in
+// version order and continues from the cursor it returns
+func TestChanges_VersionOrder(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll, version.RegisterAll)
+	s.Expect("+OK\r\n", "SET", "user:1", "a")
+	s.Expect("+OK\r\n", "SET", "order:1", "bb")
+	s.Expect(":1\r\n", "DEL", "user:1")
+	s.Expect("+OK\r\n", "SET", "user:2", "ccc")
+
+	history := func(key string) []int64 {
+		t.Helper()
+		infos, err := s.Ctx.Engine.History(key, 0)
+		if err != nil {
+			t.Fatalf("History %s: %v", key, err)
+		}
+		timestamps := make([]int64, len(infos))
+		for i, info := range infos {
+			timestamps[len(infos)-1-i] = info.Timestamp
+		}
+		return timestamps
+	}
+	user1, order1, user2 := history("user:1"), history("order:1"), history("user:2")
+	row := func(version int, timestamp int64, key, op string, size int) string {
+		return fmt.Sprintf("*5\r\n:%d\r\n:%d\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n:%d\r\n",
+			version, timestamp, len(key), key, len(op), op, size)
+	}
+	set1, set2 := row(1, user1[0], "user:1", "set", 1), row(2, order1[0], "order:1", "set", 2)
+	del, set4 := row(3, user1[1], "user:1", "del", 0), row(4, user2[0], "user:2", "set", 3)
+
+	s.Expect("*2\r\n:4\r\n*4\r\n"+set1+set2+del+set4, "CHANGES", "SINCE", "0")
+	s.Expect("*2\r\n:2\r\n*2\r\n"+set1+set2, "CHANGES", "SINCE", "0", "COUNT", "2")
+	s.Expect("*2\r\n:3\r\n*1\r\n"+del, "CHANGES", "SINCE", "2", "UNTIL", "3")
+	s.Expect("*2\r\n:4\r\n*3\r\n"+set1+del+set4, "CHANGES", "SINCE", "0", "MATCH", "user:*")
+	s.Expect("*2\r\n:4\r\n*0\r\n", "CHANGES", "SINCE", "4")
+
+	s.Ctx.Protocol = protocol.RESP3
+	s.Expect(fmt.Sprintf("*2\r\n:4\r\n*1\r\n%%5\r\n$7\r\nversion\r\n:4\r\n$9\r\ntimestamp\r\n:%d\r\n"+
+		"$3\r\nkey\r\n$6\r\nuser:2\r\n$2\r\nop\r\n$3\r\nset\r\n$4\r\nsize\r\n:3\r\n", user2[0]),
+		"CHANGES", "SINCE", "3")
+}
+
+// TestChanges_Syntax verifies CHANGES refuses malformed options
+func TestChanges_Syntax(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll, version.RegisterAll)
+	s.Expect("-ERR syntax error\r\n", "CHANGES", "FROM", "0")
+	s.Expect("-ERR syntax error\r\n", "CHANGES", "SINCE", "0", "COUNT")
+	s.Expect("-ERR syntax error\r\n", "CHANGES", "SINCE", "0", "LIMIT", "1")
+	s.Expect("-ERR value is out of range, must be positive\r\n", "CHANGES", "SINCE", "0", "COUNT", "0")
+}
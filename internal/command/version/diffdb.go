@@ -0,0 +1,199 @@
+package version
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+const defaultDiffCount = 100
+
+// diffOptions holds the parsed DIFFDB arguments
+type diffOptions struct {
+	from, to  uint64
+	match     string
+	count     int
+	cursor    string
+	withSizes bool
+}
+
+// DiffDB lists keys whose visible value differs between two versions, each
+// classified as added, modified or deleted. The reply is [cursor, [[key, status
+// (, old size, new size)], ...]], rows being maps for RESP3 clients; call again
+// with the cursor until it is "0". When from is still covered by the change log
+// and no key can have expired since, only keys written in between are looked
+// at, otherwise the keyspace is scanned in key order. On a cluster node from and to are versions of that node's
+// shard, which other shards number independently.
+// Usage: DIFFDB from to [MATCH pattern] [COUNT n] [CURSOR cursor] [WITHSIZES]
+func DiffDB(ctx *command.Context, cmd *protocol.Command) command.Result {
+	opts, err := parseDiffOptions(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if opts.from > opts.to {
+		return protocol.NewError("ERR from version must not be greater than to version")
+	}
+	if opts.to > ctx.Engine.CurrentVersion() {
+		return protocol.NewError("ERR version is in the future")
+	}
+	changeLog, ok := ctx.Engine.(storage.ChangeLogger)
+	if !ok {
+		return protocol.NewError("ERR " + storage.ErrUnsupported.Error())
+	}
+
+	cursor := opts.cursor
+	if cursor == "0" {
+		cursor = "k"
+		if opts.from+1 >= changeLog.OldestChange() && !changeLog.MayExpireAfter(opts.from) {
+			cursor = "v" + strconv.FormatUint(opts.from, 10)
+		}
+	}
+
+	var diffs []storage.KeyDiff
+	var next string
+	switch {
+	case strings.HasPrefix(cursor, "v"):
+		since, parseErr := strconv.ParseUint(cursor[1:], 10, 64)
+		if parseErr != nil {
+			return protocol.NewError("ERR invalid cursor")
+		}
+		diffs, next, err = diffFromChanges(ctx, changeLog, opts, since)
+	case strings.HasPrefix(cursor, "k"):
+		diffs, next = diffFromKeys(ctx, changeLog, opts, cursor[1:], opts.cursor == "0")
+	default:
+		return protocol.NewError("ERR invalid cursor")
+	}
+	if errors.Is(err, storage.ErrChangesPruned) {
+		return protocol.NewError("ERR the change log was pruned past the cursor, restart the diff")
+	}
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+
+	rows := make([]protocol.RESPValue, len(diffs))
+	for i, diff := range diffs {
+		row := []command.Field{
+			{Name: "key", Value: protocol.NewBulkString([]byte(diff.Key))},
+			{Name: "status", Value: protocol.NewBulkString([]byte(diff.Status.String()))},
+		}
+		if opts.withSizes {
+			row = append(row,
+				command.Field{Name: "old_size", Value: protocol.NewInteger(int64(diff.OldSize))},
+				command.Field{Name: "new_size", Value: protocol.NewInteger(int64(diff.NewSize))},
+			)
+		}
+		rows[i] = command.Entry(ctx, row)
+	}
+	return protocol.NewArray([]protocol.RESPValue{
+		protocol.NewBulkString([]byte(next)),
+		protocol.NewArray(rows),
+	})
+}
+
+// diffFromChanges walks the change log after since. A key is reported at its
+// last change up to the target version only, so it appears once however many
+// times it was written or however the walk is split across calls.
+func diffFromChanges(ctx *command.Context, changeLog storage.ChangeLogger, opts diffOptions, since uint64) ([]storage.KeyDiff, string, error) {
+	var diffs []storage.KeyDiff
+	scanned := 0
+	cursor, err := changeLog.Changes(since, opts.to, math.MaxInt, func(_ uint64, changes []storage.Change) bool {
+		for _, change := range changes {
+			scanned++
+			if opts.match != "" && !command.Glob(opts.match, change.Key) {
+				continue
+			}
+			diff, changed := changeLog.DiffKey(change.Key, opts.from, opts.to)
+			if changed && diff.ToVersion == change.Version {
+				diffs = append(diffs, diff)
+			}
+		}
+		return scanned < opts.count
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if cursor >= opts.to {
+		return diffs, "0", nil
+	}
+	return diffs, "v" + strconv.FormatUint(cursor, 10), nil
+}
+
+// diffFromKeys compares the next count keys after last in key order
+func diffFromKeys(ctx *command.Context, changeLog storage.ChangeLogger, opts diffOptions, last string, first bool) ([]storage.KeyDiff, string) {
+	var keys []string
+	for _, key := range ctx.Engine.Keys() {
+		if (first || key > last) && (opts.match == "" || command.Glob(opts.match, key)) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	next := "0"
+	if len(keys) > opts.count {
+		keys = keys[:opts.count]
+		next = "k" + keys[len(keys)-1]
+	}
+
+	var diffs []storage.KeyDiff
+	for _, key := range keys {
+		if diff, changed := changeLog.DiffKey(key, opts.from, opts.to); changed {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs, next
+}
+
+func parseDiffOptions(args [][]byte) (diffOptions, error) {
+	opts := diffOptions{count: defaultDiffCount, cursor: "0"}
+	var err error
+	if opts.from, err = command.ParseVersion(args[0]); err != nil {
+		return opts, err
+	}
+	if opts.to, err = command.ParseVersion(args[1]); err != nil {
+		return opts, err
+	}
+
+	for i := 2; i < len(args); i++ {
+		option := strings.ToUpper(string(args[i]))
+		if option == "WITHSIZES" {
+			opts.withSizes = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return opts, errors.New("ERR syntax error")
+		}
+		value := string(args[i+1])
+		i++
+		switch option {
+		case "MATCH":
+			opts.match = value
+		case "COUNT":
+			if opts.count, err = strconv.Atoi(value); err != nil || opts.count <= 0 {
+				return opts, errors.New("ERR value is out of range, must be positive")
+			}
+		case "CURSOR":
+			opts.cursor = value
+		default:
+			return opts, errors.New("ERR syntax error")
+		}
+	}
+	return opts, nil
+}
+
+func DiffDBSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "DIFFDB",
+		Handler:     command.HandlerFunc(DiffDB),
+		MinArgs:     2,
+		MaxArgs:     9,
+		Description: "List keys that changed between two versions: DIFFDB from to [MATCH pattern] [COUNT n] [CURSOR cursor] [WITHSIZES]",
+		ReadOnly:    true,
+		Mutates:     false,
+	}
+}
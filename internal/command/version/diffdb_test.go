@@ -0,0 +1,43 @@
+package version_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/command/version"
+)
+
+// TestDiffDB_ChangeLogAndScanAgree verifies both ways DIFFDB finds changed
+// keys report the same ones, a key that expired in between included
+func TestDiffDB_ChangeLogAndScanAgree(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll, version.RegisterAll)
+	s.Expect("+OK\r\n", "SET", "kept", "1")
+	s.Expect("+OK\r\n", "SET", "modified", "1")
+	s.Expect("+OK\r\n", "SET", "deleted", "1")
+	from := s.Version("SET", "base", "1", "RETURNVERSION")
+	s.Expect("+OK\r\n", "SET", "modified", "22")
+	s.Expect(":1\r\n", "DEL", "deleted")
+	to := s.Version("SET", "added", "333", "RETURNVERSION")
+
+	added := "*4\r\n$5\r\nadded\r\n$5\r\nadded\r\n:0\r\n:3\r\n"
+	deleted := "*4\r\n$7\r\ndeleted\r\n$7\r\ndeleted\r\n:1\r\n:0\r\n"
+	modified := "*4\r\n$8\r\nmodified\r\n$8\r\nmodified\r\n:1\r\n:2\r\n"
+	// the change log lists keys in the order they changed, the scan in key order
+	s.Expect("*2\r\n$1\r\n0\r\n*3\r\n"+modified+deleted+added, "DIFFDB", from, to, "WITHSIZES")
+	s.Expect("*2\r\n$1\r\n0\r\n*3\r\n"+added+deleted+modified, "DIFFDB", from, to, "WITHSIZES", "CURSOR", "k")
+
+	s.Expect("+OK\r\n", "SET", "ttl", "v", "PX", "20")
+	from = s.Version("SET", "base", "2", "RETURNVERSION")
+	time.Sleep(40 * time.Millisecond)
+	to = s.Version("SET", "base", "3", "RETURNVERSION")
+	want := "*2\r\n$1\r\n0\r\n*2\r\n" +
+		"*2\r\n$4\r\nbase\r\n$8\r\nmodified\r\n" +
+		"*2\r\n$3\r\nttl\r\n$7\r\ndeleted\r\n"
+	s.Expect(want, "DIFFDB", from, to)
+	s.Expect(want, "DIFFDB", from, to, "CURSOR", "k")
+
+	s.Expect("-ERR from version must not be greater than to version\r\n", "DIFFDB", to, from)
+	s.Expect("-ERR invalid cursor\r\n", "DIFFDB", from, to, "CURSOR", "x")
+}
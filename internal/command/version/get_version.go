@@ -5,6 +5,7 @@ import (
 
 	"github.com/ElshadHu/verdis/internal/command"
 	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
 )
 
 // GetVersion retrieves a key's value at a specific version.
@@ -19,13 +20,16 @@ func GetVersion(ctx *command.Context, cmd *protocol.Command) command.Result {
 		return protocol.NewError("ERR invalid version number: " + versionStr)
 	}
 
-	value, err := ctx.Engine.GetAtVersion(key, version)
+	entry, err := ctx.Engine.LookupAt(key, version)
 	if err != nil {
 		// return nil for not found
 		return protocol.NewNullBulkString()
 	}
+	if entry.Type != storage.TypeString {
+		return protocol.NewError(storage.ErrWrongType.Error())
+	}
 
-	return protocol.NewBulkString(value)
+	return protocol.NewBulkString(entry.Value)
 }
 
 func GetVersionSpec() *command.CommandSpec {
@@ -37,5 +41,6 @@ func GetVersionSpec() *command.CommandSpec {
 		Description: "Get value at a specific version.",
 		ReadOnly:    true,
 		Mutates:     false,
+		Keys:        command.FirstKey,
 	}
 }
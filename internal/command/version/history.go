@@ -2,49 +2,129 @@ package version
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/ElshadHu/verdis/internal/command"
 	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
 )
 
-// History returns version history for a key
-// Usage: HISTORY [key]
+// History returns version history for a key. WITHMETA adds each version's
+// comment, type and expiry, GREP keeps only versions whose comment matches a
+// glob pattern. In a sharded cluster the versions come from the counter of
+// the node serving the key; a key migrated from another node keeps the
+// numbers it was given there.
+// Usage: HISTORY key [count] [WITHMETA] [GREP pattern]
 func History(ctx *command.Context, cmd *protocol.Command) command.Result {
 	args := cmd.Args()
 	key := string(args[0])
+	args = args[1:]
 
 	maxVersion := 0
-	if len(args) > 1 {
-		count, err := strconv.Atoi(string(args[1]))
-		if err != nil || count < 0 {
-			return protocol.NewError("ERR invalid count")
+	if len(args) > 0 {
+		if count, err := strconv.Atoi(string(args[0])); err == nil {
+			if count < 0 {
+				return protocol.NewError("ERR invalid count")
+			}
+			maxVersion = count
+			args = args[1:]
 		}
-		maxVersion = count
 	}
 
-	history, err := ctx.Engine.History(key, maxVersion)
+	var withMeta bool
+	var grep string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case strings.EqualFold(string(args[i]), "WITHMETA"):
+			withMeta = true
+		case strings.EqualFold(string(args[i]), "GREP") && i+1 < len(args):
+			grep = string(args[i+1])
+			i++
+		default:
+			return protocol.NewError("ERR syntax error")
+		}
+	}
+
+	history, err := historyOf(ctx, key, maxVersion, grep)
 	if err != nil {
 		return protocol.NewNullBulkString()
 	}
-	// Return as array of arrays: [[version, timestamp, deleted, size], ...]
 	result := make([]protocol.RESPValue, len(history))
 	for i, info := range history {
-		entry := []protocol.RESPValue{
-			protocol.NewInteger(int64(info.Version)),
-			protocol.NewInteger(info.Timestamp),
-			protocol.NewInteger(boolToInt(info.Deleted)),
-			protocol.NewInteger(int64(info.Size)),
-		}
-		result[i] = protocol.NewArray(entry)
+		result[i] = command.Entry(ctx, historyFields(info, withMeta))
 	}
 	return protocol.NewArray(result)
 }
 
-func boolToInt(b bool) int64 {
-	if b {
-		return 1
+// historyFields is the shape of a HISTORY reply entry, a map for RESP3 clients
+// and an array of the values in this order for RESP2 clients. Fields are only
+// ever added at the end, the WITHMETA ones after all others, so positional
+// RESP2 readers keep working as the reply grows.
+func historyFields(info storage.VersionInfo, withMeta bool) []command.Field {
+	fields := []command.Field{
+		{Name: "version", Value: protocol.NewInteger(int64(info.Version))},
+		{Name: "timestamp", Value: protocol.NewInteger(info.Timestamp)},
+		{Name: "deleted", Value: protocol.NewBoolean(info.Deleted)},
+		{Name: "size", Value: protocol.NewInteger(int64(info.Size))},
+		{Name: "stored_size", Value: protocol.NewInteger(int64(info.StoredSize))},
+		{Name: "lineage", Value: lineage(info.Lineage)},
+		{Name: "author", Value: author(info.Author)},
+	}
+	if withMeta {
+		fields = append(fields,
+			command.Field{Name: "comment", Value: optional(info.Comment)},
+			command.Field{Name: "type", Value: protocol.NewBulkString([]byte(info.Type.String()))},
+			command.Field{Name: "expires_at", Value: protocol.NewInteger(info.ExpiresAt)},
+		)
+	}
+	return fields
+}
+
+// historyOf returns up to maxVersion versions of a key (0 = all), only those
+// whose comment matches grep when it is set
+func historyOf(ctx *command.Context, key string, maxVersion int, grep string) ([]storage.VersionInfo, error) {
+	if grep == "" {
+		return ctx.Engine.History(key, maxVersion)
+	}
+
+	history, err := ctx.Engine.History(key, 0)
+	if err != nil {
+		return nil, err
+	}
+	matched := history[:0]
+	for _, info := range history {
+		if info.Comment != "" && command.Glob(grep, info.Comment) {
+			matched = append(matched, info)
+		}
+		if maxVersion > 0 && len(matched) == maxVersion {
+			break
+		}
+	}
+	return matched, nil
+}
+
+// optional renders an empty string as null
+func optional(s string) protocol.RESPValue {
+	if s == "" {
+		return protocol.NewNullBulkString()
+	}
+	return protocol.NewBulkString([]byte(s))
+}
+
+// lineage renders the rename or copy a version came from, e.g. "renamed_to:newkey"
+func lineage(l *storage.Lineage) protocol.RESPValue {
+	if l == nil {
+		return protocol.NewNullBulkString()
+	}
+	return protocol.NewBulkString([]byte(l.String()))
+}
+
+// author renders who wrote a version, e.g. "user=alice addr=10.0.0.1:5000"
+func author(a *storage.Author) protocol.RESPValue {
+	if a == nil {
+		return protocol.NewNullBulkString()
 	}
-	return 0
+	return protocol.NewBulkString([]byte(a.String()))
 }
 
 func HistorySpec() *command.CommandSpec {
@@ -52,9 +132,10 @@ func HistorySpec() *command.CommandSpec {
 		Name:        "HISTORY",
 		Handler:     command.HandlerFunc(History),
 		MinArgs:     1,
-		MaxArgs:     2,
-		Description: "Get version historyL History key [count]",
+		MaxArgs:     5,
+		Description: "Get version history: HISTORY key [count] [WITHMETA] [GREP pattern]",
 		ReadOnly:    true,
 		Mutates:     false,
+		Keys:        command.FirstKey,
 	}
 }
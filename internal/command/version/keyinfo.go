@@ -0,0 +1,50 @@
+package version
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// KeyInfo reports a key's MVCC metadata as field/value pairs, including keys
+// whose newest version is a tombstone. Reading it does not count as an access.
+// Usage: KEYINFO key
+func KeyInfo(ctx *command.Context, cmd *protocol.Command) command.Result {
+	info, err := ctx.Engine.KeyInfo(string(cmd.Args()[0]))
+	if err != nil {
+		return protocol.NewNullBulkString()
+	}
+
+	typ := "none"
+	if info.Exists {
+		typ = info.Type.String()
+	}
+	return command.Pairs([]command.Field{
+		{Name: "type", Value: protocol.NewBulkString([]byte(typ))},
+		{Name: "exists", Value: protocol.NewBoolean(info.Exists)},
+		{Name: "versions", Value: protocol.NewInteger(int64(info.Versions))},
+		{Name: "tombstones", Value: protocol.NewInteger(int64(info.Tombstones))},
+		{Name: "first-version", Value: protocol.NewInteger(int64(info.FirstVersion))},
+		{Name: "first-timestamp", Value: protocol.NewInteger(info.FirstTimestamp)},
+		{Name: "last-version", Value: protocol.NewInteger(int64(info.LastVersion))},
+		{Name: "last-timestamp", Value: protocol.NewInteger(info.LastTimestamp)},
+		{Name: "head-bytes", Value: protocol.NewInteger(info.HeadBytes)},
+		{Name: "history-bytes", Value: protocol.NewInteger(info.HistoryBytes)},
+		{Name: "codec", Value: protocol.NewBulkString([]byte(info.Codec.String()))},
+		{Name: "last-access", Value: protocol.NewInteger(info.LastAccess)},
+		{Name: "idle-seconds", Value: protocol.NewInteger(max(ctx.Now().Unix()-info.LastAccess, 0))},
+		{Name: "freq", Value: protocol.NewInteger(int64(info.Frequency))},
+	})
+}
+
+func KeyInfoSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "KEYINFO",
+		Handler:     command.HandlerFunc(KeyInfo),
+		MinArgs:     1,
+		MaxArgs:     1,
+		Description: "Report MVCC metadata of a key: KEYINFO key",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
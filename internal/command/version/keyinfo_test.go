@@ -0,0 +1,68 @@
+package version_test
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/command/version"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// keyInfo runs KEYINFO and returns its fields with their serialized values
+func keyInfo(t *testing.T, s *commandtest.Session, key string) map[string]string {
+	t.Helper()
+	reply := s.Do("KEYINFO", key)
+	value, err := protocol.NewRESPParser(bufio.NewReader(strings.NewReader(reply))).ParseValue()
+	if err != nil {
+		t.Fatalf("KEYINFO %s = %q: %v", key, reply, err)
+	}
+	array, ok := value.(*protocol.Array)
+	if !ok {
+		t.Fatalf("KEYINFO %s = %q, want field value pairs", key, reply)
+	}
+	elements := array.Elements()
+	fields := make(map[string]string, len(elements)/2)
+	for i := 0; i+1 < len(elements); i += 2 {
+		name := strings.Split(string(elements[i].Serialize()), "\r\n")[1]
+		fields[name] = strings.TrimSuffix(string(elements[i+1].Serialize()), "\r\n")
+	}
+	return fields
+}
+
+// TestKeyInfo_Metadata verifies KEYINFO counts the versions and tombstones of a
+// key, reports deleted keys and leaves missing keys null
+func TestKeyInfo_Metadata(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll, version.RegisterAll)
+	first := s.Version("SET", "k", "a", "RETURNVERSION")
+	s.Expect(":1\r\n", "DEL", "k")
+	last := s.Version("SET", "k", "bcd", "RETURNVERSION")
+
+	want := map[string]string{
+		"type":          "$6\r\nstring",
+		"exists":        ":1",
+		"versions":      ":3",
+		"tombstones":    ":1",
+		"first-version": ":" + first,
+		"last-version":  ":" + last,
+		"codec":         "$4\r\nnone",
+	}
+	fields := keyInfo(t, s, "k")
+	for name, value := range want {
+		if fields[name] != value {
+			t.Errorf("KEYINFO %s = %q, want %q", name, fields[name], value)
+		}
+	}
+	if len(fields) != 14 {
+		t.Errorf("KEYINFO has %d fields, want 14", len(fields))
+	}
+
+	s.Expect(":1\r\n", "DEL", "k")
+	fields = keyInfo(t, s, "k")
+	if fields["type"] != "$4\r\nnone" || fields["exists"] != ":0" || fields["tombstones"] != ":2" {
+		t.Errorf("KEYINFO of a deleted key = %v", fields)
+	}
+	s.Expect("$-1\r\n", "KEYINFO", "missing")
+}
@@ -6,4 +6,8 @@ import "github.com/ElshadHu/verdis/internal/command"
 func RegisterAll(router *command.Router) {
 	router.Register(GetVersionSpec())
 	router.Register(HistorySpec())
+	router.Register(KeyInfoSpec())
+	router.Register(RollbackSpec())
+	router.Register(ChangesSpec())
+	router.Register(DiffDBSpec())
 }
@@ -0,0 +1,62 @@
+package version
+
+import (
+	"errors"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// Rollback writes the state a key had at version as a new version, so the
+// rollback itself stays in the history. Rolling back to a point where the key
+// was deleted or did not exist yet deletes it. Replies with the new version.
+// Usage: ROLLBACK key version [COMMENT text]
+func Rollback(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, comment, err := command.SplitComment(cmd.Args(), 2)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 2 {
+		return protocol.NewError("ERR syntax error")
+	}
+	key := string(args[0])
+	version, err := command.ParseVersion(args[1])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if version > ctx.Engine.CurrentVersion() {
+		return protocol.NewError("ERR version is in the future")
+	}
+
+	target, err := ctx.Engine.LookupAt(key, version)
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound):
+		return protocol.NewError("ERR no such key")
+	case errors.Is(err, storage.ErrKeyDeleted), errors.Is(err, storage.ErrVersionNotFound):
+		target = storage.Entry{}
+	case err != nil:
+		return protocol.NewError("ERR " + err.Error())
+	}
+
+	written, err := ctx.Annotate(comment).Update(key, func(storage.Entry) (storage.Entry, error) {
+		return target, nil
+	})
+	if err != nil {
+		return protocol.NewError("ERR " + err.Error())
+	}
+	return protocol.NewInteger(int64(written))
+}
+
+func RollbackSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "ROLLBACK",
+		Handler:     command.HandlerFunc(Rollback),
+		MinArgs:     2,
+		MaxArgs:     4,
+		Description: "Restore a key to its state at a version as a new version: ROLLBACK key version [COMMENT text]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
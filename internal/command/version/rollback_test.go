@@ -0,0 +1,40 @@
+package version_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/command/version"
+)
+
+// TestRollback_WritesTheOldState verifies ROLLBACK writes the state a key had
+// as a new version, deleting it when it had none, and records its comment
+func TestRollback_WritesTheOldState(t *testing.T) {
+	s := commandtest.NewSession(t, standard.RegisterAll, version.RegisterAll)
+	s.Expect("+OK\r\n", "SET", "other", "x")
+	before := strconv.FormatUint(s.Ctx.Engine.CurrentVersion(), 10)
+	first := s.Version("SET", "k", "a", "RETURNVERSION")
+	s.Expect("+OK\r\n", "SET", "k", "b")
+	deleted := s.Version("DEL", "k", "RETURNVERSION")
+	s.Expect("+OK\r\n", "SET", "k", "c")
+
+	rolled := s.Version("ROLLBACK", "k", first, "COMMENT", "undo bad deploy")
+	s.Expect("$1\r\na\r\n", "GET", "k")
+	history, err := s.Ctx.Engine.History("k", 0)
+	if err != nil || len(history) == 0 || strconv.FormatUint(history[0].Version, 10) != rolled ||
+		history[0].Comment != "undo bad deploy" {
+		t.Fatalf("history = %+v, %v, want %s with the comment on top", history, err, rolled)
+	}
+
+	s.Version("ROLLBACK", "k", deleted)
+	s.Expect(":0\r\n", "EXISTS", "k")
+	s.Expect("+OK\r\n", "SET", "k", "d")
+	s.Version("ROLLBACK", "k", before)
+	s.Expect(":0\r\n", "EXISTS", "k")
+
+	s.Expect("-ERR no such key\r\n", "ROLLBACK", "missing", first)
+	s.Expect("-ERR version is in the future\r\n", "ROLLBACK", "k", "1000")
+	s.Expect("-ERR syntax error\r\n", "ROLLBACK", "k", first, "extra")
+}
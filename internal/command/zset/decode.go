@@ -0,0 +1,102 @@
+package zset
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+var (
+	errSyntax   = errors.New("ERR syntax error")
+	errNotFloat = errors.New("ERR value is not a valid float")
+	errMinMax   = errors.New("ERR min or max is not a float")
+	errLexRange = errors.New("ERR min or max not valid string range item")
+	errNaN      = errors.New("ERR resulting score is not a number (NaN)")
+)
+
+// decode returns the sorted set held by entry, empty for missing keys and
+// storage.ErrWrongType when the key holds another type
+func decode(entry storage.Entry) (*datastructures.SortedSet, error) {
+	if !entry.Exists {
+		return datastructures.NewSortedSet(), nil
+	}
+	if entry.Type != storage.TypeZSet {
+		return nil, storage.ErrWrongType
+	}
+	return datastructures.DecodeSortedSet(entry.Value)
+}
+
+// edit prepares a change of the given members of the sorted set held by entry,
+// working on its encoding so writes do not decode the whole set
+func edit(entry storage.Entry, members [][]byte) (*datastructures.SortedSetEdit, error) {
+	if !entry.Exists {
+		return datastructures.EditSortedSet(nil, members)
+	}
+	if entry.Type != storage.TypeZSet {
+		return nil, storage.ErrWrongType
+	}
+	return datastructures.EditSortedSet(entry.Value, members)
+}
+
+// zsetEntry wraps an edited sorted set as the next state of a key, keeping its
+// expiry. An empty set deletes the key.
+func zsetEntry(current storage.Entry, z *datastructures.SortedSetEdit) storage.Entry {
+	if z.Len() == 0 {
+		return storage.Entry{}
+	}
+	return storage.Entry{Value: z.Encode(), Type: storage.TypeZSet, Exists: true, ExpiresAt: current.ExpiresAt}
+}
+
+// readZSet returns a key's latest sorted set, or the set as of version when
+// atVersion is set. Missing keys, deleted keys and unknown versions read as empty.
+func readZSet(ctx *command.Context, key string, version uint64, atVersion bool) (*datastructures.SortedSet, error) {
+	var entry storage.Entry
+	var err error
+	if atVersion {
+		if entry, err = ctx.Engine.LookupAt(key, version); err != nil {
+			return datastructures.NewSortedSet(), nil
+		}
+	} else if entry, err = ctx.Engine.Lookup(key); err != nil {
+		return nil, errors.New("ERR " + err.Error())
+	}
+	return decode(entry)
+}
+
+// parseScore parses a score, accepting inf, +inf and -inf but not NaN
+func parseScore(arg []byte) (float64, error) {
+	score, err := strconv.ParseFloat(string(arg), 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, errNotFloat
+	}
+	return score, nil
+}
+
+// formatScore renders a score the way Redis does: shortest round-trip form with
+// infinities spelled inf and -inf
+func formatScore(score float64) []byte {
+	switch {
+	case math.IsInf(score, 1):
+		return []byte("inf")
+	case math.IsInf(score, -1):
+		return []byte("-inf")
+	default:
+		return strconv.AppendFloat(nil, score, 'g', -1, 64)
+	}
+}
+
+// membersReply renders members as a flat array, interleaving scores when asked
+func membersReply(members []datastructures.ScoredMember, withScores bool) command.Result {
+	values := make([]protocol.RESPValue, 0, len(members)*2)
+	for _, m := range members {
+		values = append(values, protocol.NewBulkString(m.Member))
+		if withScores {
+			values = append(values, protocol.NewBulkString(formatScore(m.Score)))
+		}
+	}
+	return protocol.NewArray(values)
+}
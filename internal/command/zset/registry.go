@@ -0,0 +1,14 @@
+package zset
+
+import "github.com/ElshadHu/verdis/internal/command"
+
+// RegisterAll adds all command specs into the router.
+func RegisterAll(router *command.Router) {
+	router.Register(ZAddSpec())
+	router.Register(ZRemSpec())
+	router.Register(ZScoreSpec())
+	router.Register(ZRankSpec())
+	router.Register(ZRangeSpec())
+	router.Register(ZIncrBySpec())
+	router.Register(ZCardSpec())
+}
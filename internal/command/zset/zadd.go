@@ -0,0 +1,140 @@
+package zset
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// zaddOptions holds the parsed ZADD flags
+type zaddOptions struct {
+	nx, xx bool
+	gt, lt bool
+	ch     bool
+	incr   bool
+}
+
+// ZAdd adds members with scores to a sorted set as a new version and returns
+// how many were added (or changed with CH). With INCR it behaves like ZINCRBY.
+// Usage: ZADD key [NX|XX] [GT|LT] [CH] [INCR] score member [score member ...]
+func ZAdd(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	key := string(args[0])
+
+	opts, pairs, err := parseZAddOptions(args[1:])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	scores := make([]float64, len(pairs)/2)
+	members := make([][]byte, len(pairs)/2)
+	for i := range scores {
+		if scores[i], err = parseScore(pairs[2*i]); err != nil {
+			return protocol.NewError(err.Error())
+		}
+		members[i] = pairs[2*i+1]
+	}
+
+	var count int64
+	var result float64
+	var applied bool
+	_, err = ctx.Engine.Update(key, func(current storage.Entry) (storage.Entry, error) {
+		z, err := edit(current, members)
+		if err != nil {
+			return current, err
+		}
+
+		count, applied = 0, false
+		for i, score := range scores {
+			member := members[i]
+			old, exists := z.Score(member)
+			if (opts.nx && exists) || (opts.xx && !exists) {
+				continue
+			}
+			if opts.incr && exists {
+				score += old
+				if math.IsNaN(score) {
+					return current, errNaN
+				}
+			}
+			if exists && ((opts.gt && score <= old) || (opts.lt && score >= old)) {
+				continue
+			}
+
+			applied, result = true, score
+			if z.Add(member, score) || (opts.ch && old != score) {
+				count++
+			}
+		}
+
+		if count == 0 && !applied {
+			return current, storage.ErrSkipWrite
+		}
+		return zsetEntry(current, z), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	if opts.incr {
+		if !applied {
+			return protocol.NewNullBulkString()
+		}
+		return protocol.NewBulkString(formatScore(result))
+	}
+	return protocol.NewInteger(count)
+}
+
+// parseZAddOptions parses the flags in front of the score member pairs
+func parseZAddOptions(args [][]byte) (zaddOptions, [][]byte, error) {
+	var opts zaddOptions
+	i := 0
+loop:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(string(args[i])) {
+		case "NX":
+			opts.nx = true
+		case "XX":
+			opts.xx = true
+		case "GT":
+			opts.gt = true
+		case "LT":
+			opts.lt = true
+		case "CH":
+			opts.ch = true
+		case "INCR":
+			opts.incr = true
+		default:
+			break loop
+		}
+	}
+	pairs := args[i:]
+
+	switch {
+	case len(pairs) == 0 || len(pairs)%2 != 0:
+		return opts, nil, errSyntax
+	case opts.nx && opts.xx:
+		return opts, nil, errors.New("ERR XX and NX options at the same time are not compatible")
+	case (opts.gt && opts.lt) || (opts.nx && (opts.gt || opts.lt)):
+		return opts, nil, errors.New("ERR GT, LT, and/or NX options at the same time are not compatible")
+	case opts.incr && len(pairs) != 2:
+		return opts, nil, errors.New("ERR INCR option supports a single increment-element pair")
+	}
+	return opts, pairs, nil
+}
+
+func ZAddSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "ZADD",
+		Handler:     command.HandlerFunc(ZAdd),
+		MinArgs:     3,
+		MaxArgs:     -1,
+		Description: "Add members to a sorted set: ZADD key [NX|XX] [GT|LT] [CH] [INCR] score member [score member ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,37 @@
+package zset
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// ZCard returns the number of members of a sorted set, optionally as of a past version.
+// Usage: ZCARD key [AT version]
+func ZCard(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 1 {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	z, err := readZSet(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(int64(z.Len()))
+}
+
+func ZCardSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "ZCARD",
+		Handler:     command.HandlerFunc(ZCard),
+		MinArgs:     1,
+		MaxArgs:     3,
+		Description: "Get the number of members of a sorted set: ZCARD key [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,52 @@
+package zset
+
+import (
+	"math"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// ZIncrBy increments the score of a member as a new version and returns the new score.
+// Usage: ZINCRBY key increment member
+func ZIncrBy(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+	delta, err := parseScore(args[1])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	var score float64
+	_, err = ctx.Engine.Update(string(args[0]), func(current storage.Entry) (storage.Entry, error) {
+		z, err := edit(current, args[2:3])
+		if err != nil {
+			return current, err
+		}
+
+		old, _ := z.Score(args[2])
+		score = old + delta
+		if math.IsNaN(score) {
+			return current, errNaN
+		}
+		z.Add(args[2], score)
+		return zsetEntry(current, z), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewBulkString(formatScore(score))
+}
+
+func ZIncrBySpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "ZINCRBY",
+		Handler:     command.HandlerFunc(ZIncrBy),
+		MinArgs:     3,
+		MaxArgs:     3,
+		Description: "Increment the score of a sorted set member: ZINCRBY key increment member",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
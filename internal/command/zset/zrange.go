@@ -0,0 +1,230 @@
+package zset
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/datastructures"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// rangeBy selects how ZRANGE interprets its start and stop arguments
+type rangeBy int
+
+const (
+	byRank rangeBy = iota
+	byScore
+	byLex
+)
+
+// rangeOptions holds the parsed ZRANGE flags
+type rangeOptions struct {
+	by            rangeBy
+	rev           bool
+	limit         bool
+	offset, count int64
+	withScores    bool
+}
+
+// ZRange returns a range of sorted set members by rank, score or member, in
+// ascending or reverse order. With AT the range is read as of a past version,
+// which replays a leaderboard historically.
+// Usage: ZRANGE key start stop [BYSCORE|BYLEX] [REV] [LIMIT offset count] [WITHSCORES] [AT version]
+func ZRange(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) < 3 {
+		return protocol.NewError(errSyntax.Error())
+	}
+	opts, err := parseRangeOptions(args[3:])
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	// REV takes the bounds in max, min order for BYSCORE and BYLEX
+	lo, hi := args[1], args[2]
+	if opts.rev && opts.by != byRank {
+		lo, hi = hi, lo
+	}
+
+	z, err := readZSet(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	var members []datastructures.ScoredMember
+	switch opts.by {
+	case byRank:
+		members, err = rangeByRank(z, lo, hi, opts.rev)
+	case byScore:
+		members, err = rangeByScore(z, lo, hi)
+	case byLex:
+		members, err = rangeByLex(z, lo, hi)
+	}
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	if opts.by != byRank && opts.rev {
+		slices.Reverse(members)
+	}
+	if opts.limit {
+		members = applyLimit(members, opts.offset, opts.count)
+	}
+	return membersReply(members, opts.withScores)
+}
+
+// rangeByRank returns members by rank, where REV counts ranks from the highest score
+func rangeByRank(z *datastructures.SortedSet, startArg, stopArg []byte, rev bool) ([]datastructures.ScoredMember, error) {
+	start, err := command.ParseInt(startArg)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := command.ParseInt(stopArg)
+	if err != nil {
+		return nil, err
+	}
+
+	n := int64(z.Len())
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	if start > stop || start >= n {
+		return nil, nil
+	}
+
+	if !rev {
+		return z.Range(int(start), int(stop)+1), nil
+	}
+	members := z.Range(int(n-1-stop), int(n-start))
+	slices.Reverse(members)
+	return members, nil
+}
+
+func rangeByScore(z *datastructures.SortedSet, minArg, maxArg []byte) ([]datastructures.ScoredMember, error) {
+	lo, err := parseScoreBound(minArg)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := parseScoreBound(maxArg)
+	if err != nil {
+		return nil, err
+	}
+	return z.RangeByScore(lo, hi), nil
+}
+
+func rangeByLex(z *datastructures.SortedSet, minArg, maxArg []byte) ([]datastructures.ScoredMember, error) {
+	lo, err := parseLexBound(minArg)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := parseLexBound(maxArg)
+	if err != nil {
+		return nil, err
+	}
+	return z.RangeByLex(lo, hi), nil
+}
+
+// applyLimit skips offset members and keeps count of the rest, a negative count keeps all
+func applyLimit(members []datastructures.ScoredMember, offset, count int64) []datastructures.ScoredMember {
+	if offset < 0 || offset >= int64(len(members)) {
+		return nil
+	}
+	members = members[offset:]
+	if count >= 0 && count < int64(len(members)) {
+		members = members[:count]
+	}
+	return members
+}
+
+// parseRangeOptions parses the flags following ZRANGE key start stop
+func parseRangeOptions(args [][]byte) (rangeOptions, error) {
+	opts := rangeOptions{count: -1}
+	for i := 0; i < len(args); i++ {
+		switch strings.ToUpper(string(args[i])) {
+		case "BYSCORE":
+			opts.by = byScore
+		case "BYLEX":
+			opts.by = byLex
+		case "REV":
+			opts.rev = true
+		case "WITHSCORES":
+			opts.withScores = true
+		case "LIMIT":
+			if i+2 >= len(args) {
+				return opts, errSyntax
+			}
+			var err error
+			if opts.offset, err = command.ParseInt(args[i+1]); err != nil {
+				return opts, err
+			}
+			if opts.count, err = command.ParseInt(args[i+2]); err != nil {
+				return opts, err
+			}
+			opts.limit = true
+			i += 2
+		default:
+			return opts, errSyntax
+		}
+	}
+
+	if opts.limit && opts.by == byRank {
+		return opts, errors.New("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+	}
+	if opts.withScores && opts.by == byLex {
+		return opts, errors.New("ERR syntax error, WITHSCORES not supported in combination with BYLEX")
+	}
+	return opts, nil
+}
+
+// parseScoreBound parses a BYSCORE bound such as 1.5, (1.5, -inf or +inf
+func parseScoreBound(arg []byte) (datastructures.ScoreBound, error) {
+	var bound datastructures.ScoreBound
+	if len(arg) > 0 && arg[0] == '(' {
+		bound.Open = true
+		arg = arg[1:]
+	}
+	score, err := parseScore(arg)
+	if err != nil {
+		return bound, errMinMax
+	}
+	bound.Score = score
+	return bound, nil
+}
+
+// parseLexBound parses a BYLEX bound: [member, (member, - or +
+func parseLexBound(arg []byte) (datastructures.LexBound, error) {
+	switch {
+	case len(arg) == 1 && arg[0] == '-':
+		return datastructures.LexBound{Inf: -1}, nil
+	case len(arg) == 1 && arg[0] == '+':
+		return datastructures.LexBound{Inf: 1}, nil
+	case len(arg) > 0 && arg[0] == '[':
+		return datastructures.LexBound{Member: arg[1:]}, nil
+	case len(arg) > 0 && arg[0] == '(':
+		return datastructures.LexBound{Member: arg[1:], Open: true}, nil
+	default:
+		return datastructures.LexBound{}, errLexRange
+	}
+}
+
+func ZRangeSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "ZRANGE",
+		Handler:     command.HandlerFunc(ZRange),
+		MinArgs:     3,
+		MaxArgs:     -1,
+		Description: "Get a range of sorted set members: ZRANGE key start stop [BYSCORE|BYLEX] [REV] [LIMIT offset count] [WITHSCORES] [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,60 @@
+package zset
+
+import (
+	"strings"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// ZRank returns the 0-based rank of a member ordered by ascending score,
+// optionally with its score and as of a past version.
+// Usage: ZRANK key member [WITHSCORE] [AT version]
+func ZRank(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+
+	withScore := false
+	switch {
+	case len(args) == 3 && strings.EqualFold(string(args[2]), "WITHSCORE"):
+		withScore = true
+	case len(args) != 2:
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	z, err := readZSet(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	rank, ok := z.Rank(args[1])
+	if !ok {
+		if withScore {
+			return protocol.NewNullArray()
+		}
+		return protocol.NewNullBulkString()
+	}
+
+	if !withScore {
+		return protocol.NewInteger(int64(rank))
+	}
+	score, _ := z.Score(args[1])
+	return protocol.NewArray([]protocol.RESPValue{
+		protocol.NewInteger(int64(rank)),
+		protocol.NewBulkString(formatScore(score)),
+	})
+}
+
+func ZRankSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "ZRANK",
+		Handler:     command.HandlerFunc(ZRank),
+		MinArgs:     2,
+		MaxArgs:     5,
+		Description: "Get the rank of a sorted set member: ZRANK key member [WITHSCORE] [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
@@ -0,0 +1,50 @@
+package zset
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// ZRem removes members from a sorted set as a new version. Removing the last
+// member deletes the key.
+// Usage: ZREM key member [member ...]
+func ZRem(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args := cmd.Args()
+
+	var removed int64
+	_, err := ctx.Engine.Update(string(args[0]), func(current storage.Entry) (storage.Entry, error) {
+		z, err := edit(current, args[1:])
+		if err != nil {
+			return current, err
+		}
+
+		removed = 0
+		for _, member := range args[1:] {
+			if z.Remove(member) {
+				removed++
+			}
+		}
+		if removed == 0 {
+			return current, storage.ErrSkipWrite
+		}
+		return zsetEntry(current, z), nil
+	})
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	return protocol.NewInteger(removed)
+}
+
+func ZRemSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "ZREM",
+		Handler:     command.HandlerFunc(ZRem),
+		MinArgs:     2,
+		MaxArgs:     -1,
+		Description: "Remove members from a sorted set: ZREM key member [member ...]",
+		ReadOnly:    false,
+		Mutates:     true,
+		Keys:        command.FirstKey,
+	}
+}
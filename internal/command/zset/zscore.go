@@ -0,0 +1,41 @@
+package zset
+
+import (
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// ZScore returns the score of a member, optionally as of a past version.
+// Usage: ZSCORE key member [AT version]
+func ZScore(ctx *command.Context, cmd *protocol.Command) command.Result {
+	args, version, atVersion, err := command.SplitAtVersion(cmd.Args())
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	if len(args) != 2 {
+		return protocol.NewError(errSyntax.Error())
+	}
+
+	z, err := readZSet(ctx, string(args[0]), version, atVersion)
+	if err != nil {
+		return protocol.NewError(err.Error())
+	}
+	score, ok := z.Score(args[1])
+	if !ok {
+		return protocol.NewNullBulkString()
+	}
+	return protocol.NewBulkString(formatScore(score))
+}
+
+func ZScoreSpec() *command.CommandSpec {
+	return &command.CommandSpec{
+		Name:        "ZSCORE",
+		Handler:     command.HandlerFunc(ZScore),
+		MinArgs:     2,
+		MaxArgs:     4,
+		Description: "Get the score of a sorted set member: ZSCORE key member [AT version]",
+		ReadOnly:    true,
+		Mutates:     false,
+		Keys:        command.FirstKey,
+	}
+}
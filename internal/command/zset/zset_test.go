@@ -0,0 +1,97 @@
+package zset_test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/command/commandtest"
+	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/command/zset"
+)
+
+func newSession(t *testing.T) *commandtest.Session {
+	return commandtest.NewSession(t, zset.RegisterAll, standard.RegisterAll)
+}
+
+// bulks returns the RESP2 array of the given bulk strings
+func bulks(items ...string) string {
+	reply := fmt.Sprintf("*%d\r\n", len(items))
+	for _, item := range items {
+		reply += fmt.Sprintf("$%d\r\n%s\r\n", len(item), item)
+	}
+	return reply
+}
+
+// TestZSet_RangesAndRanks verifies members are ordered by score, then member,
+// whichever way ZRANGE and ZRANK read them
+func TestZSet_RangesAndRanks(t *testing.T) {
+	s := newSession(t)
+	s.Expect(":4\r\n", "ZADD", "z", "2", "b", "1", "a", "2", "c", "3.5", "d")
+	s.Expect(":4\r\n", "ZCARD", "z")
+
+	s.Expect(bulks("a", "b", "c", "d"), "ZRANGE", "z", "0", "-1")
+	s.Expect(bulks("d", "c"), "ZRANGE", "z", "0", "1", "REV")
+	s.Expect(bulks("b", "2", "c", "2"), "ZRANGE", "z", "2", "(3.5", "BYSCORE", "WITHSCORES")
+	s.Expect(bulks("c", "d"), "ZRANGE", "z", "-inf", "+inf", "BYSCORE", "LIMIT", "2", "5")
+	s.Expect(bulks("c", "b"), "ZRANGE", "z", "[c", "(a", "BYLEX", "REV")
+
+	s.Expect(":2\r\n", "ZRANK", "z", "c")
+	s.Expect("*2\r\n:3\r\n$3\r\n3.5\r\n", "ZRANK", "z", "d", "WITHSCORE")
+	s.Expect("$-1\r\n", "ZRANK", "z", "missing")
+	s.Expect("$3\r\n3.5\r\n", "ZSCORE", "z", "d")
+}
+
+// TestZSet_AddOptions verifies the ZADD flags decide which members change and
+// what the reply counts
+func TestZSet_AddOptions(t *testing.T) {
+	s := newSession(t)
+	s.Expect(":1\r\n", "ZADD", "z", "5", "m")
+	s.Expect(":0\r\n", "ZADD", "z", "NX", "1", "m")
+	s.Expect(":0\r\n", "ZADD", "z", "XX", "1", "new")
+	s.Expect(":0\r\n", "ZADD", "z", "GT", "CH", "4", "m")
+	s.Expect(":1\r\n", "ZADD", "z", "LT", "CH", "4", "m")
+	s.Expect("$1\r\n6\r\n", "ZADD", "z", "INCR", "2", "m")
+	s.Expect("$-1\r\n", "ZADD", "z", "NX", "INCR", "1", "m")
+	s.Expect("$3\r\n8.5\r\n", "ZINCRBY", "z", "2.5", "m")
+	s.Expect(":0\r\n", "EXISTS", "new")
+
+	s.Expect("-ERR XX and NX options at the same time are not compatible\r\n", "ZADD", "z", "NX", "XX", "1", "m")
+	s.Expect("-ERR syntax error\r\n", "ZADD", "z", "1", "m", "2")
+}
+
+// TestZSet_PastVersions verifies a leaderboard can be read as it stood at an
+// earlier version after members were rescored and removed
+func TestZSet_PastVersions(t *testing.T) {
+	s := newSession(t)
+	s.Expect(":2\r\n", "ZADD", "z", "10", "alice", "20", "bob")
+	before := strconv.FormatUint(s.Ctx.Engine.CurrentVersion(), 10)
+
+	s.Expect(":0\r\n", "ZADD", "z", "30", "alice")
+	s.Expect(":1\r\n", "ZREM", "z", "bob", "missing")
+	s.Expect(bulks("alice"), "ZRANGE", "z", "0", "-1")
+
+	s.Expect(bulks("bob", "20", "alice", "10"), "ZRANGE", "z", "0", "-1", "REV", "WITHSCORES", "AT", before)
+	s.Expect("$2\r\n10\r\n", "ZSCORE", "z", "alice", "AT", before)
+	s.Expect(":0\r\n", "ZRANK", "z", "alice", "AT", before)
+	s.Expect(":2\r\n", "ZCARD", "z", "AT", before)
+
+	// removing the last member deletes the key
+	s.Expect(":1\r\n", "ZREM", "z", "alice")
+	s.Expect(":0\r\n", "EXISTS", "z")
+}
+
+// TestZSet_WrongType verifies sorted set commands refuse keys of another type
+func TestZSet_WrongType(t *testing.T) {
+	s := newSession(t)
+	s.Expect("+OK\r\n", "SET", "s", "v")
+	for _, args := range [][]string{
+		{"ZADD", "s", "1", "m"},
+		{"ZRANGE", "s", "0", "-1"},
+		{"ZSCORE", "s", "m"},
+		{"ZINCRBY", "s", "1", "m"},
+	} {
+		s.Expect("-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", args...)
+	}
+	s.Expect("-ERR value is not a valid float\r\n", "ZADD", "z", "x", "m")
+}
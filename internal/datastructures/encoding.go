@@ -0,0 +1,87 @@
+package datastructures
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrCorrupt is returned when an encoded collection value cannot be decoded
+var ErrCorrupt = errors.New("corrupt encoded value")
+
+// appendBytes appends b prefixed with its uvarint length
+func appendBytes(buf, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// appendCount appends an element count
+func appendCount(buf []byte, n int) []byte {
+	return binary.AppendUvarint(buf, uint64(n))
+}
+
+// appendUint appends a plain unsigned integer
+func appendUint(buf []byte, n uint64) []byte {
+	return binary.AppendUvarint(buf, n)
+}
+
+// decoder reads the length-prefixed fields written by appendBytes
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	n, size := binary.Uvarint(d.data)
+	if size <= 0 {
+		d.err = ErrCorrupt
+		return 0
+	}
+	d.data = d.data[size:]
+	return n
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.uvarint()
+	if d.err != nil {
+		return nil
+	}
+	if n > uint64(len(d.data)) {
+		d.err = ErrCorrupt
+		return nil
+	}
+	b := d.data[:n:n]
+	d.data = d.data[n:]
+	return b
+}
+
+// count reads an element count, rejecting counts the remaining input cannot hold
+func (d *decoder) count() int {
+	n := d.uvarint()
+	if d.err == nil && n > uint64(len(d.data)) {
+		d.err = ErrCorrupt
+	}
+	return int(n)
+}
+
+func (d *decoder) done() error {
+	if d.err == nil && len(d.data) != 0 {
+		d.err = ErrCorrupt
+	}
+	return d.err
+}
+
+// fixed reads n raw bytes, returning zeros once the input is exhausted
+func (d *decoder) fixed(n int) []byte {
+	if d.err == nil && len(d.data) < n {
+		d.err = ErrCorrupt
+	}
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	b := d.data[:n:n]
+	d.data = d.data[n:]
+	return b
+}
@@ -0,0 +1,45 @@
+package datastructures
+
+import (
+	"maps"
+	"slices"
+)
+
+// Hash is a decoded hash value mapping fields to values
+type Hash map[string][]byte
+
+// Fields returns the field names in sorted order
+func (h Hash) Fields() []string {
+	return slices.Sorted(maps.Keys(h))
+}
+
+// Clone returns a shallow copy that can be modified without touching h
+func (h Hash) Clone() Hash {
+	return maps.Clone(h)
+}
+
+// Encode serializes the hash with fields in sorted order, so equal hashes encode
+// to equal bytes
+func (h Hash) Encode() []byte {
+	buf := appendCount(nil, len(h))
+	for _, field := range h.Fields() {
+		buf = appendBytes(buf, []byte(field))
+		buf = appendBytes(buf, h[field])
+	}
+	return buf
+}
+
+// DecodeHash parses a value written by Hash.Encode
+func DecodeHash(data []byte) (Hash, error) {
+	d := &decoder{data: data}
+	n := d.count()
+	h := make(Hash, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		field := d.bytes()
+		h[string(field)] = d.bytes()
+	}
+	if err := d.done(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
@@ -0,0 +1,294 @@
+package datastructures
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// HyperLogLog parameters and on-disk layout, identical to Redis so values can be
+// moved between the two with GET and SET
+const (
+	hllP          = 14
+	hllQ          = 64 - hllP
+	hllRegisters  = 1 << hllP
+	hllBits       = 6
+	hllMaxValue   = 1<<hllBits - 1
+	hllHeaderSize = 16
+	hllDenseSize  = hllHeaderSize + (hllRegisters*hllBits+7)/8
+
+	hllDense  = 0
+	hllSparse = 1
+
+	// sparse opcodes: ZERO 00xxxxxx, XZERO 01xxxxxx yyyyyyyy, VAL 1vvvvvxx
+	hllZeroMaxLen    = 64
+	hllXZeroMaxLen   = 16384
+	hllValMaxValue   = 32
+	hllValMaxLen     = 4
+	hllSparseMaxSize = 3000 // Redis hll-sparse-max-bytes default
+
+	hllAlphaInf = 0.721347520444481703680
+	hllSeed     = 0xadc83b19
+)
+
+var hllMagic = []byte("HYLL")
+
+var (
+	// ErrNotHyperLogLog is returned for strings that do not carry the HYLL header
+	ErrNotHyperLogLog = errors.New("not a HyperLogLog value")
+	// ErrCorruptHyperLogLog is returned when a HYLL value's registers cannot be decoded
+	ErrCorruptHyperLogLog = errors.New("corrupted HyperLogLog value")
+)
+
+// HyperLogLog is a decoded HyperLogLog with one byte per register. The Redis
+// sparse or dense encoding is only used for the stored value.
+type HyperLogLog struct {
+	registers [hllRegisters]uint8
+}
+
+func NewHyperLogLog() *HyperLogLog {
+	return &HyperLogLog{}
+}
+
+// IsHyperLogLog reports whether data starts with the HYLL header
+func IsHyperLogLog(data []byte) bool {
+	return len(data) >= hllHeaderSize && bytes.Equal(data[:4], hllMagic)
+}
+
+// Add hashes elem into its register and reports whether a register changed
+func (h *HyperLogLog) Add(elem []byte) bool {
+	index, count := hllPatLen(elem)
+	if count <= h.registers[index] {
+		return false
+	}
+	h.registers[index] = count
+	return true
+}
+
+// Merge keeps the maximum of each register, reporting whether any changed
+func (h *HyperLogLog) Merge(other *HyperLogLog) bool {
+	changed := false
+	for i, v := range other.registers {
+		if v > h.registers[i] {
+			h.registers[i] = v
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Count estimates the cardinality with the improved estimator Redis uses
+// (Ertl, "New cardinality estimation algorithms for HyperLogLog sketches")
+func (h *HyperLogLog) Count() uint64 {
+	var histogram [hllMaxValue + 1]int
+	for _, v := range h.registers {
+		histogram[v]++
+	}
+
+	m := float64(hllRegisters)
+	z := m * hllTau((m-float64(histogram[hllQ+1]))/m)
+	for j := hllQ; j >= 1; j-- {
+		z += float64(histogram[j])
+		z *= 0.5
+	}
+	z += m * hllSigma(float64(histogram[0])/m)
+	return uint64(math.Round(hllAlphaInf * m * m / z))
+}
+
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y := 1.0
+	z := x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if prev == z {
+			return z
+		}
+	}
+}
+
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y := 1.0
+	z := 1 - x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= math.Pow(1-x, 2) * y
+		if prev == z {
+			return z / 3
+		}
+	}
+}
+
+// hllPatLen returns the register an element maps to and the length of the
+// run of zeros in the rest of its hash plus one
+func hllPatLen(elem []byte) (int, uint8) {
+	hash := murmurHash64A(elem, hllSeed)
+	index := int(hash & (hllRegisters - 1))
+	hash >>= hllP
+	hash |= 1 << hllQ // bounds the count at Q+1
+	return index, uint8(bits.TrailingZeros64(hash) + 1)
+}
+
+// murmurHash64A is the 64-bit MurmurHash2 variant Redis hashes elements with
+func murmurHash64A(key []byte, seed uint64) uint64 {
+	const m = 0xc6a4a7935bd1e995
+	const r = 47
+
+	h := seed ^ (uint64(len(key)) * m)
+	for len(key) >= 8 {
+		k := binary.LittleEndian.Uint64(key)
+		k *= m
+		k ^= k >> r
+		k *= m
+		h ^= k
+		h *= m
+		key = key[8:]
+	}
+	if len(key) > 0 {
+		for i := len(key) - 1; i >= 0; i-- {
+			h ^= uint64(key[i]) << (8 * i)
+		}
+		h *= m
+	}
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+	return h
+}
+
+// Encode serializes the registers in the Redis sparse encoding while every
+// register fits it and the result stays under the sparse size limit, and in the
+// dense encoding otherwise. The cardinality cache in the header is filled in.
+func (h *HyperLogLog) Encode() []byte {
+	if sparse, ok := h.encodeSparse(); ok {
+		return sparse
+	}
+
+	buf := h.header(hllDense, hllDenseSize)
+	dense := buf[hllHeaderSize:hllDenseSize]
+	for i, v := range h.registers {
+		bit := i * hllBits
+		b, shift := bit/8, uint(bit%8)
+		dense[b] |= v << shift
+		if shift > 8-hllBits {
+			dense[b+1] |= v >> (8 - shift)
+		}
+	}
+	return buf[:hllDenseSize]
+}
+
+func (h *HyperLogLog) header(encoding byte, capacity int) []byte {
+	buf := make([]byte, hllHeaderSize, capacity)
+	copy(buf, hllMagic)
+	buf[4] = encoding
+	binary.LittleEndian.PutUint64(buf[8:], h.Count())
+	return buf
+}
+
+func (h *HyperLogLog) encodeSparse() ([]byte, bool) {
+	buf := h.header(hllSparse, hllHeaderSize+64)
+	for i := 0; i < hllRegisters; {
+		v := h.registers[i]
+		if v > hllValMaxValue {
+			return nil, false
+		}
+		run := 1
+		for i+run < hllRegisters && h.registers[i+run] == v {
+			run++
+		}
+		i += run
+
+		for run > 0 {
+			switch {
+			case v != 0:
+				n := min(run, hllValMaxLen)
+				buf = append(buf, 0x80|(v-1)<<2|byte(n-1))
+				run -= n
+			case run > hllZeroMaxLen:
+				n := min(run, hllXZeroMaxLen)
+				buf = append(buf, 0x40|byte((n-1)>>8), byte(n-1))
+				run -= n
+			default:
+				buf = append(buf, byte(run-1))
+				run = 0
+			}
+		}
+		if len(buf) > hllSparseMaxSize {
+			return nil, false
+		}
+	}
+	return buf, true
+}
+
+// DecodeHyperLogLog parses a HYLL value in either the sparse or dense encoding
+func DecodeHyperLogLog(data []byte) (*HyperLogLog, error) {
+	if !IsHyperLogLog(data) {
+		return nil, ErrNotHyperLogLog
+	}
+	h := &HyperLogLog{}
+	body := data[hllHeaderSize:]
+
+	switch data[4] {
+	case hllDense:
+		if len(data) != hllDenseSize {
+			return nil, ErrCorruptHyperLogLog
+		}
+		for i := range h.registers {
+			bit := i * hllBits
+			b, shift := bit/8, uint(bit%8)
+			v := uint16(body[b]) >> shift
+			if shift > 8-hllBits {
+				v |= uint16(body[b+1]) << (8 - shift)
+			}
+			h.registers[i] = uint8(v & hllMaxValue)
+		}
+		return h, nil
+
+	case hllSparse:
+		i := 0
+		for p := 0; p < len(body); p++ {
+			op := body[p]
+			var run int
+			var v uint8
+			switch {
+			case op&0xc0 == 0x00: // ZERO
+				run = int(op&0x3f) + 1
+			case op&0xc0 == 0x40: // XZERO
+				if p+1 >= len(body) {
+					return nil, ErrCorruptHyperLogLog
+				}
+				run = (int(op&0x3f)<<8 | int(body[p+1])) + 1
+				p++
+			default: // VAL
+				v = (op>>2)&0x1f + 1
+				run = int(op&0x03) + 1
+			}
+			if i+run > hllRegisters {
+				return nil, ErrCorruptHyperLogLog
+			}
+			for ; run > 0; run-- {
+				h.registers[i] = v
+				i++
+			}
+		}
+		if i != hllRegisters {
+			return nil, ErrCorruptHyperLogLog
+		}
+		return h, nil
+
+	default:
+		return nil, ErrCorruptHyperLogLog
+	}
+}
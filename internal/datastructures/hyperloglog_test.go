@@ -0,0 +1,53 @@
+package datastructures_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/datastructures"
+)
+
+// TestHyperLogLog_EncodingsRoundTrip verifies small sketches use the sparse
+// encoding, large ones switch to dense, and both decode to the same estimate
+func TestHyperLogLog_EncodingsRoundTrip(t *testing.T) {
+	for _, n := range []int{10, 1000, 100000} {
+		h := datastructures.NewHyperLogLog()
+		for i := range n {
+			h.Add([]byte("visitor:" + strconv.Itoa(i)))
+		}
+
+		encoded := h.Encode()
+		if !datastructures.IsHyperLogLog(encoded) {
+			t.Fatalf("n=%d: missing HYLL header", n)
+		}
+		sparse := encoded[4] == 1
+		if (n <= 1000) != sparse {
+			t.Errorf("n=%d: sparse=%v, %d bytes", n, sparse, len(encoded))
+		}
+
+		decoded, err := datastructures.DecodeHyperLogLog(encoded)
+		if err != nil {
+			t.Fatalf("n=%d: decode: %v", n, err)
+		}
+		if decoded.Count() != h.Count() {
+			t.Errorf("n=%d: decoded count %d, want %d", n, decoded.Count(), h.Count())
+		}
+		if relErr := math.Abs(float64(h.Count())-float64(n)) / float64(n); relErr > 0.02 {
+			t.Errorf("n=%d: estimate %d is off by %.2f%%", n, h.Count(), relErr*100)
+		}
+	}
+}
+
+func TestHyperLogLog_RejectsCorruptSparse(t *testing.T) {
+	h := datastructures.NewHyperLogLog()
+	h.Add([]byte("a"))
+	encoded := h.Encode()
+
+	if _, err := datastructures.DecodeHyperLogLog(encoded[:len(encoded)-1]); err == nil {
+		t.Error("truncated sparse value decoded without error")
+	}
+	if _, err := datastructures.DecodeHyperLogLog([]byte("plain string value")); err != datastructures.ErrNotHyperLogLog {
+		t.Errorf("plain string: err=%v", err)
+	}
+}
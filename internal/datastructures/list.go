@@ -0,0 +1,61 @@
+package datastructures
+
+// List is a decoded list value, head first
+type List [][]byte
+
+// Clone returns a copy whose elements can be added or removed without touching l
+func (l List) Clone() List {
+	return append(List(nil), l...)
+}
+
+// Range normalizes Redis style start and stop offsets, where negative offsets
+// count from the tail, into a half-open slice range. ok is false when the range
+// is empty.
+func (l List) Range(start, stop int64) (from, to int, ok bool) {
+	n := int64(len(l))
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	if start > stop || start >= n {
+		return 0, 0, false
+	}
+	return int(start), int(stop) + 1, true
+}
+
+// Index resolves an offset that may count from the tail, ok is false when it is out of range
+func (l List) Index(index int64) (int, bool) {
+	if index < 0 {
+		index += int64(len(l))
+	}
+	if index < 0 || index >= int64(len(l)) {
+		return 0, false
+	}
+	return int(index), true
+}
+
+// Encode serializes the list in order
+func (l List) Encode() []byte {
+	buf := appendCount(nil, len(l))
+	for _, elem := range l {
+		buf = appendBytes(buf, elem)
+	}
+	return buf
+}
+
+// DecodeList parses a value written by List.Encode
+func DecodeList(data []byte) (List, error) {
+	d := &decoder{data: data}
+	n := d.count()
+	l := make(List, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		l = append(l, d.bytes())
+	}
+	if err := d.done(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
@@ -0,0 +1,79 @@
+package datastructures
+
+import (
+	"maps"
+	"slices"
+)
+
+// Set is a decoded set value
+type Set map[string]struct{}
+
+// Members returns the members in sorted order
+func (s Set) Members() []string {
+	return slices.Sorted(maps.Keys(s))
+}
+
+// Clone returns a copy that can be modified without touching s
+func (s Set) Clone() Set {
+	return maps.Clone(s)
+}
+
+// Encode serializes the set with members in sorted order, so equal sets encode
+// to equal bytes
+func (s Set) Encode() []byte {
+	buf := appendCount(nil, len(s))
+	for _, member := range s.Members() {
+		buf = appendBytes(buf, []byte(member))
+	}
+	return buf
+}
+
+// DecodeSet parses a value written by Set.Encode
+func DecodeSet(data []byte) (Set, error) {
+	d := &decoder{data: data}
+	n := d.count()
+	s := make(Set, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		s[string(d.bytes())] = struct{}{}
+	}
+	if err := d.done(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Intersect returns the members present in every set
+func Intersect(sets ...Set) Set {
+	result := make(Set)
+	if len(sets) == 0 {
+		return result
+	}
+	// probe from the smallest set
+	smallest := slices.MinFunc(sets, func(a, b Set) int { return len(a) - len(b) })
+	for member := range smallest {
+		if !slices.ContainsFunc(sets, func(s Set) bool { _, ok := s[member]; return !ok }) {
+			result[member] = struct{}{}
+		}
+	}
+	return result
+}
+
+// Union returns the members present in any set
+func Union(sets ...Set) Set {
+	result := make(Set)
+	for _, s := range sets {
+		maps.Copy(result, s)
+	}
+	return result
+}
+
+// Diff returns the members of first that are in none of the others
+func Diff(first Set, others ...Set) Set {
+	result := first.Clone()
+	for _, s := range others {
+		for member := range s {
+			delete(result, member)
+		}
+	}
+	return result
+}
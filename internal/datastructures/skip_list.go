@@ -19,6 +19,10 @@ type SkipListNode struct {
 	forward []atomic.Pointer[SkipListNode]
 	marked  atomic.Bool // logical deletion flag
 	level   int
+
+	// span counts the level 0 steps each forward link skips, only kept in
+	// ranked lists
+	span []atomic.Int64
 }
 
 func newSkipListNode(key, value []byte, level int) *SkipListNode {
@@ -40,7 +44,11 @@ type SkipList struct {
 	level atomic.Int32
 	size  atomic.Int64
 	seed  atomic.Uint64
-	mu    sync.Mutex // Used only for Clear() to ensure atomicity
+	mu    sync.Mutex // Used for Clear() and, in ranked lists, to serialize writers
+
+	// ranked lists keep link spans for Rank and SeekToRank, as in Redis'
+	// zskiplist
+	ranked bool
 }
 
 func NewSkipList() *SkipList {
@@ -56,6 +64,17 @@ func NewSkipList() *SkipList {
 	return sl
 }
 
+// NewRankedSkipList returns a skip list that also answers Rank and SeekToRank
+// in O(log n). Spans cannot be kept with single CAS steps, so its writers take
+// turns on a mutex; readers stay lock-free, though a rank read while a write
+// is in flight may be off by that write.
+func NewRankedSkipList() *SkipList {
+	sl := NewSkipList()
+	sl.ranked = true
+	sl.head.span = make([]atomic.Int64, maxLevel)
+	return sl
+}
+
 // randomLevel generates a random level using lock-free xorshift64
 func (sl *SkipList) randomLevel() int {
 	level := 0
@@ -154,6 +173,9 @@ func (sl *SkipList) Put(key, value []byte) bool {
 	// Make copies to avoid external mutation
 	keyCopy := make([]byte, len(key))
 	copy(keyCopy, key)
+	if sl.ranked {
+		return sl.putRanked(keyCopy, value)
+	}
 
 	for {
 		preds, succs := sl.findPath(keyCopy)
@@ -233,6 +255,9 @@ func (sl *SkipList) Put(key, value []byte) bool {
 
 // Delete removes a key from the skip list
 func (sl *SkipList) Delete(key []byte) bool {
+	if sl.ranked {
+		return sl.deleteRanked(key)
+	}
 	preds, succs := sl.findPath(key)
 	target := succs[0]
 
@@ -303,6 +328,9 @@ func (sl *SkipList) NewIterator() *SkipListIterator {
 
 // Next advances the iterator to the next non-deleted entry.
 func (it *SkipListIterator) Next() bool {
+	if it.current == nil {
+		return false
+	}
 	for {
 		next := it.current.forward[0].Load()
 		if next == nil {
@@ -443,8 +471,153 @@ func (sl *SkipList) Clear() {
 
 	for i := 0; i < maxLevel; i++ {
 		sl.head.forward[i].Store(nil)
+		if sl.ranked {
+			sl.head.span[i].Store(0)
+		}
 	}
 
 	sl.level.Store(0)
 	sl.size.Store(0)
 }
+
+// putRanked inserts or updates key in a ranked list, keeping the spans of the
+// links it passes. The new node is fully linked before each predecessor points
+// to it, so lock-free readers never follow a half-built node.
+func (sl *SkipList) putRanked(key, value []byte) bool {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	var update [maxLevel]*SkipListNode
+	var rank [maxLevel]int64
+	levels := int(sl.level.Load()) + 1
+	x := sl.head
+	for i := levels - 1; i >= 0; i-- {
+		if i < levels-1 {
+			rank[i] = rank[i+1]
+		}
+		for next := x.forward[i].Load(); next != nil && bytes.Compare(next.key, key) < 0; next = x.forward[i].Load() {
+			rank[i] += x.span[i].Load()
+			x = next
+		}
+		update[i] = x
+	}
+
+	if next := x.forward[0].Load(); next != nil && bytes.Equal(next.key, key) {
+		valueCopy := make([]byte, len(value))
+		copy(valueCopy, value)
+		next.value.Store(&valueCopy)
+		return false
+	}
+
+	level := sl.randomLevel()
+	for i := levels; i <= level; i++ {
+		rank[i] = 0
+		update[i] = sl.head
+		sl.head.span[i].Store(sl.size.Load())
+	}
+
+	node := newSkipListNode(key, value, level)
+	node.span = make([]atomic.Int64, level+1)
+	for i := 0; i <= level; i++ {
+		pred := update[i]
+		node.forward[i].Store(pred.forward[i].Load())
+		node.span[i].Store(pred.span[i].Load() - (rank[0] - rank[i]))
+		pred.span[i].Store(rank[0] - rank[i] + 1)
+		pred.forward[i].Store(node)
+	}
+	// links above the new node now skip one more entry
+	for i := level + 1; i < levels; i++ {
+		update[i].span[i].Add(1)
+	}
+	if level >= levels {
+		sl.level.Store(int32(level))
+	}
+	sl.size.Add(1)
+	return true
+}
+
+// deleteRanked removes key from a ranked list. The node is only marked once it
+// is unlinked everywhere, so readers never unlink it behind the spans' back.
+func (sl *SkipList) deleteRanked(key []byte) bool {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	var update [maxLevel]*SkipListNode
+	levels := int(sl.level.Load()) + 1
+	x := sl.head
+	for i := levels - 1; i >= 0; i-- {
+		for next := x.forward[i].Load(); next != nil && bytes.Compare(next.key, key) < 0; next = x.forward[i].Load() {
+			x = next
+		}
+		update[i] = x
+	}
+
+	target := x.forward[0].Load()
+	if target == nil || !bytes.Equal(target.key, key) {
+		return false
+	}
+	for i := 0; i < levels; i++ {
+		pred := update[i]
+		if pred.forward[i].Load() == target {
+			pred.span[i].Add(target.span[i].Load() - 1)
+			pred.forward[i].Store(target.forward[i].Load())
+		} else {
+			pred.span[i].Add(-1)
+		}
+	}
+	target.marked.Store(true)
+
+	for levels > 1 && sl.head.forward[levels-1].Load() == nil {
+		levels--
+	}
+	sl.level.Store(int32(levels - 1))
+	sl.size.Add(-1)
+	return true
+}
+
+// Rank returns the 0-based position of key. It needs a list made by
+// NewRankedSkipList.
+func (sl *SkipList) Rank(key []byte) (int, bool) {
+	if !sl.ranked {
+		panic("datastructures: Rank on a skip list without ranks")
+	}
+	var rank int64
+	x := sl.head
+	for i := int(sl.level.Load()); i >= 0; i-- {
+		for next := x.forward[i].Load(); next != nil && bytes.Compare(next.key, key) <= 0; next = x.forward[i].Load() {
+			rank += x.span[i].Load()
+			x = next
+		}
+		if x != sl.head && bytes.Equal(x.key, key) {
+			return int(rank - 1), true
+		}
+	}
+	return 0, false
+}
+
+// SeekToRank positions the iterator at the entry with the 0-based rank, past
+// the end when rank is out of range. It needs a list made by NewRankedSkipList.
+func (sl *SkipList) SeekToRank(rank int) *SkipListIterator {
+	if !sl.ranked {
+		panic("datastructures: SeekToRank on a skip list without ranks")
+	}
+	it := &SkipListIterator{sl: sl}
+	if rank < 0 || int64(rank) >= sl.size.Load() {
+		return it
+	}
+
+	target := int64(rank) + 1
+	var traversed int64
+	x := sl.head
+	for i := int(sl.level.Load()); i >= 0; i-- {
+		for next := x.forward[i].Load(); next != nil && traversed+x.span[i].Load() <= target; next = x.forward[i].Load() {
+			traversed += x.span[i].Load()
+			x = next
+		}
+		if traversed == target {
+			it.current = x
+			return it
+		}
+	}
+	return it
+}
@@ -0,0 +1,71 @@
+package datastructures
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestRankedSkipList_ReadersDuringWrites verifies lock-free readers can walk a
+// ranked list while writers take turns, and that ranks are exact once they are done
+func TestRankedSkipList_ReadersDuringWrites(t *testing.T) {
+	sl := NewRankedSkipList()
+	key := func(i int) []byte { return []byte(fmt.Sprintf("k%04d", i)) }
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := w; i < 1000; i += 4 {
+				sl.Put(key(i), []byte("v"))
+				if i%3 == 0 {
+					sl.Delete(key(i))
+				}
+			}
+		}()
+	}
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for range 2 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				sl.Get(key(500))
+				sl.Rank(key(500))
+				for it, ok := sl.SeekToRank(10), true; ok; ok = it.Next() {
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	readers.Wait()
+
+	rank := 0
+	for i := range 1000 {
+		got, ok := sl.Rank(key(i))
+		if i%3 == 0 {
+			if ok {
+				t.Fatalf("deleted %s still ranked %d", key(i), got)
+			}
+			continue
+		}
+		if !ok || got != rank {
+			t.Fatalf("Rank(%s) = %d, %v, want %d", key(i), got, ok, rank)
+		}
+		if it := sl.SeekToRank(rank); string(it.Key()) != string(key(i)) {
+			t.Fatalf("SeekToRank(%d) = %s, want %s", rank, it.Key(), key(i))
+		}
+		rank++
+	}
+	if int(sl.Size()) != rank {
+		t.Errorf("Size = %d, want %d", sl.Size(), rank)
+	}
+}
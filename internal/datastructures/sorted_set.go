@@ -0,0 +1,209 @@
+package datastructures
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// ScoredMember is a sorted set member with its score
+type ScoredMember struct {
+	Member []byte
+	Score  float64
+}
+
+// SortedSet is a decoded sorted set. Members are ordered by score and then by
+// member bytes through a ranked SkipList keyed on an order-preserving encoding
+// of both, while a map gives O(1) score lookups.
+type SortedSet struct {
+	scores map[string]float64
+	order  *SkipList
+}
+
+func NewSortedSet() *SortedSet {
+	return &SortedSet{scores: make(map[string]float64), order: NewRankedSkipList()}
+}
+
+// scoreKey encodes score and member so that byte order matches sorted set order:
+// the float's bits are flipped into an unsigned big-endian integer that sorts
+// numerically, and the member follows as the tie breaker
+func scoreKey(score float64, member []byte) []byte {
+	if score == 0 {
+		score = 0 // fold -0 into +0
+	}
+	bits := math.Float64bits(score)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+	key := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(member)), bits)
+	return append(key, member...)
+}
+
+// Len returns the number of members
+func (z *SortedSet) Len() int {
+	return len(z.scores)
+}
+
+// Score returns the score of a member
+func (z *SortedSet) Score(member []byte) (float64, bool) {
+	score, ok := z.scores[string(member)]
+	return score, ok
+}
+
+// Add sets the score of a member and reports whether it was newly added
+func (z *SortedSet) Add(member []byte, score float64) bool {
+	old, exists := z.scores[string(member)]
+	if exists {
+		if old == score {
+			return false
+		}
+		z.order.Delete(scoreKey(old, member))
+	}
+	z.scores[string(member)] = score
+	z.order.Put(scoreKey(score, member), member)
+	return !exists
+}
+
+// Remove deletes a member and reports whether it was present
+func (z *SortedSet) Remove(member []byte) bool {
+	score, exists := z.scores[string(member)]
+	if !exists {
+		return false
+	}
+	delete(z.scores, string(member))
+	z.order.Delete(scoreKey(score, member))
+	return true
+}
+
+// Rank returns the 0-based position of a member in ascending order
+func (z *SortedSet) Rank(member []byte) (int, bool) {
+	score, exists := z.scores[string(member)]
+	if !exists {
+		return 0, false
+	}
+	return z.order.Rank(scoreKey(score, member))
+}
+
+// Range returns the members with ranks from through to-1 in ascending order
+func (z *SortedSet) Range(from, to int) []ScoredMember {
+	members := make([]ScoredMember, 0, max(to-from, 0))
+	it := z.order.SeekToRank(from)
+	for ok := it.Valid(); ok && from < to; ok, from = it.Next(), from+1 {
+		members = append(members, z.member(it))
+	}
+	return members
+}
+
+// ScoreBound is one end of a score interval, exclusive when Open is set
+type ScoreBound struct {
+	Score float64
+	Open  bool
+}
+
+// RangeByScore returns the members with scores between min and max in ascending order
+func (z *SortedSet) RangeByScore(min, max ScoreBound) []ScoredMember {
+	var members []ScoredMember
+	it := z.order.Seek(scoreKey(min.Score, nil))
+	for ok := it.Valid(); ok; ok = it.Next() {
+		m := z.member(it)
+		if m.Score > max.Score || (max.Open && m.Score == max.Score) {
+			break
+		}
+		if !min.Open || m.Score != min.Score {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// LexBound is one end of a member interval. Inf is -1 for "-" and 1 for "+",
+// which sort before and after every member.
+type LexBound struct {
+	Member []byte
+	Open   bool
+	Inf    int
+}
+
+// after reports whether member sorts past the bound when used as a maximum
+func (b LexBound) after(member []byte) bool {
+	switch b.Inf {
+	case 1:
+		return false
+	case -1:
+		return true
+	}
+	cmp := bytes.Compare(member, b.Member)
+	return cmp > 0 || (cmp == 0 && b.Open)
+}
+
+// before reports whether member sorts ahead of the bound when used as a minimum
+func (b LexBound) before(member []byte) bool {
+	switch b.Inf {
+	case -1:
+		return false
+	case 1:
+		return true
+	}
+	cmp := bytes.Compare(member, b.Member)
+	return cmp < 0 || (cmp == 0 && b.Open)
+}
+
+// RangeByLex returns the members between min and max in ascending order. As in
+// Redis, the result is only meaningful when all members share the same score,
+// which lets the search seek straight to min under the first member's score.
+func (z *SortedSet) RangeByLex(min, max LexBound) []ScoredMember {
+	it := z.order.SeekToFirst()
+	if !it.Valid() || min.Inf == 1 {
+		return nil
+	}
+	if min.Inf == 0 {
+		it = z.order.Seek(scoreKey(z.scores[string(it.Value())], min.Member))
+	}
+
+	var members []ScoredMember
+	for ok := it.Valid(); ok; ok = it.Next() {
+		m := z.member(it)
+		if max.after(m.Member) {
+			break
+		}
+		if !min.before(m.Member) {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+func (z *SortedSet) member(it *SkipListIterator) ScoredMember {
+	member := it.Value()
+	return ScoredMember{Member: member, Score: z.scores[string(member)]}
+}
+
+// Encode serializes the members in sorted set order, so equal sets encode to
+// equal bytes and decoding inserts in order
+func (z *SortedSet) Encode() []byte {
+	buf := appendCount(nil, z.Len())
+	for _, m := range z.Range(0, z.Len()) {
+		buf = appendScored(buf, m)
+	}
+	return buf
+}
+
+// DecodeSortedSet parses a value written by SortedSet.Encode
+func DecodeSortedSet(data []byte) (*SortedSet, error) {
+	d := &decoder{data: data}
+	n := d.count()
+	z := NewSortedSet()
+	for i := 0; i < n && d.err == nil; i++ {
+		member := d.bytes()
+		score := math.Float64frombits(binary.BigEndian.Uint64(d.fixed(8)))
+		if d.err == nil {
+			z.Add(member, score)
+		}
+	}
+	if err := d.done(); err != nil {
+		return nil, err
+	}
+	return z, nil
+}
@@ -0,0 +1,144 @@
+package datastructures
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"slices"
+)
+
+// SortedSetEdit changes a few members of an encoded sorted set without decoding
+// it. The scores of the members to change are found in one scan of the encoding,
+// and Encode merges the changes into it in a single pass, so a write costs one
+// copy of the value instead of a rebuild of the whole set.
+type SortedSetEdit struct {
+	// body is the encoding after the member count
+	body   []byte
+	stored int
+	length int
+	// found holds the stored scores of the members named to EditSortedSet
+	found   map[string]float64
+	changes map[string]scoreChange
+}
+
+type scoreChange struct {
+	score   float64
+	removed bool
+}
+
+// EditSortedSet prepares an edit of data, as written by SortedSet.Encode, that
+// may change the given members. An empty data edits an empty set.
+func EditSortedSet(data []byte, members [][]byte) (*SortedSetEdit, error) {
+	e := &SortedSetEdit{
+		found:   make(map[string]float64, len(members)),
+		changes: make(map[string]scoreChange, len(members)),
+	}
+	if len(data) == 0 {
+		return e, nil
+	}
+	wanted := make(map[string]bool, len(members))
+	for _, member := range members {
+		wanted[string(member)] = true
+	}
+
+	d := &decoder{data: data}
+	e.stored = d.count()
+	e.body = d.data
+	for i := 0; i < e.stored && d.err == nil; i++ {
+		member := d.bytes()
+		bits := binary.BigEndian.Uint64(d.fixed(8))
+		if wanted[string(member)] && d.err == nil {
+			e.found[string(member)] = math.Float64frombits(bits)
+		}
+	}
+	if err := d.done(); err != nil {
+		return nil, err
+	}
+	e.length = e.stored
+	return e, nil
+}
+
+// Len returns the number of members once the edit is applied
+func (e *SortedSetEdit) Len() int {
+	return e.length
+}
+
+// Score returns the score of a member named to EditSortedSet
+func (e *SortedSetEdit) Score(member []byte) (float64, bool) {
+	if change, ok := e.changes[string(member)]; ok {
+		return change.score, !change.removed
+	}
+	score, ok := e.found[string(member)]
+	return score, ok
+}
+
+// Add sets the score of a member named to EditSortedSet and reports whether it
+// was newly added
+func (e *SortedSetEdit) Add(member []byte, score float64) bool {
+	_, exists := e.Score(member)
+	e.changes[string(member)] = scoreChange{score: score}
+	if !exists {
+		e.length++
+	}
+	return !exists
+}
+
+// Remove deletes a member named to EditSortedSet and reports whether it was present
+func (e *SortedSetEdit) Remove(member []byte) bool {
+	if _, exists := e.Score(member); !exists {
+		return false
+	}
+	e.changes[string(member)] = scoreChange{removed: true}
+	e.length--
+	return true
+}
+
+// Encode returns the edited set in the SortedSet.Encode format
+func (e *SortedSetEdit) Encode() []byte {
+	added := make([]ScoredMember, 0, len(e.changes))
+	for member, change := range e.changes {
+		if !change.removed {
+			added = append(added, ScoredMember{Member: []byte(member), Score: change.score})
+		}
+	}
+	slices.SortFunc(added, func(a, b ScoredMember) int {
+		return compareScored(a.Score, a.Member, b.Score, b.Member)
+	})
+
+	buf := appendCount(make([]byte, 0, len(e.body)+16*len(added)), e.length)
+	d := &decoder{data: e.body}
+	for i := 0; i < e.stored; i++ {
+		member := d.bytes()
+		raw := d.fixed(8)
+		if _, changed := e.changes[string(member)]; changed {
+			continue
+		}
+		score := math.Float64frombits(binary.BigEndian.Uint64(raw))
+		for len(added) > 0 && compareScored(added[0].Score, added[0].Member, score, member) < 0 {
+			buf = appendScored(buf, added[0])
+			added = added[1:]
+		}
+		buf = appendBytes(buf, member)
+		buf = append(buf, raw...)
+	}
+	for _, m := range added {
+		buf = appendScored(buf, m)
+	}
+	return buf
+}
+
+// compareScored orders members the way scoreKey does: by score, then by member bytes
+func compareScored(scoreA float64, memberA []byte, scoreB float64, memberB []byte) int {
+	switch {
+	case scoreA < scoreB:
+		return -1
+	case scoreA > scoreB:
+		return 1
+	}
+	return bytes.Compare(memberA, memberB)
+}
+
+func appendScored(buf []byte, m ScoredMember) []byte {
+	buf = appendBytes(buf, m.Member)
+	return binary.BigEndian.AppendUint64(buf, math.Float64bits(m.Score))
+}
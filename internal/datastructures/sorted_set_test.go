@@ -0,0 +1,175 @@
+package datastructures_test
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/datastructures"
+)
+
+// TestSortedSet_ScoreOrder verifies negative, zero, fractional and infinite scores
+// sort numerically with ties broken by member, and survive an encode round trip
+func TestSortedSet_ScoreOrder(t *testing.T) {
+	z := datastructures.NewSortedSet()
+	z.Add([]byte("b"), 1)
+	z.Add([]byte("a"), 1)
+	z.Add([]byte("neg"), -2.5)
+	z.Add([]byte("big"), 1e10)
+	z.Add([]byte("zero"), 0)
+	z.Add([]byte("low"), math.Inf(-1))
+	z.Add([]byte("high"), math.Inf(1))
+	z.Add([]byte("neg"), -3) // moves within the order
+
+	want := []string{"low", "neg", "zero", "a", "b", "big", "high"}
+
+	decoded, err := datastructures.DecodeSortedSet(z.Encode())
+	if err != nil {
+		t.Fatalf("DecodeSortedSet: %v", err)
+	}
+	for _, set := range []*datastructures.SortedSet{z, decoded} {
+		members := set.Range(0, set.Len())
+		if len(members) != len(want) {
+			t.Fatalf("got %d members, want %d", len(members), len(want))
+		}
+		for i, m := range members {
+			if string(m.Member) != want[i] {
+				t.Errorf("rank %d = %s, want %s", i, m.Member, want[i])
+			}
+			if rank, ok := set.Rank(m.Member); !ok || rank != i {
+				t.Errorf("Rank(%s) = %d, want %d", m.Member, rank, i)
+			}
+		}
+	}
+
+	got := z.RangeByScore(datastructures.ScoreBound{Score: -3, Open: true}, datastructures.ScoreBound{Score: 1})
+	if len(got) != 3 || string(got[0].Member) != "zero" || string(got[2].Member) != "b" {
+		t.Errorf("RangeByScore((-3, 1]) = %v", got)
+	}
+}
+
+// TestSortedSet_RankAfterChurn verifies span counts stay exact through inserts,
+// score moves and removals by checking every rank against a sorted copy
+func TestSortedSet_RankAfterChurn(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	z := datastructures.NewSortedSet()
+	scores := make(map[string]float64)
+	for range 5000 {
+		member := fmt.Sprintf("m%03d", rng.IntN(500))
+		if rng.IntN(4) == 0 {
+			z.Remove([]byte(member))
+			delete(scores, member)
+			continue
+		}
+		score := float64(rng.IntN(50))
+		z.Add([]byte(member), score)
+		scores[member] = score
+	}
+
+	want := slices.SortedFunc(maps.Keys(scores), func(a, b string) int {
+		if c := cmp.Compare(scores[a], scores[b]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	if z.Len() != len(want) {
+		t.Fatalf("Len = %d, want %d", z.Len(), len(want))
+	}
+	for i, member := range want {
+		if rank, ok := z.Rank([]byte(member)); !ok || rank != i {
+			t.Fatalf("Rank(%s) = %d, %v, want %d", member, rank, ok, i)
+		}
+		if got := z.Range(i, i+1); len(got) != 1 || string(got[0].Member) != member {
+			t.Fatalf("Range(%d, %d) = %v, want %s", i, i+1, got, member)
+		}
+	}
+	if got := z.Range(len(want), len(want)+1); len(got) != 0 {
+		t.Errorf("Range past the end = %v", got)
+	}
+}
+
+func TestSortedSet_RangeByLex(t *testing.T) {
+	z := datastructures.NewSortedSet()
+	for _, member := range []string{"e", "a", "d", "c", "b", "f"} {
+		z.Add([]byte(member), 0)
+	}
+
+	for _, tc := range []struct {
+		min, max datastructures.LexBound
+		want     string
+	}{
+		{datastructures.LexBound{Inf: -1}, datastructures.LexBound{Inf: 1}, "abcdef"},
+		{datastructures.LexBound{Member: []byte("b")}, datastructures.LexBound{Member: []byte("d")}, "bcd"},
+		{datastructures.LexBound{Member: []byte("b"), Open: true}, datastructures.LexBound{Member: []byte("d"), Open: true}, "c"},
+		{datastructures.LexBound{Member: []byte("bb")}, datastructures.LexBound{Inf: 1}, "cdef"},
+		{datastructures.LexBound{Inf: 1}, datastructures.LexBound{Inf: 1}, ""},
+		{datastructures.LexBound{Member: []byte("z")}, datastructures.LexBound{Inf: 1}, ""},
+	} {
+		var got strings.Builder
+		for _, m := range z.RangeByLex(tc.min, tc.max) {
+			got.Write(m.Member)
+		}
+		if got.String() != tc.want {
+			t.Errorf("RangeByLex(%+v, %+v) = %q, want %q", tc.min, tc.max, got.String(), tc.want)
+		}
+	}
+}
+
+// TestSortedSetEdit_MatchesDecoded verifies editing the encoding produces the
+// same bytes as decoding, changing and re-encoding the set
+func TestSortedSetEdit_MatchesDecoded(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	z := datastructures.NewSortedSet()
+	encoded := z.Encode()
+	for range 300 {
+		members := make([][]byte, 1+rng.IntN(4))
+		for i := range members {
+			members[i] = []byte(fmt.Sprintf("m%02d", rng.IntN(40)))
+		}
+
+		e, err := datastructures.EditSortedSet(encoded, members)
+		if err != nil {
+			t.Fatalf("EditSortedSet: %v", err)
+		}
+		for _, member := range members {
+			wantScore, wantOK := z.Score(member)
+			if score, ok := e.Score(member); ok != wantOK || score != wantScore {
+				t.Fatalf("Score(%s) = %v, %v, want %v, %v", member, score, ok, wantScore, wantOK)
+			}
+			if rng.IntN(3) == 0 {
+				if e.Remove(member) != z.Remove(member) {
+					t.Fatalf("Remove(%s) disagrees", member)
+				}
+				continue
+			}
+			score := float64(rng.IntN(10)) - 5
+			if e.Add(member, score) != z.Add(member, score) {
+				t.Fatalf("Add(%s) disagrees", member)
+			}
+		}
+
+		if e.Len() != z.Len() {
+			t.Fatalf("Len = %d, want %d", e.Len(), z.Len())
+		}
+		encoded = e.Encode()
+		if want := z.Encode(); !bytes.Equal(encoded, want) {
+			t.Fatalf("edited encoding differs from re-encoding")
+		}
+	}
+}
+
+func TestEditSortedSet_Corrupt(t *testing.T) {
+	z := datastructures.NewSortedSet()
+	z.Add([]byte("a"), 1)
+	encoded := z.Encode()
+	if _, err := datastructures.EditSortedSet(encoded[:len(encoded)-1], nil); !errors.Is(err, datastructures.ErrCorrupt) {
+		t.Errorf("truncated encoding: err = %v, want ErrCorrupt", err)
+	}
+}
@@ -0,0 +1,277 @@
+package datastructures
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// StreamID identifies a stream entry. Verdis assigns Ms from the global version
+// when XADD runs and only uses Seq to keep IDs increasing when several entries
+// are added under the same version, but IDs are kept in the Redis ms-seq form
+// so clients can parse and compare them.
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+// MaxStreamID sorts after every entry
+var MaxStreamID = StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// Compare returns -1, 0 or 1 as id sorts before, equal to or after other
+func (id StreamID) Compare(other StreamID) int {
+	switch {
+	case id.Ms != other.Ms:
+		return cmpUint(id.Ms, other.Ms)
+	default:
+		return cmpUint(id.Seq, other.Seq)
+	}
+}
+
+// Next returns the smallest ID after id, ok is false for MaxStreamID
+func (id StreamID) Next() (StreamID, bool) {
+	switch {
+	case id.Seq < math.MaxUint64:
+		return StreamID{id.Ms, id.Seq + 1}, true
+	case id.Ms < math.MaxUint64:
+		return StreamID{id.Ms + 1, 0}, true
+	default:
+		return id, false
+	}
+}
+
+// Prev returns the largest ID before id, ok is false for 0-0
+func (id StreamID) Prev() (StreamID, bool) {
+	switch {
+	case id.Seq > 0:
+		return StreamID{id.Ms, id.Seq - 1}, true
+	case id.Ms > 0:
+		return StreamID{id.Ms - 1, math.MaxUint64}, true
+	default:
+		return id, false
+	}
+}
+
+func cmpUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ParseStreamID parses "ms-seq" or "ms". A bare ms takes seq as the given default,
+// 0 for range starts and the maximum for range ends.
+func ParseStreamID(s string, defaultSeq uint64) (StreamID, error) {
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return StreamID{}, fmt.Errorf("invalid stream ID %q", s)
+	}
+	if !hasSeq {
+		return StreamID{Ms: ms, Seq: defaultSeq}, nil
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return StreamID{}, fmt.Errorf("invalid stream ID %q", s)
+	}
+	return StreamID{Ms: ms, Seq: seq}, nil
+}
+
+// StreamEntry is one stream record, Fields holds field value pairs flattened
+type StreamEntry struct {
+	ID     StreamID
+	Fields [][]byte
+}
+
+// PendingEntry is an entry delivered to a consumer of a group but not yet acknowledged
+type PendingEntry struct {
+	ID          StreamID
+	Consumer    string
+	DeliveredAt int64 // unix milliseconds of the last delivery
+	Deliveries  uint64
+}
+
+// ConsumerGroup tracks what a group has been delivered. Pending is the group's
+// pending entries list (PEL), sorted by ID.
+type ConsumerGroup struct {
+	Name          string
+	LastDelivered StreamID
+	Pending       []PendingEntry
+	// Consumers maps consumer names to when they were last seen, in unix milliseconds
+	Consumers map[string]int64
+}
+
+// PendingIndex returns the position of id in the PEL, or where it would be inserted
+func (g *ConsumerGroup) PendingIndex(id StreamID) (int, bool) {
+	return slices.BinarySearchFunc(g.Pending, id, func(p PendingEntry, id StreamID) int {
+		return p.ID.Compare(id)
+	})
+}
+
+// Stream is a decoded stream value: entries in ID order plus consumer groups
+type Stream struct {
+	LastID  StreamID
+	Entries []StreamEntry
+	Groups  map[string]*ConsumerGroup
+}
+
+func NewStream() *Stream {
+	return &Stream{Groups: make(map[string]*ConsumerGroup)}
+}
+
+// NextID returns the ID for a new entry added at the given global version: the
+// version itself, or the next sequence after the last ID when the stream
+// already holds an entry at or past it
+func (s *Stream) NextID(version uint64) StreamID {
+	if version > s.LastID.Ms {
+		return StreamID{Ms: version}
+	}
+	return StreamID{Ms: s.LastID.Ms, Seq: s.LastID.Seq + 1}
+}
+
+// Append adds an entry with an ID from NextID
+func (s *Stream) Append(id StreamID, fields [][]byte) {
+	s.Entries = append(s.Entries, StreamEntry{ID: id, Fields: fields})
+	s.LastID = id
+}
+
+// Search returns the position of the first entry with an ID not below id
+func (s *Stream) Search(id StreamID) int {
+	i, _ := slices.BinarySearchFunc(s.Entries, id, func(e StreamEntry, id StreamID) int {
+		return e.ID.Compare(id)
+	})
+	return i
+}
+
+// Range returns up to count entries with IDs in [start, end] in ascending order,
+// a negative count returns all of them
+func (s *Stream) Range(start, end StreamID, count int) []StreamEntry {
+	var entries []StreamEntry
+	for _, e := range s.Entries[s.Search(start):] {
+		if e.ID.Compare(end) > 0 || count == len(entries) {
+			break
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Entry returns the entry with the given ID
+func (s *Stream) Entry(id StreamID) (StreamEntry, bool) {
+	i := s.Search(id)
+	if i < len(s.Entries) && s.Entries[i].ID == id {
+		return s.Entries[i], true
+	}
+	return StreamEntry{}, false
+}
+
+// TrimLen drops the oldest entries until at most maxLen remain, returning how many were removed
+func (s *Stream) TrimLen(maxLen int) int {
+	removed := max(len(s.Entries)-maxLen, 0)
+	s.Entries = s.Entries[removed:]
+	return removed
+}
+
+// TrimMinID drops entries with IDs below minID, returning how many were removed
+func (s *Stream) TrimMinID(minID StreamID) int {
+	removed := s.Search(minID)
+	s.Entries = s.Entries[removed:]
+	return removed
+}
+
+// Encode serializes the stream with groups and consumers in sorted order
+func (s *Stream) Encode() []byte {
+	buf := appendID(nil, s.LastID)
+
+	buf = appendCount(buf, len(s.Entries))
+	for _, e := range s.Entries {
+		buf = appendID(buf, e.ID)
+		buf = appendCount(buf, len(e.Fields))
+		for _, f := range e.Fields {
+			buf = appendBytes(buf, f)
+		}
+	}
+
+	buf = appendCount(buf, len(s.Groups))
+	for _, name := range slices.Sorted(maps.Keys(s.Groups)) {
+		g := s.Groups[name]
+		buf = appendBytes(buf, []byte(g.Name))
+		buf = appendID(buf, g.LastDelivered)
+		buf = appendCount(buf, len(g.Pending))
+		for _, p := range g.Pending {
+			buf = appendID(buf, p.ID)
+			buf = appendBytes(buf, []byte(p.Consumer))
+			buf = appendUint(buf, uint64(p.DeliveredAt))
+			buf = appendUint(buf, p.Deliveries)
+		}
+		buf = appendCount(buf, len(g.Consumers))
+		for _, consumer := range slices.Sorted(maps.Keys(g.Consumers)) {
+			buf = appendBytes(buf, []byte(consumer))
+			buf = appendUint(buf, uint64(g.Consumers[consumer]))
+		}
+	}
+	return buf
+}
+
+// DecodeStream parses a value written by Stream.Encode
+func DecodeStream(data []byte) (*Stream, error) {
+	d := &decoder{data: data}
+	s := NewStream()
+	s.LastID = d.id()
+
+	n := d.count()
+	s.Entries = make([]StreamEntry, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		e := StreamEntry{ID: d.id()}
+		fields := d.count()
+		e.Fields = make([][]byte, 0, fields)
+		for j := 0; j < fields && d.err == nil; j++ {
+			e.Fields = append(e.Fields, d.bytes())
+		}
+		s.Entries = append(s.Entries, e)
+	}
+
+	groups := d.count()
+	for i := 0; i < groups && d.err == nil; i++ {
+		g := &ConsumerGroup{Name: string(d.bytes()), LastDelivered: d.id(), Consumers: make(map[string]int64)}
+		pending := d.count()
+		for j := 0; j < pending && d.err == nil; j++ {
+			g.Pending = append(g.Pending, PendingEntry{
+				ID:          d.id(),
+				Consumer:    string(d.bytes()),
+				DeliveredAt: int64(d.uvarint()),
+				Deliveries:  d.uvarint(),
+			})
+		}
+		consumers := d.count()
+		for j := 0; j < consumers && d.err == nil; j++ {
+			name := string(d.bytes())
+			g.Consumers[name] = int64(d.uvarint())
+		}
+		s.Groups[g.Name] = g
+	}
+	if err := d.done(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func appendID(buf []byte, id StreamID) []byte {
+	buf = appendUint(buf, id.Ms)
+	return appendUint(buf, id.Seq)
+}
+
+func (d *decoder) id() StreamID {
+	return StreamID{Ms: d.uvarint(), Seq: d.uvarint()}
+}
@@ -0,0 +1,42 @@
+package datastructures_test
+
+import (
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/datastructures"
+)
+
+// TestStream_IDsSurviveEncoding verifies appended entries keep the IDs they were
+// given through an encode round trip, and that NextID keeps increasing when the
+// version does not move past the last ID
+func TestStream_IDsSurviveEncoding(t *testing.T) {
+	s := datastructures.NewStream()
+	first := s.NextID(7)
+	s.Append(first, [][]byte{[]byte("f"), []byte("1")})
+	second := s.NextID(7)
+	s.Append(second, [][]byte{[]byte("f"), []byte("2")})
+	if want := (datastructures.StreamID{Ms: 7, Seq: 1}); second != want {
+		t.Fatalf("NextID at the last version = %s, want %s", second, want)
+	}
+
+	s, err := datastructures.DecodeStream(s.Encode())
+	if err != nil {
+		t.Fatalf("DecodeStream: %v", err)
+	}
+	if s.Entries[0].ID != first || s.Entries[1].ID != second || s.LastID != second {
+		t.Fatalf("ids = %s, %s last %s, want %s, %s", s.Entries[0].ID, s.Entries[1].ID, s.LastID, first, second)
+	}
+
+	// trimming every entry away keeps the last ID, so IDs never go back
+	s.TrimLen(0)
+	s, err = datastructures.DecodeStream(s.Encode())
+	if err != nil {
+		t.Fatalf("DecodeStream: %v", err)
+	}
+	if len(s.Entries) != 0 || s.LastID != second {
+		t.Fatalf("entries=%d last=%s, want 0 entries last %s", len(s.Entries), s.LastID, second)
+	}
+	if next := s.NextID(3); next.Compare(second) <= 0 {
+		t.Errorf("NextID(3) = %s, want past %s", next, second)
+	}
+}
@@ -0,0 +1,67 @@
+package mvcc
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Access frequency follows Redis' LFU counter: a logarithmic 8-bit counter that
+// new keys start at lfuInitVal and that loses one point per idle decay period
+const (
+	lfuInitVal      = 5
+	lfuLogFactor    = 10
+	lfuDecayMinutes = 1
+)
+
+// touch records a read or write of the key. Hot keys only load the access
+// fields: the second-resolution clock and the probabilistic counter mean a
+// store happens at most once per second plus on the rare counter increments,
+// so readers do not fight over the cache line.
+func (vch *VersionChainHead) touch(now int64) {
+	sec := now / int64(time.Second)
+	if vch.lastAccess.Load() != sec {
+		vch.lastAccess.Store(sec)
+	}
+
+	packed := vch.freq.Load()
+	minutes := uint64(sec / 60)
+	counter := decayFrequency(packed, minutes)
+	if counter < 255 {
+		base := max(int(counter)-lfuInitVal, 0)
+		if rand.Float64() < 1/float64(base*lfuLogFactor+1) {
+			counter++
+		}
+	}
+
+	// a lost race only drops one sample of a counter that is approximate anyway
+	if next := minutes<<8 | counter; next != packed {
+		vch.freq.CompareAndSwap(packed, next)
+	}
+}
+
+// decayFrequency returns the counter held in packed, decayed to now (in minutes)
+func decayFrequency(packed, minutes uint64) uint64 {
+	if packed == 0 {
+		return lfuInitVal
+	}
+	counter := packed & 0xff
+	last := packed >> 8
+	if minutes <= last {
+		return counter
+	}
+	periods := (minutes - last) / lfuDecayMinutes
+	if periods >= counter {
+		return 0
+	}
+	return counter - periods
+}
+
+// LastAccess returns the Unix second of the last touch (0 if never touched)
+func (vch *VersionChainHead) LastAccess() int64 {
+	return vch.lastAccess.Load()
+}
+
+// Frequency returns the logarithmic access counter as of now
+func (vch *VersionChainHead) Frequency(now int64) uint8 {
+	return uint8(decayFrequency(vch.freq.Load(), uint64(now/int64(time.Minute))))
+}
@@ -0,0 +1,29 @@
+package mvcc
+
+import "strings"
+
+// Author identifies the client that wrote a version. Authors are shared by
+// every version a connection writes and must not be modified once in use.
+type Author struct {
+	// Identity is the authenticated user or the identity the client declared
+	Identity string
+	// Name is the connection name set with CLIENT SETNAME
+	Name string
+	// Addr is the remote address of the connection
+	Addr string
+}
+
+// String renders the non-empty fields, e.g. "user=alice name=worker addr=10.0.0.1:5000"
+func (a *Author) String() string {
+	var b strings.Builder
+	for _, field := range [][2]string{{"user", a.Identity}, {"name", a.Name}, {"addr", a.Addr}} {
+		if field[1] == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(field[0] + "=" + field[1])
+	}
+	return b.String()
+}
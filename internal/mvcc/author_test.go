@@ -0,0 +1,53 @@
+package mvcc_test
+
+import (
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+// TestAttributed_RecordsAuthor verifies views share data but stamp their own author
+func TestAttributed_RecordsAuthor(t *testing.T) {
+	engine := mvcc.NewEngine()
+	alice := engine.Attributed(&mvcc.Author{Identity: "alice", Addr: "10.0.0.1:5000"})
+	bob := engine.Attributed(&mvcc.Author{Identity: "bob"})
+
+	alice.Set("k", []byte("1"))
+	bob.Update("k", func(current mvcc.Entry) (mvcc.Entry, error) {
+		return current.WithValue([]byte("2")), nil
+	})
+	engine.Del("k")
+
+	history, _ := engine.History("k", 0)
+	if len(history) != 3 {
+		t.Fatalf("expected 3 versions, got %d", len(history))
+	}
+	if history[0].Author != nil {
+		t.Errorf("anonymous delete attributed to %v", history[0].Author)
+	}
+	if got := history[1].Author.String(); got != "user=bob" {
+		t.Errorf("update author = %q", got)
+	}
+	if got := history[2].Author.String(); got != "user=alice addr=10.0.0.1:5000" {
+		t.Errorf("set author = %q", got)
+	}
+}
+
+// TestAnnotated_RecordsComment verifies a comment lands only on versions
+// written through the annotated view, renames included
+func TestAnnotated_RecordsComment(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("k", []byte("1"))
+	engine.Annotated("TICKET-42").Set("k", []byte("2"))
+	engine.Annotated("move it").Rename("k", "k2", false)
+
+	history, _ := engine.History("k2", 0)
+	comments := []string{history[0].Comment, history[1].Comment, history[2].Comment}
+	want := []string{"move it", "TICKET-42", ""}
+	for i := range want {
+		if comments[i] != want[i] {
+			t.Errorf("comments = %q, want %q", comments, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package mvcc
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrBatchConflict is returned when a batch written with IfAbsent finds an existing key
+var ErrBatchConflict = errors.New("batch precondition failed: key exists")
+
+// batchOp is a single write queued in a Batch
+type batchOp struct {
+	key     string
+	value   []byte
+	deleted bool
+}
+
+// Batch groups writes that Engine.Write commits atomically under a single version
+type Batch struct {
+	ops      []batchOp
+	ifAbsent bool
+}
+
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Set queues a write of value to key
+func (b *Batch) Set(key string, value []byte) {
+	b.ops = append(b.ops, batchOp{key: key, value: value})
+}
+
+// Del queues a tombstone for key
+func (b *Batch) Del(key string) {
+	b.ops = append(b.ops, batchOp{key: key, deleted: true})
+}
+
+// IfAbsent makes the batch fail with ErrBatchConflict if any of its keys exists
+func (b *Batch) IfAbsent() *Batch {
+	b.ifAbsent = true
+	return b
+}
+
+// Len returns the number of queued writes
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// normalized returns the ops with later writes to a key replacing earlier ones,
+// sorted by key so concurrent conditional batches always wait on each other in
+// the same order and cannot deadlock
+func (b *Batch) normalized() []batchOp {
+	ops := make([]batchOp, 0, len(b.ops))
+	seen := make(map[string]int, len(b.ops))
+	for _, op := range b.ops {
+		if i, ok := seen[op.key]; ok {
+			ops[i] = op
+			continue
+		}
+		seen[op.key] = len(ops)
+		ops = append(ops, op)
+	}
+	slices.SortFunc(ops, func(a, b batchOp) int { return strings.Compare(a.key, b.key) })
+	return ops
+}
+
+// linkedNode remembers where a batch node was prepended so an aborted batch can unlink it
+type linkedNode struct {
+	key   string
+	chain *VersionChainHead
+	node  *VersionNode
+}
+
+// Write commits every operation of the batch under one version from a single
+// NextVersion call. Nodes are prepended while invisible and published together,
+// so readers never observe a partial batch. Deleting a key that never existed is
+// a no-op.
+func (e *Engine) Write(b *Batch) (uint64, error) {
+	ops := b.normalized()
+	if len(ops) == 0 {
+		return 0, nil
+	}
+
+	version, timestamp := e.versionManager.NextVersion()
+	commit := newCommitState()
+	linked := make([]linkedNode, 0, len(ops))
+
+	for _, op := range ops {
+		var chain *VersionChainHead
+		if op.deleted {
+			if chain = e.index.GetChain(op.key); chain == nil {
+				continue
+			}
+		} else {
+			chain = e.index.GetOrCreateChain(op.key)
+		}
+
+		policy := e.config.GetCompressionForKey(op.key)
+		node := e.newNode(policy, version, timestamp, op.value, op.deleted)
+		node.commit = commit
+
+		if !b.ifAbsent {
+			e.prepend(chain, node)
+			linked = append(linked, linkedNode{op.key, chain, node})
+			continue
+		}
+
+		if !e.prependIfAbsent(chain, node) {
+			commit.resolve(false)
+			unlink(linked)
+			return 0, ErrBatchConflict
+		}
+		linked = append(linked, linkedNode{op.key, chain, node})
+	}
+
+	commit.resolve(true)
+	e.logChanges(version, timestamp, linkedChanges(linked)...)
+	for _, l := range linked {
+		e.watchers.notify(l.key)
+	}
+	return version, nil
+}
+
+// UpdateManyFunc computes the next state of several keys from their current ones.
+// Entries come and go in the order the keys were passed to UpdateMany.
+type UpdateManyFunc func(current []Entry) ([]Entry, error)
+
+// UpdateMany is the multi-key form of Update: fn sees the current entry of every
+// key and all changed keys are written together under one version. Returning a
+// current entry unchanged leaves that key untouched. If another writer changes a
+// written key before the nodes are published, the pending nodes are dropped and fn
+// runs again. Keys must be distinct.
+func (e *Engine) UpdateMany(keys []string, fn UpdateManyFunc) (uint64, error) {
+	// like Update, chains are created only for keys that get written
+	chains := make([]*VersionChainHead, len(keys))
+	for i, key := range keys {
+		chains[i] = e.index.GetChain(key)
+	}
+
+	// publish in key order so concurrent multi-key updates contend the same way
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int { return strings.Compare(keys[a], keys[b]) })
+
+	heads := make([]*VersionNode, len(keys))
+	current := make([]Entry, len(keys))
+	for {
+		now := e.now()
+		for i, chain := range chains {
+			heads[i], current[i] = nil, Entry{}
+			if chain == nil {
+				continue
+			}
+			head, visible := chain.settled()
+			heads[i] = head
+			if visible.Live(now) {
+				var err error
+				if current[i], err = visible.entry(); err != nil {
+					return 0, err
+				}
+			}
+		}
+
+		next, err := fn(slices.Clone(current))
+		if errors.Is(err, ErrSkipWrite) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if len(next) != len(keys) {
+			return 0, fmt.Errorf("update returned %d entries for %d keys", len(next), len(keys))
+		}
+
+		version, timestamp := e.versionManager.NextVersion()
+		commit := newCommitState()
+		linked := make([]linkedNode, 0, len(keys))
+		conflict := false
+		for _, i := range order {
+			if unchanged(current[i], next[i]) {
+				continue
+			}
+
+			policy := e.config.GetCompressionForKey(keys[i])
+			node := e.newNode(policy, version, timestamp, next[i].Value, !next[i].Exists)
+			if next[i].Exists {
+				node.Type = next[i].Type
+				node.ExpiresAt = next[i].ExpiresAt
+				e.noteExpiry(next[i].ExpiresAt)
+			}
+			node.commit = commit
+			node.Prev = e.retain(keys[i], node.Type, demote(heads[i]))
+
+			if chains[i] == nil {
+				chains[i] = e.index.GetOrCreateChain(keys[i])
+			}
+			if !chains[i].CompareAndSwap(heads[i], node) {
+				conflict = true
+				break
+			}
+			linked = append(linked, linkedNode{keys[i], chains[i], node})
+		}
+
+		if conflict {
+			commit.resolve(false)
+			unlink(linked)
+			continue
+		}
+		if len(linked) == 0 {
+			return 0, nil
+		}
+
+		commit.resolve(true)
+		e.logChanges(version, timestamp, linkedChanges(linked)...)
+		for _, l := range linked {
+			e.watchers.notify(l.key)
+		}
+		return version, nil
+	}
+}
+
+// linkedChanges describes the nodes of a committed batch for the change log
+func linkedChanges(linked []linkedNode) []Change {
+	changes := make([]Change, len(linked))
+	for i, l := range linked {
+		changes[i] = changeOf(l.key, l.node)
+	}
+	return changes
+}
+
+// unchanged reports whether an UpdateManyFunc left a key as it was
+func unchanged(current, next Entry) bool {
+	if !current.Exists && !next.Exists {
+		return true
+	}
+	return current.Exists && next.Version == current.Version
+}
+
+// prependIfAbsent links node only while the key has no live value
+func (e *Engine) prependIfAbsent(chain *VersionChainHead, node *VersionNode) bool {
+	for {
+		currentHead, visible := chain.settled()
+		if visible.Live(e.now()) {
+			return false
+		}
+
+		if chain.CompareAndSwap(currentHead, link(currentHead, node)) {
+			return true
+		}
+	}
+}
+
+// unlink removes aborted nodes that are still chain heads. Nodes another writer
+// already built on stay in place but are skipped by every reader.
+func unlink(linked []linkedNode) {
+	for _, l := range linked {
+		l.chain.CompareAndSwap(l.node, l.node.Prev)
+	}
+}
@@ -0,0 +1,212 @@
+package mvcc_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+func TestBatch_SharedVersion(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("c", []byte("old"))
+
+	batch := mvcc.NewBatch()
+	batch.Set("a", []byte("1"))
+	batch.Set("b", []byte("2"))
+	batch.Del("c")
+	version, err := engine.Write(batch)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if engine.CurrentVersion() != version {
+		t.Errorf("batch should consume exactly one version, current=%d batch=%d", engine.CurrentVersion(), version)
+	}
+
+	for _, key := range []string{"a", "b", "c"} {
+		history, _ := engine.History(key, 0)
+		if history[0].Version != version {
+			t.Errorf("%s head version = %d, want %d", key, history[0].Version, version)
+		}
+	}
+	if engine.Exists("c") {
+		t.Errorf("c should be deleted by the batch")
+	}
+}
+
+func TestBatch_IfAbsentConflict(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("taken", []byte("x"))
+
+	batch := mvcc.NewBatch()
+	batch.Set("free", []byte("1"))
+	batch.Set("taken", []byte("2"))
+	if _, err := engine.Write(batch.IfAbsent()); !errors.Is(err, mvcc.ErrBatchConflict) {
+		t.Fatalf("expected ErrBatchConflict, got %v", err)
+	}
+
+	if engine.Exists("free") {
+		t.Errorf("aborted batch must not leave free visible")
+	}
+	if value, _, _ := engine.Get("taken"); string(value) != "x" {
+		t.Errorf("aborted batch changed taken to %q", value)
+	}
+	// an empty chain may remain, but it must not report versions
+	if history, err := engine.History("free", 0); err == nil && len(history) != 0 {
+		t.Errorf("aborted batch left history %v", history)
+	}
+}
+
+// TestBatch_NoPartialReads verifies GetMany never observes half of a batch
+func TestBatch_NoPartialReads(t *testing.T) {
+	engine := mvcc.NewEngine()
+	keys := []string{"k1", "k2", "k3", "k4"}
+	const writers = 200
+	const readers = 20
+
+	var wg sync.WaitGroup
+	var torn atomic.Int32
+	stop := make(chan struct{})
+
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				values, _ := engine.GetMany(keys)
+				for _, v := range values[1:] {
+					if string(v.Value) != string(values[0].Value) {
+						torn.Add(1)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	var writersWG sync.WaitGroup
+	writersWG.Add(writers)
+	for i := range writers {
+		go func(idx int) {
+			defer writersWG.Done()
+			batch := mvcc.NewBatch()
+			for _, key := range keys {
+				batch.Set(key, []byte(strconv.Itoa(idx)))
+			}
+			if _, err := engine.Write(batch); err != nil {
+				t.Errorf("Write: %v", err)
+			}
+		}(i)
+	}
+	writersWG.Wait()
+	close(stop)
+	wg.Wait()
+
+	if n := torn.Load(); n > 0 {
+		t.Errorf("%d readers observed a partial batch", n)
+	}
+}
+
+// TestBatch_IfAbsentRace verifies exactly one of many competing conditional batches wins
+func TestBatch_IfAbsentRace(t *testing.T) {
+	engine := mvcc.NewEngine()
+	const writers = 100
+
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(writers)
+	for i := range writers {
+		go func(idx int) {
+			defer wg.Done()
+			batch := mvcc.NewBatch()
+			// overlapping key sets in different orders
+			batch.Set(fmt.Sprintf("shared-%d", idx%2), []byte("x"))
+			batch.Set(fmt.Sprintf("shared-%d", (idx+1)%2), []byte("x"))
+			if _, err := engine.Write(batch.IfAbsent()); err == nil {
+				wins.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if wins.Load() != 1 {
+		t.Errorf("expected exactly one winning batch, got %d", wins.Load())
+	}
+}
+
+// TestBatch_SnapshotAheadOfLinking races MSET-sized batches against MGET-style
+// reads. A reader can pick a snapshot that includes a batch whose nodes are not
+// all linked yet; it must still see either the whole batch or none of it.
+func TestBatch_SnapshotAheadOfLinking(t *testing.T) {
+	engine := mvcc.NewEngine()
+	keys := make([]string, 64)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key:%02d", i)
+	}
+	// batches link their keys in sorted order, reading them backwards reaches the
+	// last linked key first and so widens the window for a torn read
+	reversed := slices.Clone(keys)
+	slices.Reverse(reversed)
+
+	var wg sync.WaitGroup
+	var torn atomic.Int32
+	stop := make(chan struct{})
+	for w := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for n := 0; ; n++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				batch := mvcc.NewBatch()
+				for _, key := range keys {
+					batch.Set(key, []byte(fmt.Sprintf("%d-%d", w, n)))
+				}
+				if _, err := engine.Write(batch); err != nil {
+					t.Errorf("Write: %v", err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				values, snapshot := engine.GetMany(reversed)
+				for i, v := range values[1:] {
+					if string(v.Value) != string(values[0].Value) {
+						torn.Add(1)
+						t.Logf("snapshot %d: %s=%q but %s=%q", snapshot, reversed[0], values[0].Value, reversed[i+1], v.Value)
+						break
+					}
+				}
+			}
+		}()
+	}
+
+	time.Sleep(time.Second)
+	close(stop)
+	wg.Wait()
+
+	if n := torn.Load(); n > 0 {
+		t.Errorf("%d reads observed a partial batch", n)
+	}
+}
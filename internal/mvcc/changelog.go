@@ -0,0 +1,231 @@
+package mvcc
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrChangesPruned is returned when changes are requested from before the
+// oldest version the change log still holds
+var ErrChangesPruned = errors.New("changes have been pruned")
+
+const (
+	// changeChunkSize is how many versions one chunk of the change log covers
+	changeChunkSize = 4096
+
+	// changeSettleWindow is how long a version may stay unrecorded before the
+	// change log treats it as abandoned (a retried or aborted write) rather
+	// than still in flight
+	changeSettleWindow = 100 * time.Millisecond
+)
+
+// Change is one key written at a version, as recorded in the change log
+type Change struct {
+	Version   uint64
+	Timestamp int64
+	Key       string
+	// Op is "set", "del", "moved" or the lineage of a rename or copy
+	Op   string
+	Size int
+	// History is set when the write also replaced the key's older versions
+	// (a rename, a copy with history or a move between databases)
+	History bool
+}
+
+// changeSet holds every key written under one version (several for batches)
+type changeSet struct {
+	changes []Change
+}
+
+type changeChunk [changeChunkSize]atomic.Pointer[changeSet]
+
+// changeChunks is an immutable view of the retained chunks, first is the
+// chunk index of chunks[0] and floor the lowest version still reported
+type changeChunks struct {
+	first  uint64
+	floor  uint64
+	chunks []*changeChunk
+}
+
+// changeLog indexes every write by version. Each version is recorded once by
+// the writer that committed it with a single atomic store, so the write path
+// takes no lock except when a new chunk is needed.
+type changeLog struct {
+	view atomic.Pointer[changeChunks]
+
+	// mu serialises growing and pruning the chunk list
+	mu sync.Mutex
+
+	// retain is how many recent versions are kept (0 disables the log)
+	retain uint64
+
+	// waiting is closed by the next record, nil while nobody waits
+	waiting atomic.Pointer[chan struct{}]
+}
+
+func newChangeLog(retain int) *changeLog {
+	log := &changeLog{retain: uint64(max(retain, 0))}
+	log.view.Store(&changeChunks{})
+	return log
+}
+
+// record stores the keys written at version
+func (l *changeLog) record(version uint64, timestamp int64, changes ...Change) {
+	if l.retain == 0 || len(changes) == 0 {
+		return
+	}
+	for i := range changes {
+		changes[i].Version, changes[i].Timestamp = version, timestamp
+	}
+	if chunk := l.chunk(version); chunk != nil {
+		chunk[version%changeChunkSize].Store(&changeSet{changes: changes})
+	}
+	if l.waiting.Load() != nil {
+		if ch := l.waiting.Swap(nil); ch != nil {
+			close(*ch)
+		}
+	}
+}
+
+// next returns a channel closed once the next version is recorded
+func (l *changeLog) next() <-chan struct{} {
+	for {
+		if ch := l.waiting.Load(); ch != nil {
+			return *ch
+		}
+		ch := make(chan struct{})
+		if l.waiting.CompareAndSwap(nil, &ch) {
+			return ch
+		}
+	}
+}
+
+// chunk returns the chunk holding version, growing the log (and dropping
+// chunks that fell out of retention) when it is past the end
+func (l *changeLog) chunk(version uint64) *changeChunk {
+	index := version / changeChunkSize
+	if view := l.view.Load(); index >= view.first && index < view.first+uint64(len(view.chunks)) {
+		return view.chunks[index-view.first]
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	view := l.view.Load()
+	if index < view.first {
+		return nil // already pruned
+	}
+	// drop chunks that fall out of the window before growing, so a jump in
+	// versions never allocates the chunks in between
+	var oldest uint64
+	if version > l.retain {
+		oldest = (version - l.retain) / changeChunkSize
+	}
+	first, floor, chunks := view.first, view.floor, view.chunks
+	for len(chunks) > 0 && first < oldest {
+		first++
+		chunks = chunks[1:]
+	}
+	if len(chunks) == 0 {
+		first = max(first, oldest)
+	}
+	for first+uint64(len(chunks)) <= index {
+		chunks = append(chunks, new(changeChunk))
+	}
+	l.view.Store(&changeChunks{first: first, floor: max(floor, first*changeChunkSize), chunks: chunks})
+	return chunks[index-first]
+}
+
+// pruneBefore drops the chunks holding only versions below version
+func (l *changeLog) pruneBefore(version uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	view := l.view.Load()
+	first, chunks := view.first, view.chunks
+	for len(chunks) > 0 && (first+1)*changeChunkSize <= version {
+		first++
+		chunks = chunks[1:]
+	}
+	if len(chunks) == 0 {
+		first = max(first, version/changeChunkSize)
+	}
+	l.view.Store(&changeChunks{first: first, floor: max(view.floor, version), chunks: chunks})
+}
+
+// oldest returns the lowest version the log can still answer for
+func (l *changeLog) oldest() uint64 {
+	return max(l.view.Load().floor, 1)
+}
+
+// Changes calls fn with the changes of every version recorded after since and
+// up to until, in version order, until fn returns false. It returns the last
+// version visited: a version that was taken but is not recorded yet stops the
+// walk while it may still be in flight, so resuming from the returned version
+// never skips a change. At most limit versions are visited (0 = no limit).
+func (e *Engine) Changes(since, until uint64, limit int, fn func(version uint64, changes []Change) bool) (uint64, error) {
+	log := e.changes
+	if log.retain == 0 {
+		return since, ErrChangesPruned
+	}
+	if since+1 < log.oldest() {
+		return since, ErrChangesPruned
+	}
+	until = min(until, e.versionManager.CurrentVersion())
+
+	now := e.now()
+	last := since
+	for version := since + 1; version <= until; version++ {
+		if limit > 0 && version-since > uint64(limit) {
+			break
+		}
+		view := log.view.Load()
+		index := version / changeChunkSize
+		if version < view.floor {
+			return last, ErrChangesPruned
+		}
+
+		var set *changeSet
+		if index-view.first < uint64(len(view.chunks)) {
+			set = view.chunks[index-view.first][version%changeChunkSize].Load()
+		}
+		if set == nil {
+			if ts, ok := e.versionManager.GetTimestamp(version); ok && now-ts < int64(changeSettleWindow) {
+				break // possibly still being written
+			}
+			last = version
+			continue
+		}
+
+		last = version
+		if !fn(version, set.changes) {
+			break
+		}
+	}
+	return last, nil
+}
+
+// NextChange returns a channel closed once the next change is recorded. Take
+// it before reading the log, so a change recorded in between is not missed.
+func (e *Engine) NextChange() <-chan struct{} {
+	return e.changes.next()
+}
+
+// OldestChange returns the lowest version the change log can still report
+func (e *Engine) OldestChange() uint64 {
+	return e.changes.oldest()
+}
+
+// changeOf describes a node written to key for the change log
+func changeOf(key string, node *VersionNode) Change {
+	op := "set"
+	switch {
+	case node.Lineage != nil:
+		op = node.Lineage.Op.String()
+	case node.Deleted:
+		op = "del"
+	}
+	return Change{Key: key, Op: op, Size: node.Size}
+}
@@ -0,0 +1,83 @@
+package mvcc_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+// collectChanges returns every change after since and the cursor
+func collectChanges(t *testing.T, engine *mvcc.Engine, since uint64) ([]mvcc.Change, uint64) {
+	t.Helper()
+	var changes []mvcc.Change
+	cursor, err := engine.Changes(since, math.MaxUint64, 0, func(_ uint64, set []mvcc.Change) bool {
+		changes = append(changes, set...)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+	return changes, cursor
+}
+
+func TestChanges_VersionOrder(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("a", []byte("1"))
+	b := mvcc.NewBatch()
+	b.Set("x", []byte("xx"))
+	b.Set("y", []byte("yyy"))
+	batchVersion, _ := engine.Write(b)
+	engine.Del("a")
+	engine.Rename("x", "z", false)
+
+	changes, cursor := collectChanges(t, engine, 0)
+	want := []struct {
+		key, op string
+		size    int
+	}{
+		{"a", "set", 1}, {"x", "set", 2}, {"y", "set", 3}, {"a", "del", 0},
+		{"x", "renamed_to", 0}, {"z", "renamed_from", 2},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(changes), len(want), changes)
+	}
+	for i, w := range want {
+		if changes[i].Key != w.key || changes[i].Op != w.op || changes[i].Size != w.size {
+			t.Errorf("change %d = %+v, want %+v", i, changes[i], w)
+		}
+	}
+	if changes[1].Version != batchVersion || changes[2].Version != batchVersion {
+		t.Errorf("batch keys logged under %d and %d, want %d", changes[1].Version, changes[2].Version, batchVersion)
+	}
+	if cursor != engine.CurrentVersion() {
+		t.Errorf("cursor = %d, want %d", cursor, engine.CurrentVersion())
+	}
+
+	// resuming from a cursor only returns what came after it
+	engine.Set("late", []byte("1"))
+	if changes, _ := collectChanges(t, engine, cursor); len(changes) != 1 || changes[0].Key != "late" {
+		t.Errorf("resumed changes = %+v", changes)
+	}
+}
+
+func TestChanges_Pruned(t *testing.T) {
+	cfg := mvcc.DefaultConfig()
+	cfg.ChangeLogVersions = 8192
+	engine := mvcc.NewEngineWithConfig(cfg)
+	for range 20000 {
+		engine.Set("k", []byte("v"))
+	}
+
+	oldest := engine.OldestChange()
+	if oldest <= 1 || oldest > 20000-8192 {
+		t.Fatalf("oldest change %d outside the retention window", oldest)
+	}
+	if _, err := engine.Changes(0, math.MaxUint64, 0, func(uint64, []mvcc.Change) bool { return true }); !errors.Is(err, mvcc.ErrChangesPruned) {
+		t.Errorf("expected ErrChangesPruned, got %v", err)
+	}
+	if changes, _ := collectChanges(t, engine, oldest-1); uint64(len(changes)) != 20000-oldest+1 {
+		t.Errorf("got %d changes from %d", len(changes), oldest)
+	}
+}
@@ -0,0 +1,188 @@
+package mvcc
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var ErrUnknownCodec = errors.New("unknown compression codec")
+
+// Codec identifies the format a version's value is stored in
+type Codec uint8
+
+const (
+	CodecNone Codec = iota
+	CodecFlate
+	CodecZlib
+	CodecGzip
+)
+
+func (c Codec) String() string {
+	switch c {
+	case CodecNone:
+		return "none"
+	case CodecFlate:
+		return "flate"
+	case CodecZlib:
+		return "zlib"
+	case CodecGzip:
+		return "gzip"
+	default:
+		return fmt.Sprintf("codec(%d)", uint8(c))
+	}
+}
+
+// ParseCodec maps a codec name (none, flate, zlib, gzip) to its Codec
+func ParseCodec(name string) (Codec, error) {
+	switch strings.ToLower(name) {
+	case "none", "":
+		return CodecNone, nil
+	case "flate", "deflate":
+		return CodecFlate, nil
+	case "zlib":
+		return CodecZlib, nil
+	case "gzip":
+		return CodecGzip, nil
+	default:
+		return CodecNone, fmt.Errorf("%w: %q", ErrUnknownCodec, name)
+	}
+}
+
+// CompressionPolicy defines how values are compressed for keys matching a pattern
+type CompressionPolicy struct {
+	// Pattern selects the keys this policy applies to (nil for the default policy)
+	Pattern *regexp.Regexp
+	// Codec is the compression format, CodecNone disables compression
+	Codec Codec
+	// Level is the flate compression level (0 means flate.DefaultCompression)
+	Level int
+	// MinSize is the smallest value in bytes that gets compressed when written
+	MinSize int
+	// CompressHistory compresses every version once it stops being the head
+	CompressHistory bool
+}
+
+// Name returns the pattern the policy is keyed by ("default" for the global one)
+func (p *CompressionPolicy) Name() string {
+	if p.Pattern == nil {
+		return "default"
+	}
+	return p.Pattern.String()
+}
+
+// shouldCompress reports whether a value of the given size is compressed on write
+func (p *CompressionPolicy) shouldCompress(size int, head bool) bool {
+	if p.Codec == CodecNone || size == 0 {
+		return false
+	}
+	if !head && p.CompressHistory {
+		return true
+	}
+	return p.MinSize > 0 && size >= p.MinSize
+}
+
+func (p *CompressionPolicy) level() int {
+	if p.Level == 0 {
+		return flate.DefaultCompression
+	}
+	return p.Level
+}
+
+// compress encodes data with the codec, it returns the input unchanged (and CodecNone)
+// when compression would not make the value smaller
+func compress(codec Codec, level int, data []byte) ([]byte, Codec, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+
+	switch codec {
+	case CodecNone:
+		return data, CodecNone, nil
+	case CodecFlate:
+		w, err = flate.NewWriter(&buf, level)
+	case CodecZlib:
+		w, err = zlib.NewWriterLevel(&buf, level)
+	case CodecGzip:
+		w, err = gzip.NewWriterLevel(&buf, level)
+	default:
+		return nil, CodecNone, fmt.Errorf("%w: %d", ErrUnknownCodec, codec)
+	}
+	if err != nil {
+		return nil, CodecNone, fmt.Errorf("creating %s writer: %w", codec, err)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, CodecNone, fmt.Errorf("compressing with %s: %w", codec, err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, CodecNone, fmt.Errorf("compressing with %s: %w", codec, err)
+	}
+
+	if buf.Len() >= len(data) {
+		return data, CodecNone, nil
+	}
+	return buf.Bytes(), codec, nil
+}
+
+// decompress decodes data that was stored with the codec
+func decompress(codec Codec, data []byte, size int) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+
+	switch codec {
+	case CodecNone:
+		return data, nil
+	case CodecFlate:
+		r = flate.NewReader(bytes.NewReader(data))
+	case CodecZlib:
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	case CodecGzip:
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCodec, codec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s reader: %w", codec, err)
+	}
+	defer r.Close()
+
+	out := bytes.NewBuffer(make([]byte, 0, size))
+	if _, err := io.Copy(out, r); err != nil {
+		return nil, fmt.Errorf("decompressing %s: %w", codec, err)
+	}
+	return out.Bytes(), nil
+}
+
+// CompressionStats reports how much space compression saves for one policy
+type CompressionStats struct {
+	Pattern string
+	Codec   Codec
+	// Versions is the number of non-tombstone versions stored under the policy
+	Versions int
+	// Compressed is how many of those versions are stored compressed
+	Compressed int
+	// LogicalBytes is the total uncompressed size of the values
+	LogicalBytes int64
+	// StoredBytes is the total size actually held in memory
+	StoredBytes int64
+}
+
+// Saved returns the number of bytes compression saves
+func (s CompressionStats) Saved() int64 {
+	return s.LogicalBytes - s.StoredBytes
+}
+
+// Ratio returns the stored/logical ratio (1 when nothing is stored)
+func (s CompressionStats) Ratio() float64 {
+	if s.LogicalBytes == 0 {
+		return 1
+	}
+	return float64(s.StoredBytes) / float64(s.LogicalBytes)
+}
@@ -0,0 +1,108 @@
+package mvcc_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+func compressionConfig() *mvcc.Config {
+	cfg := mvcc.DefaultConfig()
+	cfg.DefaultCompression = mvcc.CompressionPolicy{Codec: mvcc.CodecFlate, MinSize: 256}
+	cfg.CompressionPolicies = []mvcc.CompressionPolicy{
+		{Pattern: regexp.MustCompile(`^audit:`), Codec: mvcc.CodecGzip, CompressHistory: true},
+		{Pattern: regexp.MustCompile(`^raw:`), Codec: mvcc.CodecNone},
+	}
+	return cfg
+}
+
+func TestCompression_LargeValueRoundTrip(t *testing.T) {
+	for _, codec := range []mvcc.Codec{mvcc.CodecFlate, mvcc.CodecZlib, mvcc.CodecGzip} {
+		t.Run(codec.String(), func(t *testing.T) {
+			cfg := mvcc.DefaultConfig()
+			cfg.DefaultCompression = mvcc.CompressionPolicy{Codec: codec, MinSize: 256}
+			engine := mvcc.NewEngineWithConfig(cfg)
+
+			value := bytes.Repeat([]byte("verdis "), 200)
+			version := engine.Set("big", value)
+
+			got, ok, _ := engine.Get("big")
+			if !ok || !bytes.Equal(got, value) {
+				t.Fatalf("Get returned %d bytes, want %d", len(got), len(value))
+			}
+			got, err := engine.GetAtVersion("big", version)
+			if err != nil || !bytes.Equal(got, value) {
+				t.Fatalf("GetAtVersion: err=%v, %d bytes", err, len(got))
+			}
+
+			history, err := engine.History("big", 0)
+			if err != nil {
+				t.Fatalf("History: %v", err)
+			}
+			info := history[0]
+			if info.Size != len(value) {
+				t.Errorf("Size = %d, want logical size %d", info.Size, len(value))
+			}
+			if info.StoredSize >= info.Size || info.Codec != codec {
+				t.Errorf("expected compressed storage, got stored=%d codec=%s", info.StoredSize, info.Codec)
+			}
+		})
+	}
+}
+
+func TestCompression_SmallValueStaysRaw(t *testing.T) {
+	engine := mvcc.NewEngineWithConfig(compressionConfig())
+	engine.Set("small", []byte("tiny"))
+
+	history, _ := engine.History("small", 0)
+	if history[0].Codec != mvcc.CodecNone || history[0].StoredSize != 4 {
+		t.Errorf("small value should stay raw, got codec=%s stored=%d", history[0].Codec, history[0].StoredSize)
+	}
+}
+
+func TestCompression_HistoryDemotion(t *testing.T) {
+	engine := mvcc.NewEngineWithConfig(compressionConfig())
+	old := bytes.Repeat([]byte("a"), 100)
+	v1 := engine.Set("audit:1", old)
+	engine.Set("audit:1", []byte("new"))
+
+	history, _ := engine.History("audit:1", 0)
+	if len(history) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(history))
+	}
+	if history[0].Codec != mvcc.CodecNone {
+		t.Errorf("head should stay raw below MinSize, got %s", history[0].Codec)
+	}
+	if history[1].Codec != mvcc.CodecGzip || history[1].Size != len(old) {
+		t.Errorf("old head should be demoted to gzip, got codec=%s size=%d", history[1].Codec, history[1].Size)
+	}
+
+	got, err := engine.GetAtVersion("audit:1", v1)
+	if err != nil || !bytes.Equal(got, old) {
+		t.Errorf("historical read after demotion: err=%v value=%q", err, got)
+	}
+}
+
+func TestCompression_Stats(t *testing.T) {
+	engine := mvcc.NewEngineWithConfig(compressionConfig())
+	big := bytes.Repeat([]byte("x"), 1024)
+	engine.Set("doc:1", big)
+	engine.Set("raw:1", big)
+
+	stats := engine.CompressionStats()
+	byPattern := make(map[string]mvcc.CompressionStats)
+	for _, s := range stats {
+		byPattern[s.Pattern] = s
+	}
+
+	def := byPattern["default"]
+	if def.Versions != 1 || def.Compressed != 1 || def.Saved() <= 0 {
+		t.Errorf("default policy stats = %+v", def)
+	}
+	raw := byPattern["^raw:"]
+	if raw.Compressed != 0 || raw.Saved() != 0 || raw.LogicalBytes != int64(len(big)) {
+		t.Errorf("raw policy stats = %+v", raw)
+	}
+}
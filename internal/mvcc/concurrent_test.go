@@ -140,7 +140,7 @@ func runWritersAndReaders(t *testing.T, cfg rwTestConfig) {
 		go func(idx int) {
 			defer wg.Done()
 			for range cfg.readsPerReader {
-				value, ok := engine.Get(key)
+				value, ok, _ := engine.Get(key)
 				if ok {
 					if value == nil {
 						consistencyErrors.Add(1)
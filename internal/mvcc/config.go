@@ -10,7 +10,8 @@ type RetentionPolicy struct {
 
 // Config holds MVCC engine configuration
 type Config struct {
-	// DefaultMaxVersions is the default number of versions to keep  per key
+	// DefaultMaxVersions is the default number of versions to keep per key.
+	// Collection keys are cut back to it (0 keeps every version).
 	DefaultMaxVersions int
 	// RetentionPolicies allows per key pattern version limits
 	RetentionPolicies []RetentionPolicy
@@ -18,8 +19,18 @@ type Config struct {
 	TombstoneRetentionVersions int
 	// EnableTimestampIndex enables version -> timestamp mapping
 	EnableTimestampIndex bool
+	// DefaultCompression is applied to keys that match no compression policy
+	DefaultCompression CompressionPolicy
+	// CompressionPolicies allows per key pattern compression settings
+	CompressionPolicies []CompressionPolicy
+	// ChangeLogVersions is how many recent versions the global change log
+	// keeps for CHANGES (0 disables it)
+	ChangeLogVersions int
 }
 
+// DefaultChangeLogVersions is the change log window of the preset configs
+const DefaultChangeLogVersions = 1 << 20
+
 // DefaultConfig returns default configuration settings for development environment
 func DefaultConfig() *Config {
 	return &Config{
@@ -27,6 +38,7 @@ func DefaultConfig() *Config {
 		RetentionPolicies:          nil,
 		TombstoneRetentionVersions: 100,
 		EnableTimestampIndex:       true,
+		ChangeLogVersions:          DefaultChangeLogVersions,
 	}
 }
 
@@ -36,11 +48,17 @@ func ProductionConfig() *Config {
 		DefaultMaxVersions:         1000,
 		TombstoneRetentionVersions: 1000,
 		EnableTimestampIndex:       true,
+		ChangeLogVersions:          DefaultChangeLogVersions,
 		RetentionPolicies: []RetentionPolicy{
 			{Pattern: regexp.MustCompile(`^audit:`), MaxVersions: 10000},
 			{Pattern: regexp.MustCompile(`^cache:`), MaxVersions: 10},
 			{Pattern: regexp.MustCompile(`^session:`), MaxVersions: 100},
 		},
+		DefaultCompression: CompressionPolicy{Codec: CodecFlate, MinSize: 4096},
+		CompressionPolicies: []CompressionPolicy{
+			{Pattern: regexp.MustCompile(`^audit:`), Codec: CodecZlib, MinSize: 1024, CompressHistory: true},
+			{Pattern: regexp.MustCompile(`^cache:`), Codec: CodecNone},
+		},
 	}
 }
 
@@ -53,3 +71,13 @@ func (c *Config) GetMaxVersionsForKey(key string) int {
 	}
 	return c.DefaultMaxVersions
 }
+
+// GetCompressionForKey returns the compression policy for a specific key
+func (c *Config) GetCompressionForKey(key string) *CompressionPolicy {
+	for i := range c.CompressionPolicies {
+		if c.CompressionPolicies[i].Pattern.MatchString(key) {
+			return &c.CompressionPolicies[i]
+		}
+	}
+	return &c.DefaultCompression
+}
@@ -0,0 +1,102 @@
+package mvcc
+
+// DiffStatus classifies how a key changed between two versions
+type DiffStatus uint8
+
+const (
+	DiffAdded DiffStatus = iota + 1
+	DiffModified
+	DiffDeleted
+)
+
+func (s DiffStatus) String() string {
+	switch s {
+	case DiffAdded:
+		return "added"
+	case DiffModified:
+		return "modified"
+	case DiffDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// KeyDiff describes a key whose visible value differs between two versions
+type KeyDiff struct {
+	Key    string
+	Status DiffStatus
+	// OldSize and NewSize are the logical value sizes (0 when absent)
+	OldSize int
+	NewSize int
+	// ToVersion is the version the key's state at the later version was written at
+	ToVersion uint64
+}
+
+// DiffKey compares the visible state of a key at from and to using only chain
+// metadata: a key counts as modified when a different version is visible,
+// even if it was rewritten with the same bytes. ok is false when nothing
+// visible changed. Expiry is judged at the time each version was taken, so a
+// key that expired in between is deleted even though nothing was written.
+func (e *Engine) DiffKey(key string, from, to uint64) (diff KeyDiff, ok bool) {
+	chain := e.index.GetChain(key)
+	if chain == nil {
+		return KeyDiff{}, false
+	}
+
+	before, after := chain.At(from), chain.At(to)
+	wasLive := before.Live(e.timestampOf(from))
+	isLive := after.Live(e.timestampOf(to))
+	if before != nil && after != nil && before.Version == after.Version && wasLive == isLive {
+		return KeyDiff{}, false
+	}
+	diff = KeyDiff{Key: key}
+	switch {
+	case !wasLive && isLive:
+		diff.Status = DiffAdded
+	case wasLive && !isLive:
+		diff.Status = DiffDeleted
+	case wasLive && isLive:
+		diff.Status = DiffModified
+	default:
+		return KeyDiff{}, false
+	}
+	if wasLive {
+		diff.OldSize = before.Size
+	}
+	if isLive {
+		diff.NewSize = after.Size
+	}
+	if after != nil {
+		diff.ToVersion = after.Version
+	}
+	return diff, true
+}
+
+// MayExpireAfter reports whether a value written so far carries a deadline
+// later than the time version was taken. When none does, no key expired after
+// version without a change being recorded.
+func (e *Engine) MayExpireAfter(version uint64) bool {
+	return e.latestExpiry.Load() > e.timestampOf(version)
+}
+
+// noteExpiry records the deadline of a value being written
+func (e *Engine) noteExpiry(at int64) {
+	for {
+		latest := e.latestExpiry.Load()
+		if at <= latest || e.latestExpiry.CompareAndSwap(latest, at) {
+			return
+		}
+	}
+}
+
+// timestampOf returns when version was taken, or now if it is not known
+func (e *Engine) timestampOf(version uint64) int64 {
+	if version == 0 {
+		return 0
+	}
+	if ts, ok := e.versionManager.GetTimestamp(version); ok {
+		return ts
+	}
+	return e.now()
+}
@@ -0,0 +1,89 @@
+package mvcc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+func TestDiffKey_Classification(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("kept", []byte("1"))
+	engine.Set("modified", []byte("1"))
+	engine.Set("deleted", []byte("1"))
+	from := engine.CurrentVersion()
+
+	engine.Set("modified", []byte("123"))
+	engine.Del("deleted")
+	engine.Set("added", []byte("12"))
+	engine.Set("transient", []byte("1"))
+	engine.Del("transient")
+	to := engine.CurrentVersion()
+	engine.Set("kept", []byte("after"))
+
+	tests := []struct {
+		key              string
+		status           mvcc.DiffStatus
+		oldSize, newSize int
+	}{
+		{"modified", mvcc.DiffModified, 1, 3},
+		{"deleted", mvcc.DiffDeleted, 1, 0},
+		{"added", mvcc.DiffAdded, 0, 2},
+	}
+	for _, tt := range tests {
+		diff, ok := engine.DiffKey(tt.key, from, to)
+		if !ok {
+			t.Errorf("%s: reported unchanged", tt.key)
+			continue
+		}
+		if diff.Status != tt.status || diff.OldSize != tt.oldSize || diff.NewSize != tt.newSize {
+			t.Errorf("%s: got %+v, want %v %d→%d", tt.key, diff, tt.status, tt.oldSize, tt.newSize)
+		}
+	}
+
+	// kept only changed after to and transient was born and died in between
+	for _, key := range []string{"kept", "transient", "missing"} {
+		if diff, ok := engine.DiffKey(key, from, to); ok {
+			t.Errorf("%s: got %+v, want unchanged", key, diff)
+		}
+	}
+}
+
+func TestDiffKey_RewriteCountsAsModified(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("k", []byte("same"))
+	from := engine.CurrentVersion()
+	version := engine.Set("k", []byte("same"))
+
+	diff, ok := engine.DiffKey("k", from, version)
+	if !ok || diff.Status != mvcc.DiffModified || diff.ToVersion != version {
+		t.Fatalf("got %+v, %v, want modified at %d", diff, ok, version)
+	}
+	if _, ok := engine.DiffKey("k", version, version); ok {
+		t.Error("diff of a version against itself reported a change")
+	}
+}
+
+// TestDiffKey_ExpiredInBetween verifies a key that expired between the two
+// versions is deleted although nothing was written to it
+func TestDiffKey_ExpiredInBetween(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Update("ttl", func(mvcc.Entry) (mvcc.Entry, error) {
+		return mvcc.Entry{Value: []byte("v"), Exists: true, ExpiresAt: time.Now().Add(20 * time.Millisecond).UnixNano()}, nil
+	})
+	from := engine.CurrentVersion()
+	if !engine.MayExpireAfter(from) {
+		t.Errorf("MayExpireAfter(%d) = false with a deadline still ahead", from)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	to := engine.Set("other", []byte("1"))
+	diff, ok := engine.DiffKey("ttl", from, to)
+	if !ok || diff.Status != mvcc.DiffDeleted || diff.OldSize != 1 {
+		t.Errorf("DiffKey(ttl) = %+v, %v, want deleted", diff, ok)
+	}
+	if engine.MayExpireAfter(to) {
+		t.Errorf("MayExpireAfter(%d) = true with every deadline passed", to)
+	}
+}
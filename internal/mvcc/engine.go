@@ -1,17 +1,49 @@
 package mvcc
 
-import "errors"
+import (
+	"errors"
+	"slices"
+	"sync/atomic"
+	"time"
+)
 
 var (
+	// ErrSkipWrite is returned by an UpdateFunc to leave the key untouched
+	ErrSkipWrite = errors.New("skip write")
+
 	ErrKeyNotFound     = errors.New("key not found")
 	ErrVersionNotFound = errors.New("version not found")
 	ErrKeyDeleted      = errors.New("key was deleted at this versi")
 )
 
 type Engine struct {
+	*engineCore
+
+	// author is recorded on every version written through this engine (nil = anonymous)
+	author *Author
+
+	// comment is the note recorded on every version written through this engine
+	comment string
+
+	// clock decides expiry for the reads and writes of this engine (nil = wall clock)
+	clock func() time.Time
+}
+
+// engineCore is the state shared by an engine and its attributed views
+type engineCore struct {
 	index          *Index
 	versionManager *GlobalVersionManager
 	config         *Config
+	watchers       *watchers
+	changes        *changeLog
+
+	// lastFlush is the version of the most recent Flush, the default Restore point
+	lastFlush atomic.Uint64
+
+	// latestExpiry is the latest deadline a written value carries
+	latestExpiry atomic.Int64
+
+	keyspace keyspace
 }
 
 // NewEngine creates a new MVCC engine with DEFAULT config
@@ -21,96 +53,310 @@ func NewEngine() *Engine {
 
 // NewEngineWithConfig creates a new MVCC engine with given config
 func NewEngineWithConfig(config *Config) *Engine {
-	return &Engine{
+	return &Engine{engineCore: &engineCore{
 		index:          NewIndex(),
 		versionManager: NewGlobalVersionManager(),
 		config:         config,
+		watchers:       newWatchers(),
+		changes:        newChangeLog(config.ChangeLogVersions),
+	}}
+}
+
+// Attributed returns a view of the engine that records author on every version
+// it writes. Views share all data with the engine and are cheap to create.
+func (e *Engine) Attributed(author *Author) *Engine {
+	return &Engine{engineCore: e.engineCore, author: author, comment: e.comment, clock: e.clock}
+}
+
+// Annotated returns a view of the engine that records comment on every version
+// it writes, keeping the view's author
+func (e *Engine) Annotated(comment string) *Engine {
+	return &Engine{engineCore: e.engineCore, author: e.author, comment: comment, clock: e.clock}
+}
+
+// Clocked returns a view of the engine that decides whether values have
+// expired by clock instead of the wall clock, keeping the view's author and
+// comment. Nodes applying a replicated write judge expiry at the write's time.
+func (e *Engine) Clocked(clock func() time.Time) *Engine {
+	return &Engine{engineCore: e.engineCore, author: e.author, comment: e.comment, clock: clock}
+}
+
+// now returns the time in nanoseconds against which the view checks expiry
+func (e *Engine) now() int64 {
+	if e.clock == nil {
+		return time.Now().UnixNano()
 	}
+	return e.clock().UnixNano()
 }
 
-// Get returns the latest value for a key
-func (e *Engine) Get(key string) ([]byte, bool) {
+// Get returns the latest value for a key. A value that cannot be decompressed
+// is reported as an error rather than as a missing key.
+func (e *Engine) Get(key string) ([]byte, bool, error) {
 	chain := e.index.GetChain(key)
 	if chain == nil {
-		return nil, false
-	}
-	head := chain.Load()
-	if head == nil {
-		return nil, false
+		return nil, false, nil
 	}
+	head := chain.Visible()
+	now := e.now()
+	chain.touch(now)
 
-	// if latest version is tombstone key is deleted
-	if head.Deleted {
-		return nil, false
+	// if latest version is a tombstone or has expired the key is gone
+	if !head.Live(now) {
+		return nil, false, nil
 	}
 
-	return head.Value, true
+	value, err := head.Data()
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
 }
 
 // Set stores a value for a key, creating a new version
 func (e *Engine) Set(key string, value []byte) uint64 {
 	version, timestamp := e.versionManager.NextVersion()
 
-	newNode := &VersionNode{
-		Version:   version,
-		Timestamp: timestamp,
-		Value:     value,
-		Deleted:   false,
-		Prev:      nil,
-	}
+	policy := e.config.GetCompressionForKey(key)
+	newNode := e.newNode(policy, version, timestamp, value, false)
 
 	chain := e.index.GetOrCreateChain(key)
-
-	// CAS loop to try until prepend successful
-	for {
-		currentHead := chain.Load()
-		newNode.Prev = currentHead
-
-		if chain.CompareAndSwap(currentHead, newNode) {
-			// prepended current version
-			break
-		}
-
-		// another writer won, retry with their version as the Prev
-	}
+	e.prepend(chain, newNode)
+	chain.touch(timestamp)
+	e.logChanges(version, timestamp, changeOf(key, newNode))
+	e.watchers.notify(key)
 
 	return version
 }
 
 // Del marks a key as deleted by adding a tombstone version
 func (e *Engine) Del(key string) bool {
+	_, ok := e.del(key)
+	return ok
+}
+
+// del prepends a tombstone and returns its version
+func (e *Engine) del(key string) (uint64, bool) {
 	chain := e.index.GetChain(key)
 	if chain == nil {
-		return false
+		return 0, false
 	}
 
 	currentHead := chain.Load()
 	if currentHead == nil {
-		return false // key has no versions
+		return 0, false // key has no versions
 	}
 
 	// if already deleted, still creates new tombstone
 	version, timestamp := e.versionManager.NextVersion()
 
-	tombstone := &VersionNode{
+	policy := e.config.GetCompressionForKey(key)
+	tombstone := e.newNode(policy, version, timestamp, nil, true)
+	e.prepend(chain, tombstone)
+	e.logChanges(version, timestamp, changeOf(key, tombstone))
+	e.watchers.notify(key)
+
+	return version, true
+}
+
+// Entry is the state of a key at one version, as returned by lookups and seen by an UpdateFunc
+type Entry struct {
+	// Value is the logical value (nil when the key does not exist)
+	Value []byte
+	// Type is the kind of value (TypeString for missing keys)
+	Type ValueType
+	// Exists is false when the key is missing, deleted or expired
+	Exists bool
+	// ExpiresAt is the Unix nano expiry deadline (0 = never)
+	ExpiresAt int64
+	// Version is the version the entry was read from (0 for missing keys)
+	Version uint64
+}
+
+// WithValue returns a live entry holding value that keeps the current type and expiry
+func (en Entry) WithValue(value []byte) Entry {
+	return Entry{Value: value, Type: en.Type, Exists: true, ExpiresAt: en.ExpiresAt}
+}
+
+// Is reports whether the entry is missing or holds the given type
+func (en Entry) Is(typ ValueType) bool {
+	return !en.Exists || en.Type == typ
+}
+
+// UpdateFunc computes the next state of a key from its current one. Returning an
+// entry with Exists false writes a tombstone, returning ErrSkipWrite leaves the key
+// untouched. Current values are shared with readers and must not be modified in place.
+type UpdateFunc func(current Entry) (Entry, error)
+
+// Update atomically replaces a key's state with the result of fn. fn runs inside
+// the CAS loop and is called again with the new head whenever another writer wins,
+// so it must not have side effects. An error from fn aborts the update, a skipped
+// write returns version 0.
+func (e *Engine) Update(key string, fn UpdateFunc) (uint64, error) {
+	return e.update(key, false, func(current Entry, _ uint64) (Entry, error) {
+		return fn(current)
+	})
+}
+
+// VersionedUpdateFunc computes a key's next state knowing the version it is
+// written at
+type VersionedUpdateFunc func(current Entry, version uint64) (Entry, error)
+
+// UpdateVersioned is Update for values that embed the version they are written
+// at, such as stream IDs. The version is taken before fn runs, and fn runs
+// again with a fresh one when a newer version lands on the key first, so the
+// version fn saw is always the one the write gets. A skipped write still uses
+// up its version.
+func (e *Engine) UpdateVersioned(key string, fn VersionedUpdateFunc) (uint64, error) {
+	return e.update(key, true, fn)
+}
+
+// update runs the CAS loop of Update, taking the version before fn when early
+func (e *Engine) update(key string, early bool, fn VersionedUpdateFunc) (uint64, error) {
+	policy := e.config.GetCompressionForKey(key)
+	chain := e.index.GetChain(key)
+
+	var version uint64
+	var timestamp int64
+	for {
+		// wait out in-flight batches so fn never computes from a value that is
+		// about to be replaced underneath it
+		var currentHead, visible *VersionNode
+		if chain != nil {
+			currentHead, visible = chain.settled()
+		}
+
+		var current Entry
+		if visible.Live(e.now()) {
+			var err error
+			if current, err = visible.entry(); err != nil {
+				return 0, err
+			}
+		}
+
+		// the new value depends on the head it was computed from, so it must land
+		// directly on top of it: take a fresh version if a newer one got there first
+		stale := version == 0 || (currentHead != nil && currentHead.Version > version)
+		if early && stale {
+			version, timestamp = e.versionManager.NextVersion()
+		}
+		next, err := fn(current, version)
+		if errors.Is(err, ErrSkipWrite) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !early && stale {
+			version, timestamp = e.versionManager.NextVersion()
+		}
+
+		newNode := e.newNode(policy, version, timestamp, next.Value, !next.Exists)
+		if next.Exists {
+			newNode.Type = next.Type
+			newNode.ExpiresAt = next.ExpiresAt
+			e.noteExpiry(next.ExpiresAt)
+		}
+		newNode.Prev = e.retain(key, newNode.Type, demote(currentHead))
+		if chain == nil {
+			// the chain is only created once there is something to write, so a
+			// failed or skipped update leaves no empty chain behind
+			chain = e.index.GetOrCreateChain(key)
+		}
+		if chain.CompareAndSwap(currentHead, newNode) {
+			chain.touch(timestamp)
+			e.logChanges(version, timestamp, changeOf(key, newNode))
+			e.watchers.notify(key)
+			return version, nil
+		}
+
+		// another writer won, recompute from their value
+	}
+}
+
+// newNode builds a version node, compressing the value when the policy asks for it
+func (e *Engine) newNode(policy *CompressionPolicy, version uint64, timestamp int64, value []byte, deleted bool) *VersionNode {
+	node := &VersionNode{
 		Version:   version,
 		Timestamp: timestamp,
-		Value:     nil,
-		Deleted:   true,
+		Value:     value,
+		Size:      len(value),
+		Deleted:   deleted,
+		Author:    e.author,
+		Comment:   e.comment,
 		Prev:      nil,
 	}
+	pack(policy, node)
+	return node
+}
+
+// pack compresses a new node's value when the policy compresses heads, or else
+// prepares the compressed form it is stored in once demoted. Either way the
+// compression happens once, before the node is linked, and never inside a CAS
+// loop that may run several times. The prepared form costs memory only while
+// the node is the head.
+func pack(policy *CompressionPolicy, node *VersionNode) {
+	if node.Deleted {
+		return
+	}
+	if policy.shouldCompress(node.Size, true) {
+		node.Value, node.Codec = encodeValue(policy, node.Value)
+		return
+	}
+	if policy.shouldCompress(node.Size, false) {
+		node.history, node.historyCodec = encodeValue(policy, node.Value)
+	}
+}
 
-	// CAS loop to prepend tombstone
+// prepend links node into the chain, keeping the chain ordered newest version first
+func (e *Engine) prepend(chain *VersionChainHead, node *VersionNode) {
+	// CAS loop to try until prepend successful
 	for {
 		currentHead := chain.Load()
-		tombstone.Prev = currentHead
-
-		if chain.CompareAndSwap(currentHead, tombstone) {
-			break
+		if chain.CompareAndSwap(currentHead, link(currentHead, node)) {
+			return
 		}
+
+		// another writer won, retry with their version in the chain
+	}
+}
+
+// link returns the chain head after placing node in version order. Writers race
+// between taking a version and linking it, so a node can arrive after a newer one.
+// The newer nodes above it are then copied (nodes are immutable) rather than letting
+// the chain go out of order, which would make snapshot reads pick the wrong node.
+func link(head, node *VersionNode) *VersionNode {
+	if head == nil || head.Version < node.Version {
+		node.Prev = demote(head)
+		return node
 	}
 
-	return true
+	above := *head
+	above.Prev = link(head.Prev, node)
+	return &above
+}
+
+// demote returns the node as it should be stored once it is no longer the head.
+// Nodes are immutable, so a copy holding the form pack prepared replaces the
+// original in the chain while readers still holding the old head keep seeing the
+// uncompressed value.
+func demote(node *VersionNode) *VersionNode {
+	if node == nil || node.Codec != CodecNone || node.historyCodec == CodecNone {
+		return node
+	}
+
+	demoted := *node
+	demoted.Value, demoted.Codec = node.history, node.historyCodec
+	demoted.history, demoted.historyCodec = nil, CodecNone
+	return &demoted
+}
+
+// encodeValue compresses value, it falls back to storing the raw bytes on failure
+func encodeValue(policy *CompressionPolicy, value []byte) ([]byte, Codec) {
+	stored, codec, err := compress(policy.Codec, policy.level(), value)
+	if err != nil {
+		return value, CodecNone
+	}
+	return stored, codec
 }
 
 // Exists checks if a key exists and is not deleted
@@ -119,39 +365,141 @@ func (e *Engine) Exists(key string) bool {
 	if chain == nil {
 		return false
 	}
-	head := chain.Load()
-
-	if head == nil {
-		return false
-	}
-	return !head.Deleted
+	return chain.Visible().Live(e.now())
 }
 
 // GetAtVersion returns the value at a specific version or earlier
 func (e *Engine) GetAtVersion(key string, version uint64) ([]byte, error) {
 	chain := e.index.GetChain(key)
-	if chain == nil {
+	if chain == nil || chain.Load() == nil {
 		return nil, ErrKeyNotFound
 	}
 
-	head := chain.Load()
-	if head == nil {
-		return nil, ErrKeyNotFound
-	}
+	chain.touch(e.now())
 
 	// walk chain backwards until we find the version <= requested
-	current := head
-	for current != nil {
-		if current.Version <= version {
-			if current.Deleted {
-				return nil, ErrKeyDeleted
+	node := chain.At(version)
+	if node == nil {
+		return nil, ErrVersionNotFound
+	}
+	// a version that had expired by the time version was taken is gone like a tombstone
+	if !node.Live(e.timestampOf(version)) {
+		return nil, ErrKeyDeleted
+	}
+	return node.Data()
+}
+
+// Lookup returns the latest live entry of a key (Exists is false when it is
+// missing, deleted or expired)
+func (e *Engine) Lookup(key string) (Entry, error) {
+	chain := e.index.GetChain(key)
+	if chain == nil {
+		return Entry{}, nil
+	}
+	head := chain.Visible()
+	now := e.now()
+	chain.touch(now)
+	if !head.Live(now) {
+		return Entry{}, nil
+	}
+	return head.entry()
+}
+
+// LookupAt returns the entry of a key as of a specific version
+func (e *Engine) LookupAt(key string, version uint64) (Entry, error) {
+	chain := e.index.GetChain(key)
+	if chain == nil || chain.Load() == nil {
+		return Entry{}, ErrKeyNotFound
+	}
+	chain.touch(e.now())
+
+	node := chain.At(version)
+	if node == nil {
+		return Entry{}, ErrVersionNotFound
+	}
+	if !node.Live(e.timestampOf(version)) {
+		return Entry{Version: node.Version}, ErrKeyDeleted
+	}
+	return node.entry()
+}
+
+// GetMany reads every key at one snapshot version, so a batch written concurrently
+// is either fully visible or not at all
+func (e *Engine) GetMany(keys []string) ([]Entry, uint64) {
+	snapshot := e.versionManager.CurrentVersion()
+	nodes := e.nodesAt(keys, snapshot)
+	now := e.now()
+
+	entries := make([]Entry, len(keys))
+	for i, node := range nodes {
+		if chain := e.index.GetChain(keys[i]); chain != nil {
+			chain.touch(now)
+		}
+		if !node.Live(now) {
+			continue
+		}
+		if entry, err := node.entry(); err == nil {
+			entries[i] = entry
+		}
+	}
+	return entries, snapshot
+}
+
+// WalkHistory calls fn with every committed version of a key and its decoded
+// value, newest first, until fn returns false
+func (e *Engine) WalkHistory(key string, fn func(info VersionInfo, entry Entry) bool) error {
+	chain := e.index.GetChain(key)
+	if chain == nil || chain.Load() == nil {
+		return ErrKeyNotFound
+	}
+
+	for current := chain.Load(); current != nil; current = current.Prev {
+		if !current.committed() {
+			continue
+		}
+		entry, err := current.entry()
+		if err != nil {
+			return err
+		}
+		if !fn(current.ToInfo(), entry) {
+			break
+		}
+	}
+	return nil
+}
+
+// nodesAt returns the node of every key at snapshot. A batch that took its version
+// before the snapshot may still be linking its nodes, so a read can find it on one
+// key and miss it on another. Once it is found anywhere it is fully linked, so the
+// keys are read again until two passes agree; only versions handed out before the
+// snapshot can change the result, which bounds the retries.
+func (e *Engine) nodesAt(keys []string, snapshot uint64) []*VersionNode {
+	read := func() []*VersionNode {
+		nodes := make([]*VersionNode, len(keys))
+		for i, key := range keys {
+			if chain := e.index.GetChain(key); chain != nil {
+				nodes[i] = chain.At(snapshot)
 			}
-			return current.Value, nil
 		}
-		current = current.Prev
+		return nodes
 	}
 
-	return nil, ErrVersionNotFound
+	nodes := read()
+	for {
+		again := read()
+		if slices.EqualFunc(nodes, again, sameVersion) {
+			return again
+		}
+		nodes = again
+	}
+}
+
+// sameVersion reports whether two reads of a key found the same version
+func sameVersion(a, b *VersionNode) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Version == b.Version
 }
 
 // History returns version meta a key
@@ -170,6 +518,10 @@ func (e *Engine) History(key string, maxVersions int) ([]VersionInfo, error) {
 	current := head
 
 	for current != nil {
+		if !current.committed() {
+			current = current.Prev
+			continue
+		}
 		history = append(history, current.ToInfo())
 		if maxVersions > 0 && len(history) > maxVersions {
 			break
@@ -179,6 +531,22 @@ func (e *Engine) History(key string, maxVersions int) ([]VersionInfo, error) {
 	return history, nil
 }
 
+// Range calls fn with the latest value of every live key until fn returns false
+func (e *Engine) Range(fn func(key string, value []byte) bool) {
+	now := e.now()
+	e.index.Range(func(key string, chain *VersionChainHead) bool {
+		head := chain.Visible()
+		if !head.Live(now) {
+			return true
+		}
+		value, err := head.Data()
+		if err != nil {
+			return true
+		}
+		return fn(key, value)
+	})
+}
+
 // CurrentVersion returns the global version counter (for snapshots)
 func (e *Engine) CurrentVersion() uint64 {
 	return e.versionManager.CurrentVersion()
@@ -186,14 +554,71 @@ func (e *Engine) CurrentVersion() uint64 {
 
 // EngineStats holds engine statistics
 type EngineStats struct {
-	KeyCount       int
+	// KeyCount is the number of chains, including keys that are deleted
+	KeyCount int
+	// LiveKeys is the number of keys with a live value
+	LiveKeys int
+	// ExpiringKeys is the number of live keys with an expiry set
+	ExpiringKeys int
+	// Versions is the number of committed versions across all chains
+	Versions       int
 	CurrentVersion uint64
 }
 
-// Stats returns engine statistics (for INFO command)
+// Stats walks every chain and returns engine statistics (for INFO command)
 func (e *Engine) Stats() EngineStats {
-	return EngineStats{
-		KeyCount:       e.index.Count(),
-		CurrentVersion: e.versionManager.CurrentVersion(),
+	stats := EngineStats{CurrentVersion: e.versionManager.CurrentVersion()}
+	now := e.now()
+	e.index.Range(func(key string, chain *VersionChainHead) bool {
+		stats.KeyCount++
+		if head := chain.Visible(); head.Live(now) {
+			stats.LiveKeys++
+			if head.ExpiresAt != 0 {
+				stats.ExpiringKeys++
+			}
+		}
+		for current := chain.Load(); current != nil; current = current.Prev {
+			if current.committed() {
+				stats.Versions++
+			}
+		}
+		return true
+	})
+	return stats
+}
+
+// CompressionStats walks every chain and reports how much each compression
+// policy saves (for the MEMORY DOCTOR command)
+func (e *Engine) CompressionStats() []CompressionStats {
+	byPolicy := make(map[*CompressionPolicy]*CompressionStats)
+	policies := make([]*CompressionPolicy, 0, len(e.config.CompressionPolicies)+1)
+	for i := range e.config.CompressionPolicies {
+		policies = append(policies, &e.config.CompressionPolicies[i])
+	}
+	policies = append(policies, &e.config.DefaultCompression)
+	for _, policy := range policies {
+		byPolicy[policy] = &CompressionStats{Pattern: policy.Name(), Codec: policy.Codec}
+	}
+
+	e.index.Range(func(key string, chain *VersionChainHead) bool {
+		stats := byPolicy[e.config.GetCompressionForKey(key)]
+		for current := chain.Load(); current != nil; current = current.Prev {
+			if current.Deleted || !current.committed() {
+				continue
+			}
+			stats.Versions++
+			if current.Codec != CodecNone {
+				stats.Compressed++
+			}
+			stats.LogicalBytes += int64(current.Size)
+			stats.StoredBytes += int64(len(current.Value))
+		}
+		return true
+	})
+
+	result := make([]CompressionStats, len(policies))
+	for i, policy := range policies {
+		result[i] = *byPolicy[policy]
 	}
+	return result
 }
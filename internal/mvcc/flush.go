@@ -0,0 +1,105 @@
+package mvcc
+
+import (
+	"errors"
+)
+
+// ErrNoFlush is returned by Restore when asked to undo a flush that never happened
+var ErrNoFlush = errors.New("no flush to restore")
+
+// Keys returns every key with a version chain, including deleted ones
+func (e *Engine) Keys() []string {
+	return e.index.Keys()
+}
+
+// Flush tombstones every live key under a single version, so the whole database
+// can be read or restored as of the version before it. It returns the flush
+// version and how many keys it deleted (version 0 when there was nothing to flush).
+func (e *Engine) Flush() (uint64, int, error) {
+	var keys []string
+	e.Range(func(key string, _ []byte) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) == 0 {
+		return 0, 0, nil
+	}
+
+	version, err := e.UpdateMany(keys, func(current []Entry) ([]Entry, error) {
+		return make([]Entry, len(current)), nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	e.lastFlush.Store(version)
+	return version, len(keys), nil
+}
+
+// LastFlush returns the version of the most recent Flush (0 if none)
+func (e *Engine) LastFlush() uint64 {
+	return e.lastFlush.Load()
+}
+
+// Restore brings every key back to its state as of version under a single new
+// version. Keys unchanged since then are left untouched and keys created later
+// are deleted. A version of 0 undoes the most recent Flush. It returns the new
+// version and how many keys were rewritten.
+func (e *Engine) Restore(version uint64) (uint64, int, error) {
+	if version == 0 {
+		flushed := e.lastFlush.Load()
+		if flushed == 0 {
+			return 0, 0, ErrNoFlush
+		}
+		version = flushed - 1
+	}
+	if version > e.versionManager.CurrentVersion() {
+		return 0, 0, ErrVersionNotFound
+	}
+
+	keys := e.index.Keys()
+	targets := make([]Entry, len(keys))
+	// a key counts as it was at version, even if it expired since
+	at := e.timestampOf(version)
+	for i, key := range keys {
+		node := e.index.GetChain(key).At(version)
+		if !node.Live(at) {
+			continue
+		}
+		entry, err := node.entry()
+		if err != nil {
+			return 0, 0, err
+		}
+		targets[i] = entry
+	}
+
+	var restored int
+	newVersion, err := e.UpdateMany(keys, func(current []Entry) ([]Entry, error) {
+		next := make([]Entry, len(current))
+		restored = 0
+		for i := range current {
+			next[i] = targets[i]
+			if unchanged(current[i], targets[i]) {
+				next[i] = current[i]
+				continue
+			}
+			restored++
+		}
+		return next, nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return newVersion, restored, nil
+}
+
+// Drop discards every key with its whole history, change log included. The
+// version counter keeps running, so versions handed out before the drop are
+// never reused, and the drop takes a version of its own that is pruned with
+// the rest, so a reader of the log who was fully caught up still notices it.
+func (e *Engine) Drop() {
+	e.index.Clear()
+	e.keyspace.reset()
+	e.lastFlush.Store(0)
+	version, _ := e.versionManager.NextVersion()
+	e.changes.pruneBefore(version + 1)
+}
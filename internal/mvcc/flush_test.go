@@ -0,0 +1,84 @@
+package mvcc_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+// TestFlush_Restore verifies a flush is one version that Restore can undo
+func TestFlush_Restore(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("a", []byte("1"))
+	engine.Set("b", []byte("2"))
+
+	version, deleted, err := engine.Flush()
+	if err != nil || deleted != 2 {
+		t.Fatalf("Flush: deleted=%d err=%v", deleted, err)
+	}
+	for _, key := range []string{"a", "b"} {
+		if engine.Exists(key) {
+			t.Errorf("%s survived the flush", key)
+		}
+		history, _ := engine.History(key, 0)
+		if history[0].Version != version {
+			t.Errorf("%s tombstoned at %d, want %d", key, history[0].Version, version)
+		}
+	}
+
+	engine.Set("c", []byte("after"))
+	if _, restored, err := engine.Restore(0); err != nil || restored != 3 {
+		t.Fatalf("Restore: restored=%d err=%v", restored, err)
+	}
+	if value, _, _ := engine.Get("a"); string(value) != "1" {
+		t.Errorf("a = %q after restore", value)
+	}
+	if engine.Exists("c") {
+		t.Errorf("key written after the flush should be gone")
+	}
+}
+
+// TestRestore_JudgesKeysAtTheVersion verifies a key live at the restored
+// version comes back with its deadline even when it has expired since
+func TestRestore_JudgesKeysAtTheVersion(t *testing.T) {
+	engine := mvcc.NewEngine()
+	deadline := time.Now().Add(time.Minute)
+	engine.Set("a", []byte("1"))
+	engine.Update("k", func(mvcc.Entry) (mvcc.Entry, error) {
+		return mvcc.Entry{Value: []byte("v"), Exists: true, ExpiresAt: deadline.UnixNano()}, nil
+	})
+	engine.Flush()
+
+	late := engine.Clocked(func() time.Time { return deadline.Add(time.Minute) })
+	version, restored, err := late.Restore(0)
+	if err != nil || restored != 2 {
+		t.Fatalf("Restore: restored=%d err=%v, want both keys", restored, err)
+	}
+	history, _ := engine.History("k", 0)
+	if history[0].Version != version || history[0].Deleted {
+		t.Errorf("k history starts with %+v, want the restore at %d", history[0], version)
+	}
+	if late.Exists("k") {
+		t.Error("k is live past its restored deadline")
+	}
+}
+
+func TestDrop_DiscardsHistory(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("a", []byte("1"))
+	engine.Flush()
+	before := engine.CurrentVersion()
+
+	engine.Drop()
+	if _, err := engine.History("a", 0); !errors.Is(err, mvcc.ErrKeyNotFound) {
+		t.Errorf("history kept after drop: %v", err)
+	}
+	if _, _, err := engine.Restore(0); !errors.Is(err, mvcc.ErrNoFlush) {
+		t.Errorf("expected ErrNoFlush, got %v", err)
+	}
+	if engine.Set("a", []byte("2")) <= before {
+		t.Errorf("version reused after drop")
+	}
+}
@@ -8,6 +8,15 @@ import (
 // VersionChainHead wraps atomic pointer to the head of a version chain.
 type VersionChainHead struct {
 	head atomic.Pointer[VersionNode]
+
+	// lastAccess is the Unix second of the last read or write of the key
+	lastAccess atomic.Int64
+
+	// freq packs the LFU counter (low 8 bits) with the minute it was last decayed
+	freq atomic.Uint64
+
+	// counted is what the chain currently adds to the engine's keyspace counts
+	counted atomic.Uint32
 }
 
 // Load returns the current head of the version chain
@@ -15,6 +24,41 @@ func (vch *VersionChainHead) Load() *VersionNode {
 	return vch.head.Load()
 }
 
+// Visible returns the newest committed version, skipping nodes of batches that
+// are still in flight or were aborted
+func (vch *VersionChainHead) Visible() *VersionNode {
+	for node := vch.head.Load(); node != nil; node = node.Prev {
+		if node.committed() {
+			return node
+		}
+	}
+	return nil
+}
+
+// At returns the newest committed version at or below version. Batches that were
+// assigned such a version but are still in flight are waited for, so a snapshot
+// read never sees part of a batch.
+func (vch *VersionChainHead) At(version uint64) *VersionNode {
+	for node := vch.head.Load(); node != nil; node = node.Prev {
+		if node.Version <= version && node.settle() {
+			return node
+		}
+	}
+	return nil
+}
+
+// settled waits for in-flight batches on the chain and returns the raw head (to CAS
+// against) together with the newest committed version
+func (vch *VersionChainHead) settled() (head, visible *VersionNode) {
+	head = vch.head.Load()
+	for node := head; node != nil; node = node.Prev {
+		if node.settle() {
+			return head, node
+		}
+	}
+	return head, nil
+}
+
 // CompareAndSwap automically updates head if it matches expected
 func (vch *VersionChainHead) CompareAndSwap(expected, new *VersionNode) bool {
 	return vch.head.CompareAndSwap(expected, new)
@@ -58,6 +102,18 @@ func (idx *Index) Keys() []string {
 	return keys
 }
 
+// Range calls fn for every key and its version chain until fn returns false
+func (idx *Index) Range(fn func(key string, chain *VersionChainHead) bool) {
+	idx.data.Range(func(key, value any) bool {
+		return fn(key.(string), value.(*VersionChainHead))
+	})
+}
+
+// Clear removes every key
+func (idx *Index) Clear() {
+	idx.data.Clear()
+}
+
 // Count  returns approximate number of keys
 func (idx *Index) Count() int {
 	count := 0
@@ -0,0 +1,73 @@
+package mvcc
+
+import "time"
+
+// KeyInfo describes a key's version chain (for the KEYINFO and OBJECT commands)
+type KeyInfo struct {
+	// Exists is false when the newest version is a tombstone or has expired
+	Exists bool
+	// Type is the kind of value in the newest version
+	Type ValueType
+	// Codec is the compression format the newest version is stored in
+	Codec Codec
+
+	Versions   int
+	Tombstones int
+
+	FirstVersion   uint64
+	FirstTimestamp int64
+	LastVersion    uint64
+	LastTimestamp  int64
+
+	// HeadBytes and HistoryBytes are the bytes held in memory by the newest
+	// version and by all older ones
+	HeadBytes    int64
+	HistoryBytes int64
+
+	// LastAccess is the Unix second of the last read or write, falling back to
+	// the newest version's time for keys only written by batches
+	LastAccess int64
+	// Frequency is the logarithmic access counter
+	Frequency uint8
+}
+
+// KeyInfo reports metadata of a key's committed versions without counting as
+// an access to it
+func (e *Engine) KeyInfo(key string) (KeyInfo, error) {
+	chain := e.index.GetChain(key)
+	if chain == nil {
+		return KeyInfo{}, ErrKeyNotFound
+	}
+
+	var info KeyInfo
+	now := e.now()
+	for current := chain.Load(); current != nil; current = current.Prev {
+		if !current.committed() {
+			continue
+		}
+		if info.Versions == 0 {
+			info.Exists = current.Live(now)
+			info.Type = current.Type
+			info.Codec = current.Codec
+			info.LastVersion, info.LastTimestamp = current.Version, current.Timestamp
+			info.HeadBytes = int64(len(current.Value))
+		} else {
+			info.HistoryBytes += int64(len(current.Value))
+		}
+		info.Versions++
+		if current.Deleted {
+			info.Tombstones++
+		}
+		info.FirstVersion, info.FirstTimestamp = current.Version, current.Timestamp
+	}
+	if info.Versions == 0 {
+		return KeyInfo{}, ErrKeyNotFound
+	}
+
+	info.LastAccess = chain.LastAccess()
+	if info.LastAccess == 0 {
+		info.LastAccess = info.LastTimestamp / int64(time.Second)
+	}
+	info.Frequency = chain.Frequency(now)
+	return info, nil
+}
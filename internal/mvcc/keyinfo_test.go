@@ -0,0 +1,60 @@
+package mvcc_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+func TestKeyInfo_Counts(t *testing.T) {
+	engine := mvcc.NewEngine()
+	v1 := engine.Set("k", []byte("first"))
+	engine.Del("k")
+	v3 := engine.Set("k", []byte("abc"))
+
+	info, err := engine.KeyInfo("k")
+	if err != nil {
+		t.Fatalf("KeyInfo: %v", err)
+	}
+	if !info.Exists || info.Versions != 3 || info.Tombstones != 1 {
+		t.Errorf("exists=%v versions=%d tombstones=%d", info.Exists, info.Versions, info.Tombstones)
+	}
+	if info.FirstVersion != v1 || info.LastVersion != v3 {
+		t.Errorf("first=%d last=%d, want %d and %d", info.FirstVersion, info.LastVersion, v1, v3)
+	}
+	if info.HeadBytes != 3 || info.HistoryBytes != 5 {
+		t.Errorf("head=%d history=%d bytes, want 3 and 5", info.HeadBytes, info.HistoryBytes)
+	}
+	if info.LastAccess == 0 {
+		t.Errorf("write not recorded as access")
+	}
+
+	if _, err := engine.KeyInfo("missing"); !errors.Is(err, mvcc.ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+// TestKeyInfo_Frequency verifies reads raise the logarithmic counter and
+// KeyInfo itself does not
+func TestKeyInfo_Frequency(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("hot", []byte("v"))
+	engine.Set("cold", []byte("v"))
+
+	for range 10000 {
+		engine.Get("hot")
+	}
+	for range 100 {
+		engine.KeyInfo("cold")
+	}
+
+	hot, _ := engine.KeyInfo("hot")
+	cold, _ := engine.KeyInfo("cold")
+	if hot.Frequency <= cold.Frequency {
+		t.Errorf("hot freq %d not above cold freq %d", hot.Frequency, cold.Frequency)
+	}
+	if hot.Frequency == 255 {
+		t.Errorf("counter saturated after 10000 reads, it should grow logarithmically")
+	}
+}
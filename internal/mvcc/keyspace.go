@@ -0,0 +1,78 @@
+package mvcc
+
+import "sync/atomic"
+
+// what a chain adds to the keyspace counts
+const (
+	countedKey uint32 = 1 << iota
+	countedExpiry
+)
+
+// keyspace counts the keys whose newest version holds a value and how many of
+// them carry an expiry. Like Redis it still counts a key that expired until
+// the key is written again, so the counts only change when chains do.
+type keyspace struct {
+	keys    atomic.Int64
+	expires atomic.Int64
+}
+
+// KeyCounts returns how many keys hold a value and how many of those have an
+// expiry, without walking the chains
+func (e *Engine) KeyCounts() (keys, expires int64) {
+	return e.keyspace.keys.Load(), e.keyspace.expires.Load()
+}
+
+// countedAs returns what a chain whose newest committed version is node adds
+// to the counts
+func countedAs(node *VersionNode) uint32 {
+	if node == nil || node.Deleted {
+		return 0
+	}
+	if node.ExpiresAt != 0 {
+		return countedKey | countedExpiry
+	}
+	return countedKey
+}
+
+// logChanges records the keys written at version in the change log and brings
+// their counts up to date
+func (e *Engine) logChanges(version uint64, timestamp int64, changes ...Change) {
+	for _, change := range changes {
+		e.recount(change.Key)
+	}
+	e.changes.record(version, timestamp, changes...)
+}
+
+// recount moves key's part of the counts to what its chain holds now. When
+// writers race the one that swaps last checks the chain again, so the counts
+// end up matching the newest version.
+func (e *Engine) recount(key string) {
+	chain := e.index.GetChain(key)
+	if chain == nil {
+		return
+	}
+	for {
+		now := countedAs(chain.Visible())
+		before := chain.counted.Swap(now)
+		e.keyspace.add(now, 1)
+		e.keyspace.add(before, -1)
+		if countedAs(chain.Visible()) == now {
+			return
+		}
+	}
+}
+
+func (k *keyspace) add(counted uint32, delta int64) {
+	if counted&countedKey != 0 {
+		k.keys.Add(delta)
+	}
+	if counted&countedExpiry != 0 {
+		k.expires.Add(delta)
+	}
+}
+
+// reset zeroes the counts once every chain is gone
+func (k *keyspace) reset() {
+	k.keys.Store(0)
+	k.expires.Store(0)
+}
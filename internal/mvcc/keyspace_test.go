@@ -0,0 +1,118 @@
+package mvcc_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+// TestKeyCounts_FollowWrites verifies the key and expiry counts move with
+// every kind of write without walking the chains
+func TestKeyCounts_FollowWrites(t *testing.T) {
+	engine := mvcc.NewEngine()
+	expect := func(step string, keys, expires int64) {
+		t.Helper()
+		if k, e := engine.KeyCounts(); k != keys || e != expires {
+			t.Errorf("%s: counts = %d keys, %d expires, want %d, %d", step, k, e, keys, expires)
+		}
+	}
+	expire := func(key string) {
+		if _, err := engine.Update(key, func(current mvcc.Entry) (mvcc.Entry, error) {
+			current.ExpiresAt = time.Now().Add(time.Hour).UnixNano()
+			return current, nil
+		}); err != nil {
+			t.Fatalf("Update %s: %v", key, err)
+		}
+	}
+
+	engine.Set("a", []byte("1"))
+	engine.Set("a", []byte("2"))
+	engine.Set("b", []byte("1"))
+	expect("set", 2, 0)
+
+	expire("a")
+	expect("expire", 2, 1)
+
+	engine.Set("a", []byte("3"))
+	expect("overwrite drops the expiry", 2, 0)
+
+	if _, err := engine.Rename("b", "c", false); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	expect("rename", 2, 0)
+
+	if _, err := engine.Copy("c", "d", false, true); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	expect("copy", 3, 0)
+
+	batch := mvcc.NewBatch()
+	batch.Del("a")
+	batch.Set("e", []byte("1"))
+	if _, err := engine.Write(batch); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	expect("batch", 3, 0)
+
+	if _, err := engine.Write(mvcc.NewBatch().IfAbsent()); err != nil {
+		t.Fatalf("empty batch: %v", err)
+	}
+	conflict := mvcc.NewBatch()
+	conflict.Set("f", []byte("1"))
+	conflict.Set("e", []byte("2"))
+	if _, err := engine.Write(conflict.IfAbsent()); err == nil {
+		t.Fatal("conditional batch on a live key succeeded")
+	}
+	expect("refused batch", 3, 0)
+
+	engine.Del("c")
+	expect("del", 2, 0)
+
+	if _, _, err := engine.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	expect("flush", 0, 0)
+
+	if _, _, err := engine.Restore(0); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	expect("restore", 2, 0)
+
+	engine.Drop()
+	expect("drop", 0, 0)
+}
+
+// TestKeyCounts_ConcurrentWriters verifies racing writers leave the counts
+// matching the keys they wrote
+func TestKeyCounts_ConcurrentWriters(t *testing.T) {
+	engine := mvcc.NewEngine()
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				key := "k" + strconv.Itoa(i%20)
+				if (i+w)%3 == 0 {
+					engine.Del(key)
+				} else {
+					engine.Set(key, []byte("v"))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var live int64
+	for i := range 20 {
+		if engine.Exists("k" + strconv.Itoa(i)) {
+			live++
+		}
+	}
+	if keys, _ := engine.KeyCounts(); keys != live {
+		t.Errorf("keys = %d, want %d", keys, live)
+	}
+}
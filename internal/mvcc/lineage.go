@@ -0,0 +1,37 @@
+package mvcc
+
+// LineageOp says how a version relates to the key named in its Lineage
+type LineageOp uint8
+
+const (
+	// LineageRenamedTo marks the tombstone left on a key renamed away
+	LineageRenamedTo LineageOp = iota + 1
+	// LineageRenamedFrom marks the first version of a key under its new name
+	LineageRenamedFrom
+	// LineageCopiedFrom marks a version written by copying another key
+	LineageCopiedFrom
+)
+
+func (op LineageOp) String() string {
+	switch op {
+	case LineageRenamedTo:
+		return "renamed_to"
+	case LineageRenamedFrom:
+		return "renamed_from"
+	case LineageCopiedFrom:
+		return "copied_from"
+	default:
+		return "unknown"
+	}
+}
+
+// Lineage records the other key of a rename or copy, so audits can follow a
+// key's history across names
+type Lineage struct {
+	Op  LineageOp
+	Key string
+}
+
+func (l *Lineage) String() string {
+	return l.Op.String() + ":" + l.Key
+}
@@ -0,0 +1,203 @@
+package mvcc
+
+import "errors"
+
+// ErrKeyExists is returned by Attach when the key already has a live value
+var ErrKeyExists = errors.New("key exists")
+
+// Chain is a key's committed versions detached from an engine, newest first.
+// It carries the full history of a key moved between databases.
+type Chain struct {
+	nodes []*VersionNode
+}
+
+// Len returns the number of versions in the chain
+func (c *Chain) Len() int {
+	return len(c.nodes)
+}
+
+// committedNodes returns the committed nodes reachable from head, newest first
+func committedNodes(head *VersionNode) []*VersionNode {
+	var nodes []*VersionNode
+	for current := head; current != nil; current = current.Prev {
+		if current.committed() {
+			nodes = append(nodes, current)
+		}
+	}
+	return nodes
+}
+
+// adoptHistory returns the head of a chain built from copies of history, a
+// key's versions newest first, taken over by another key at a version (nodes
+// are immutable). Copies of batch nodes share the batch's commit state, so
+// they stay pending until it resolves.
+func adoptHistory(history []*VersionNode, at uint64) *VersionNode {
+	var prev *VersionNode
+	for i := len(history) - 1; i >= 0; i-- {
+		node := *history[i]
+		node.mergedAt = at
+		node.Prev = prev
+		prev = &node
+	}
+	return prev
+}
+
+// Detach atomically removes key together with its whole history. The removal
+// takes a version of its own so the change log shows the key leaving. ok is
+// false when the key has no live value, in which case nothing is removed.
+func (e *Engine) Detach(key string) (*Chain, bool) {
+	chain := e.index.GetChain(key)
+	if chain == nil {
+		return nil, false
+	}
+
+	for {
+		head, visible := chain.settled()
+		if !visible.Live(e.now()) {
+			return nil, false
+		}
+		if chain.CompareAndSwap(head, nil) {
+			version, timestamp := e.versionManager.NextVersion()
+			e.logChanges(version, timestamp, Change{Key: key, Op: "moved", History: true})
+			e.watchers.notify(key)
+			return &Chain{nodes: committedNodes(head)}, true
+		}
+	}
+}
+
+// Attach installs a detached chain under key. The moved versions keep their
+// numbers and timestamps and replace whatever dead history the key has here,
+// the counter is advanced past them, and the move itself is recorded as a new
+// version holding the moved value. It fails with ErrKeyExists when the key has
+// a live value.
+func (e *Engine) Attach(key string, c *Chain) (uint64, error) {
+	if c.Len() == 0 {
+		return 0, ErrKeyNotFound
+	}
+	e.versionManager.AdvanceTo(c.nodes[0].Version)
+	chain := e.index.GetChain(key)
+
+	for {
+		var head, visible *VersionNode
+		if chain != nil {
+			head, visible = chain.settled()
+		}
+		if visible.Live(e.now()) {
+			return 0, ErrKeyExists
+		}
+
+		version, timestamp := e.versionManager.NextVersion()
+		prev := adoptHistory(c.nodes, version)
+		top := *c.nodes[0]
+		top.Version, top.Timestamp = version, timestamp
+		top.commit = nil
+		top.Lineage = nil
+		top.Author = e.author
+		top.Comment = e.comment
+		top.Prev = prev
+
+		if chain == nil {
+			chain = e.index.GetOrCreateChain(key)
+		}
+		if chain.CompareAndSwap(head, &top) {
+			change := changeOf(key, &top)
+			change.History = true
+			e.logChanges(version, timestamp, change)
+			e.watchers.notify(key)
+			return version, nil
+		}
+	}
+}
+
+// Move transfers key with its whole history to target in one step, the way
+// Detach and Attach would but without a moment where the key is in neither
+// engine. The new head is linked into target while pending, so writers of the
+// key there wait, and only published once the key left this engine; if either
+// side changed meanwhile it is unlinked and the move retried. The moved history
+// replaces any dead history the key has in target. It fails with
+// ErrKeyNotFound when the key has no live value here and with ErrKeyExists
+// when it has one in target.
+func (e *Engine) Move(key string, target *Engine) (uint64, error) {
+	src := e.index.GetChain(key)
+	if src == nil {
+		return 0, ErrKeyNotFound
+	}
+
+	dst := target.index.GetChain(key)
+	for {
+		srcHead, visible := src.settled()
+		if !visible.Live(e.now()) {
+			return 0, ErrKeyNotFound
+		}
+		var dstHead, dstVisible *VersionNode
+		if dst != nil {
+			dstHead, dstVisible = dst.settled()
+		}
+		if dstVisible.Live(target.now()) {
+			return 0, ErrKeyExists
+		}
+
+		history := committedNodes(srcHead)
+		target.versionManager.AdvanceTo(history[0].Version)
+		version, timestamp := target.versionManager.NextVersion()
+		commit := newCommitState()
+		top := *visible
+		top.Version, top.Timestamp = version, timestamp
+		top.commit = commit
+		top.Lineage = nil
+		top.Author = target.author
+		top.Comment = target.comment
+		top.Prev = demote(dstHead)
+
+		if dst == nil {
+			dst = target.index.GetOrCreateChain(key)
+		}
+		if !dst.CompareAndSwap(dstHead, &top) {
+			commit.resolve(false)
+			continue
+		}
+		if !src.CompareAndSwap(srcHead, nil) {
+			commit.resolve(false)
+			unlink([]linkedNode{{key, dst, &top}})
+			continue
+		}
+		graft(dst, &top, history)
+		commit.resolve(true)
+
+		left, at := e.versionManager.NextVersion()
+		e.logChanges(left, at, Change{Key: key, Op: "moved", History: true})
+		e.watchers.notify(key)
+		arrived := changeOf(key, &top)
+		arrived.History = true
+		target.logChanges(version, timestamp, arrived)
+		target.watchers.notify(key)
+		return version, nil
+	}
+}
+
+// Records returns the chain's versions for shipping to another engine, newest first
+func (c *Chain) Records() ([]Record, error) {
+	records := make([]Record, len(c.nodes))
+	for i, node := range c.nodes {
+		record, err := recordOf(node)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+// Import installs the history of a key migrated from another engine, newest
+// first, the way Attach installs a detached chain. The imported versions keep
+// the numbers the other engine's counter gave them, which this engine's
+// counter then moves past, so they order the key's history but not writes
+// across both engines.
+func (e *Engine) Import(key string, records []Record) (uint64, error) {
+	policy := e.config.GetCompressionForKey(key)
+	nodes := make([]*VersionNode, len(records))
+	for i, r := range records {
+		nodes[i] = e.restoreNode(policy, r)
+	}
+	return e.Attach(key, &Chain{nodes: nodes})
+}
@@ -0,0 +1,150 @@
+package mvcc_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+// TestDetachAttach_MovesHistory verifies a moved key keeps its versions and
+// the destination counter never hands them out again
+func TestDetachAttach_MovesHistory(t *testing.T) {
+	src, dst := mvcc.NewEngine(), mvcc.NewEngine()
+	v1 := src.Set("k", []byte("a"))
+	src.Set("k", []byte("b"))
+	src.Set("k", []byte("c"))
+
+	chain, ok := src.Detach("k")
+	if !ok || chain.Len() != 3 {
+		t.Fatalf("Detach: ok=%v", ok)
+	}
+	if src.Exists("k") {
+		t.Errorf("key still exists in source")
+	}
+	if history, _ := src.History("k", 0); len(history) != 0 {
+		t.Errorf("source kept %d versions", len(history))
+	}
+
+	version, err := dst.Attach("k", chain)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if version <= 3 {
+		t.Errorf("move version %d reuses a moved version", version)
+	}
+	if value, _, _ := dst.Get("k"); string(value) != "c" {
+		t.Errorf("Get = %q, want c", value)
+	}
+	if value, err := dst.GetAtVersion("k", v1); err != nil || string(value) != "a" {
+		t.Errorf("GetAtVersion(%d) = %q, %v", v1, value, err)
+	}
+	if history, _ := dst.History("k", 0); len(history) != 4 {
+		t.Errorf("expected 3 moved versions plus the move, got %d", len(history))
+	}
+}
+
+func TestAttach_LiveKeyExists(t *testing.T) {
+	src, dst := mvcc.NewEngine(), mvcc.NewEngine()
+	src.Set("k", []byte("moved"))
+	dst.Set("k", []byte("here"))
+
+	chain, _ := src.Detach("k")
+	if _, err := dst.Attach("k", chain); !errors.Is(err, mvcc.ErrKeyExists) {
+		t.Fatalf("expected ErrKeyExists, got %v", err)
+	}
+	if value, _, _ := dst.Get("k"); string(value) != "here" {
+		t.Errorf("destination overwritten: %q", value)
+	}
+
+	if _, ok := src.Detach("missing"); ok {
+		t.Errorf("Detach of a missing key should fail")
+	}
+}
+
+// TestImport_ShipsHistoryBetweenCounters verifies a key shipped as records
+// keeps its versions and timestamps on an engine with its own counter
+// TestMove_KeepsHistoryWhenRefused verifies a move refused by a live key in
+// the target leaves the source untouched, and that racing writes to the target
+// key never lose the moved history
+func TestMove_KeepsHistoryWhenRefused(t *testing.T) {
+	src, dst := mvcc.NewEngine(), mvcc.NewEngine()
+	src.Set("k", []byte("a"))
+	src.Set("k", []byte("b"))
+	dst.Set("k", []byte("here"))
+
+	if _, err := src.Move("k", dst); !errors.Is(err, mvcc.ErrKeyExists) {
+		t.Fatalf("Move onto a live key: %v, want ErrKeyExists", err)
+	}
+	if history, _ := src.History("k", 0); len(history) != 2 {
+		t.Errorf("source kept %d versions after a refused move, want 2", len(history))
+	}
+	if _, err := mvcc.NewEngine().Move("k", dst); !errors.Is(err, mvcc.ErrKeyNotFound) {
+		t.Errorf("Move of a missing key: %v, want ErrKeyNotFound", err)
+	}
+
+	dst.Del("k")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		dst.Set("k", []byte("racer"))
+	}()
+	_, err := src.Move("k", dst)
+	wg.Wait()
+
+	inSource, _ := src.History("k", 0)
+	switch {
+	case err == nil:
+		if len(inSource) != 0 {
+			t.Errorf("moved key left %d versions in the source", len(inSource))
+		}
+		history, _ := dst.History("k", 0)
+		// the racer may have landed on top of the move
+		if len(history) < 3 {
+			t.Errorf("target holds %d versions, want the moved 2 and the move", len(history))
+		}
+		for _, info := range history {
+			if value, _ := dst.GetAtVersion("k", info.Version); string(value) == "here" {
+				t.Errorf("target kept its own version %d below the moved history", info.Version)
+			}
+		}
+	case errors.Is(err, mvcc.ErrKeyExists):
+		if len(inSource) != 2 {
+			t.Errorf("refused move left %d versions in the source, want 2", len(inSource))
+		}
+	default:
+		t.Fatalf("Move: %v", err)
+	}
+}
+
+func TestImport_ShipsHistoryBetweenCounters(t *testing.T) {
+	src, dst := mvcc.NewEngine(), mvcc.NewEngine()
+	for range 10 {
+		src.Set("other", []byte("x"))
+	}
+	v1 := src.Set("k", []byte("a"))
+	src.Set("k", []byte("b"))
+	dst.Set("local", []byte("y"))
+
+	chain, _ := src.Detach("k")
+	records, err := chain.Records()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Records: %d records, %v", len(records), err)
+	}
+	version, err := dst.Import("k", records)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if version <= records[0].Version {
+		t.Errorf("import version %d not past the imported %d", version, records[0].Version)
+	}
+	if value, err := dst.GetAtVersion("k", v1); err != nil || string(value) != "a" {
+		t.Errorf("GetAtVersion(%d) = %q, %v", v1, value, err)
+	}
+	history, _ := dst.History("k", 0)
+	if len(history) != 3 || history[2].Timestamp != records[1].Timestamp {
+		t.Errorf("history = %+v, want the 2 imported versions with their timestamps plus the import", history)
+	}
+}
@@ -0,0 +1,194 @@
+package mvcc
+
+import (
+	"strings"
+)
+
+// Rename gives the value of src to dst together with its history. The old key
+// gets a tombstone recording the new name and dst's first version records the
+// old one, both under the same version and published at once. src keeps its
+// chain below the tombstone, so reads of its past still work. With nx the
+// rename fails with ErrKeyExists when dst has a live value, otherwise dst is
+// overwritten and its own history replaced by the moved one: reads of dst at
+// any earlier version see what src held then.
+func (e *Engine) Rename(src, dst string, nx bool) (uint64, error) {
+	srcChain := e.index.GetChain(src)
+	if srcChain == nil {
+		return 0, ErrKeyNotFound
+	}
+	if src == dst {
+		visible := srcChain.Visible()
+		switch {
+		case !visible.Live(e.now()):
+			return 0, ErrKeyNotFound
+		case nx:
+			return 0, ErrKeyExists
+		default:
+			return visible.Version, nil
+		}
+	}
+
+	dstChain := e.index.GetChain(dst)
+	srcPolicy := e.config.GetCompressionForKey(src)
+	for {
+		now := e.now()
+		srcHead, visible := srcChain.settled()
+		if !visible.Live(now) {
+			return 0, ErrKeyNotFound
+		}
+		var dstHead, dstVisible *VersionNode
+		if dstChain != nil {
+			dstHead, dstVisible = dstChain.settled()
+		}
+		if nx && dstVisible.Live(now) {
+			return 0, ErrKeyExists
+		}
+
+		version, timestamp := e.versionManager.NextVersion()
+		commit := newCommitState()
+
+		tombstone := e.newNode(srcPolicy, version, timestamp, nil, true)
+		tombstone.Lineage = &Lineage{Op: LineageRenamedTo, Key: dst}
+		tombstone.commit = commit
+		tombstone.Prev = demote(srcHead)
+
+		moved := *visible
+		moved.Version, moved.Timestamp = version, timestamp
+		moved.Lineage = &Lineage{Op: LineageRenamedFrom, Key: src}
+		moved.Author = e.author
+		moved.Comment = e.comment
+		moved.commit = commit
+		moved.Prev = demote(dstHead)
+		if dstChain == nil {
+			// dst's chain is only created once the rename is about to commit
+			dstChain = e.index.GetOrCreateChain(dst)
+		}
+
+		// both nodes keep the old heads below them, so aborting leaves no trace
+		pending := []linkedNode{{src, srcChain, tombstone}, {dst, dstChain, &moved}}
+		expected := []*VersionNode{srcHead, dstHead}
+		if strings.Compare(dst, src) < 0 {
+			pending[0], pending[1] = pending[1], pending[0]
+			expected[0], expected[1] = expected[1], expected[0]
+		}
+		linked := pending[:0:0]
+		for i, l := range pending {
+			if !l.chain.CompareAndSwap(expected[i], l.node) {
+				break
+			}
+			linked = append(linked, l)
+		}
+
+		if len(linked) < len(pending) {
+			commit.resolve(false)
+			unlink(linked)
+			continue
+		}
+		// graft while the batch is pending, so a writer waiting on dst sees the moved history
+		graft(dstChain, &moved, committedNodes(srcHead))
+		commit.resolve(true)
+		renamed := changeOf(dst, &moved)
+		renamed.History = true
+		e.logChanges(version, timestamp, changeOf(src, tombstone), renamed)
+		e.watchers.notify(src)
+		e.watchers.notify(dst)
+		return version, nil
+	}
+}
+
+// Copy writes the current value of src to dst as a new version recording where
+// it came from, on top of dst's own history. withHistory instead gives dst a copy
+// of src's history in place of its own. Without replace it fails with
+// ErrKeyExists when dst has a live value.
+func (e *Engine) Copy(src, dst string, replace, withHistory bool) (uint64, error) {
+	srcChain := e.index.GetChain(src)
+	if srcChain == nil {
+		return 0, ErrKeyNotFound
+	}
+
+	dstChain := e.index.GetChain(dst)
+	var version uint64
+	var timestamp int64
+	for {
+		var dstHead, dstVisible *VersionNode
+		if dstChain != nil {
+			dstHead, dstVisible = dstChain.settled()
+		}
+		if !replace && dstVisible.Live(e.now()) {
+			return 0, ErrKeyExists
+		}
+
+		if version == 0 || (dstHead != nil && dstHead.Version > version) {
+			version, timestamp = e.versionManager.NextVersion()
+		}
+
+		// src is read after the version is taken, so the copied history holds
+		// every version of src older than the copy
+		srcHead, visible := srcChain.settled()
+		if !visible.Live(e.now()) {
+			return 0, ErrKeyNotFound
+		}
+		if srcHead.Version > version {
+			version = 0
+			continue
+		}
+
+		copied := *visible
+		copied.Version, copied.Timestamp = version, timestamp
+		copied.Lineage = &Lineage{Op: LineageCopiedFrom, Key: src}
+		copied.Author = e.author
+		copied.Comment = e.comment
+		copied.commit = nil
+		if withHistory {
+			copied.Prev = adoptHistory(committedNodes(srcHead), version)
+		} else {
+			copied.Prev = demote(dstHead)
+		}
+
+		if dstChain == nil {
+			dstChain = e.index.GetOrCreateChain(dst)
+		}
+		if dstChain.CompareAndSwap(dstHead, &copied) {
+			change := changeOf(dst, &copied)
+			change.History = withHistory
+			e.logChanges(version, timestamp, change)
+			e.watchers.notify(dst)
+			return version, nil
+		}
+	}
+}
+
+// graft places history below node in chain, in place of what was below it.
+// Nodes linked above node since it was published are copied. Nothing happens
+// if node is no longer in the chain.
+func graft(chain *VersionChainHead, node *VersionNode, history []*VersionNode) {
+	for {
+		head := chain.Load()
+		grafted, ok := replaceBelow(head, node, history)
+		if !ok || chain.CompareAndSwap(head, grafted) {
+			return
+		}
+	}
+}
+
+// replaceBelow returns a copy of the chain from current down to node with
+// history below node. Nodes are matched by batch and version, since link
+// may have replaced node with a copy.
+func replaceBelow(current, node *VersionNode, history []*VersionNode) (*VersionNode, bool) {
+	if current == nil || current.Version < node.Version {
+		return nil, false
+	}
+
+	copied := *current
+	if current.commit == node.commit && current.Version == node.Version {
+		copied.Prev = adoptHistory(history, node.Version)
+		return &copied, true
+	}
+
+	prev, ok := replaceBelow(current.Prev, node, history)
+	if !ok {
+		return nil, false
+	}
+	copied.Prev = prev
+	return &copied, true
+}
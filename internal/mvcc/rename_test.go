@@ -0,0 +1,102 @@
+package mvcc_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+// TestRename_MovesHistory verifies the new key carries the old history and
+// both keys record the rename under the same version
+func TestRename_MovesHistory(t *testing.T) {
+	engine := mvcc.NewEngine()
+	v1 := engine.Set("old", []byte("a"))
+	engine.Set("old", []byte("b"))
+
+	version, err := engine.Rename("old", "new", false)
+	if err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if engine.Exists("old") {
+		t.Errorf("old key still exists")
+	}
+	if value, err := engine.GetAtVersion("new", v1); err != nil || string(value) != "a" {
+		t.Errorf("GetAtVersion(new, %d) = %q, %v", v1, value, err)
+	}
+
+	oldHistory, _ := engine.History("old", 0)
+	newHistory, _ := engine.History("new", 0)
+	if len(newHistory) != 3 {
+		t.Errorf("new key has %d versions, want 3", len(newHistory))
+	}
+	tombstone, head := oldHistory[0], newHistory[0]
+	if tombstone.Version != version || head.Version != version {
+		t.Errorf("rename versions %d and %d, want %d", tombstone.Version, head.Version, version)
+	}
+	if tombstone.Lineage.String() != "renamed_to:new" || head.Lineage.String() != "renamed_from:old" {
+		t.Errorf("lineage = %v and %v", tombstone.Lineage, head.Lineage)
+	}
+
+	if _, err := engine.Rename("missing", "x", false); !errors.Is(err, mvcc.ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	engine.Set("taken", []byte("1"))
+	if _, err := engine.Rename("new", "taken", true); !errors.Is(err, mvcc.ErrKeyExists) {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+}
+
+// TestRename_Concurrent renames a value back and forth between two keys from
+// both directions and verifies exactly one of them holds it afterwards
+func TestRename_Concurrent(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("a", []byte("v"))
+
+	var wg sync.WaitGroup
+	for _, pair := range [][2]string{{"a", "b"}, {"b", "a"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				_, err := engine.Rename(pair[0], pair[1], false)
+				if err != nil && !errors.Is(err, mvcc.ErrKeyNotFound) {
+					t.Errorf("Rename: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, _ := engine.GetMany([]string{"a", "b"})
+	if entries[0].Exists == entries[1].Exists {
+		t.Errorf("a exists=%v b exists=%v, want exactly one", entries[0].Exists, entries[1].Exists)
+	}
+}
+
+func TestCopy_WithHistory(t *testing.T) {
+	engine := mvcc.NewEngine()
+	v1 := engine.Set("src", []byte("a"))
+	engine.Set("src", []byte("b"))
+
+	if _, err := engine.Copy("src", "plain", false, false); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	if history, _ := engine.History("plain", 0); len(history) != 1 {
+		t.Errorf("plain copy has %d versions, want 1", len(history))
+	}
+	if _, err := engine.Copy("src", "plain", false, false); !errors.Is(err, mvcc.ErrKeyExists) {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+
+	if _, err := engine.Copy("src", "full", false, true); err != nil {
+		t.Fatalf("Copy WITHHISTORY: %v", err)
+	}
+	if value, err := engine.GetAtVersion("full", v1); err != nil || string(value) != "a" {
+		t.Errorf("GetAtVersion(full, %d) = %q, %v", v1, value, err)
+	}
+	if value, _, _ := engine.Get("src"); string(value) != "b" {
+		t.Errorf("source changed by copy: %q", value)
+	}
+}
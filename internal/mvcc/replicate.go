@@ -0,0 +1,256 @@
+package mvcc
+
+import "time"
+
+// Record is a committed version of a key with its value decompressed, in a
+// form that can be shipped to another engine
+type Record struct {
+	Version   uint64
+	Timestamp int64
+	Value     []byte
+	Type      ValueType
+	Deleted   bool
+	ExpiresAt int64
+	Author    *Author
+	Comment   string
+	Lineage   *Lineage
+	// MergedAt is the version that merged the record into the key's history
+	// from another key (0 when it was written to the key itself)
+	MergedAt uint64
+}
+
+// Mutation is what one key went through at a version, as replayed by a replica
+type Mutation struct {
+	Key string
+	// Op is the change log operation of the write
+	Op string
+	// Replace is set when the write rewrote the key's history. Records then
+	// holds the key's whole chain, newest first (empty when the key was
+	// removed), otherwise the single version to add.
+	Replace bool
+	Records []Record
+	// From names the key a rename or a copy with history merged the history
+	// of below the added version. The replica holds the same history and
+	// merges its own copy rather than receiving the whole chain.
+	From string
+}
+
+// recordOf decodes a node for shipping
+func recordOf(node *VersionNode) (Record, error) {
+	value, err := node.Data()
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{
+		Version:   node.Version,
+		Timestamp: node.Timestamp,
+		Value:     value,
+		Type:      node.Type,
+		Deleted:   node.Deleted,
+		ExpiresAt: node.ExpiresAt,
+		Author:    node.Author,
+		Comment:   node.Comment,
+		Lineage:   node.Lineage,
+		MergedAt:  node.mergedAt,
+	}, nil
+}
+
+// SyncPoint waits until every version taken so far is either recorded in the
+// change log or abandoned and returns the newest one. Records taken at it
+// form a consistent snapshot and Changes streams on from it without a gap.
+func (e *Engine) SyncPoint() (uint64, error) {
+	target := e.versionManager.CurrentVersion()
+	since := e.changes.oldest() - 1
+	for {
+		cursor, err := e.Changes(since, target, 0, func(uint64, []Change) bool { return true })
+		if err != nil {
+			return 0, err
+		}
+		if cursor >= target {
+			return target, nil
+		}
+		since = cursor
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Records returns the committed versions the key held at version, newest
+// first. History merged into the key by a later rename, copy or move is left
+// out, so records taken while such writes go on still match the version.
+func (e *Engine) Records(key string, version uint64) ([]Record, error) {
+	chain := e.index.GetChain(key)
+	if chain == nil {
+		return nil, nil
+	}
+	var records []Record
+	for current := chain.Load(); current != nil; current = current.Prev {
+		if !current.heldAt(version) || !current.committed() {
+			continue
+		}
+		record, err := recordOf(current)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Export returns the mutation a change made, for a replica to apply. Writes
+// that merged another key's history name that key, other history rewrites
+// carry the key's chain up to the change. ok is false when the version is no
+// longer in the key's chain (the key was dropped).
+func (e *Engine) Export(change Change) (m Mutation, ok bool, err error) {
+	m = Mutation{Key: change.Key, Op: change.Op}
+	var node *VersionNode
+	if chain := e.index.GetChain(change.Key); chain != nil {
+		for current := chain.Load(); current != nil && current.Version >= change.Version; current = current.Prev {
+			if current.Version == change.Version && current.committed() {
+				node = current
+				break
+			}
+		}
+	}
+
+	if change.History && (node == nil || !mergedHistory(node)) {
+		m.Replace = true
+		m.Records, err = e.Records(change.Key, change.Version)
+		return m, err == nil, err
+	}
+	if node == nil {
+		return m, false, nil
+	}
+	if change.History {
+		m.From = node.Lineage.Key
+	}
+	record, err := recordOf(node)
+	if err != nil {
+		return m, false, err
+	}
+	m.Records = []Record{record}
+	return m, true, nil
+}
+
+// mergedHistory reports whether node was written by a rename or copy that
+// merged the other key's history below it
+func mergedHistory(node *VersionNode) bool {
+	return node.Lineage != nil && (node.Lineage.Op == LineageRenamedFrom || node.Lineage.Op == LineageCopiedFrom)
+}
+
+// Load installs a key's history taken from another engine's snapshot. It is
+// meant for filling a fresh engine and records no changes.
+func (e *Engine) Load(key string, records []Record) {
+	if len(records) == 0 {
+		return
+	}
+	for _, r := range records {
+		e.versionManager.Adopt(r.Version, r.Timestamp)
+	}
+	e.index.GetOrCreateChain(key).head.Store(e.chainOf(key, records))
+	e.recount(key)
+}
+
+// SetBase marks a loaded engine as holding everything up to version: the
+// counter continues after it and the change log starts there
+func (e *Engine) SetBase(version uint64) {
+	e.versionManager.AdvanceTo(version)
+	e.changes.pruneBefore(version + 1)
+}
+
+// Reserve makes the engine's next write take version and timestamp, so
+// engines fed the same writes through a consensus log agree on both. The
+// versions skipped over stay unused.
+func (e *Engine) Reserve(version uint64, timestamp int64) {
+	e.versionManager.Reserve(version, timestamp)
+}
+
+// Apply replays the mutations another engine committed at version, keeping
+// their version numbers, timestamps, authors and lineage, so the histories of
+// both engines stay identical. Added versions are published together.
+func (e *Engine) Apply(version uint64, timestamp int64, mutations []Mutation) {
+	e.versionManager.Adopt(version, timestamp)
+
+	commit := newCommitState()
+	added := make([]*VersionNode, len(mutations))
+	for i, m := range mutations {
+		if m.Replace || len(m.Records) == 0 {
+			continue
+		}
+		policy := e.config.GetCompressionForKey(m.Key)
+		added[i] = e.restoreNode(policy, m.Records[0])
+		added[i].commit = commit
+		e.prepend(e.index.GetOrCreateChain(m.Key), added[i])
+	}
+	commit.resolve(true)
+
+	changes := make([]Change, len(mutations))
+	for i, m := range mutations {
+		chain := e.index.GetOrCreateChain(m.Key)
+		switch {
+		case m.Replace:
+			chain.head.Store(e.chainOf(m.Key, m.Records))
+		case m.From != "" && added[i] != nil:
+			graft(chain, added[i], e.historyBefore(m.From, version))
+		}
+		changes[i] = Change{Key: m.Key, Op: m.Op, History: m.Replace || m.From != ""}
+		if len(m.Records) > 0 {
+			changes[i].Size = len(m.Records[0].Value)
+		}
+	}
+	e.logChanges(version, timestamp, changes...)
+	for _, m := range mutations {
+		e.watchers.notify(m.Key)
+	}
+}
+
+// historyBefore returns the committed versions key held just before version, newest first
+func (e *Engine) historyBefore(key string, version uint64) []*VersionNode {
+	chain := e.index.GetChain(key)
+	if chain == nil {
+		return nil
+	}
+	var nodes []*VersionNode
+	for _, node := range committedNodes(chain.Load()) {
+		if node.heldAt(version - 1) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// heldAt reports whether the node was part of its key's history at version
+func (vn *VersionNode) heldAt(version uint64) bool {
+	return vn.Version <= version && vn.mergedAt <= version
+}
+
+// chainOf builds a chain from records, newest first
+func (e *Engine) chainOf(key string, records []Record) *VersionNode {
+	policy := e.config.GetCompressionForKey(key)
+	var prev *VersionNode
+	for i := len(records) - 1; i >= 0; i-- {
+		node := e.restoreNode(policy, records[i])
+		node.Prev = demote(prev)
+		prev = node
+	}
+	return prev
+}
+
+// restoreNode builds the node of a shipped record, compressed by this engine's policy
+func (e *Engine) restoreNode(policy *CompressionPolicy, r Record) *VersionNode {
+	e.noteExpiry(r.ExpiresAt)
+	node := &VersionNode{
+		Version:   r.Version,
+		Timestamp: r.Timestamp,
+		Value:     r.Value,
+		Type:      r.Type,
+		Size:      len(r.Value),
+		Deleted:   r.Deleted,
+		ExpiresAt: r.ExpiresAt,
+		Author:    r.Author,
+		Comment:   r.Comment,
+		Lineage:   r.Lineage,
+		mergedAt:  r.MergedAt,
+	}
+	pack(policy, node)
+	return node
+}
@@ -0,0 +1,129 @@
+package mvcc_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+// replay applies every change the leader logged after since to the replica,
+// the way a replication stream does
+func replay(t *testing.T, leader, replica *mvcc.Engine, since uint64) {
+	t.Helper()
+	_, err := leader.Changes(since, math.MaxUint64, 0, func(version uint64, changes []mvcc.Change) bool {
+		var mutations []mvcc.Mutation
+		for _, change := range changes {
+			m, ok, err := leader.Export(change)
+			if err != nil {
+				t.Fatalf("Export(%q at %d): %v", change.Key, version, err)
+			}
+			if ok {
+				mutations = append(mutations, m)
+			}
+		}
+		replica.Apply(version, changes[0].Timestamp, mutations)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+}
+
+func assertSameHistory(t *testing.T, leader, replica *mvcc.Engine, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		want, _ := leader.History(key, 0)
+		got, _ := replica.History(key, 0)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: replica history\n%+v\nwant\n%+v", key, got, want)
+		}
+	}
+	if got, want := replica.CurrentVersion(), leader.CurrentVersion(); got != want {
+		t.Errorf("replica at version %d, want %d", got, want)
+	}
+}
+
+// TestApply_ReplaysIdenticalHistory verifies a replica fed from the change log
+// ends up with the leader's histories, versions, timestamps and lineage
+func TestApply_ReplaysIdenticalHistory(t *testing.T) {
+	leader, replica := mvcc.NewEngine(), mvcc.NewEngine()
+	leader.Set("a", []byte("1"))
+	leader.Set("b", []byte("2"))
+	batch := mvcc.NewBatch()
+	batch.Set("a", []byte("3"))
+	batch.Set("c", []byte("4"))
+	if _, err := leader.Write(batch); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := leader.Rename("a", "b", false); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if _, err := leader.Copy("b", "d", false, true); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	leader.Del("c")
+	chain, ok := leader.Detach("d")
+	if !ok {
+		t.Fatal("Detach found no live value")
+	}
+	if _, err := leader.Attach("e", chain); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+
+	replay(t, leader, replica, 0)
+	assertSameHistory(t, leader, replica, "a", "b", "c", "d", "e")
+}
+
+// TestRecords_LeavesOutLaterMerges verifies a snapshot taken while a rename
+// merges history into a key leaves out what the rename brought, so streaming
+// the rename afterwards does not merge it twice
+func TestRecords_LeavesOutLaterMerges(t *testing.T) {
+	leader, replica := mvcc.NewEngine(), mvcc.NewEngine()
+	leader.Set("src", []byte("1"))
+	leader.Set("dst", []byte("2"))
+	base := leader.CurrentVersion()
+	if _, err := leader.Rename("src", "dst", false); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+
+	records, err := leader.Records("dst", base)
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	for _, record := range records {
+		if string(record.Value) == "1" {
+			t.Fatalf("Records(dst, %d) = %+v, want no version the rename brought", base, records)
+		}
+	}
+
+	for _, key := range []string{"src", "dst"} {
+		records, err := leader.Records(key, base)
+		if err != nil {
+			t.Fatalf("Records(%s): %v", key, err)
+		}
+		replica.Load(key, records)
+	}
+	replica.SetBase(base)
+	replay(t, leader, replica, base)
+	assertSameHistory(t, leader, replica, "src", "dst")
+}
+
+// TestReserve_StampsNextVersion verifies writes after a reservation get the
+// reserved version and timestamp, the way a raft entry is applied
+func TestReserve_StampsNextVersion(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("a", []byte("1"))
+	engine.Reserve(10, 12345)
+	engine.Set("a", []byte("2"))
+	engine.Set("b", []byte("3"))
+
+	history, _ := engine.History("a", 0)
+	if len(history) != 2 || history[0].Version != 10 || history[0].Timestamp != 12345 {
+		t.Fatalf("history of a = %+v, want the newest at version 10 stamped 12345", history)
+	}
+	if got := engine.CurrentVersion(); got != 11 {
+		t.Errorf("current version %d, want 11", got)
+	}
+}
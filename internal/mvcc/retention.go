@@ -0,0 +1,41 @@
+package mvcc
+
+// retain returns the history to link below a new version of key. Every version
+// of a collection is a full copy of it, so a collection's history is cut back
+// to the key's version limit instead of growing with every write. The cut lets
+// the history run a quarter past the limit first, so the copy it takes is paid
+// once every few writes rather than on each one.
+func (e *Engine) retain(key string, typ ValueType, prev *VersionNode) *VersionNode {
+	if typ == TypeString || prev == nil {
+		return prev
+	}
+	limit := e.config.GetMaxVersionsForKey(key)
+	if limit <= 0 {
+		return prev
+	}
+
+	// the new version counts toward the limit
+	keep := limit - 1
+	depth := 0
+	for node := prev; node != nil && depth <= keep+keep/4; node = node.Prev {
+		depth++
+	}
+	if depth <= keep+keep/4 {
+		return prev
+	}
+	return truncate(prev, keep)
+}
+
+// truncate returns a copy of the newest n nodes of a history (nodes are
+// immutable, so the cut cannot be made in place)
+func truncate(node *VersionNode, n int) *VersionNode {
+	var head *VersionNode
+	next := &head
+	for ; node != nil && n > 0; node, n = node.Prev, n-1 {
+		copied := *node
+		copied.Prev = nil
+		*next = &copied
+		next = &copied.Prev
+	}
+	return head
+}
@@ -0,0 +1,49 @@
+package mvcc_test
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+// TestRetention_CutsCollectionHistory verifies a collection keeps about its
+// version limit while strings keep every version
+func TestRetention_CutsCollectionHistory(t *testing.T) {
+	cfg := mvcc.DefaultConfig()
+	cfg.DefaultMaxVersions = 8
+	cfg.RetentionPolicies = []mvcc.RetentionPolicy{{Pattern: regexp.MustCompile(`^audit:`), MaxVersions: 0}}
+	engine := mvcc.NewEngineWithConfig(cfg)
+
+	write := func(key string, typ mvcc.ValueType, n int) {
+		for i := range n {
+			if _, err := engine.Update(key, func(current mvcc.Entry) (mvcc.Entry, error) {
+				next := current.WithValue([]byte(strconv.Itoa(i)))
+				next.Type = typ
+				return next, nil
+			}); err != nil {
+				t.Fatalf("Update %s: %v", key, err)
+			}
+		}
+	}
+	write("h", mvcc.TypeHash, 100)
+	write("s", mvcc.TypeString, 100)
+	write("audit:h", mvcc.TypeHash, 100)
+
+	history, _ := engine.History("h", 0)
+	if len(history) < 8 || len(history) > 10 {
+		t.Errorf("hash kept %d versions, want about 8", len(history))
+	}
+	if value, _, _ := engine.Get("h"); string(value) != "99" {
+		t.Errorf("hash value = %s, want 99", value)
+	}
+	if value, err := engine.GetAtVersion("h", history[len(history)-1].Version); err != nil || string(value) != strconv.Itoa(100-len(history)) {
+		t.Errorf("oldest kept version = %s, %v", value, err)
+	}
+	for key, want := range map[string]int{"s": 100, "audit:h": 100} {
+		if history, _ := engine.History(key, 0); len(history) != want {
+			t.Errorf("%s kept %d versions, want %d", key, len(history), want)
+		}
+	}
+}
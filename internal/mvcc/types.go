@@ -0,0 +1,40 @@
+package mvcc
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+// ValueType tags what kind of value a version holds. Collection values are
+// stored encoded as bytes, so every type shares compression and history.
+type ValueType uint8
+
+const (
+	TypeString ValueType = iota
+	TypeHash
+	TypeList
+	TypeZSet
+	TypeSet
+	TypeStream
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case TypeString:
+		return "string"
+	case TypeHash:
+		return "hash"
+	case TypeList:
+		return "list"
+	case TypeZSet:
+		return "zset"
+	case TypeSet:
+		return "set"
+	case TypeStream:
+		return "stream"
+	default:
+		return fmt.Sprintf("type(%d)", uint8(t))
+	}
+}
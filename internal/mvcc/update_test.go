@@ -0,0 +1,314 @@
+package mvcc_test
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+// TestUpdate_ConcurrentIncrements verifies read-modify-write never loses an update
+func TestUpdate_ConcurrentIncrements(t *testing.T) {
+	engine := mvcc.NewEngine()
+	const key = "counter"
+	const writers = 500
+
+	incr := func(current mvcc.Entry) (mvcc.Entry, error) {
+		n := 0
+		if current.Exists {
+			n, _ = strconv.Atoi(string(current.Value))
+		}
+		return current.WithValue(strconv.AppendInt(nil, int64(n+1), 10)), nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(writers)
+	for range writers {
+		go func() {
+			defer wg.Done()
+			if _, err := engine.Update(key, incr); err != nil {
+				t.Errorf("Update: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	value, _, _ := engine.Get(key)
+	if string(value) != strconv.Itoa(writers) {
+		t.Errorf("counter = %s, want %d", value, writers)
+	}
+
+	history, _ := engine.History(key, 0)
+	if len(history) != writers {
+		t.Errorf("expected one version per increment (%d), got %d", writers, len(history))
+	}
+}
+
+func TestUpdate_ErrorAbortsWrite(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("k", []byte("v"))
+	before := engine.CurrentVersion()
+
+	errBoom := errors.New("boom")
+	_, err := engine.Update("k", func(current mvcc.Entry) (mvcc.Entry, error) { return current, errBoom })
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected errBoom, got %v", err)
+	}
+
+	if engine.CurrentVersion() != before {
+		t.Errorf("failed update should not consume a version")
+	}
+	if value, _, _ := engine.Get("k"); string(value) != "v" {
+		t.Errorf("value changed after failed update: %q", value)
+	}
+}
+
+func TestUpdate_SkipAndTombstone(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("k", []byte("v"))
+
+	version, err := engine.Update("k", func(mvcc.Entry) (mvcc.Entry, error) { return mvcc.Entry{}, mvcc.ErrSkipWrite })
+	if err != nil || version != 0 {
+		t.Fatalf("skipped update: version=%d err=%v", version, err)
+	}
+
+	version, err = engine.Update("k", func(mvcc.Entry) (mvcc.Entry, error) { return mvcc.Entry{}, nil })
+	if err != nil || version == 0 {
+		t.Fatalf("tombstone update: version=%d err=%v", version, err)
+	}
+	if engine.Exists("k") {
+		t.Errorf("key should be deleted")
+	}
+	if _, err := engine.GetAtVersion("k", version-1); err != nil {
+		t.Errorf("old version should stay readable: %v", err)
+	}
+}
+
+func TestUpdate_Expiry(t *testing.T) {
+	engine := mvcc.NewEngine()
+	past := time.Now().Add(-time.Second).UnixNano()
+
+	engine.Update("k", func(mvcc.Entry) (mvcc.Entry, error) {
+		return mvcc.Entry{Value: []byte("v"), Exists: true, ExpiresAt: past}, nil
+	})
+	if engine.Exists("k") {
+		t.Errorf("expired key should not exist")
+	}
+	if _, ok, _ := engine.Get("k"); ok {
+		t.Errorf("expired key should not be readable")
+	}
+
+	var sawExisting bool
+	engine.Update("k", func(current mvcc.Entry) (mvcc.Entry, error) {
+		sawExisting = current.Exists
+		return current.WithValue([]byte("fresh")), nil
+	})
+	if sawExisting {
+		t.Errorf("UpdateFunc should see expired key as missing")
+	}
+	if value, ok, _ := engine.Get("k"); !ok || string(value) != "fresh" {
+		t.Errorf("Get after rewrite = %q, %v", value, ok)
+	}
+}
+
+// TestGetAtVersion_Expiry verifies historical reads judge expiry by the time the
+// requested version was taken, not by the current time
+func TestGetAtVersion_Expiry(t *testing.T) {
+	engine := mvcc.NewEngine()
+	deadline := time.Now().Add(50 * time.Millisecond).UnixNano()
+
+	engine.Update("k", func(mvcc.Entry) (mvcc.Entry, error) {
+		return mvcc.Entry{Value: []byte("v"), Exists: true, ExpiresAt: deadline}, nil
+	})
+	before := engine.Set("other", []byte("1"))
+	time.Sleep(time.Until(time.Unix(0, deadline)) + 10*time.Millisecond)
+	after := engine.Set("other", []byte("2"))
+
+	if value, err := engine.GetAtVersion("k", before); err != nil || string(value) != "v" {
+		t.Errorf("GetAtVersion(%d) = %q, %v, want the value live at that version", before, value, err)
+	}
+	if entry, err := engine.LookupAt("k", before); err != nil || !entry.Exists {
+		t.Errorf("LookupAt(%d) = %+v, %v", before, entry, err)
+	}
+	if _, err := engine.GetAtVersion("k", after); !errors.Is(err, mvcc.ErrKeyDeleted) {
+		t.Errorf("GetAtVersion(%d) err = %v, want ErrKeyDeleted", after, err)
+	}
+	if _, err := engine.LookupAt("k", after); !errors.Is(err, mvcc.ErrKeyDeleted) {
+		t.Errorf("LookupAt(%d) err = %v, want ErrKeyDeleted", after, err)
+	}
+}
+
+// TestUpdate_FailureLeavesNoChain verifies an update that writes nothing does
+// not create the key
+func TestUpdate_FailureLeavesNoChain(t *testing.T) {
+	engine := mvcc.NewEngine()
+	fail := errors.New("not an integer")
+
+	if _, err := engine.Update("k", func(mvcc.Entry) (mvcc.Entry, error) { return mvcc.Entry{}, fail }); !errors.Is(err, fail) {
+		t.Fatalf("Update error = %v, want %v", err, fail)
+	}
+	if _, err := engine.Update("k", func(mvcc.Entry) (mvcc.Entry, error) { return mvcc.Entry{}, mvcc.ErrSkipWrite }); err != nil {
+		t.Fatalf("skipped Update: %v", err)
+	}
+	if _, err := engine.UpdateMany([]string{"a", "b"}, func([]mvcc.Entry) ([]mvcc.Entry, error) { return nil, fail }); !errors.Is(err, fail) {
+		t.Fatalf("UpdateMany error = %v, want %v", err, fail)
+	}
+	if keys := engine.Keys(); len(keys) != 0 {
+		t.Errorf("failed updates left chains for %v", keys)
+	}
+}
+
+// TestUpdateMany_ConcurrentTransfers moves units between two keys from many
+// goroutines and verifies no unit is lost or duplicated and both keys always
+// change under the same version
+// TestUpdateVersioned_SeesItsVersion verifies the version given to fn is the one
+// the write lands at, even while other writers race for versions
+func TestUpdateVersioned_SeesItsVersion(t *testing.T) {
+	engine := mvcc.NewEngine()
+	const writers = 200
+
+	var wg sync.WaitGroup
+	wg.Add(2 * writers)
+	for i := range writers {
+		go func() {
+			defer wg.Done()
+			version, err := engine.UpdateVersioned("k", func(current mvcc.Entry, version uint64) (mvcc.Entry, error) {
+				return current.WithValue(strconv.AppendUint(nil, version, 10)), nil
+			})
+			if err != nil {
+				t.Errorf("UpdateVersioned: %v", err)
+			}
+			value, _ := engine.GetAtVersion("k", version)
+			if string(value) != strconv.FormatUint(version, 10) {
+				t.Errorf("value at version %d = %s", version, value)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			engine.Set("other"+strconv.Itoa(i), []byte("v"))
+		}()
+	}
+	wg.Wait()
+
+	history, _ := engine.History("k", 0)
+	if len(history) != writers {
+		t.Errorf("expected %d versions, got %d", writers, len(history))
+	}
+}
+
+func TestUpdateMany_ConcurrentTransfers(t *testing.T) {
+	engine := mvcc.NewEngine()
+	engine.Set("a", []byte("1000"))
+	engine.Set("b", []byte("0"))
+
+	transfer := func(current []mvcc.Entry) ([]mvcc.Entry, error) {
+		a, _ := strconv.Atoi(string(current[0].Value))
+		b, _ := strconv.Atoi(string(current[1].Value))
+		return []mvcc.Entry{
+			current[0].WithValue([]byte(strconv.Itoa(a - 1))),
+			current[1].WithValue([]byte(strconv.Itoa(b + 1))),
+		}, nil
+	}
+
+	const writers = 200
+	var wg sync.WaitGroup
+	wg.Add(writers)
+	for range writers {
+		go func() {
+			defer wg.Done()
+			if _, err := engine.UpdateMany([]string{"a", "b"}, transfer); err != nil {
+				t.Errorf("UpdateMany: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, _ := engine.GetMany([]string{"a", "b"})
+	if string(entries[0].Value) != "800" || string(entries[1].Value) != "200" {
+		t.Fatalf("a=%s b=%s, want 800 and 200", entries[0].Value, entries[1].Value)
+	}
+	if entries[0].Version != entries[1].Version {
+		t.Errorf("keys written under different versions: %d and %d", entries[0].Version, entries[1].Version)
+	}
+}
+
+// TestUpdateMany_UnchangedKeys verifies keys returned as read get no new version
+func TestUpdateMany_UnchangedKeys(t *testing.T) {
+	engine := mvcc.NewEngine()
+	v1 := engine.Set("src", []byte("x"))
+
+	version, err := engine.UpdateMany([]string{"src", "dst"}, func(current []mvcc.Entry) ([]mvcc.Entry, error) {
+		return []mvcc.Entry{current[0], current[0].WithValue([]byte("copy"))}, nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateMany: %v", err)
+	}
+
+	src, _ := engine.Lookup("src")
+	if src.Version != v1 {
+		t.Errorf("src rewritten at version %d", src.Version)
+	}
+	dst, _ := engine.Lookup("dst")
+	if dst.Version != version || string(dst.Value) != "copy" {
+		t.Errorf("dst = %q at %d, want copy at %d", dst.Value, dst.Version, version)
+	}
+}
+
+// TestWatch_WakesOnWrite verifies a watcher is woken by a write to any watched key
+func TestWatch_WakesOnWrite(t *testing.T) {
+	engine := mvcc.NewEngine()
+	written, cancel := engine.Watch("x", "y")
+	defer cancel()
+
+	engine.Set("other", []byte("1"))
+	select {
+	case <-written:
+		t.Fatal("woken by a write to an unwatched key")
+	default:
+	}
+
+	go engine.Update("y", func(current mvcc.Entry) (mvcc.Entry, error) {
+		return current.WithValue([]byte("1")), nil
+	})
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Fatal("watcher not woken by write")
+	}
+}
+
+// TestClocked_JudgesExpiryByClock verifies a clocked view decides expiry at
+// its own time for reads and for the current entry an update sees
+func TestClocked_JudgesExpiryByClock(t *testing.T) {
+	engine := mvcc.NewEngine()
+	deadline := time.Now().Add(-time.Minute)
+	engine.Update("k", func(mvcc.Entry) (mvcc.Entry, error) {
+		return mvcc.Entry{Value: []byte("v"), Exists: true, ExpiresAt: deadline.UnixNano()}, nil
+	})
+	if engine.Exists("k") {
+		t.Fatal("k is live on the wall clock past its deadline")
+	}
+
+	before := engine.Clocked(func() time.Time { return deadline.Add(-time.Second) })
+	if value, ok, err := before.Get("k"); err != nil || !ok || string(value) != "v" {
+		t.Errorf("Get before the deadline = %q, %v, %v", value, ok, err)
+	}
+	var seen mvcc.Entry
+	before.Update("k", func(current mvcc.Entry) (mvcc.Entry, error) {
+		seen = current
+		return current, mvcc.ErrSkipWrite
+	})
+	if !seen.Exists || string(seen.Value) != "v" {
+		t.Errorf("update before the deadline saw %+v, want the live value", seen)
+	}
+
+	if !before.Attributed(&mvcc.Author{Name: "w"}).Exists("k") {
+		t.Error("an attributed view dropped the clock")
+	}
+	if engine.Clocked(func() time.Time { return deadline }).Exists("k") {
+		t.Error("k is live at its deadline")
+	}
+}
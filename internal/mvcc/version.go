@@ -14,36 +14,159 @@ type VersionNode struct {
 	// Timestamp Unix nano timestamp when version was created
 	Timestamp int64
 
-	// Value is the actual data
+	// Value is the stored data (compressed when Codec is not CodecNone)
 	Value []byte
 
+	// Type is the kind of value held by this version
+	Type ValueType
+
+	// Codec is the compression format Value is stored in
+	Codec Codec
+
+	// Size is the logical (uncompressed) length of the value
+	Size int
+
 	// Deleted is a tombstone marker
 	Deleted bool
 
+	// ExpiresAt is the Unix nano deadline after which the value is gone (0 = never)
+	ExpiresAt int64
+
+	// Author is the client that wrote the version (nil when unknown)
+	Author *Author
+
+	// Comment is the note the writer attached to the version
+	Comment string
+
+	// Lineage links the version to another key when it was written by a rename or copy
+	Lineage *Lineage
+
 	// Previous is a pointer to older version
 	Prev *VersionNode
+
+	// mergedAt is the version of the rename, copy or move that merged the node
+	// into this chain from another key (0 when it was written here)
+	mergedAt uint64
+
+	// history is the compressed value the node is stored with once it is
+	// demoted, prepared by pack (historyCodec is CodecNone when there is none)
+	history      []byte
+	historyCodec Codec
+
+	// commit is set on nodes written by a batch (nil for single key writes)
+	commit *commitState
+}
+
+// commitState is shared by every node a batch writes. The nodes stay invisible
+// to readers until the batch resolves, so a batch is observed all at once or not at all.
+type commitState struct {
+	done    chan struct{}
+	aborted atomic.Bool
+}
+
+func newCommitState() *commitState {
+	return &commitState{done: make(chan struct{})}
+}
+
+// resolve publishes (or aborts) every node of the batch at once
+func (c *commitState) resolve(committed bool) {
+	if !committed {
+		c.aborted.Store(true)
+	}
+	close(c.done)
+}
+
+func (c *commitState) pending() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// committed reports whether the node is visible: a single key write or part of a committed batch
+func (vn *VersionNode) committed() bool {
+	return vn.commit == nil || (!vn.commit.pending() && !vn.commit.aborted.Load())
+}
+
+// settle waits until the node's batch is resolved and reports whether it committed
+func (vn *VersionNode) settle() bool {
+	if vn.commit == nil {
+		return true
+	}
+	<-vn.commit.done
+	return !vn.commit.aborted.Load()
 }
 
 // VersionInfo is a read-only view of version metadata (for HISTORY command)
 type VersionInfo struct {
-	Version   uint64
-	Timestamp int64
-	Deleted   bool
-	Size      int // len(Value)
+	Version    uint64
+	Timestamp  int64
+	Deleted    bool
+	Size       int // logical length of the value
+	StoredSize int // bytes held in memory after compression
+	Codec      Codec
+	ExpiresAt  int64
+	Type       ValueType
+	Lineage    *Lineage
+	Author     *Author
+	Comment    string
 }
 
 // ToInfo creates a version info (read-only metadata) of the node
 func (vn *VersionNode) ToInfo() VersionInfo {
-	size := 0
-	if vn.Value != nil {
-		size = len(vn.Value)
-	}
 	return VersionInfo{
+		Version:    vn.Version,
+		Timestamp:  vn.Timestamp,
+		Deleted:    vn.Deleted,
+		Size:       vn.Size,
+		StoredSize: len(vn.Value),
+		Codec:      vn.Codec,
+		ExpiresAt:  vn.ExpiresAt,
+		Type:       vn.Type,
+		Lineage:    vn.Lineage,
+		Author:     vn.Author,
+		Comment:    vn.Comment,
+	}
+}
+
+// entry decodes the node into the Entry view handed to callers
+func (vn *VersionNode) entry() (Entry, error) {
+	if vn.Deleted {
+		return Entry{Version: vn.Version}, nil
+	}
+	data, err := vn.Data()
+	if err != nil {
+		return Entry{}, err
+	}
+	// keep empty values distinguishable from missing keys
+	if data == nil {
+		data = []byte{}
+	}
+	return Entry{
+		Value:     data,
+		Type:      vn.Type,
+		Exists:    true,
+		ExpiresAt: vn.ExpiresAt,
 		Version:   vn.Version,
-		Timestamp: vn.Timestamp,
-		Deleted:   vn.Deleted,
-		Size:      size,
+	}, nil
+}
+
+// Live reports whether the node holds a value that is neither deleted nor expired at now
+func (vn *VersionNode) Live(now int64) bool {
+	if vn == nil || vn.Deleted {
+		return false
+	}
+	return vn.ExpiresAt == 0 || now < vn.ExpiresAt
+}
+
+// Data returns the logical value of the node, decompressing it if needed
+func (vn *VersionNode) Data() ([]byte, error) {
+	if vn.Codec == CodecNone {
+		return vn.Value, nil
 	}
+	return decompress(vn.Codec, vn.Value, vn.Size)
 }
 
 // Uses atomic operations  for lock-free version generation
@@ -51,6 +174,14 @@ type GlobalVersionManager struct {
 	currentVersion atomic.Uint64
 	// timestampMap stores version -> timestamp mapping
 	timestampMap sync.Map
+	// reserved is the version and timestamp set by Reserve, nil when unset
+	reserved atomic.Pointer[reservation]
+}
+
+// reservation stamps the versions from version on with a timestamp chosen elsewhere
+type reservation struct {
+	version   uint64
+	timestamp int64
 }
 
 func NewGlobalVersionManager() *GlobalVersionManager {
@@ -61,10 +192,42 @@ func NewGlobalVersionManager() *GlobalVersionManager {
 func (gvm *GlobalVersionManager) NextVersion() (version uint64, timestamp int64) {
 	version = gvm.currentVersion.Add(1)
 	timestamp = time.Now().UnixNano()
+	if r := gvm.reserved.Load(); r != nil && version >= r.version {
+		timestamp = r.timestamp
+	}
 	gvm.timestampMap.Store(version, timestamp)
 	return version, timestamp
 }
 
+// Reserve makes version the next one handed out, unless the counter already
+// passed it, and stamps it and the versions after it with timestamp until
+// the next Reserve. Engines replaying the same writes after the same
+// reservations assign the same versions and timestamps. Callers leave room
+// between reservations for every version the writes in between take, or a
+// reservation lands behind the counter and later versions shift.
+func (gvm *GlobalVersionManager) Reserve(version uint64, timestamp int64) {
+	gvm.AdvanceTo(version - 1)
+	gvm.reserved.Store(&reservation{version: version, timestamp: timestamp})
+}
+
+// AdvanceTo moves the counter forward to at least version, so versions brought
+// in from another engine are never handed out again
+func (gvm *GlobalVersionManager) AdvanceTo(version uint64) {
+	for {
+		current := gvm.currentVersion.Load()
+		if current >= version || gvm.currentVersion.CompareAndSwap(current, version) {
+			return
+		}
+	}
+}
+
+// Adopt takes on a version another engine assigned, moving the counter past it
+// and remembering its timestamp
+func (gvm *GlobalVersionManager) Adopt(version uint64, timestamp int64) {
+	gvm.AdvanceTo(version)
+	gvm.timestampMap.Store(version, timestamp)
+}
+
 // CurrentVersion returns the latest version number without incrementing
 func (gvm *GlobalVersionManager) CurrentVersion() uint64 {
 	return gvm.currentVersion.Load()
@@ -0,0 +1,80 @@
+package mvcc
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// waiter is woken by the first write to any of the keys it watches
+type waiter struct {
+	ch   chan struct{}
+	once sync.Once
+}
+
+func (w *waiter) wake() {
+	w.once.Do(func() { close(w.ch) })
+}
+
+// watchers tracks goroutines parked until a key is written (blocking list pops,
+// stream reads). Writers only pay an atomic load while nobody is waiting.
+type watchers struct {
+	mu      sync.Mutex
+	byKey   map[string]map[*waiter]struct{}
+	waiting atomic.Int64
+}
+
+func newWatchers() *watchers {
+	return &watchers{byKey: make(map[string]map[*waiter]struct{})}
+}
+
+// Watch returns a channel that is closed the next time any of the keys is written.
+// cancel must be called once the caller stops waiting.
+func (e *Engine) Watch(keys ...string) (<-chan struct{}, func()) {
+	ws := e.watchers
+	w := &waiter{ch: make(chan struct{})}
+
+	ws.mu.Lock()
+	for _, key := range keys {
+		set, ok := ws.byKey[key]
+		if !ok {
+			set = make(map[*waiter]struct{})
+			ws.byKey[key] = set
+		}
+		set[w] = struct{}{}
+	}
+	ws.waiting.Add(1)
+	ws.mu.Unlock()
+
+	cancel := func() {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		for _, key := range keys {
+			set := ws.byKey[key]
+			if _, ok := set[w]; !ok {
+				continue
+			}
+			delete(set, w)
+			if len(set) == 0 {
+				delete(ws.byKey, key)
+			}
+		}
+		ws.waiting.Add(-1)
+	}
+	return w.ch, cancel
+}
+
+// notify wakes everyone watching key
+func (ws *watchers) notify(key string) {
+	if ws.waiting.Load() == 0 {
+		return
+	}
+
+	ws.mu.Lock()
+	set := ws.byKey[key]
+	delete(ws.byKey, key)
+	ws.mu.Unlock()
+
+	for w := range set {
+		w.wake()
+	}
+}
@@ -2,7 +2,9 @@ package protocol
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -11,6 +13,11 @@ type Command struct {
 	args [][]byte
 }
 
+// NewCommand builds a command from its name and arguments
+func NewCommand(name string, args [][]byte) *Command {
+	return &Command{name: name, args: args}
+}
+
 func (c *Command) Name() string {
 	return c.name
 }
@@ -120,3 +127,51 @@ func (cp *CommandParser) extractCommandFromArray(arr *Array) (*Command, error) {
 
 	return &Command{name: cmdName, args: args}, nil
 }
+
+// completeCommand reports whether buf starts with a whole command: an inline
+// command up to its newline, or an array with all of its bulk strings. Anything
+// it cannot vouch for, malformed input included, counts as incomplete.
+func completeCommand(buf []byte) bool {
+	if len(buf) == 0 {
+		return false
+	}
+	if rune(buf[0]) != TypeArray {
+		return bytes.IndexByte(buf, '\n') >= 0
+	}
+	n, rest, ok := lengthLine(buf[1:])
+	if !ok {
+		return false
+	}
+	for range n {
+		if len(rest) == 0 || rune(rest[0]) != TypeBulkString {
+			return false
+		}
+		size, body, ok := lengthLine(rest[1:])
+		if !ok {
+			return false
+		}
+		if size < 0 {
+			rest = body
+			continue
+		}
+		if len(body) < size+2 {
+			return false
+		}
+		rest = body[size+2:]
+	}
+	return true
+}
+
+// lengthLine parses the length that follows a type marker up to its CRLF and
+// returns it with the bytes after the line
+func lengthLine(buf []byte) (int, []byte, bool) {
+	end := bytes.Index(buf, []byte(CRLF))
+	if end < 0 {
+		return 0, nil, false
+	}
+	n, err := strconv.Atoi(string(buf[:end]))
+	if err != nil {
+		return 0, nil, false
+	}
+	return n, buf[end+2:], true
+}
@@ -0,0 +1,29 @@
+package protocol
+
+import "testing"
+
+func TestCompleteCommand(t *testing.T) {
+	set := "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$5\r\nvalue\r\n"
+	cases := []struct {
+		input string
+		want  bool
+	}{
+		{"", false},
+		{set, true},
+		{set + "*1\r\n$4\r\nPI", true}, // a whole command ahead of a partial one
+		{"PING\r\n", true},
+		{"PIN", false},
+		{"*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$5\r\nval", false},
+		{"*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$5\r\nvalue\r", false},
+		{"*3\r\n$3\r\nSET\r\n", false},
+		{"*3\r", false},
+		{"*2\r\n$-1\r\n$1\r\nx\r\n", true},
+		{"*x\r\n", false},
+		{"*1\r\n:1\r\n", false},
+	}
+	for i, tc := range cases {
+		if got := completeCommand([]byte(tc.input)); got != tc.want {
+			t.Errorf("case %d: completeCommand(%q) = %v, want %v", i, tc.input, got, tc.want)
+		}
+	}
+}
@@ -9,6 +9,9 @@ type RESPConnection struct {
 	reader    *bufio.Reader
 	writer    *bufio.Writer
 	cmdParser *CommandParser
+
+	// version is the RESP version replies are encoded in
+	version Version
 }
 
 func NewRESPConnection(reader *bufio.Reader, writer *bufio.Writer) *RESPConnection {
@@ -16,6 +19,7 @@ func NewRESPConnection(reader *bufio.Reader, writer *bufio.Writer) *RESPConnecti
 		reader:    reader,
 		writer:    writer,
 		cmdParser: NewCommandParser(reader),
+		version:   RESP2,
 	}
 }
 
@@ -23,12 +27,35 @@ func (rc *RESPConnection) ReadCommand() (*Command, error) {
 	return rc.cmdParser.ParseCommand()
 }
 
+// SetVersion changes the RESP version of the replies that follow
+func (rc *RESPConnection) SetVersion(version Version) {
+	rc.version = version
+}
+
+// WriteResponse buffers a reply, it reaches the client with the next Flush or
+// once the buffer fills
 func (rc *RESPConnection) WriteResponse(resp RESPValue) error {
-	_, err := rc.writer.Write(resp.Serialize())
+	_, err := rc.writer.Write(Encode(resp, rc.version))
 	if err != nil {
 		return fmt.Errorf("failed to write response: %w", err)
 	}
-	return rc.writer.Flush()
+	return nil
+}
+
+// Flush sends the buffered replies
+func (rc *RESPConnection) Flush() error {
+	if err := rc.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush responses: %w", err)
+	}
+	return nil
+}
+
+// CommandBuffered reports whether a whole command was received and waits to be
+// read, so reading it cannot block. The start of a command still arriving
+// does not count.
+func (rc *RESPConnection) CommandBuffered() bool {
+	buffered, _ := rc.reader.Peek(rc.reader.Buffered())
+	return completeCommand(buffered)
 }
 
 func (rc *RESPConnection) Close() error {
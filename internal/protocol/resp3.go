@@ -0,0 +1,299 @@
+package protocol
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Version is the RESP version a connection speaks, negotiated with HELLO
+type Version int
+
+const (
+	RESP2 Version = 2
+	RESP3 Version = 3
+)
+
+// RESP3 type markers
+const (
+	TypeNull      rune = '_'
+	TypeBoolean   rune = '#'
+	TypeDouble    rune = ','
+	TypeBigNumber rune = '('
+	TypeVerbatim  rune = '='
+	TypeMap       rune = '%'
+	TypeSet       rune = '~'
+	TypeAttribute rune = '|'
+	TypePush      rune = '>'
+)
+
+// The RESP3 types serialize to their RESP3 encoding, Encode gives RESP2
+// clients the RESP2 shape Redis replies with instead.
+
+type Null struct{}
+
+func NewNull() *Null {
+	return &Null{}
+}
+
+func (n *Null) Serialize() []byte {
+	return fmt.Appendf(nil, "%c%s", TypeNull, CRLF)
+}
+
+type Boolean struct {
+	value bool
+}
+
+func NewBoolean(val bool) *Boolean {
+	return &Boolean{value: val}
+}
+
+func (b *Boolean) Value() bool {
+	return b.value
+}
+
+func (b *Boolean) Serialize() []byte {
+	if b.value {
+		return fmt.Appendf(nil, "%ct%s", TypeBoolean, CRLF)
+	}
+	return fmt.Appendf(nil, "%cf%s", TypeBoolean, CRLF)
+}
+
+type Double struct {
+	value float64
+}
+
+func NewDouble(val float64) *Double {
+	return &Double{value: val}
+}
+
+func (d *Double) Value() float64 {
+	return d.value
+}
+
+// text renders the double the way RESP3 spells it, with inf, -inf and nan
+func (d *Double) text() string {
+	switch {
+	case math.IsInf(d.value, 1):
+		return "inf"
+	case math.IsInf(d.value, -1):
+		return "-inf"
+	case math.IsNaN(d.value):
+		return "nan"
+	}
+	return strconv.FormatFloat(d.value, 'g', -1, 64)
+}
+
+func (d *Double) Serialize() []byte {
+	return fmt.Appendf(nil, "%c%s%s", TypeDouble, d.text(), CRLF)
+}
+
+type BigNumber struct {
+	value *big.Int
+}
+
+func NewBigNumber(val *big.Int) *BigNumber {
+	return &BigNumber{value: val}
+}
+
+func (n *BigNumber) Value() *big.Int {
+	return n.value
+}
+
+func (n *BigNumber) Serialize() []byte {
+	return fmt.Appendf(nil, "%c%s%s", TypeBigNumber, n.value.String(), CRLF)
+}
+
+// VerbatimString is text with a three letter format hint such as "txt" or "mkd"
+type VerbatimString struct {
+	format string
+	data   []byte
+}
+
+func NewVerbatimString(format string, data []byte) *VerbatimString {
+	return &VerbatimString{format: format, data: data}
+}
+
+func (v *VerbatimString) Format() string {
+	return v.format
+}
+
+func (v *VerbatimString) Data() []byte {
+	return v.data
+}
+
+func (v *VerbatimString) Serialize() []byte {
+	buf := make([]byte, 0, len(v.data)+24)
+	buf = fmt.Appendf(buf, "%c%d%s%s:", TypeVerbatim, len(v.format)+1+len(v.data), CRLF, v.format)
+	buf = append(buf, v.data...)
+	return append(buf, '\r', '\n')
+}
+
+// MapEntry is one field of a Map or an Attribute
+type MapEntry struct {
+	Key   RESPValue
+	Value RESPValue
+}
+
+// Map keeps its entries in the order they were given
+type Map struct {
+	entries []MapEntry
+}
+
+func NewMap(entries []MapEntry) *Map {
+	return &Map{entries: entries}
+}
+
+func (m *Map) Entries() []MapEntry {
+	return m.entries
+}
+
+func (m *Map) Serialize() []byte {
+	return Encode(m, RESP3)
+}
+
+type Set struct {
+	elements []RESPValue
+}
+
+func NewSet(elements []RESPValue) *Set {
+	return &Set{elements: elements}
+}
+
+func (s *Set) Elements() []RESPValue {
+	return s.elements
+}
+
+func (s *Set) Serialize() []byte {
+	return Encode(s, RESP3)
+}
+
+// Attribute carries out-of-band information about the reply that follows it,
+// clients that do not know the attributes skip them
+type Attribute struct {
+	entries []MapEntry
+	value   RESPValue
+}
+
+func NewAttribute(entries []MapEntry, value RESPValue) *Attribute {
+	return &Attribute{entries: entries, value: value}
+}
+
+func (a *Attribute) Entries() []MapEntry {
+	return a.entries
+}
+
+// Value returns the reply the attributes describe
+func (a *Attribute) Value() RESPValue {
+	return a.value
+}
+
+func (a *Attribute) Serialize() []byte {
+	return Encode(a, RESP3)
+}
+
+// Push is data the server sends without a request, such as a pub/sub message
+type Push struct {
+	elements []RESPValue
+}
+
+func NewPush(elements []RESPValue) *Push {
+	return &Push{elements: elements}
+}
+
+func (p *Push) Elements() []RESPValue {
+	return p.elements
+}
+
+func (p *Push) Serialize() []byte {
+	return Encode(p, RESP3)
+}
+
+// Encode serializes v for a client speaking version. RESP3 clients get nulls
+// as _ and the RESP3 types as they are. RESP2 clients get maps as flat key
+// value arrays, sets and pushes as arrays, doubles, big numbers and verbatim
+// strings as bulk strings, booleans as 1 or 0, nulls as the null bulk string,
+// and attributes dropped.
+func Encode(v RESPValue, version Version) []byte {
+	return appendEncoded(nil, v, version)
+}
+
+func appendEncoded(buf []byte, v RESPValue, version Version) []byte {
+	if version == RESP3 {
+		return appendRESP3(buf, v)
+	}
+	switch v := v.(type) {
+	case *Array:
+		if v.isNull {
+			return append(buf, v.Serialize()...)
+		}
+		return appendAggregate(buf, TypeArray, v.elements, RESP2)
+	case *Map:
+		buf = fmt.Appendf(buf, "%c%d%s", TypeArray, 2*len(v.entries), CRLF)
+		return appendEntries(buf, v.entries, RESP2)
+	case *Set:
+		return appendAggregate(buf, TypeArray, v.elements, RESP2)
+	case *Push:
+		return appendAggregate(buf, TypeArray, v.elements, RESP2)
+	case *Attribute:
+		return appendEncoded(buf, v.value, RESP2)
+	case *Null:
+		return append(buf, NewNullBulkString().Serialize()...)
+	case *Boolean:
+		if v.value {
+			return append(buf, NewInteger(1).Serialize()...)
+		}
+		return append(buf, NewInteger(0).Serialize()...)
+	case *Double:
+		return append(buf, NewBulkString([]byte(v.text())).Serialize()...)
+	case *BigNumber:
+		return append(buf, NewBulkString([]byte(v.value.String())).Serialize()...)
+	case *VerbatimString:
+		return append(buf, NewBulkString(v.data).Serialize()...)
+	default:
+		return append(buf, v.Serialize()...)
+	}
+}
+
+func appendRESP3(buf []byte, v RESPValue) []byte {
+	switch v := v.(type) {
+	case *BulkString:
+		if v.isNull {
+			return append(buf, NewNull().Serialize()...)
+		}
+	case *Array:
+		if v.isNull {
+			return append(buf, NewNull().Serialize()...)
+		}
+		return appendAggregate(buf, TypeArray, v.elements, RESP3)
+	case *Map:
+		buf = fmt.Appendf(buf, "%c%d%s", TypeMap, len(v.entries), CRLF)
+		return appendEntries(buf, v.entries, RESP3)
+	case *Set:
+		return appendAggregate(buf, TypeSet, v.elements, RESP3)
+	case *Push:
+		return appendAggregate(buf, TypePush, v.elements, RESP3)
+	case *Attribute:
+		buf = fmt.Appendf(buf, "%c%d%s", TypeAttribute, len(v.entries), CRLF)
+		buf = appendEntries(buf, v.entries, RESP3)
+		return appendRESP3(buf, v.value)
+	}
+	return append(buf, v.Serialize()...)
+}
+
+func appendAggregate(buf []byte, marker rune, elements []RESPValue, version Version) []byte {
+	buf = fmt.Appendf(buf, "%c%d%s", marker, len(elements), CRLF)
+	for _, elem := range elements {
+		buf = appendEncoded(buf, elem, version)
+	}
+	return buf
+}
+
+func appendEntries(buf []byte, entries []MapEntry, version Version) []byte {
+	for _, entry := range entries {
+		buf = appendEncoded(buf, entry.Key, version)
+		buf = appendEncoded(buf, entry.Value, version)
+	}
+	return buf
+}
@@ -0,0 +1,111 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestRESP3RoundTrip(t *testing.T) {
+	huge, _ := new(big.Int).SetString("3492890328409238509324850943850943825024385", 10)
+	cases := []RESPValue{
+		NewNull(),
+		NewBoolean(true),
+		NewBoolean(false),
+		NewDouble(1.5),
+		NewDouble(-0.25),
+		NewDouble(math.Inf(1)),
+		NewDouble(math.Inf(-1)),
+		NewBigNumber(huge),
+		NewVerbatimString("txt", []byte("Some string")),
+		NewVerbatimString("mkd", []byte{}),
+		NewMap([]MapEntry{
+			{NewBulkString([]byte("first")), NewInteger(1)},
+			{NewSimpleString("second"), NewMap(nil)},
+		}),
+		NewSet([]RESPValue{NewSimpleString("orange"), NewDouble(2), NewBoolean(false)}),
+		NewPush([]RESPValue{NewBulkString([]byte("message")), NewBulkString([]byte("channel"))}),
+		NewAttribute([]MapEntry{{NewSimpleString("ttl"), NewInteger(3600)}},
+			NewArray([]RESPValue{NewInteger(2039123), NewInteger(9543892)})),
+		NewArray([]RESPValue{NewNull(), NewMap([]MapEntry{{NewInteger(1), NewSet(nil)}})}),
+	}
+	for i, original := range cases {
+		serialized := Encode(original, RESP3)
+		parsed, err := NewRESPParser(bufio.NewReader(bytes.NewReader(serialized))).ParseValue()
+		if err != nil {
+			t.Errorf("case %d: parse of %q failed: %v", i, serialized, err)
+			continue
+		}
+		if reserialized := Encode(parsed, RESP3); !bytes.Equal(serialized, reserialized) {
+			t.Errorf("case %d: round-trip mismatch\n got %q\n want: %q", i, reserialized, serialized)
+		}
+	}
+}
+
+func TestRESP3NaN(t *testing.T) {
+	parsed, err := NewRESPParser(bufio.NewReader(bytes.NewReader([]byte(",nan\r\n")))).ParseValue()
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if d, ok := parsed.(*Double); !ok || !math.IsNaN(d.Value()) {
+		t.Fatalf("parsed %#v, want NaN", parsed)
+	}
+}
+
+// TestEncode verifies what each version of the protocol sees of a reply
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		value RESPValue
+		resp2 string
+		resp3 string
+	}{
+		{NewNullBulkString(), "$-1\r\n", "_\r\n"},
+		{NewNullArray(), "*-1\r\n", "_\r\n"},
+		{NewNull(), "$-1\r\n", "_\r\n"},
+		{NewBoolean(true), ":1\r\n", "#t\r\n"},
+		{NewDouble(3.25), "$4\r\n3.25\r\n", ",3.25\r\n"},
+		{NewBigNumber(big.NewInt(-7)), "$2\r\n-7\r\n", "(-7\r\n"},
+		{NewVerbatimString("txt", []byte("hi")), "$2\r\nhi\r\n", "=6\r\ntxt:hi\r\n"},
+		{
+			NewMap([]MapEntry{{NewBulkString([]byte("version")), NewInteger(3)}, {NewBulkString([]byte("deleted")), NewBoolean(false)}}),
+			"*4\r\n$7\r\nversion\r\n:3\r\n$7\r\ndeleted\r\n:0\r\n",
+			"%2\r\n$7\r\nversion\r\n:3\r\n$7\r\ndeleted\r\n#f\r\n",
+		},
+		{NewSet([]RESPValue{NewInteger(1)}), "*1\r\n:1\r\n", "~1\r\n:1\r\n"},
+		{NewPush([]RESPValue{NewInteger(1)}), "*1\r\n:1\r\n", ">1\r\n:1\r\n"},
+		{
+			NewAttribute([]MapEntry{{NewSimpleString("ttl"), NewInteger(1)}}, NewInteger(7)),
+			":7\r\n",
+			"|1\r\n+ttl\r\n:1\r\n:7\r\n",
+		},
+		{NewArray([]RESPValue{NewNullBulkString(), NewBoolean(true)}), "*2\r\n$-1\r\n:1\r\n", "*2\r\n_\r\n#t\r\n"},
+	}
+	for i, tt := range tests {
+		if got := string(Encode(tt.value, RESP2)); got != tt.resp2 {
+			t.Errorf("case %d: RESP2 = %q, want %q", i, got, tt.resp2)
+		}
+		if got := string(Encode(tt.value, RESP3)); got != tt.resp3 {
+			t.Errorf("case %d: RESP3 = %q, want %q", i, got, tt.resp3)
+		}
+	}
+}
+
+func TestRESP3MalformedInput(t *testing.T) {
+	for _, input := range []string{
+		"#x\r\n",
+		",abc\r\n",
+		"(12a\r\n",
+		"=5\r\ntxtab\r\n",
+		"%-1\r\n",
+		"%1\r\n+key\r\n",
+		"~2\r\n:1\r\n",
+		"|1\r\n+a\r\n:1\r\n",
+		"_x\r\n",
+	} {
+		if _, err := NewRESPParser(bufio.NewReader(bytes.NewReader([]byte(input)))).ParseValue(); err == nil {
+			t.Errorf("parsing %q succeeded", input)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"strconv"
 )
 
@@ -27,7 +29,7 @@ func (p *RESPParser) ReadCommand() (string, error) {
 func (p *RESPParser) Peek(n int) ([]byte, error) {
 	b, err := p.reader.Peek(n)
 	if err != nil {
-		return nil, fmt.Errorf("failed to peek")
+		return nil, fmt.Errorf("failed to peek: %w", err)
 	}
 	return b, nil
 }
@@ -49,6 +51,24 @@ func (p *RESPParser) ParseValue() (RESPValue, error) {
 		return p.parseInteger()
 	case TypeError:
 		return p.parseError()
+	case TypeNull:
+		return p.parseNull()
+	case TypeBoolean:
+		return p.parseBoolean()
+	case TypeDouble:
+		return p.parseDouble()
+	case TypeBigNumber:
+		return p.parseBigNumber()
+	case TypeVerbatim:
+		return p.parseVerbatimString()
+	case TypeMap:
+		return p.parseMap()
+	case TypeSet:
+		return p.parseSet()
+	case TypePush:
+		return p.parsePush()
+	case TypeAttribute:
+		return p.parseAttribute()
 	default:
 		// TODO: Create error types for consistent error messaging
 		return nil, fmt.Errorf("invalid RESP type marker: %c", typeByte)
@@ -168,6 +188,170 @@ func (p *RESPParser) parseError() (*Error, error) {
 	return &Error{msg: line}, err
 }
 
+func (p *RESPParser) parseNull() (*Null, error) {
+	line, err := p.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if line != "" {
+		return nil, fmt.Errorf("invalid null: %q", line)
+	}
+	return &Null{}, nil
+}
+
+func (p *RESPParser) parseBoolean() (*Boolean, error) {
+	line, err := p.readLine()
+	if err != nil {
+		return nil, err
+	}
+	switch line {
+	case "t":
+		return &Boolean{value: true}, nil
+	case "f":
+		return &Boolean{value: false}, nil
+	}
+	return nil, fmt.Errorf("invalid boolean: %q", line)
+}
+
+func (p *RESPParser) parseDouble() (*Double, error) {
+	line, err := p.readLine()
+	if err != nil {
+		return nil, err
+	}
+	switch line {
+	case "inf":
+		return &Double{value: math.Inf(1)}, nil
+	case "-inf":
+		return &Double{value: math.Inf(-1)}, nil
+	case "nan":
+		return &Double{value: math.NaN()}, nil
+	}
+	value, err := strconv.ParseFloat(line, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid double format: %w", err)
+	}
+	return &Double{value: value}, nil
+}
+
+func (p *RESPParser) parseBigNumber() (*BigNumber, error) {
+	line, err := p.readLine()
+	if err != nil {
+		return nil, err
+	}
+	value, ok := new(big.Int).SetString(line, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid big number: %q", line)
+	}
+	return &BigNumber{value: value}, nil
+}
+
+func (p *RESPParser) parseVerbatimString() (*VerbatimString, error) {
+	bulk, err := p.parseBulkString()
+	if err != nil {
+		return nil, err
+	}
+	if bulk.isNull || len(bulk.data) < 4 || bulk.data[3] != ':' {
+		return nil, fmt.Errorf("invalid verbatim string: missing format")
+	}
+	return &VerbatimString{format: string(bulk.data[:3]), data: bulk.data[4:]}, nil
+}
+
+// parseCount reads the element count of an aggregate type, which unlike
+// arrays cannot be null
+func (p *RESPParser) parseCount(kind string) (int, error) {
+	line, err := p.readLine()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s count: %w", kind, err)
+	}
+	count, err := strconv.Atoi(line)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s count: %w", kind, err)
+	}
+	if count < 0 {
+		return 0, fmt.Errorf("invalid %s length: %d", kind, count)
+	}
+	if count > MAX_ARRAY_SIZE {
+		return 0, fmt.Errorf("%s too large: %d exceeds max %d", kind, count, MAX_ARRAY_SIZE)
+	}
+	return count, nil
+}
+
+func (p *RESPParser) parseElements(kind string) ([]RESPValue, error) {
+	count, err := p.parseCount(kind)
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]RESPValue, 0, count)
+	for i := 0; i < count; i++ {
+		elem, err := p.ParseValue()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s element %d: %w", kind, i, err)
+		}
+		elements = append(elements, elem)
+	}
+	return elements, nil
+}
+
+func (p *RESPParser) parseEntries(kind string) ([]MapEntry, error) {
+	count, err := p.parseCount(kind)
+	if err != nil {
+		return nil, err
+	}
+	if count > MAX_ARRAY_SIZE/2 {
+		return nil, fmt.Errorf("%s too large: %d exceeds max %d", kind, count, MAX_ARRAY_SIZE/2)
+	}
+	entries := make([]MapEntry, 0, count)
+	for i := 0; i < count; i++ {
+		key, err := p.ParseValue()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s key %d: %w", kind, i, err)
+		}
+		value, err := p.ParseValue()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s value %d: %w", kind, i, err)
+		}
+		entries = append(entries, MapEntry{Key: key, Value: value})
+	}
+	return entries, nil
+}
+
+func (p *RESPParser) parseMap() (*Map, error) {
+	entries, err := p.parseEntries("map")
+	if err != nil {
+		return nil, err
+	}
+	return &Map{entries: entries}, nil
+}
+
+func (p *RESPParser) parseSet() (*Set, error) {
+	elements, err := p.parseElements("set")
+	if err != nil {
+		return nil, err
+	}
+	return &Set{elements: elements}, nil
+}
+
+func (p *RESPParser) parsePush() (*Push, error) {
+	elements, err := p.parseElements("push")
+	if err != nil {
+		return nil, err
+	}
+	return &Push{elements: elements}, nil
+}
+
+// parseAttribute reads the attributes and the reply they describe
+func (p *RESPParser) parseAttribute() (*Attribute, error) {
+	entries, err := p.parseEntries("attribute")
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.ParseValue()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse attributed value: %w", err)
+	}
+	return &Attribute{entries: entries, value: value}, nil
+}
+
 func (p *RESPParser) discardCRLF() error {
 	cr, err := p.reader.ReadByte()
 	if err != nil {
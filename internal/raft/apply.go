@@ -0,0 +1,118 @@
+package raft
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// applyBatch bounds the entries applied between two looks at the node state
+const applyBatch = 256
+
+// applier feeds committed entries and installed snapshots to the state
+// machine, in order and outside the node lock
+func (n *Node) applier() {
+	defer n.wg.Done()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for {
+		for !n.closed() && n.pendingSnapshot == nil && n.lastApplied >= n.commitIndex {
+			n.applyCond.Wait()
+		}
+		if n.closed() {
+			return
+		}
+
+		if snapshot := n.pendingSnapshot; snapshot != nil {
+			n.pendingSnapshot = nil
+			n.mu.Unlock()
+			err := n.fsm.Restore(snapshot)
+			n.mu.Lock()
+			if err != nil {
+				panic(fmt.Sprintf("raft: restoring snapshot %d: %v", snapshot.Index, err))
+			}
+			n.lastApplied, n.restoredIndex = max(n.lastApplied, snapshot.Index), snapshot.Index
+			n.settleProposals()
+			notify(&n.applied)
+			continue
+		}
+
+		entries := n.log.slice(n.lastApplied+1, min(n.commitIndex, n.lastApplied+applyBatch))
+		n.mu.Unlock()
+		results := make([]any, len(entries))
+		for i := range entries {
+			if entries[i].Type == EntryCommand {
+				results[i] = n.fsm.Apply(entries[i])
+			}
+		}
+		n.mu.Lock()
+
+		for i := range entries {
+			if entries[i].Origin == n.id {
+				if p := n.proposals[entries[i].Proposal]; p != nil {
+					p.finish(results[i], nil)
+				}
+			}
+		}
+		n.lastApplied = max(n.lastApplied, entries[len(entries)-1].Index)
+		n.settleProposals()
+		notify(&n.applied)
+		n.maybeSnapshot()
+	}
+}
+
+// settleProposals fails the proposals whose index was applied without them:
+// a leader change replaced their entry, or a snapshot skipped over it
+func (n *Node) settleProposals() {
+	for _, p := range n.proposals {
+		if p.index == 0 || p.index > n.lastApplied {
+			continue
+		}
+		if p.index <= n.restoredIndex {
+			p.finish(nil, ErrResultUnknown)
+		} else {
+			p.finish(nil, ErrLost)
+		}
+	}
+}
+
+func (p *proposal) finish(result any, err error) {
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+	p.result, p.err = result, err
+	close(p.done)
+}
+
+// maybeSnapshot compacts the log once enough entries were applied since the
+// last snapshot. It is called by the applier with n.mu held, and drops it
+// while the state machine takes the snapshot.
+func (n *Node) maybeSnapshot() {
+	if n.lastApplied-n.log.snapIndex < n.cfg.SnapshotThreshold {
+		return
+	}
+	index := n.lastApplied
+	term, _ := n.log.term(index)
+	members := n.configAt(index)
+
+	n.mu.Unlock()
+	data, err := n.fsm.Snapshot()
+	n.mu.Lock()
+	if err != nil {
+		slog.Error("raft snapshot failed", "node", n.id, "index", index, "error", err)
+		return
+	}
+	if index <= n.log.snapIndex {
+		// the leader installed a newer one meanwhile
+		return
+	}
+
+	snapshot := &Snapshot{Index: index, Term: term, Members: members, Data: data}
+	n.log.compact(index, term)
+	n.snapshot = snapshot
+	if err := n.cfg.Storage.SaveSnapshot(snapshot, n.log.entries); err != nil {
+		panic(fmt.Sprintf("raft: saving snapshot: %v", err))
+	}
+	slog.Debug("raft log compacted", "node", n.id, "index", index)
+}
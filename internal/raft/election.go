@@ -0,0 +1,122 @@
+package raft
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// campaign starts an election for the next term
+func (n *Node) campaign() {
+	n.resetElectionTimer()
+	if _, ok := n.members[n.id]; !ok {
+		// a node outside the configuration waits to be added
+		return
+	}
+	n.role = Candidate
+	n.term++
+	n.votedFor = n.id
+	n.leader = ""
+	n.persistState()
+
+	req := VoteRequest{Term: n.term, Candidate: n.id, LastIndex: n.log.lastIndex(), LastTerm: n.log.lastTerm()}
+	votes := map[string]bool{n.id: true}
+	if n.quorum(func(id string) bool { return votes[id] }) {
+		n.becomeLeader()
+		return
+	}
+	for id, addr := range n.members {
+		if id != n.id {
+			n.wg.Add(1)
+			go n.requestVote(id, addr, req, votes)
+		}
+	}
+}
+
+// requestVote asks one member for its vote, votes is guarded by n.mu
+func (n *Node) requestVote(id, addr string, req VoteRequest, votes map[string]bool) {
+	defer n.wg.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), n.cfg.ElectionTimeout)
+	defer cancel()
+	var reply VoteReply
+	if err := n.cfg.Transport.Call(ctx, addr, methodVote, &req, &reply); err != nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed() {
+		return
+	}
+	if reply.Term > n.term {
+		n.becomeFollower(reply.Term, "")
+		return
+	}
+	if n.role != Candidate || n.term != req.Term || !reply.Granted {
+		return
+	}
+	votes[id] = true
+	if n.quorum(func(id string) bool { return votes[id] }) {
+		n.becomeLeader()
+	}
+}
+
+func (n *Node) handleVote(req *VoteRequest, reply *VoteReply) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	reply.Term = n.term
+	if n.closed() || req.Term < n.term {
+		return
+	}
+	// while a leader is heard from, candidates are ignored, so a node that
+	// was cut off or removed cannot depose a working leader when it returns
+	if req.Term > n.term && (n.role == Leader || time.Since(n.lastContact) < n.cfg.ElectionTimeout) {
+		return
+	}
+	if req.Term > n.term {
+		n.becomeFollower(req.Term, "")
+		reply.Term = n.term
+	}
+
+	upToDate := req.LastTerm > n.log.lastTerm() ||
+		(req.LastTerm == n.log.lastTerm() && req.LastIndex >= n.log.lastIndex())
+	if (n.votedFor == "" || n.votedFor == req.Candidate) && upToDate {
+		n.votedFor = req.Candidate
+		n.persistState()
+		n.resetElectionTimer()
+		reply.Granted = true
+	}
+}
+
+func (n *Node) becomeLeader() {
+	slog.Info("raft node became leader", "node", n.id, "term", n.term)
+	n.role = Leader
+	n.leader = n.id
+	n.peers = make(map[string]*peer)
+	n.syncPeers()
+	// a leader commits entries of earlier terms only through one of its own,
+	// and cannot serve ReadIndex before it knows what is committed
+	n.appendLocal(&ProposeRequest{Type: EntryNoop})
+	n.broadcast()
+}
+
+// lostQuorum reports whether the leader has not heard from a majority within
+// an election timeout, the cluster has probably elected someone else
+func (n *Node) lostQuorum(now time.Time) bool {
+	return !n.quorum(func(id string) bool {
+		if id == n.id {
+			return true
+		}
+		p := n.peers[id]
+		return p != nil && now.Sub(p.lastAck) < n.cfg.ElectionTimeout
+	})
+}
+
+func (n *Node) closed() bool {
+	select {
+	case <-n.stopped:
+		return true
+	default:
+		return false
+	}
+}
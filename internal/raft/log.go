@@ -0,0 +1,161 @@
+package raft
+
+// EntryType tells what a log entry carries
+type EntryType uint8
+
+const (
+	// EntryCommand is a state machine command
+	EntryCommand EntryType = iota
+	// EntryNoop is appended by a new leader to commit entries of earlier terms
+	EntryNoop
+	// EntryConfig holds the full member list from its index on
+	EntryConfig
+)
+
+func (t EntryType) String() string {
+	switch t {
+	case EntryCommand:
+		return "command"
+	case EntryNoop:
+		return "noop"
+	case EntryConfig:
+		return "config"
+	default:
+		return "unknown"
+	}
+}
+
+// Entry is one slot of the replicated log
+type Entry struct {
+	Index uint64
+	Term  uint64
+	Type  EntryType
+
+	// Timestamp is the leader's Unix nano clock when it appended the entry
+	Timestamp int64
+
+	Data []byte
+
+	// Origin and Proposal identify the proposal on the node that made it, so
+	// that node can hand the applied result back to the caller
+	Origin   string
+	Proposal uint64
+}
+
+// Snapshot is the state machine as of a log index, replacing every entry up to it
+type Snapshot struct {
+	Index uint64
+	Term  uint64
+
+	// Members is the cluster configuration as of Index
+	Members map[string]string
+
+	Data []byte
+}
+
+// raftLog is the in-memory log after the latest snapshot
+type raftLog struct {
+	// snapIndex and snapTerm describe the last entry folded into the snapshot
+	snapIndex uint64
+	snapTerm  uint64
+
+	// entries[0] has index snapIndex+1
+	entries []Entry
+}
+
+func (l *raftLog) lastIndex() uint64 {
+	return l.snapIndex + uint64(len(l.entries))
+}
+
+func (l *raftLog) lastTerm() uint64 {
+	if len(l.entries) == 0 {
+		return l.snapTerm
+	}
+	return l.entries[len(l.entries)-1].Term
+}
+
+// term returns the term of the entry at index, ok is false when the log no
+// longer or not yet holds it
+func (l *raftLog) term(index uint64) (uint64, bool) {
+	switch {
+	case index == l.snapIndex:
+		return l.snapTerm, true
+	case index < l.snapIndex || index > l.lastIndex():
+		return 0, false
+	default:
+		return l.entries[index-l.snapIndex-1].Term, true
+	}
+}
+
+// entry returns the entry at index, which must be held
+func (l *raftLog) entry(index uint64) *Entry {
+	return &l.entries[index-l.snapIndex-1]
+}
+
+// slice returns a copy of the entries from from to to inclusive
+func (l *raftLog) slice(from, to uint64) []Entry {
+	if from <= l.snapIndex {
+		from = l.snapIndex + 1
+	}
+	if to > l.lastIndex() {
+		to = l.lastIndex()
+	}
+	if from > to {
+		return nil
+	}
+	out := make([]Entry, to-from+1)
+	copy(out, l.entries[from-l.snapIndex-1:to-l.snapIndex])
+	return out
+}
+
+func (l *raftLog) append(entries ...Entry) {
+	l.entries = append(l.entries, entries...)
+}
+
+// truncate drops the entries from index on
+func (l *raftLog) truncate(index uint64) {
+	if index <= l.snapIndex {
+		l.entries = nil
+		return
+	}
+	if index <= l.lastIndex() {
+		l.entries = l.entries[:index-l.snapIndex-1]
+	}
+}
+
+// compact folds the entries up to index into a snapshot with term. Entries
+// after it are kept only when the log agrees with the snapshot at index.
+func (l *raftLog) compact(index, term uint64) {
+	if t, ok := l.term(index); ok && t == term && index >= l.snapIndex {
+		l.entries = append([]Entry(nil), l.entries[index-l.snapIndex:]...)
+	} else {
+		l.entries = nil
+	}
+	l.snapIndex, l.snapTerm = index, term
+}
+
+// firstIndexOf returns the first index the log holds with the same term as
+// the entry at index, used to skip a whole conflicting term at once
+func (l *raftLog) firstIndexOf(index uint64) uint64 {
+	term, _ := l.term(index)
+	for index > l.snapIndex+1 {
+		if t, _ := l.term(index - 1); t != term {
+			break
+		}
+		index--
+	}
+	return index
+}
+
+// lastIndexOf returns the last index holding term, 0 when the log has none
+func (l *raftLog) lastIndexOf(term uint64) uint64 {
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		switch {
+		case l.entries[i].Term == term:
+			return l.entries[i].Index
+		case l.entries[i].Term < term:
+			return 0
+		}
+	}
+	return 0
+}
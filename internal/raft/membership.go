@@ -0,0 +1,132 @@
+package raft
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Membership changes add or remove one node at a time: any majority of the
+// old configuration then overlaps any majority of the new one, so the
+// change takes effect as soon as it is appended. The next change waits until
+// it commits.
+
+type changeOp uint8
+
+const (
+	opAdd changeOp = iota
+	opRemove
+)
+
+// AddMember adds a node to the cluster. The node should be started first
+// without peers; it waits until the leader sends it the log.
+func (n *Node) AddMember(ctx context.Context, id, addr string) error {
+	_, err := n.propose(ctx, EntryConfig, encodeChange(opAdd, id, addr))
+	return err
+}
+
+// RemoveMember removes a node from the cluster, a leader removing itself
+// steps down once the change commits
+func (n *Node) RemoveMember(ctx context.Context, id string) error {
+	_, err := n.propose(ctx, EntryConfig, encodeChange(opRemove, id, ""))
+	return err
+}
+
+func encodeChange(op changeOp, id, addr string) []byte {
+	e := encoder{buf: []byte{byte(op)}}
+	e.string(id)
+	e.string(addr)
+	return e.buf
+}
+
+// applyChange returns the configuration after an encoded change to members
+func applyChange(members map[string]string, data []byte) (map[string]string, error) {
+	d := decoder{buf: data}
+	op, id, addr := changeOp(d.byte()), d.string(), d.string()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if id == "" {
+		return nil, fmt.Errorf("%w: empty node id", ErrInvalidMembers)
+	}
+
+	next := make(map[string]string, len(members)+1)
+	for member, memberAddr := range members {
+		next[member] = memberAddr
+	}
+	switch op {
+	case opAdd:
+		if addr == "" {
+			return nil, fmt.Errorf("%w: empty address for %s", ErrInvalidMembers, id)
+		}
+		if _, ok := members[id]; ok {
+			return nil, fmt.Errorf("%w: %s is already a member", ErrInvalidMembers, id)
+		}
+		next[id] = addr
+	case opRemove:
+		if _, ok := members[id]; !ok {
+			return nil, ErrUnknownMember
+		}
+		if len(members) == 1 {
+			return nil, fmt.Errorf("%w: cannot remove the last member", ErrInvalidMembers)
+		}
+		delete(next, id)
+	default:
+		return nil, fmt.Errorf("%w: unknown change %d", ErrInvalidMembers, op)
+	}
+	return next, nil
+}
+
+func encodeMembers(members map[string]string) []byte {
+	ids := make([]string, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var e encoder
+	e.uint64(uint64(len(ids)))
+	for _, id := range ids {
+		e.string(id)
+		e.string(members[id])
+	}
+	return e.buf
+}
+
+func decodeMembers(data []byte) map[string]string {
+	d := decoder{buf: data}
+	members := make(map[string]string)
+	for count := d.uint64(); count > 0 && d.err == nil; count-- {
+		id := d.string()
+		members[id] = d.string()
+	}
+	return members
+}
+
+// reloadMembers takes the configuration from the latest config entry in the
+// log, or the snapshot when the log has none
+func (n *Node) reloadMembers() {
+	n.members, n.configIndex = n.configAt(n.log.lastIndex()), n.log.snapIndex
+	for i := len(n.log.entries) - 1; i >= 0; i-- {
+		if n.log.entries[i].Type == EntryConfig {
+			n.configIndex = n.log.entries[i].Index
+			break
+		}
+	}
+	n.syncPeers()
+}
+
+// configAt returns the configuration as of index
+func (n *Node) configAt(index uint64) map[string]string {
+	for i := index; i > n.log.snapIndex; i-- {
+		if entry := n.log.entry(i); entry.Type == EntryConfig {
+			return decodeMembers(entry.Data)
+		}
+	}
+	members := make(map[string]string)
+	if n.snapshot != nil {
+		for id, addr := range n.snapshot.Members {
+			members[id] = addr
+		}
+	}
+	return members
+}
@@ -0,0 +1,620 @@
+// Package raft is an embedded implementation of the Raft consensus algorithm:
+// leader election, log replication, snapshots and single-server membership
+// changes, with ReadIndex reads. Entries are numbered from 1 and a state
+// machine sees every committed command exactly once, in index order, on every
+// node.
+package raft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotLeader      = errors.New("raft: not the leader")
+	ErrNoLeader       = errors.New("raft: no leader")
+	ErrStopped        = errors.New("raft: node stopped")
+	ErrConfigPending  = errors.New("raft: a membership change is in progress")
+	ErrUnknownMember  = errors.New("raft: unknown member")
+	ErrLost           = errors.New("raft: entry lost in a leader change")
+	ErrResultUnknown  = errors.New("raft: entry applied from a snapshot, result unknown")
+	ErrInvalidMembers = errors.New("raft: invalid membership")
+)
+
+// Role is a node's part in the current term
+type Role int
+
+const (
+	Follower Role = iota
+	Candidate
+	Leader
+)
+
+func (r Role) String() string {
+	switch r {
+	case Follower:
+		return "follower"
+	case Candidate:
+		return "candidate"
+	case Leader:
+		return "leader"
+	default:
+		return "unknown"
+	}
+}
+
+// StateMachine is what the log is applied to
+type StateMachine interface {
+	// Apply runs a committed command entry and returns its result, handed
+	// back to the proposer when it proposed on this node
+	Apply(entry Entry) any
+
+	// Snapshot captures the state after the last applied entry
+	Snapshot() ([]byte, error)
+
+	// Restore replaces the state with a snapshot
+	Restore(snapshot *Snapshot) error
+}
+
+// Config configures a node
+type Config struct {
+	// ID names the node in the cluster
+	ID string
+
+	// Addr is the address the node receives RPCs on
+	Addr string
+
+	// Peers is the initial cluster as id -> address, this node included. It
+	// is only used when the node starts with empty storage: every founding
+	// node must get the same peers. A node started without peers waits until
+	// a leader adds it.
+	Peers map[string]string
+
+	// ElectionTimeout is the minimum time without a leader before a node
+	// campaigns, the actual timeout is random in [ElectionTimeout, 2*ElectionTimeout)
+	ElectionTimeout time.Duration
+
+	// HeartbeatInterval is how often the leader contacts idle followers
+	HeartbeatInterval time.Duration
+
+	// SnapshotThreshold is how many applied entries trigger a snapshot that
+	// compacts the log
+	SnapshotThreshold uint64
+
+	// MaxAppendEntries bounds the entries sent in one AppendEntries RPC
+	MaxAppendEntries int
+
+	// Storage keeps the state durable (nil = in memory)
+	Storage Storage
+
+	// Transport carries RPCs (nil = TCP on Addr)
+	Transport Transport
+}
+
+const (
+	DefaultElectionTimeout   = 500 * time.Millisecond
+	DefaultHeartbeatInterval = 50 * time.Millisecond
+	DefaultSnapshotThreshold = 8192
+	DefaultMaxAppendEntries  = 512
+)
+
+func (c *Config) withDefaults() {
+	if c.ElectionTimeout <= 0 {
+		c.ElectionTimeout = DefaultElectionTimeout
+	}
+	if c.HeartbeatInterval <= 0 {
+		c.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if c.SnapshotThreshold == 0 {
+		c.SnapshotThreshold = DefaultSnapshotThreshold
+	}
+	if c.MaxAppendEntries <= 0 {
+		c.MaxAppendEntries = DefaultMaxAppendEntries
+	}
+	if c.Storage == nil {
+		c.Storage = NewMemoryStorage()
+	}
+	if c.Transport == nil {
+		c.Transport = NewTCPTransport(c.Addr)
+	}
+}
+
+// Node is one member of a Raft cluster
+type Node struct {
+	id  string
+	cfg Config
+	fsm StateMachine
+
+	// mu guards everything below
+	mu sync.Mutex
+
+	role     Role
+	term     uint64
+	votedFor string
+	leader   string
+
+	log *raftLog
+
+	// snapshot is the latest snapshot, sent to followers behind the log
+	snapshot *Snapshot
+
+	// members is the configuration of the latest config entry in the log
+	// (it takes effect as soon as it is appended), configIndex its index
+	members     map[string]string
+	configIndex uint64
+
+	commitIndex uint64
+	lastApplied uint64
+
+	// restoredIndex is the index of the last snapshot restored into the
+	// state machine, entries up to it were never applied one by one here
+	restoredIndex uint64
+
+	// pendingSnapshot was installed by the leader and awaits the applier
+	pendingSnapshot *Snapshot
+
+	electionDeadline time.Time
+	// lastContact is when a leader was last heard from
+	lastContact time.Time
+
+	// peers is the replication state of every other member while leader
+	peers map[string]*peer
+
+	// heartbeatSeq numbers heartbeat rounds, lastBroadcast is the last round's start
+	heartbeatSeq  uint64
+	lastBroadcast time.Time
+
+	// proposals waits for results of entries proposed on this node
+	proposals    map[uint64]*proposal
+	nextProposal uint64
+
+	// applied and acked are closed and replaced when entries are applied or
+	// followers acknowledge a heartbeat round
+	applied chan struct{}
+	acked   chan struct{}
+
+	applyCond *sync.Cond
+	stopped   chan struct{}
+	wg        sync.WaitGroup
+}
+
+// peer is the leader's view of one follower
+type peer struct {
+	id, addr string
+	next     uint64
+	match    uint64
+
+	// ackSeq is the newest heartbeat round the follower answered in the
+	// current term, lastAck when it last answered
+	ackSeq  uint64
+	lastAck time.Time
+
+	trigger chan struct{}
+	stop    chan struct{}
+}
+
+// proposal is a caller waiting for its entry to be applied
+type proposal struct {
+	// index and term are where the entry was appended, 0 until known
+	index uint64
+	term  uint64
+
+	done   chan struct{}
+	result any
+	err    error
+}
+
+// Start restores a node from its storage and starts it
+func Start(cfg Config, fsm StateMachine) (*Node, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("raft: node id is required")
+	}
+	cfg.withDefaults()
+
+	state, snapshot, entries, err := cfg.Storage.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Node{
+		id:        cfg.ID,
+		cfg:       cfg,
+		fsm:       fsm,
+		term:      state.Term,
+		votedFor:  state.Vote,
+		log:       &raftLog{},
+		members:   map[string]string{},
+		proposals: make(map[uint64]*proposal),
+		// proposal ids must not repeat across restarts, or an entry proposed
+		// before one would be handed to a caller after it
+		nextProposal: uint64(time.Now().UnixNano()),
+		applied:      make(chan struct{}),
+		acked:        make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+	n.applyCond = sync.NewCond(&n.mu)
+
+	if snapshot != nil {
+		n.snapshot = snapshot
+		n.log.snapIndex, n.log.snapTerm = snapshot.Index, snapshot.Term
+		if err := fsm.Restore(snapshot); err != nil {
+			return nil, fmt.Errorf("raft: restoring snapshot: %w", err)
+		}
+		n.commitIndex, n.lastApplied, n.restoredIndex = snapshot.Index, snapshot.Index, snapshot.Index
+	}
+	n.log.append(followingSnapshot(n.log.snapIndex, n.log.snapTerm, entries)...)
+
+	if n.log.lastIndex() == 0 && len(cfg.Peers) > 0 {
+		// a founding node: the initial configuration is the first entry, the
+		// same on every founding node
+		bootstrap := Entry{Index: 1, Type: EntryConfig, Data: encodeMembers(cfg.Peers)}
+		if err := cfg.Storage.Append([]Entry{bootstrap}); err != nil {
+			return nil, err
+		}
+		n.log.append(bootstrap)
+	}
+	n.reloadMembers()
+	n.resetElectionTimer()
+
+	if err := cfg.Transport.Serve(n); err != nil {
+		return nil, err
+	}
+	n.wg.Add(2)
+	go n.ticker()
+	go n.applier()
+	return n, nil
+}
+
+// Stop shuts the node down, pending proposals fail with ErrStopped
+func (n *Node) Stop() {
+	n.mu.Lock()
+	select {
+	case <-n.stopped:
+		n.mu.Unlock()
+		return
+	default:
+	}
+	close(n.stopped)
+	n.stopReplicators()
+	for id, p := range n.proposals {
+		p.finish(nil, ErrStopped)
+		delete(n.proposals, id)
+	}
+	n.applyCond.Broadcast()
+	n.mu.Unlock()
+
+	n.cfg.Transport.Close()
+	n.wg.Wait()
+	n.cfg.Storage.Close()
+}
+
+// Status describes a node
+type Status struct {
+	ID            string
+	Role          Role
+	Term          uint64
+	Leader        string
+	CommitIndex   uint64
+	AppliedIndex  uint64
+	LastIndex     uint64
+	SnapshotIndex uint64
+	Members       map[string]string
+}
+
+func (n *Node) Status() Status {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	members := make(map[string]string, len(n.members))
+	for id, addr := range n.members {
+		members[id] = addr
+	}
+	return Status{
+		ID:            n.id,
+		Role:          n.role,
+		Term:          n.term,
+		Leader:        n.leader,
+		CommitIndex:   n.commitIndex,
+		AppliedIndex:  n.lastApplied,
+		LastIndex:     n.log.lastIndex(),
+		SnapshotIndex: n.log.snapIndex,
+		Members:       members,
+	}
+}
+
+// ticker drives elections and heartbeats
+func (n *Node) ticker() {
+	defer n.wg.Done()
+	tick := time.NewTicker(min(n.cfg.HeartbeatInterval/2, 10*time.Millisecond))
+	defer tick.Stop()
+	for {
+		select {
+		case <-n.stopped:
+			return
+		case <-tick.C:
+		}
+
+		n.mu.Lock()
+		if n.closed() {
+			n.mu.Unlock()
+			return
+		}
+		now := time.Now()
+		switch {
+		case n.role == Leader:
+			if n.lostQuorum(now) {
+				slog.Warn("raft leader lost contact with a majority, stepping down", "node", n.id, "term", n.term)
+				n.becomeFollower(n.term, "")
+			} else if now.Sub(n.lastBroadcast) >= n.cfg.HeartbeatInterval {
+				n.broadcast()
+			}
+		case now.After(n.electionDeadline):
+			n.campaign()
+		}
+		n.mu.Unlock()
+	}
+}
+
+func (n *Node) resetElectionTimer() {
+	timeout := n.cfg.ElectionTimeout
+	n.electionDeadline = time.Now().Add(timeout + rand.N(timeout))
+}
+
+func (n *Node) persistState() {
+	if err := n.cfg.Storage.SaveState(HardState{Term: n.term, Vote: n.votedFor}); err != nil {
+		// a node that cannot remember its vote must not keep voting
+		panic(fmt.Sprintf("raft: saving state: %v", err))
+	}
+}
+
+func (n *Node) persistAppend(entries []Entry) {
+	if err := n.cfg.Storage.Append(entries); err != nil {
+		panic(fmt.Sprintf("raft: appending to log: %v", err))
+	}
+}
+
+func (n *Node) persistRewrite() {
+	if err := n.cfg.Storage.Rewrite(n.log.entries); err != nil {
+		panic(fmt.Sprintf("raft: rewriting log: %v", err))
+	}
+}
+
+// followingSnapshot returns the loaded entries that continue the snapshot. A
+// crash between saving a snapshot and rewriting the log leaves entries the
+// snapshot already holds, and ones that disagree with it when it came from
+// the leader.
+func followingSnapshot(snapIndex, snapTerm uint64, entries []Entry) []Entry {
+	for i, entry := range entries {
+		switch {
+		case entry.Index == snapIndex+1 && i == 0:
+			return entries
+		case entry.Index == snapIndex:
+			if entry.Term != snapTerm {
+				return nil
+			}
+			return entries[i+1:]
+		}
+	}
+	return nil
+}
+
+// becomeFollower moves to term (when newer) and follows leader ("" = unknown)
+func (n *Node) becomeFollower(term uint64, leader string) {
+	if term > n.term {
+		n.term, n.votedFor = term, ""
+		n.persistState()
+	}
+	if n.role == Leader {
+		// uncommitted entries may still be committed by the next leader, so
+		// their proposals keep waiting until their index is applied
+		n.stopReplicators()
+	}
+	n.role = Follower
+	n.leader = leader
+}
+
+// quorum reports whether the members in votes form a majority
+func (n *Node) quorum(votes func(id string) bool) bool {
+	count := 0
+	for id := range n.members {
+		if votes(id) {
+			count++
+		}
+	}
+	return count > len(n.members)/2
+}
+
+// notify wakes everyone waiting on ch and arms a fresh one
+func notify(ch *chan struct{}) {
+	close(*ch)
+	*ch = make(chan struct{})
+}
+
+// Propose appends a command to the log and returns the state machine's
+// result once the entry is applied on this node. Followers forward the entry
+// to the leader. An error does not always mean the command was dropped: it
+// may still commit when ctx ends first.
+func (n *Node) Propose(ctx context.Context, data []byte) (any, error) {
+	return n.propose(ctx, EntryCommand, data)
+}
+
+func (n *Node) propose(ctx context.Context, typ EntryType, data []byte) (any, error) {
+	n.mu.Lock()
+	n.nextProposal++
+	id := n.nextProposal
+	p := &proposal{done: make(chan struct{})}
+	n.proposals[id] = p
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		delete(n.proposals, id)
+		n.mu.Unlock()
+	}()
+
+	req := &ProposeRequest{Type: typ, Data: data, Origin: n.id, Proposal: id}
+	if err := n.submit(ctx, req, p); err != nil {
+		return nil, err
+	}
+	select {
+	case <-p.done:
+		return p.result, p.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-n.stopped:
+		return nil, ErrStopped
+	}
+}
+
+// submit gets the proposal appended to the leader's log
+func (n *Node) submit(ctx context.Context, req *ProposeRequest, p *proposal) error {
+	local := func() error {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if n.role != Leader {
+			return ErrNotLeader
+		}
+		index, term, err := n.appendLocal(req)
+		if err == nil {
+			p.index, p.term = index, term
+		}
+		return err
+	}
+	remote := func(addr string) error {
+		var reply ProposeReply
+		if err := n.cfg.Transport.Call(ctx, addr, methodPropose, req, &reply); err != nil {
+			return err
+		}
+		if err := replyError(reply.Err); err != nil {
+			return err
+		}
+		n.mu.Lock()
+		p.index, p.term = reply.Index, reply.Term
+		// the entry may have been applied here before the leader replied
+		n.settleProposals()
+		n.mu.Unlock()
+		return nil
+	}
+	return n.forward(ctx, local, remote)
+}
+
+// forward runs local when this node leads and remote with the leader's
+// address otherwise, retrying while there is no leader or it cannot be reached
+func (n *Node) forward(ctx context.Context, local func() error, remote func(addr string) error) error {
+	for {
+		n.mu.Lock()
+		leading := n.role == Leader
+		addr := ""
+		if !leading && n.leader != "" {
+			addr = n.members[n.leader]
+		}
+		n.mu.Unlock()
+
+		var err error
+		switch {
+		case leading:
+			err = local()
+		case addr != "":
+			err = remote(addr)
+		default:
+			err = ErrNoLeader
+		}
+		if !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", err, ctx.Err())
+		case <-n.stopped:
+			return ErrStopped
+		case <-time.After(n.cfg.HeartbeatInterval):
+		}
+	}
+}
+
+// retryable reports whether a proposal certainly did not reach a log
+func retryable(err error) bool {
+	return errors.Is(err, ErrNoLeader) || errors.Is(err, ErrNotLeader) || errors.Is(err, ErrUnreachable)
+}
+
+// replyErrors maps errors sent back over RPC to their values
+var replyErrors = []error{ErrNotLeader, ErrNoLeader, ErrStopped, ErrConfigPending, ErrUnknownMember, ErrInvalidMembers}
+
+func replyError(msg string) error {
+	if msg == "" {
+		return nil
+	}
+	for _, err := range replyErrors {
+		if err.Error() == msg {
+			return err
+		}
+	}
+	return errors.New(msg)
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// handlePropose appends an entry for a follower
+func (n *Node) handlePropose(req *ProposeRequest, reply *ProposeReply) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.role != Leader {
+		reply.Err = ErrNotLeader.Error()
+		return
+	}
+	index, term, err := n.appendLocal(req)
+	reply.Index, reply.Term, reply.Err = index, term, errorString(err)
+}
+
+// appendLocal appends a proposal to the leader's log and starts replicating it
+func (n *Node) appendLocal(req *ProposeRequest) (uint64, uint64, error) {
+	select {
+	case <-n.stopped:
+		return 0, 0, ErrStopped
+	default:
+	}
+	data := req.Data
+	if req.Type == EntryConfig {
+		// a new leader must also commit an entry of its own term first, a
+		// change the previous leader left uncommitted may still be pending
+		if committed, _ := n.log.term(n.commitIndex); n.configIndex > n.commitIndex || committed != n.term {
+			return 0, 0, ErrConfigPending
+		}
+		members, err := applyChange(n.members, data)
+		if err != nil {
+			return 0, 0, err
+		}
+		data = encodeMembers(members)
+	}
+
+	// timestamps never go backwards in the log, even when leaders' clocks disagree
+	timestamp := time.Now().UnixNano()
+	if last := len(n.log.entries); last > 0 {
+		timestamp = max(timestamp, n.log.entries[last-1].Timestamp)
+	}
+	entry := Entry{
+		Index:     n.log.lastIndex() + 1,
+		Term:      n.term,
+		Type:      req.Type,
+		Timestamp: timestamp,
+		Data:      data,
+		Origin:    req.Origin,
+		Proposal:  req.Proposal,
+	}
+	n.log.append(entry)
+	n.persistAppend([]Entry{entry})
+	if entry.Type == EntryConfig {
+		n.reloadMembers()
+	}
+	n.advanceCommit()
+	n.triggerAll()
+	return entry.Index, entry.Term, nil
+}
@@ -0,0 +1,359 @@
+package raft_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/raft"
+)
+
+// appendLog is a state machine recording every applied command in order
+type appendLog struct {
+	mu      sync.Mutex
+	applied []string
+}
+
+func (l *appendLog) Apply(entry raft.Entry) any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.applied = append(l.applied, string(entry.Data))
+	return len(l.applied)
+}
+
+func (l *appendLog) Snapshot() ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return json.Marshal(l.applied)
+}
+
+func (l *appendLog) Restore(snapshot *raft.Snapshot) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.applied = nil
+	return json.Unmarshal(snapshot.Data, &l.applied)
+}
+
+func (l *appendLog) commands() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.applied)
+}
+
+type cluster struct {
+	t       *testing.T
+	network *raft.MemoryNetwork
+	nodes   map[string]*raft.Node
+	logs    map[string]*appendLog
+	storage map[string]raft.Storage
+}
+
+func newCluster(t *testing.T, size int, threshold uint64) *cluster {
+	c := &cluster{
+		t:       t,
+		network: raft.NewMemoryNetwork(),
+		nodes:   make(map[string]*raft.Node),
+		logs:    make(map[string]*appendLog),
+		storage: make(map[string]raft.Storage),
+	}
+	peers := make(map[string]string)
+	for i := 1; i <= size; i++ {
+		peers[fmt.Sprintf("n%d", i)] = fmt.Sprintf("addr%d", i)
+	}
+	for id := range peers {
+		c.start(id, peers, threshold)
+	}
+	t.Cleanup(func() {
+		for _, node := range c.nodes {
+			node.Stop()
+		}
+	})
+	return c
+}
+
+func (c *cluster) start(id string, peers map[string]string, threshold uint64) {
+	c.t.Helper()
+	if c.storage[id] == nil {
+		c.storage[id] = raft.NewMemoryStorage()
+	}
+	c.logs[id] = &appendLog{}
+	node, err := raft.Start(raft.Config{
+		ID:                id,
+		Addr:              "addr" + id[1:],
+		Peers:             peers,
+		ElectionTimeout:   100 * time.Millisecond,
+		HeartbeatInterval: 20 * time.Millisecond,
+		SnapshotThreshold: threshold,
+		Storage:           c.storage[id],
+		Transport:         c.network.Transport("addr" + id[1:]),
+	}, c.logs[id])
+	if err != nil {
+		c.t.Fatalf("Start(%s): %v", id, err)
+	}
+	c.nodes[id] = node
+}
+
+func (c *cluster) stop(id string) {
+	c.nodes[id].Stop()
+	delete(c.nodes, id)
+}
+
+// leader waits for a single leader among the running nodes
+func (c *cluster) leader() string {
+	c.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var leaders []string
+		for id, node := range c.nodes {
+			if node.Status().Role == raft.Leader {
+				leaders = append(leaders, id)
+			}
+		}
+		if len(leaders) == 1 {
+			return leaders[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.t.Fatal("no single leader elected")
+	return ""
+}
+
+func (c *cluster) propose(id, command string) any {
+	c.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := c.nodes[id].Propose(ctx, []byte(command))
+	if err != nil {
+		c.t.Fatalf("Propose(%s) on %s: %v", command, id, err)
+	}
+	return result
+}
+
+// converge waits until every running node applied exactly want
+func (c *cluster) converge(want []string) {
+	c.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		done := true
+		for id := range c.nodes {
+			if !slices.Equal(c.logs[id].commands(), want) {
+				done = false
+			}
+		}
+		if done {
+			return
+		}
+		if time.Now().After(deadline) {
+			for id := range c.nodes {
+				c.t.Errorf("%s applied %v", id, c.logs[id].commands())
+			}
+			c.t.Fatalf("nodes did not converge on %v", want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestElectsSingleLeader(t *testing.T) {
+	c := newCluster(t, 3, 0)
+	leader := c.leader()
+	term := c.nodes[leader].Status().Term
+	for id, node := range c.nodes {
+		status := node.Status()
+		if id != leader && status.Role == raft.Leader {
+			t.Errorf("%s also leads in term %d", id, status.Term)
+		}
+		if len(status.Members) != 3 {
+			t.Errorf("%s members = %v", id, status.Members)
+		}
+	}
+	if term == 0 {
+		t.Error("leader elected in term 0")
+	}
+}
+
+func TestProposeFromLeaderAndFollower(t *testing.T) {
+	c := newCluster(t, 3, 0)
+	leader := c.leader()
+	follower := "n1"
+	if follower == leader {
+		follower = "n2"
+	}
+
+	if got := c.propose(leader, "a"); got != 1 {
+		t.Errorf("Propose(a) = %v, want 1", got)
+	}
+	if got := c.propose(follower, "b"); got != 2 {
+		t.Errorf("Propose(b) from follower = %v, want 2", got)
+	}
+	c.converge([]string{"a", "b"})
+}
+
+func TestReelectsAfterLeaderFailure(t *testing.T) {
+	c := newCluster(t, 3, 0)
+	old := c.leader()
+	c.propose(old, "a")
+	c.converge([]string{"a"})
+
+	c.network.Isolate("addr" + old[1:])
+	survivor := "n1"
+	if survivor == old {
+		survivor = "n2"
+	}
+	c.stop(old)
+	leader := c.leader()
+	if leader == old {
+		t.Fatalf("isolated %s still leads", old)
+	}
+	c.propose(survivor, "b")
+	c.converge([]string{"a", "b"})
+}
+
+func TestPartitionedLeaderCannotCommit(t *testing.T) {
+	c := newCluster(t, 3, 0)
+	old := c.leader()
+	c.network.Isolate("addr" + old[1:])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if _, err := c.nodes[old].Propose(ctx, []byte("lost")); err == nil {
+		t.Fatal("isolated leader committed a proposal")
+	}
+
+	c.network.Heal("addr" + old[1:])
+	leader := c.leader()
+	c.propose(leader, "kept")
+	c.converge([]string{"kept"})
+}
+
+func TestLaggingFollowerInstallsSnapshot(t *testing.T) {
+	c := newCluster(t, 3, 4)
+	leader := c.leader()
+	lagging := "n1"
+	if lagging == leader {
+		lagging = "n2"
+	}
+	c.network.Isolate("addr" + lagging[1:])
+
+	var want []string
+	for i := range 20 {
+		command := fmt.Sprintf("c%d", i)
+		want = append(want, command)
+		c.propose(leader, command)
+	}
+	if status := c.nodes[leader].Status(); status.SnapshotIndex == 0 {
+		t.Fatalf("leader did not compact its log: %+v", status)
+	}
+
+	c.network.Heal("addr" + lagging[1:])
+	c.converge(want)
+	if status := c.nodes[lagging].Status(); status.SnapshotIndex == 0 {
+		t.Errorf("lagging follower caught up without a snapshot: %+v", status)
+	}
+}
+
+func TestAddAndRemoveMember(t *testing.T) {
+	c := newCluster(t, 3, 0)
+	leader := c.leader()
+	c.propose(leader, "a")
+
+	c.start("n4", nil, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.nodes[leader].AddMember(ctx, "n4", "addr4"); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	c.propose(leader, "b")
+	c.converge([]string{"a", "b"})
+	if members := c.nodes["n4"].Status().Members; len(members) != 4 {
+		t.Errorf("n4 members = %v", members)
+	}
+
+	if err := c.nodes[leader].RemoveMember(ctx, leader); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	c.stop(leader)
+	next := c.leader()
+	c.propose(next, "c")
+	c.converge([]string{"a", "b", "c"})
+	if members := c.nodes[next].Status().Members; len(members) != 3 {
+		t.Errorf("members after removal = %v", members)
+	}
+
+	if err := c.nodes[next].RemoveMember(ctx, "nope"); !errors.Is(err, raft.ErrUnknownMember) {
+		t.Errorf("RemoveMember(unknown) = %v, want ErrUnknownMember", err)
+	}
+}
+
+func TestReadIndexSeesCommittedWrites(t *testing.T) {
+	c := newCluster(t, 3, 0)
+	leader := c.leader()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := range 10 {
+		c.propose(leader, fmt.Sprint(i))
+		for id, node := range c.nodes {
+			if err := node.ReadIndex(ctx); err != nil {
+				t.Fatalf("ReadIndex on %s: %v", id, err)
+			}
+			if got := len(c.logs[id].commands()); got != i+1 {
+				t.Fatalf("%s read after ReadIndex sees %d commands, want %d", id, got, i+1)
+			}
+		}
+	}
+}
+
+func TestIsolatedLeaderFailsReadIndex(t *testing.T) {
+	c := newCluster(t, 3, 0)
+	leader := c.leader()
+	c.network.Isolate("addr" + leader[1:])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := c.nodes[leader].ReadIndex(ctx); err == nil {
+		t.Error("isolated leader confirmed a read")
+	}
+}
+
+func TestFileStorageRestart(t *testing.T) {
+	dir := t.TempDir()
+	open := func() raft.Storage {
+		storage, err := raft.OpenFileStorage(dir)
+		if err != nil {
+			t.Fatalf("OpenFileStorage: %v", err)
+		}
+		return storage
+	}
+
+	c := &cluster{
+		t:       t,
+		network: raft.NewMemoryNetwork(),
+		nodes:   make(map[string]*raft.Node),
+		logs:    make(map[string]*appendLog),
+		storage: map[string]raft.Storage{"n1": open()},
+	}
+	peers := map[string]string{"n1": "addr1"}
+	c.start("n1", peers, 5)
+	for i := range 12 {
+		c.propose("n1", fmt.Sprint(i))
+	}
+	want := c.logs["n1"].commands()
+	term := c.nodes["n1"].Status().Term
+	c.stop("n1")
+
+	c.storage["n1"] = open()
+	c.start("n1", peers, 5)
+	defer c.stop("n1")
+	c.leader()
+	c.converge(want)
+	if got := c.nodes["n1"].Status().Term; got <= term {
+		t.Errorf("term after restart = %d, want above %d", got, term)
+	}
+	c.propose("n1", "after")
+	c.converge(append(want, "after"))
+}
@@ -0,0 +1,108 @@
+package raft
+
+import (
+	"context"
+)
+
+// ReadIndex waits until this node's state machine reflects every write
+// committed before the call, so a read that follows is linearizable. The
+// leader confirms with a majority that it still leads; a follower asks the
+// leader for its commit index and waits to apply up to it.
+func (n *Node) ReadIndex(ctx context.Context) error {
+	var index uint64
+	local := func() (err error) {
+		index, err = n.leaderReadIndex(ctx)
+		return err
+	}
+	remote := func(addr string) error {
+		var reply ReadIndexReply
+		if err := n.cfg.Transport.Call(ctx, addr, methodReadIndex, &ReadIndexRequest{From: n.id}, &reply); err != nil {
+			return err
+		}
+		index = reply.Index
+		return replyError(reply.Err)
+	}
+	if err := n.forward(ctx, local, remote); err != nil {
+		return err
+	}
+	return n.waitApplied(ctx, index)
+}
+
+// leaderReadIndex returns the commit index once a heartbeat round started
+// after reading it was answered by a majority
+func (n *Node) leaderReadIndex(ctx context.Context) (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.role != Leader {
+		return 0, ErrNotLeader
+	}
+	term := n.term
+
+	// a new leader knows what is committed once its first entry is
+	for {
+		if committed, _ := n.log.term(n.commitIndex); committed == term {
+			break
+		}
+		if err := n.waitSignal(ctx, n.applied); err != nil {
+			return 0, err
+		}
+		if n.role != Leader || n.term != term {
+			return 0, ErrNotLeader
+		}
+	}
+
+	index := n.commitIndex
+	n.broadcast()
+	seq := n.heartbeatSeq
+	for {
+		confirmed := n.quorum(func(id string) bool {
+			if id == n.id {
+				return true
+			}
+			p := n.peers[id]
+			return p != nil && p.ackSeq >= seq
+		})
+		if confirmed {
+			return index, nil
+		}
+		if err := n.waitSignal(ctx, n.acked); err != nil {
+			return 0, err
+		}
+		if n.role != Leader || n.term != term {
+			return 0, ErrNotLeader
+		}
+	}
+}
+
+func (n *Node) handleReadIndex(reply *ReadIndexReply) {
+	ctx, cancel := context.WithTimeout(context.Background(), n.cfg.ElectionTimeout)
+	defer cancel()
+	index, err := n.leaderReadIndex(ctx)
+	reply.Index, reply.Err = index, errorString(err)
+}
+
+// waitApplied blocks until the entry at index was applied on this node
+func (n *Node) waitApplied(ctx context.Context, index uint64) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for n.lastApplied < index {
+		if err := n.waitSignal(ctx, n.applied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitSignal releases n.mu until ch is closed, ctx ends or the node stops
+func (n *Node) waitSignal(ctx context.Context, ch chan struct{}) error {
+	n.mu.Unlock()
+	defer n.mu.Lock()
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-n.stopped:
+		return ErrStopped
+	}
+}
@@ -0,0 +1,300 @@
+package raft
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// snapshotTimeout bounds sending a snapshot, which can be far larger than
+// any AppendEntries
+const snapshotTimeout = time.Minute
+
+// syncPeers starts replicating to members without a replicator and stops
+// replicating to ones no longer in the configuration
+func (n *Node) syncPeers() {
+	if n.role != Leader {
+		return
+	}
+	for id, addr := range n.members {
+		if id == n.id || n.peers[id] != nil {
+			continue
+		}
+		p := &peer{
+			id:      id,
+			addr:    addr,
+			next:    n.log.lastIndex() + 1,
+			lastAck: time.Now(),
+			trigger: make(chan struct{}, 1),
+			stop:    make(chan struct{}),
+		}
+		n.peers[id] = p
+		n.wg.Add(1)
+		go n.replicate(p, n.term)
+		n.trigger(p)
+	}
+	for id, p := range n.peers {
+		if _, ok := n.members[id]; !ok {
+			close(p.stop)
+			delete(n.peers, id)
+		}
+	}
+}
+
+func (n *Node) stopReplicators() {
+	for id, p := range n.peers {
+		close(p.stop)
+		delete(n.peers, id)
+	}
+}
+
+func (n *Node) trigger(p *peer) {
+	select {
+	case p.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (n *Node) triggerAll() {
+	for _, p := range n.peers {
+		n.trigger(p)
+	}
+}
+
+// broadcast starts a heartbeat round, every follower is contacted
+func (n *Node) broadcast() {
+	n.heartbeatSeq++
+	n.lastBroadcast = time.Now()
+	n.triggerAll()
+}
+
+// replicate sends entries to one follower each time it is triggered, until
+// the follower catches up
+func (n *Node) replicate(p *peer, term uint64) {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-n.stopped:
+			return
+		case <-p.trigger:
+		}
+		for n.sendTo(p, term) {
+		}
+	}
+}
+
+// sendTo sends one AppendEntries or InstallSnapshot and reports whether
+// there is more to send right away
+func (n *Node) sendTo(p *peer, term uint64) bool {
+	n.mu.Lock()
+	if n.role != Leader || n.term != term || n.closed() {
+		n.mu.Unlock()
+		return false
+	}
+	seq := n.heartbeatSeq
+	if p.next <= n.log.snapIndex {
+		req := &SnapshotRequest{Term: term, Leader: n.id, Snapshot: *n.snapshot, Seq: seq}
+		n.mu.Unlock()
+		return n.sendSnapshot(p, req)
+	}
+	prevTerm, _ := n.log.term(p.next - 1)
+	req := &AppendRequest{
+		Term:      term,
+		Leader:    n.id,
+		PrevIndex: p.next - 1,
+		PrevTerm:  prevTerm,
+		Entries:   n.log.slice(p.next, p.next+uint64(n.cfg.MaxAppendEntries)-1),
+		Commit:    n.commitIndex,
+		Seq:       seq,
+	}
+	n.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.cfg.ElectionTimeout)
+	defer cancel()
+	var reply AppendReply
+	if err := n.cfg.Transport.Call(ctx, p.addr, methodAppend, req, &reply); err != nil {
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.acknowledged(p, term, reply.Term, seq) {
+		return false
+	}
+	if reply.Success {
+		p.match = max(p.match, req.PrevIndex+uint64(len(req.Entries)))
+		p.next = p.match + 1
+		n.advanceCommit()
+		return p.next <= n.log.lastIndex()
+	}
+
+	// skip the follower's whole conflicting term, or the leader's one when
+	// it holds entries of it
+	next := reply.ConflictIndex
+	if reply.ConflictTerm != 0 {
+		if last := n.log.lastIndexOf(reply.ConflictTerm); last > 0 {
+			next = last + 1
+		}
+	}
+	p.next = max(min(next, req.PrevIndex), p.match+1, 1)
+	return true
+}
+
+func (n *Node) sendSnapshot(p *peer, req *SnapshotRequest) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+	var reply SnapshotReply
+	if err := n.cfg.Transport.Call(ctx, p.addr, methodSnapshot, req, &reply); err != nil {
+		slog.Warn("raft snapshot not delivered", "node", n.id, "peer", p.id, "error", err)
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.acknowledged(p, req.Term, reply.Term, req.Seq) {
+		return false
+	}
+	p.match = max(p.match, req.Snapshot.Index)
+	p.next = p.match + 1
+	return true
+}
+
+// acknowledged handles a follower's reply term: a newer one ends this
+// node's leadership, one of the leader's term confirms the heartbeat round seq
+func (n *Node) acknowledged(p *peer, term, replyTerm, seq uint64) bool {
+	if replyTerm > n.term {
+		n.becomeFollower(replyTerm, "")
+		return false
+	}
+	if n.role != Leader || n.term != term || n.closed() {
+		return false
+	}
+	p.ackSeq = max(p.ackSeq, seq)
+	p.lastAck = time.Now()
+	notify(&n.acked)
+	return true
+}
+
+// advanceCommit commits the newest entry of the current term stored on a majority
+func (n *Node) advanceCommit() {
+	if n.role != Leader {
+		return
+	}
+	for index := n.log.lastIndex(); index > n.commitIndex; index-- {
+		if term, _ := n.log.term(index); term != n.term {
+			return
+		}
+		stored := n.quorum(func(id string) bool {
+			if id == n.id {
+				return true
+			}
+			p := n.peers[id]
+			return p != nil && p.match >= index
+		})
+		if stored {
+			n.commit(index)
+			return
+		}
+	}
+}
+
+func (n *Node) commit(index uint64) {
+	n.commitIndex = index
+	n.applyCond.Broadcast()
+	if n.role != Leader {
+		return
+	}
+	if _, ok := n.members[n.id]; !ok && n.configIndex <= index {
+		slog.Info("raft leader removed from the cluster, stepping down", "node", n.id, "term", n.term)
+		n.becomeFollower(n.term, "")
+		return
+	}
+	// followers learn the new commit index right away
+	n.triggerAll()
+}
+
+func (n *Node) handleAppend(req *AppendRequest, reply *AppendReply) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	reply.Term = n.term
+	if n.closed() || req.Term < n.term {
+		return
+	}
+	n.heardFrom(req.Term, req.Leader)
+	reply.Term = n.term
+
+	prevIndex, prevTerm, entries := req.PrevIndex, req.PrevTerm, req.Entries
+	if prevIndex < n.log.snapIndex {
+		// the start is folded into the snapshot, which holds only committed entries
+		skip := min(n.log.snapIndex-prevIndex, uint64(len(entries)))
+		prevIndex, prevTerm, entries = n.log.snapIndex, n.log.snapTerm, entries[skip:]
+	}
+	if term, ok := n.log.term(prevIndex); !ok {
+		reply.ConflictIndex = n.log.lastIndex() + 1
+		return
+	} else if term != prevTerm {
+		reply.ConflictTerm = term
+		reply.ConflictIndex = n.log.firstIndexOf(prevIndex)
+		return
+	}
+
+	for i, entry := range entries {
+		if term, ok := n.log.term(entry.Index); ok {
+			if term == entry.Term {
+				continue
+			}
+			if entry.Index <= n.commitIndex {
+				panic(fmt.Sprintf("raft: leader %s conflicts with committed entry %d", req.Leader, entry.Index))
+			}
+			n.log.truncate(entry.Index)
+			n.persistRewrite()
+			n.reloadMembers()
+		}
+		n.log.append(entries[i:]...)
+		n.persistAppend(entries[i:])
+		n.reloadMembers()
+		break
+	}
+
+	if last := req.PrevIndex + uint64(len(req.Entries)); req.Commit > n.commitIndex && last > n.commitIndex {
+		n.commit(min(req.Commit, last))
+	}
+	reply.Success = true
+}
+
+func (n *Node) handleSnapshot(req *SnapshotRequest, reply *SnapshotReply) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	reply.Term = n.term
+	if n.closed() || req.Term < n.term {
+		return
+	}
+	n.heardFrom(req.Term, req.Leader)
+	reply.Term = n.term
+
+	snapshot := req.Snapshot
+	if snapshot.Index <= n.commitIndex {
+		return
+	}
+	slog.Info("raft installing snapshot", "node", n.id, "leader", req.Leader, "index", snapshot.Index)
+	n.log.compact(snapshot.Index, snapshot.Term)
+	n.snapshot = &snapshot
+	if err := n.cfg.Storage.SaveSnapshot(&snapshot, n.log.entries); err != nil {
+		panic(fmt.Sprintf("raft: saving snapshot: %v", err))
+	}
+	n.reloadMembers()
+	n.pendingSnapshot = &snapshot
+	n.commit(snapshot.Index)
+}
+
+// heardFrom follows the leader of term, which contacted this node
+func (n *Node) heardFrom(term uint64, leader string) {
+	if term > n.term || n.role != Follower || n.leader != leader {
+		n.becomeFollower(term, leader)
+	}
+	n.lastContact = time.Now()
+	n.resetElectionTimer()
+}
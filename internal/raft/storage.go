@@ -0,0 +1,430 @@
+package raft
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// HardState is what a node must remember across restarts besides its log
+type HardState struct {
+	Term uint64
+	Vote string
+}
+
+// Storage keeps a node's state, log and latest snapshot. Every call must be
+// durable when it returns, the node answers RPCs only after saving.
+type Storage interface {
+	// Load returns what was saved: the state, the latest snapshot (nil when
+	// there is none) and the log entries following it
+	Load() (HardState, *Snapshot, []Entry, error)
+
+	// SaveState stores the current term and vote
+	SaveState(state HardState) error
+
+	// Append adds entries to the end of the log
+	Append(entries []Entry) error
+
+	// Rewrite replaces every log entry after the snapshot, used when a
+	// conflicting suffix was dropped
+	Rewrite(entries []Entry) error
+
+	// SaveSnapshot stores snapshot and replaces the log with entries, the
+	// ones that follow it
+	SaveSnapshot(snapshot *Snapshot, entries []Entry) error
+
+	Close() error
+}
+
+// MemoryStorage keeps everything in memory, for tests and nodes that rebuild
+// from their peers after a restart
+type MemoryStorage struct {
+	mu       sync.Mutex
+	state    HardState
+	snapshot *Snapshot
+	entries  []Entry
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{}
+}
+
+func (s *MemoryStorage) Load() (HardState, *Snapshot, []Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state, s.snapshot, append([]Entry(nil), s.entries...), nil
+}
+
+func (s *MemoryStorage) SaveState(state HardState) error {
+	s.mu.Lock()
+	s.state = state
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStorage) Append(entries []Entry) error {
+	s.mu.Lock()
+	s.entries = append(s.entries, entries...)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStorage) Rewrite(entries []Entry) error {
+	s.mu.Lock()
+	s.entries = append([]Entry(nil), entries...)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStorage) SaveSnapshot(snapshot *Snapshot, entries []Entry) error {
+	s.mu.Lock()
+	s.snapshot = snapshot
+	s.entries = append([]Entry(nil), entries...)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStorage) Close() error {
+	return nil
+}
+
+const (
+	stateFile    = "state"
+	snapshotFile = "snapshot"
+	logFile      = "log"
+)
+
+var errCorrupt = errors.New("raft: corrupt record")
+
+// FileStorage keeps the state, log and snapshot in three files of a
+// directory. Log entries are appended as checksummed records, a torn record
+// at the end (a crash mid-write) is dropped on load.
+type FileStorage struct {
+	dir string
+	log *os.File
+}
+
+// OpenFileStorage opens or creates the storage in dir
+func OpenFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	log, err := os.OpenFile(filepath.Join(dir, logFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStorage{dir: dir, log: log}, nil
+}
+
+func (s *FileStorage) Load() (HardState, *Snapshot, []Entry, error) {
+	var state HardState
+	if data, err := readRecordFile(filepath.Join(s.dir, stateFile)); err != nil {
+		return state, nil, nil, err
+	} else if data != nil {
+		d := decoder{buf: data}
+		state.Term, state.Vote = d.uint64(), d.string()
+		if d.err != nil {
+			return state, nil, nil, fmt.Errorf("raft state: %w", d.err)
+		}
+	}
+
+	var snapshot *Snapshot
+	if data, err := readRecordFile(filepath.Join(s.dir, snapshotFile)); err != nil {
+		return state, nil, nil, err
+	} else if data != nil {
+		if snapshot, err = decodeSnapshot(data); err != nil {
+			return state, nil, nil, fmt.Errorf("raft snapshot: %w", err)
+		}
+	}
+
+	entries, err := s.loadLog()
+	if err != nil {
+		return state, nil, nil, fmt.Errorf("raft log: %w", err)
+	}
+	return state, snapshot, entries, nil
+}
+
+// loadLog reads every intact record and cuts the file after the last one
+func (s *FileStorage) loadLog() ([]Entry, error) {
+	data, err := io.ReadAll(io.NewSectionReader(s.log, 0, 1<<62))
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	offset := 0
+	for {
+		payload, n := nextRecord(data[offset:])
+		if n == 0 {
+			break
+		}
+		entry, err := decodeEntry(payload)
+		if err != nil {
+			break
+		}
+		entries = append(entries, entry)
+		offset += n
+	}
+	if offset < len(data) {
+		if err := s.log.Truncate(int64(offset)); err != nil {
+			return nil, err
+		}
+	}
+	_, err = s.log.Seek(int64(offset), io.SeekStart)
+	return entries, err
+}
+
+func (s *FileStorage) SaveState(state HardState) error {
+	var e encoder
+	e.uint64(state.Term)
+	e.string(state.Vote)
+	return writeRecordFile(filepath.Join(s.dir, stateFile), e.buf)
+}
+
+func (s *FileStorage) Append(entries []Entry) error {
+	var buf []byte
+	for i := range entries {
+		buf = appendRecord(buf, encodeEntry(&entries[i]))
+	}
+	if _, err := s.log.Write(buf); err != nil {
+		return err
+	}
+	return s.log.Sync()
+}
+
+func (s *FileStorage) Rewrite(entries []Entry) error {
+	var buf []byte
+	for i := range entries {
+		buf = appendRecord(buf, encodeEntry(&entries[i]))
+	}
+	path := filepath.Join(s.dir, logFile)
+	if err := writeFileSync(path+".tmp", buf); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	log, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := log.Seek(0, io.SeekEnd); err != nil {
+		log.Close()
+		return err
+	}
+	s.log.Close()
+	s.log = log
+	return syncDir(s.dir)
+}
+
+func (s *FileStorage) SaveSnapshot(snapshot *Snapshot, entries []Entry) error {
+	if err := writeRecordFile(filepath.Join(s.dir, snapshotFile), encodeSnapshot(snapshot)); err != nil {
+		return err
+	}
+	return s.Rewrite(entries)
+}
+
+func (s *FileStorage) Close() error {
+	return s.log.Close()
+}
+
+// Records are framed as [length][crc32 of payload][payload], both big endian uint32
+
+func appendRecord(buf, payload []byte) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(payload))
+	return append(buf, payload...)
+}
+
+// nextRecord returns the payload of the record at the start of data and the
+// record's length, 0 when data does not start with an intact record
+func nextRecord(data []byte) ([]byte, int) {
+	if len(data) < 8 {
+		return nil, 0
+	}
+	size := int(binary.BigEndian.Uint32(data))
+	if size > len(data)-8 {
+		return nil, 0
+	}
+	payload := data[8 : 8+size]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[4:]) {
+		return nil, 0
+	}
+	return payload, 8 + size
+}
+
+// readRecordFile returns the single record of a file, nil when it does not exist
+func readRecordFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	payload, n := nextRecord(data)
+	if n == 0 {
+		return nil, fmt.Errorf("%s: %w", path, errCorrupt)
+	}
+	return payload, nil
+}
+
+// writeRecordFile replaces a file atomically with a single record
+func writeRecordFile(path string, payload []byte) error {
+	if err := writeFileSync(path+".tmp", appendRecord(nil, payload)); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func encodeEntry(entry *Entry) []byte {
+	var e encoder
+	e.uint64(entry.Index)
+	e.uint64(entry.Term)
+	e.buf = append(e.buf, byte(entry.Type))
+	e.uint64(uint64(entry.Timestamp))
+	e.string(entry.Origin)
+	e.uint64(entry.Proposal)
+	e.bytes(entry.Data)
+	return e.buf
+}
+
+func decodeEntry(data []byte) (Entry, error) {
+	d := decoder{buf: data}
+	var entry Entry
+	entry.Index = d.uint64()
+	entry.Term = d.uint64()
+	entry.Type = EntryType(d.byte())
+	entry.Timestamp = int64(d.uint64())
+	entry.Origin = d.string()
+	entry.Proposal = d.uint64()
+	entry.Data = d.bytes()
+	return entry, d.err
+}
+
+func encodeSnapshot(snapshot *Snapshot) []byte {
+	var e encoder
+	e.uint64(snapshot.Index)
+	e.uint64(snapshot.Term)
+	ids := make([]string, 0, len(snapshot.Members))
+	for id := range snapshot.Members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	e.uint64(uint64(len(ids)))
+	for _, id := range ids {
+		e.string(id)
+		e.string(snapshot.Members[id])
+	}
+	e.bytes(snapshot.Data)
+	return e.buf
+}
+
+func decodeSnapshot(data []byte) (*Snapshot, error) {
+	d := decoder{buf: data}
+	snapshot := &Snapshot{Index: d.uint64(), Term: d.uint64(), Members: make(map[string]string)}
+	for n := d.uint64(); n > 0 && d.err == nil; n-- {
+		id := d.string()
+		snapshot.Members[id] = d.string()
+	}
+	snapshot.Data = d.bytes()
+	return snapshot, d.err
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) uint64(v uint64) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, v)
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.uint64(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) string(s string) {
+	e.uint64(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// decoder reads what encoder wrote, remembering the first error
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) take(n uint64) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > uint64(len(d.buf)) {
+		d.err = errCorrupt
+		return nil
+	}
+	out := d.buf[:n]
+	d.buf = d.buf[n:]
+	return out
+}
+
+func (d *decoder) uint64() uint64 {
+	b := d.take(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (d *decoder) byte() byte {
+	b := d.take(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (d *decoder) bytes() []byte {
+	b := d.take(d.uint64())
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+func (d *decoder) string() string {
+	return string(d.take(d.uint64()))
+}
@@ -0,0 +1,351 @@
+package raft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+// ErrUnreachable is returned by a transport when a request could not be sent
+// at all, so it is safe to send again
+var ErrUnreachable = errors.New("raft: node unreachable")
+
+// VoteRequest asks for a vote in an election
+type VoteRequest struct {
+	Term      uint64
+	Candidate string
+	LastIndex uint64
+	LastTerm  uint64
+}
+
+type VoteReply struct {
+	Term    uint64
+	Granted bool
+}
+
+// AppendRequest replicates entries following PrevIndex, an empty one is a heartbeat
+type AppendRequest struct {
+	Term      uint64
+	Leader    string
+	PrevIndex uint64
+	PrevTerm  uint64
+	Entries   []Entry
+	Commit    uint64
+
+	// Seq numbers the leader's heartbeat rounds, confirming a ReadIndex
+	Seq uint64
+}
+
+// AppendReply tells the leader where the logs diverge when Success is false:
+// at ConflictIndex, the first index of ConflictTerm (0 when the follower's
+// log is simply shorter)
+type AppendReply struct {
+	Term          uint64
+	Success       bool
+	ConflictIndex uint64
+	ConflictTerm  uint64
+}
+
+// SnapshotRequest replaces a lagging follower's log with the leader's snapshot
+type SnapshotRequest struct {
+	Term     uint64
+	Leader   string
+	Snapshot Snapshot
+	Seq      uint64
+}
+
+type SnapshotReply struct {
+	Term uint64
+}
+
+// ProposeRequest hands an entry to the leader on behalf of another node
+type ProposeRequest struct {
+	Type     EntryType
+	Data     []byte
+	Origin   string
+	Proposal uint64
+}
+
+type ProposeReply struct {
+	Index uint64
+	Term  uint64
+	Err   string
+}
+
+// ReadIndexRequest asks the leader for an index that is safe to read at
+type ReadIndexRequest struct {
+	From string
+}
+
+type ReadIndexReply struct {
+	Index uint64
+	Err   string
+}
+
+// RPC method names
+const (
+	methodVote      = "RequestVote"
+	methodAppend    = "AppendEntries"
+	methodSnapshot  = "InstallSnapshot"
+	methodPropose   = "Propose"
+	methodReadIndex = "ReadIndex"
+)
+
+// Transport carries RPCs between nodes
+type Transport interface {
+	// Serve delivers the RPCs sent to this node's address to node until Close
+	Serve(node *Node) error
+
+	// Call sends one RPC to the node at addr and waits for its reply. It
+	// returns ErrUnreachable when nothing was sent.
+	Call(ctx context.Context, addr, method string, args, reply any) error
+
+	Close() error
+}
+
+// dispatch runs an incoming RPC on node
+func dispatch(node *Node, method string, args, reply any) error {
+	switch method {
+	case methodVote:
+		node.handleVote(args.(*VoteRequest), reply.(*VoteReply))
+	case methodAppend:
+		node.handleAppend(args.(*AppendRequest), reply.(*AppendReply))
+	case methodSnapshot:
+		node.handleSnapshot(args.(*SnapshotRequest), reply.(*SnapshotReply))
+	case methodPropose:
+		node.handlePropose(args.(*ProposeRequest), reply.(*ProposeReply))
+	case methodReadIndex:
+		node.handleReadIndex(reply.(*ReadIndexReply))
+	default:
+		return fmt.Errorf("raft: unknown method %q", method)
+	}
+	return nil
+}
+
+// rpcService exposes a node to net/rpc
+type rpcService struct {
+	node *Node
+}
+
+func (s *rpcService) RequestVote(args *VoteRequest, reply *VoteReply) error {
+	return dispatch(s.node, methodVote, args, reply)
+}
+
+func (s *rpcService) AppendEntries(args *AppendRequest, reply *AppendReply) error {
+	return dispatch(s.node, methodAppend, args, reply)
+}
+
+func (s *rpcService) InstallSnapshot(args *SnapshotRequest, reply *SnapshotReply) error {
+	return dispatch(s.node, methodSnapshot, args, reply)
+}
+
+func (s *rpcService) Propose(args *ProposeRequest, reply *ProposeReply) error {
+	return dispatch(s.node, methodPropose, args, reply)
+}
+
+func (s *rpcService) ReadIndex(args *ReadIndexRequest, reply *ReadIndexReply) error {
+	return dispatch(s.node, methodReadIndex, args, reply)
+}
+
+// TCPTransport sends RPCs with net/rpc over TCP, keeping one connection per peer
+type TCPTransport struct {
+	addr string
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	clients  map[string]*rpc.Client
+	closed   bool
+}
+
+// dialTimeout bounds connecting to a peer
+const dialTimeout = time.Second
+
+func NewTCPTransport(addr string) *TCPTransport {
+	return &TCPTransport{addr: addr, conns: make(map[net.Conn]struct{}), clients: make(map[string]*rpc.Client)}
+}
+
+func (t *TCPTransport) Serve(node *Node) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Raft", &rpcService{node: node}); err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", t.addr)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.listener = listener
+	t.mu.Unlock()
+	go t.accept(server, listener)
+	return nil
+}
+
+// accept serves every incoming connection, tracked so Close can end them
+func (t *TCPTransport) accept(server *rpc.Server, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			conn.Close()
+			return
+		}
+		t.conns[conn] = struct{}{}
+		t.mu.Unlock()
+		go func() {
+			server.ServeConn(conn)
+			t.mu.Lock()
+			delete(t.conns, conn)
+			t.mu.Unlock()
+		}()
+	}
+}
+
+func (t *TCPTransport) Call(ctx context.Context, addr, method string, args, reply any) error {
+	client, err := t.client(addr)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnreachable, err)
+	}
+	call := client.Go("Raft."+method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		if errors.Is(call.Error, rpc.ErrShutdown) {
+			t.drop(addr, client)
+		}
+		return call.Error
+	case <-ctx.Done():
+		// the connection may be stuck, start over with a fresh one
+		t.drop(addr, client)
+		return ctx.Err()
+	}
+}
+
+func (t *TCPTransport) client(addr string) (*rpc.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, net.ErrClosed
+	}
+	if client := t.clients[addr]; client != nil {
+		return client, nil
+	}
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	client := rpc.NewClient(conn)
+	t.clients[addr] = client
+	return client, nil
+}
+
+func (t *TCPTransport) drop(addr string, client *rpc.Client) {
+	t.mu.Lock()
+	if t.clients[addr] == client {
+		delete(t.clients, addr)
+	}
+	t.mu.Unlock()
+	client.Close()
+}
+
+func (t *TCPTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	for addr, client := range t.clients {
+		client.Close()
+		delete(t.clients, addr)
+	}
+	for conn := range t.conns {
+		conn.Close()
+	}
+	if t.listener != nil {
+		return t.listener.Close()
+	}
+	return nil
+}
+
+// MemoryNetwork connects nodes of one process without sockets. Nodes can be
+// cut off and reconnected to simulate partitions.
+type MemoryNetwork struct {
+	mu       sync.Mutex
+	nodes    map[string]*Node
+	isolated map[string]bool
+}
+
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{nodes: make(map[string]*Node), isolated: make(map[string]bool)}
+}
+
+// Transport returns the transport of the node at addr
+func (m *MemoryNetwork) Transport(addr string) Transport {
+	return &memoryTransport{network: m, addr: addr}
+}
+
+// Isolate drops every RPC to and from addr until Heal
+func (m *MemoryNetwork) Isolate(addr string) {
+	m.mu.Lock()
+	m.isolated[addr] = true
+	m.mu.Unlock()
+}
+
+func (m *MemoryNetwork) Heal(addr string) {
+	m.mu.Lock()
+	delete(m.isolated, addr)
+	m.mu.Unlock()
+}
+
+type memoryTransport struct {
+	network *MemoryNetwork
+	addr    string
+}
+
+func (t *memoryTransport) Serve(node *Node) error {
+	t.network.mu.Lock()
+	defer t.network.mu.Unlock()
+	t.network.nodes[t.addr] = node
+	return nil
+}
+
+func (t *memoryTransport) Call(ctx context.Context, addr, method string, args, reply any) error {
+	m := t.network
+	m.mu.Lock()
+	node := m.nodes[addr]
+	cut := m.isolated[addr] || m.isolated[t.addr]
+	m.mu.Unlock()
+	if node == nil || cut {
+		return ErrUnreachable
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- dispatch(node, method, args, reply) }()
+	select {
+	case err := <-done:
+		m.mu.Lock()
+		cut = m.isolated[addr] || m.isolated[t.addr]
+		m.mu.Unlock()
+		if cut {
+			// the reply is lost on the way back
+			return context.DeadlineExceeded
+		}
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *memoryTransport) Close() error {
+	t.network.mu.Lock()
+	defer t.network.mu.Unlock()
+	if t.network.nodes[t.addr] != nil {
+		delete(t.network.nodes, t.addr)
+	}
+	return nil
+}
@@ -0,0 +1,764 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/cluster"
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+const (
+	// clusterGossipInterval is how often a node gossips with every node it knows
+	clusterGossipInterval = time.Second
+
+	// clusterNodeTimeout is how long a node may leave pings unanswered before it is failing
+	clusterNodeTimeout = 15 * time.Second
+
+	// clusterForgetTTL keeps a forgotten node from being gossiped back in
+	clusterForgetTTL = time.Minute
+
+	// clusterDialTimeout bounds connecting to and exchanging gossip with a peer
+	clusterDialTimeout = 2 * time.Second
+)
+
+// sharding runs the server as a node of a Redis Cluster compatible cluster.
+// Nodes gossip over the client port with CLUSTER GOSSIP, every exchange
+// carrying both sides' view, and the view is saved in the nodes.conf format so
+// a restarted node keeps its id, epoch and slots.
+//
+// Every node numbers versions with its own counter, so versions only order
+// the history of the keys one node serves: a key migrated here keeps the
+// versions it had on the node it came from.
+type sharding struct {
+	server *Server
+
+	// file keeps the node's view of the cluster across restarts
+	file string
+
+	// mu protects state and links
+	mu    sync.Mutex
+	state *cluster.State
+
+	// learnHost is set when the server listens on an unspecified host, the
+	// node then takes the host peers reach it on from their gossip
+	learnHost bool
+
+	// links holds the gossip connection to every peer by id
+	links map[string]*gossipLink
+
+	// routes is read without locking by every keyed command
+	routes [cluster.SlotCount]atomic.Pointer[command.SlotRoute]
+	locks  [cluster.SlotCount]sync.RWMutex
+
+	sent, received atomic.Int64
+
+	// trigger asks the gossip loop for a round now, after a change
+	trigger chan struct{}
+	stop    chan struct{}
+
+	// done is closed when the gossip loop exits, nil until it starts
+	done chan struct{}
+}
+
+var _ command.Cluster = (*sharding)(nil)
+
+// gossipLink is a connection gossip is exchanged over
+type gossipLink struct {
+	conn   net.Conn
+	parser *protocol.RESPParser
+}
+
+// newSharding loads the node's view from file, or starts as a new node knowing
+// only itself when there is none
+func newSharding(s *Server, file string) (*sharding, error) {
+	sh := &sharding{
+		server:  s,
+		file:    file,
+		links:   make(map[string]*gossipLink),
+		trigger: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+	data, err := os.ReadFile(file)
+	switch {
+	case err == nil:
+		if sh.state, err = cluster.Unmarshal(data); err != nil {
+			return nil, fmt.Errorf("loading cluster config %s: %w", file, err)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		sh.state = cluster.NewState(&cluster.Node{ID: cluster.NewNodeID()})
+	default:
+		return nil, fmt.Errorf("loading cluster config: %w", err)
+	}
+	sh.refresh(nil)
+	return sh, nil
+}
+
+// start announces the node on the address it listens on and starts gossiping
+func (sh *sharding) start(addr net.Addr) error {
+	sh.mu.Lock()
+	host := sh.server.cfg.Host
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		sh.learnHost = true
+		if host, _ = cluster.SplitAddr(sh.state.Self().Addr); host == "" {
+			host = "127.0.0.1"
+		}
+	}
+	_, port := cluster.SplitAddr(addr.String())
+	sh.state.Self().Addr = net.JoinHostPort(host, strconv.Itoa(port))
+	sh.refresh(nil)
+	if err := sh.save(); err != nil {
+		sh.mu.Unlock()
+		return err
+	}
+	sh.done = make(chan struct{})
+	sh.mu.Unlock()
+	go sh.run()
+	return nil
+}
+
+func (sh *sharding) shutdown() {
+	close(sh.stop)
+	sh.mu.Lock()
+	done := sh.done
+	sh.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	for id, link := range sh.links {
+		link.conn.Close()
+		delete(sh.links, id)
+	}
+}
+
+// run gossips with every known node once a second, or sooner when triggered
+func (sh *sharding) run() {
+	defer close(sh.done)
+	ticker := time.NewTicker(clusterGossipInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sh.stop:
+			return
+		case <-ticker.C:
+		case <-sh.trigger:
+		}
+		sh.gossipRound()
+	}
+}
+
+// changed refreshes the routes of slots, saves the view and spreads it.
+// Callers hold mu.
+func (sh *sharding) changed(slots []int) error {
+	sh.refresh(slots)
+	select {
+	case sh.trigger <- struct{}{}:
+	default:
+	}
+	return sh.save()
+}
+
+// refresh recomputes the routes of slots (nil = all). Callers hold mu.
+func (sh *sharding) refresh(slots []int) {
+	update := func(slot int) {
+		route := &command.SlotRoute{}
+		if owner := sh.state.Owner(slot); owner != nil {
+			route.Owner, route.Mine = owner.Addr, owner.ID == sh.state.Myself
+		}
+		if node := sh.state.Nodes[sh.state.Migrating[slot]]; node != nil {
+			route.Migrating = node.Addr
+		}
+		_, route.Importing = sh.state.Importing[slot]
+		sh.routes[slot].Store(route)
+	}
+	if slots == nil {
+		for slot := range cluster.SlotCount {
+			update(slot)
+		}
+		return
+	}
+	for _, slot := range slots {
+		update(slot)
+	}
+}
+
+// save writes the view to the config file, replacing it atomically. Callers hold mu.
+func (sh *sharding) save() error {
+	tmp := sh.file + ".tmp"
+	if dir := filepath.Dir(sh.file); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("saving cluster config: %w", err)
+		}
+	}
+	if err := os.WriteFile(tmp, sh.state.Marshal(), 0o644); err != nil {
+		return fmt.Errorf("saving cluster config: %w", err)
+	}
+	if err := os.Rename(tmp, sh.file); err != nil {
+		return fmt.Errorf("saving cluster config: %w", err)
+	}
+	return nil
+}
+
+// gossipRound exchanges gossip with every known node at once, so a node that
+// does not answer does not hold up the others
+func (sh *sharding) gossipRound() {
+	sh.mu.Lock()
+	peers := make(map[string]string)
+	for id, node := range sh.state.Nodes {
+		if id != sh.state.Myself && node.Addr != "" {
+			peers[id] = node.Addr
+		}
+	}
+	sh.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for id, addr := range peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sh.ping(id, addr)
+		}()
+	}
+	wg.Wait()
+}
+
+// ping exchanges gossip with the node id and records whether it answered
+func (sh *sharding) ping(id, addr string) {
+	now := time.Now()
+	sh.mu.Lock()
+	node := sh.state.Nodes[id]
+	if node == nil {
+		sh.mu.Unlock()
+		return
+	}
+	if node.PingSent == 0 {
+		node.PingSent = now.UnixMilli()
+	}
+	msg := sh.state.Gossip(addr)
+	link := sh.links[id]
+	delete(sh.links, id)
+	sh.mu.Unlock()
+
+	reply, link, err := sh.exchange(link, addr, msg)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if node = sh.state.Nodes[id]; node == nil {
+		if link != nil {
+			link.conn.Close()
+		}
+		return
+	}
+	if err != nil {
+		node.Connected = false
+		node.Failing = time.Since(time.UnixMilli(node.PingSent)) > clusterNodeTimeout
+		return
+	}
+	if reply.ID != id {
+		// another node took over the address, learn it from its gossip and
+		// let the old one time out
+		link.conn.Close()
+		sh.merge(reply)
+		return
+	}
+	sh.links[id] = link
+	node.Connected, node.Failing = true, false
+	node.PingSent, node.PongReceived = 0, time.Now().UnixMilli()
+	sh.merge(reply)
+}
+
+// exchange sends msg over link, dialing addr when there is no link, and
+// returns the peer's answer with the link to reuse
+func (sh *sharding) exchange(link *gossipLink, addr string, msg *cluster.Gossip) (*cluster.Gossip, *gossipLink, error) {
+	if link == nil {
+		conn, err := net.DialTimeout("tcp", addr, clusterDialTimeout)
+		if err != nil {
+			return nil, nil, err
+		}
+		link = &gossipLink{conn: conn, parser: protocol.NewRESPParser(bufio.NewReader(conn))}
+	}
+	args := append([]string{"CLUSTER", "GOSSIP"}, msg.Args()...)
+	values := make([]protocol.RESPValue, len(args))
+	for i, arg := range args {
+		values[i] = replBulk(arg)
+	}
+
+	link.conn.SetDeadline(time.Now().Add(clusterDialTimeout))
+	if _, err := link.conn.Write(protocol.NewArray(values).Serialize()); err != nil {
+		link.conn.Close()
+		return nil, nil, err
+	}
+	sh.sent.Add(1)
+	value, err := link.parser.ParseValue()
+	if err != nil {
+		link.conn.Close()
+		return nil, nil, err
+	}
+	if reply, ok := value.(*protocol.Error); ok {
+		link.conn.Close()
+		return nil, nil, errors.New(reply.Msg())
+	}
+	decoder := newReplDecoder()
+	fields, _, err := decoder.array(value)
+	if err != nil {
+		link.conn.Close()
+		return nil, nil, err
+	}
+	reply := make([]string, len(fields))
+	for i, field := range fields {
+		if reply[i], err = decoder.str(field); err != nil {
+			link.conn.Close()
+			return nil, nil, err
+		}
+	}
+	g, err := cluster.ParseGossip(reply)
+	if err != nil {
+		link.conn.Close()
+		return nil, nil, err
+	}
+	sh.received.Add(1)
+	return g, link, nil
+}
+
+// merge takes in a peer's gossip. Callers hold mu.
+func (sh *sharding) merge(g *cluster.Gossip) {
+	changed, updated := sh.state.Merge(g, time.Now())
+	if !updated {
+		return
+	}
+	// a node whose address changed has its routes pointing at the old one
+	slots := changed
+	if len(changed) == 0 {
+		slots = nil
+	}
+	sh.refresh(slots)
+	if err := sh.save(); err != nil {
+		slog.Error("cluster", "error", err)
+	}
+}
+
+// learn takes the host peers reach this node on from the address a gossip was
+// sent to. Callers hold mu.
+func (sh *sharding) learn(to string) {
+	host, _ := cluster.SplitAddr(to)
+	self := sh.state.Self()
+	current, port := cluster.SplitAddr(self.Addr)
+	if !sh.learnHost || host == "" || host == current || port == 0 {
+		return
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() && current != "" {
+		return
+	}
+	self.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+	sh.refresh(nil)
+	if err := sh.save(); err != nil {
+		slog.Error("cluster", "error", err)
+	}
+}
+
+func (sh *sharding) Slot(slot int) command.SlotRoute {
+	return *sh.routes[slot].Load()
+}
+
+func (sh *sharding) SlotLock(slot int) *sync.RWMutex {
+	return &sh.locks[slot]
+}
+
+func (sh *sharding) MyID() string {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return sh.state.Myself
+}
+
+func (sh *sharding) Nodes() string {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return sh.state.Format()
+}
+
+// Shards lists the nodes in the order of the slots they serve, nodes without
+// slots last
+func (sh *sharding) Shards() []command.ClusterNode {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	nodes := make([]command.ClusterNode, 0, len(sh.state.Nodes))
+	for id, node := range sh.state.Nodes {
+		nodes = append(nodes, command.ClusterNode{
+			ID:      id,
+			Addr:    node.Addr,
+			Slots:   sh.state.Slots(id),
+			Myself:  id == sh.state.Myself,
+			Failing: node.Failing,
+		})
+	}
+	slices.SortFunc(nodes, func(a, b command.ClusterNode) int {
+		switch {
+		case len(a.Slots) == 0 || len(b.Slots) == 0:
+			if len(a.Slots) != len(b.Slots) {
+				return len(b.Slots) - len(a.Slots)
+			}
+		case a.Slots[0].Start != b.Slots[0].Start:
+			return a.Slots[0].Start - b.Slots[0].Start
+		}
+		if a.ID < b.ID {
+			return -1
+		}
+		return 1
+	})
+	return nodes
+}
+
+func (sh *sharding) Info() []string {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	assigned, pfail, size := 0, 0, 0
+	for id, node := range sh.state.Nodes {
+		slots := 0
+		for _, r := range sh.state.Slots(id) {
+			slots += r.End - r.Start + 1
+		}
+		assigned += slots
+		if slots > 0 {
+			size++
+			if node.Failing {
+				pfail += slots
+			}
+		}
+	}
+	state := "ok"
+	if assigned < cluster.SlotCount || pfail > 0 {
+		state = "fail"
+	}
+	return []string{
+		"cluster_state:" + state,
+		"cluster_slots_assigned:" + strconv.Itoa(assigned),
+		"cluster_slots_ok:" + strconv.Itoa(assigned-pfail),
+		"cluster_slots_pfail:" + strconv.Itoa(pfail),
+		"cluster_slots_fail:0",
+		"cluster_known_nodes:" + strconv.Itoa(len(sh.state.Nodes)),
+		"cluster_size:" + strconv.Itoa(size),
+		"cluster_current_epoch:" + strconv.FormatUint(sh.state.CurrentEpoch, 10),
+		"cluster_my_epoch:" + strconv.FormatUint(sh.state.Self().ConfigEpoch, 10),
+		"cluster_stats_messages_sent:" + strconv.FormatInt(sh.sent.Load(), 10),
+		"cluster_stats_messages_received:" + strconv.FormatInt(sh.received.Load(), 10),
+	}
+}
+
+// Meet exchanges gossip with the node at addr right away, so both know each
+// other when it returns
+func (sh *sharding) Meet(addr string) error {
+	sh.mu.Lock()
+	msg := sh.state.Gossip(addr)
+	sh.mu.Unlock()
+
+	reply, link, err := sh.exchange(nil, addr, msg)
+	if err != nil {
+		return fmt.Errorf("meeting %s: %w", addr, err)
+	}
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if old := sh.links[reply.ID]; old != nil || reply.ID == sh.state.Myself {
+		link.conn.Close()
+	} else {
+		sh.links[reply.ID] = link
+	}
+	sh.merge(reply)
+	if node := sh.state.Nodes[reply.ID]; node != nil {
+		node.Connected, node.PongReceived = true, time.Now().UnixMilli()
+	}
+	return sh.changed(nil)
+}
+
+func (sh *sharding) Forget(id string) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	switch {
+	case id == sh.state.Myself:
+		return errors.New("I tried hard but I can't forget myself...")
+	case sh.state.Nodes[id] == nil:
+		return fmt.Errorf("Unknown node %s", id)
+	}
+	sh.state.Forget(id, time.Now().Add(clusterForgetTTL))
+	if link := sh.links[id]; link != nil {
+		link.conn.Close()
+		delete(sh.links, id)
+	}
+	return sh.changed(nil)
+}
+
+func (sh *sharding) AddSlots(slots []int) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	for _, slot := range slots {
+		if sh.state.Owner(slot) != nil {
+			return fmt.Errorf("Slot %d is already busy", slot)
+		}
+	}
+	for _, slot := range slots {
+		sh.state.Assign(slot, sh.state.Myself)
+		delete(sh.state.Importing, slot)
+	}
+	return sh.changed(slots)
+}
+
+func (sh *sharding) DelSlots(slots []int) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	for _, slot := range slots {
+		if sh.state.Owner(slot) == nil {
+			return fmt.Errorf("Slot %d is already unassigned", slot)
+		}
+	}
+	for _, slot := range slots {
+		sh.state.Assign(slot, "")
+		delete(sh.state.Importing, slot)
+	}
+	return sh.changed(slots)
+}
+
+// SetSlot changes how slot is served. Handing a slot over ends with NODE on
+// both sides: the importing node takes the slot with a new config epoch,
+// which the rest of the cluster learns through gossip.
+func (sh *sharding) SetSlot(slot int, state, id string) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	st := sh.state
+	if state != "STABLE" && st.Nodes[id] == nil {
+		return fmt.Errorf("I don't know about node %s", id)
+	}
+	owner := st.Owner(slot)
+	mine := owner != nil && owner.ID == st.Myself
+
+	switch state {
+	case "MIGRATING":
+		if !mine {
+			return fmt.Errorf("I'm not the owner of hash slot %d", slot)
+		}
+		if id == st.Myself {
+			return errors.New("I can't migrate a slot to myself")
+		}
+		st.Migrating[slot] = id
+	case "IMPORTING":
+		if mine {
+			return fmt.Errorf("I'm already the owner of hash slot %d", slot)
+		}
+		if id == st.Myself {
+			return errors.New("I can't import a slot from myself")
+		}
+		st.Importing[slot] = id
+	case "STABLE":
+		delete(st.Migrating, slot)
+		delete(st.Importing, slot)
+	case "NODE":
+		if mine && id != st.Myself && sh.countKeys(slot) > 0 {
+			return fmt.Errorf("Can't assign hashslot %d to a different node while I still hold keys for this hash slot.", slot)
+		}
+		_, importing := st.Importing[slot]
+		delete(st.Importing, slot)
+		st.Assign(slot, id)
+		if id == st.Myself {
+			delete(st.Migrating, slot)
+			if importing {
+				st.BumpEpoch()
+			}
+		}
+	}
+	return sh.changed([]int{slot})
+}
+
+// countKeys counts the live keys of slot this node holds
+func (sh *sharding) countKeys(slot int) int {
+	engine, _ := sh.server.databases.Get(0)
+	n := 0
+	for _, key := range engine.Keys() {
+		if cluster.KeySlot([]byte(key)) == slot && engine.Exists(key) {
+			n++
+		}
+	}
+	return n
+}
+
+func (sh *sharding) SetConfigEpoch(epoch uint64) error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	self := sh.state.Self()
+	switch {
+	case epoch == 0:
+		return errors.New("Invalid config epoch specified: 0")
+	case len(sh.state.Nodes) > 1:
+		return errors.New("The user can assign a config epoch only when the node does not know any other node.")
+	case self.ConfigEpoch != 0:
+		return errors.New("Node config epoch is already non-zero")
+	}
+	self.ConfigEpoch = epoch
+	sh.state.CurrentEpoch = max(sh.state.CurrentEpoch, epoch)
+	return sh.save()
+}
+
+// Gossip takes in a peer's gossip and answers with this node's
+func (sh *sharding) Gossip(args []string) ([]string, error) {
+	g, err := cluster.ParseGossip(args)
+	if err != nil {
+		return nil, err
+	}
+	sh.received.Add(1)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.learn(g.To)
+	sh.merge(g)
+	sh.sent.Add(1)
+	return sh.state.Gossip(g.Addr).Args(), nil
+}
+
+// Migrate ships the live keys with their histories to the node at m.Addr,
+// each as ASKING followed by RESTORE-HISTORY, and removes the ones it took
+// unless m.Copy is set. The slots of the keys stay locked until then, so no
+// command changes a key between being shipped and removed.
+func (sh *sharding) Migrate(ctx *command.Context, m *command.Migration) (int, error) {
+	var slots []int
+	for _, key := range m.Keys {
+		slots = append(slots, cluster.KeySlot([]byte(key)))
+	}
+	slices.Sort(slots)
+	for _, slot := range slices.Compact(slots) {
+		sh.locks[slot].Lock()
+		defer sh.locks[slot].Unlock()
+	}
+
+	type shipment struct {
+		key     string
+		payload []byte
+	}
+	var batch []shipment
+	for _, key := range m.Keys {
+		if !ctx.Engine.Exists(key) {
+			continue
+		}
+		records, err := ctx.Engine.(storage.Replicator).Records(key, ctx.Engine.CurrentVersion())
+		if err != nil {
+			return 0, fmt.Errorf("ERR %w", err)
+		}
+		batch = append(batch, shipment{key, encodeRecords(records).Serialize()})
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	conn, err := net.DialTimeout("tcp", m.Addr, m.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("IOERR error or timeout connecting to the client: %w", err)
+	}
+	defer conn.Close()
+
+	var out bytes.Buffer
+	request := func(args ...[]byte) {
+		values := make([]protocol.RESPValue, len(args))
+		for i, arg := range args {
+			values[i] = protocol.NewBulkString(arg)
+		}
+		out.Write(protocol.NewArray(values).Serialize())
+	}
+	preamble := 0
+	if m.Password != "" {
+		if m.Username != "" {
+			request([]byte("AUTH"), []byte(m.Username), []byte(m.Password))
+		} else {
+			request([]byte("AUTH"), []byte(m.Password))
+		}
+		preamble++
+	}
+	if m.DB != 0 {
+		request([]byte("SELECT"), []byte(strconv.Itoa(m.DB)))
+		preamble++
+	}
+	for _, s := range batch {
+		request([]byte("ASKING"))
+		if m.Replace {
+			request([]byte("RESTORE-HISTORY"), []byte(s.key), s.payload, []byte("REPLACE"))
+		} else {
+			request([]byte("RESTORE-HISTORY"), []byte(s.key), s.payload)
+		}
+	}
+	conn.SetWriteDeadline(time.Now().Add(m.Timeout))
+	if _, err := conn.Write(out.Bytes()); err != nil {
+		return 0, fmt.Errorf("IOERR error or timeout writing to target instance: %w", err)
+	}
+
+	parser := protocol.NewRESPParser(bufio.NewReader(conn))
+	reply := func() (protocol.RESPValue, error) {
+		conn.SetReadDeadline(time.Now().Add(m.Timeout))
+		value, err := parser.ParseValue()
+		if err != nil {
+			return nil, fmt.Errorf("IOERR error or timeout reading to target instance: %w", err)
+		}
+		return value, nil
+	}
+	for range preamble {
+		value, err := reply()
+		if err != nil {
+			return 0, err
+		}
+		if e, ok := value.(*protocol.Error); ok {
+			return 0, errors.New("ERR Target instance replied with error: " + e.Msg())
+		}
+	}
+	moved := 0
+	var failed error
+	for _, s := range batch {
+		if _, err := reply(); err != nil {
+			return moved, err
+		}
+		value, err := reply()
+		if err != nil {
+			return moved, err
+		}
+		if e, ok := value.(*protocol.Error); ok {
+			if failed == nil {
+				failed = errors.New("ERR Target instance replied with error: " + e.Msg())
+			}
+			continue
+		}
+		if !m.Copy {
+			ctx.Engine.(storage.Mover).Detach(s.key)
+		}
+		moved++
+	}
+	return moved, failed
+}
+
+// Import decodes the history MIGRATE shipped and installs it under key
+func (sh *sharding) Import(ctx *command.Context, key string, payload []byte, replace bool) error {
+	value, err := protocol.NewRESPParser(bufio.NewReader(bytes.NewReader(payload))).ParseValue()
+	if err != nil {
+		return fmt.Errorf("ERR Bad history payload: %w", err)
+	}
+	records, err := newReplDecoder().records(value)
+	if err != nil {
+		return fmt.Errorf("ERR Bad history payload: %w", err)
+	}
+	if replace {
+		ctx.Engine.Del(key)
+	}
+	if _, err := ctx.Engine.(storage.Mover).Import(key, records); err != nil {
+		if errors.Is(err, storage.ErrKeyExists) {
+			return errors.New("BUSYKEY Target key name already exists.")
+		}
+		return fmt.Errorf("ERR %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package server_test
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/server"
+)
+
+// clusterNode is a server of a test sharded cluster
+type clusterNode struct {
+	addr   string
+	id     string
+	client *client
+}
+
+func startClusterNode(t *testing.T) *clusterNode {
+	t.Helper()
+	addr := startServer(t, server.WithCluster(filepath.Join(t.TempDir(), "nodes.conf")))
+	c := dial(t, addr)
+	id := strings.Split(c.do("CLUSTER", "MYID"), "\r\n")[1]
+	return &clusterNode{addr: addr, id: id, client: c}
+}
+
+// historyEntries splits a HISTORY reply into its serialized versions, newest first
+func historyEntries(t *testing.T, reply string) []string {
+	t.Helper()
+	value, err := protocol.NewRESPParser(bufio.NewReader(strings.NewReader(reply))).ParseValue()
+	if err != nil {
+		t.Fatalf("HISTORY reply %q: %v", reply, err)
+	}
+	arr, ok := value.(*protocol.Array)
+	if !ok {
+		t.Fatalf("HISTORY reply = %q, want an array", reply)
+	}
+	entries := make([]string, len(arr.Elements()))
+	for i, elem := range arr.Elements() {
+		entries[i] = string(elem.Serialize())
+	}
+	return entries
+}
+
+// TestCluster_MigrateSlot hands a slot from one node to another the way
+// cluster tools do: IMPORTING and MIGRATING marks, MIGRATE per key, then
+// NODE on both sides. Clients are redirected with ASK while the slot moves
+// and with MOVED once it has, and the keys arrive with their histories.
+func TestCluster_MigrateSlot(t *testing.T) {
+	source, target := startClusterNode(t), startClusterNode(t)
+	source.client.expect("+OK\r\n", "CLUSTER", "ADDSLOTSRANGE", "0", "16383")
+	host, port, _ := net.SplitHostPort(target.addr)
+	source.client.expect("+OK\r\n", "CLUSTER", "MEET", host, port)
+
+	slot := strings.TrimPrefix(strings.TrimSpace(source.client.do("CLUSTER", "KEYSLOT", "k")), ":")
+	moved := "-MOVED " + slot + " "
+	eventually(t, 10*time.Second, "the target to learn the slot owner", func() bool {
+		return target.client.do("GET", "k") == moved+source.addr+"\r\n"
+	})
+
+	source.client.expect("+OK\r\n", "SET", "k", "one", "COMMENT", "first")
+	source.client.expect("+OK\r\n", "SET", "k", "two", "COMMENT", "second")
+	source.client.expect("+OK\r\n", "SET", "{k}stay", "here")
+	history := historyEntries(t, source.client.do("HISTORY", "k", "WITHMETA"))
+
+	target.client.expect("+OK\r\n", "CLUSTER", "SETSLOT", slot, "IMPORTING", source.id)
+	source.client.expect("+OK\r\n", "CLUSTER", "SETSLOT", slot, "MIGRATING", target.id)
+	source.client.expect("+OK\r\n", "MIGRATE", host, port, "", "0", "5000", "KEYS", "k")
+
+	// while the slot moves the source serves the keys it still holds and
+	// sends the others to the target with ASK, which only takes them after ASKING
+	ask := "-ASK " + slot + " " + target.addr + "\r\n"
+	source.client.expect(ask, "GET", "k")
+	source.client.expect(bulk("here"), "GET", "{k}stay")
+	target.client.expect(moved+source.addr+"\r\n", "GET", "k")
+	target.client.expect("+OK\r\n", "ASKING")
+	target.client.expect(bulk("two"), "GET", "k")
+
+	// the target records the move as a new version on top of the shipped history
+	target.client.expect("+OK\r\n", "ASKING")
+	migrated := historyEntries(t, target.client.do("HISTORY", "k", "WITHMETA"))
+	if len(migrated) != len(history)+1 {
+		t.Fatalf("target HISTORY has %d versions, want the %d shipped and the move", len(migrated), len(history))
+	}
+	for i, want := range history {
+		if got := migrated[i+1]; got != want {
+			t.Errorf("target HISTORY version %d = %q, want %q", i, got, want)
+		}
+	}
+
+	source.client.expect("+OK\r\n", "MIGRATE", host, port, "", "0", "5000", "KEYS", "{k}stay")
+	target.client.expect("+OK\r\n", "CLUSTER", "SETSLOT", slot, "NODE", target.id)
+	source.client.expect("+OK\r\n", "CLUSTER", "SETSLOT", slot, "NODE", target.id)
+
+	source.client.expect(moved+target.addr+"\r\n", "GET", "k")
+	target.client.expect(bulk("two"), "GET", "k")
+	target.client.expect(bulk("here"), "GET", "{k}stay")
+	if count := source.client.do("CLUSTER", "COUNTKEYSINSLOT", slot); count != ":0\r\n" {
+		t.Errorf("source still counts %s keys in slot %s", count, slot)
+	}
+}
@@ -1,11 +1,14 @@
 package server
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"net"
 	"strconv"
 	"time"
+
+	"github.com/ElshadHu/verdis/internal/storage"
 )
 
 var (
@@ -17,6 +20,14 @@ var (
 	ErrNonPositiveMaxConns     = errors.New("max connections must be positive")
 	ErrNonPositiveReadBufSize  = errors.New("read buffer size must be positive")
 	ErrNonPositiveWriteBufSize = errors.New("write buffer size must be positive")
+	ErrNonPositiveDatabases    = errors.New("databases must be positive")
+	ErrInvalidDatabaseIndex    = errors.New("database index out of range")
+	ErrRaftWithoutAddress      = errors.New("raft needs a node id and an address")
+	ErrRaftPeersWithoutSelf    = errors.New("raft peers must include the node itself")
+	ErrRaftWithReplicaOf       = errors.New("a raft node cannot be a replica")
+	ErrClusterWithRaft         = errors.New("a cluster node cannot be a raft node")
+	ErrClusterWithReplicaOf    = errors.New("a cluster node cannot be a replica")
+	ErrReplicationUnsupported  = errors.New("the storage backend cannot replicate")
 )
 
 // ConfigOption applies a configuration setting to a Config.
@@ -44,6 +55,53 @@ type Config struct {
 
 	// WriteBufferSize is the per-connection write buffer in bytes.
 	WriteBufferSize int
+
+	// Backend is the name of the registered storage backend to open.
+	Backend string
+
+	// EngineConfig is passed to the storage backend, which defines its kind
+	// (nil = the backend's defaults).
+	EngineConfig storage.Config
+
+	// Databases is the number of logical databases clients can SELECT.
+	Databases int
+
+	// DatabaseConfigs overrides EngineConfig for individual databases, so each
+	// can have its own retention.
+	DatabaseConfigs map[int]storage.Config
+
+	// Users maps usernames to passwords accepted by AUTH (empty = AUTH disabled).
+	// A successful AUTH records the user as the author of the connection's writes.
+	Users map[string]string
+
+	// ReplicaOf is the "host:port" of a leader to replicate on start (empty = leader).
+	ReplicaOf string
+
+	// LeaderUser and LeaderPassword authenticate a replica with a leader that
+	// has users configured (empty password = no AUTH).
+	LeaderUser     string
+	LeaderPassword string
+
+	// RaftID names the server in a raft cluster (empty = no cluster). Writes
+	// then go through the cluster's log and reads confirm with its leader.
+	RaftID string
+
+	// RaftAddr is the "host:port" the raft node talks to its peers on.
+	RaftAddr string
+
+	// RaftDir keeps the raft log and snapshots across restarts (empty = in memory).
+	RaftDir string
+
+	// RaftPeers is the founding cluster as id -> raft address, this node
+	// included. A node started without peers waits to be added with RAFT ADDNODE.
+	RaftPeers map[string]string
+
+	// ClusterEnabled makes the server a node of a sharded cluster, serving
+	// the keys of the hash slots it owns and redirecting clients for the rest.
+	ClusterEnabled bool
+
+	// ClusterConfigFile keeps the node's id and view of the cluster across restarts.
+	ClusterConfigFile string
 }
 
 // NewDefaultConfig creates a Config with sensible defaults with variadic options.
@@ -53,6 +111,10 @@ func NewDefaultConfig(opts ...ConfigOption) (*Config, error) {
 		Port:            6379,
 		ReadBufferSize:  4096, // 4 KB
 		WriteBufferSize: 4096, // 4 KB
+		Backend:         storage.BackendMemory,
+		Databases:       16,
+
+		ClusterConfigFile: "nodes.conf",
 	}
 
 	for _, opt := range opts {
@@ -73,6 +135,20 @@ func (c *Config) Address() string {
 	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
 }
 
+// EngineConfigFor returns the storage configuration of one database.
+func (c *Config) EngineConfigFor(db int) storage.Config {
+	if cfg, ok := c.DatabaseConfigs[db]; ok {
+		return cfg
+	}
+	return c.EngineConfig
+}
+
+// authenticate reports whether the credentials match a configured user.
+func (c *Config) authenticate(username, password string) bool {
+	expected, ok := c.Users[username]
+	return ok && subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1
+}
+
 // Validate checks all config fields for invalid values.
 func (c *Config) Validate() error {
 	if c.Port < 0 || c.Port > 65535 {
@@ -96,6 +172,56 @@ func (c *Config) Validate() error {
 	if c.WriteBufferSize <= 0 {
 		return ErrNonPositiveWriteBufSize
 	}
+	if c.Databases <= 0 {
+		return ErrNonPositiveDatabases
+	}
+	for db := range c.DatabaseConfigs {
+		if db < 0 || db >= c.Databases {
+			return fmt.Errorf("%w: %d", ErrInvalidDatabaseIndex, db)
+		}
+	}
+	if c.ReplicaOf != "" {
+		if _, _, err := net.SplitHostPort(c.ReplicaOf); err != nil {
+			return fmt.Errorf("%w %q: %w", ErrInvalidAddress, c.ReplicaOf, err)
+		}
+	}
+	if err := c.validateRaft(); err != nil {
+		return err
+	}
+	if c.ClusterEnabled && c.RaftID != "" {
+		return ErrClusterWithRaft
+	}
+	if c.ClusterEnabled && c.ReplicaOf != "" {
+		return ErrClusterWithReplicaOf
+	}
+	if !storage.IsRegistered(c.Backend) {
+		return fmt.Errorf("%w %q (available: %v)", storage.ErrUnknownBackend, c.Backend, storage.Backends())
+	}
+	return nil
+}
+
+// validateRaft checks the raft settings.
+func (c *Config) validateRaft() error {
+	if c.RaftID == "" && c.RaftAddr == "" && len(c.RaftPeers) == 0 {
+		return nil
+	}
+	if c.RaftID == "" || c.RaftAddr == "" {
+		return ErrRaftWithoutAddress
+	}
+	if c.ReplicaOf != "" {
+		return ErrRaftWithReplicaOf
+	}
+	if _, _, err := net.SplitHostPort(c.RaftAddr); err != nil {
+		return fmt.Errorf("%w %q: %w", ErrInvalidAddress, c.RaftAddr, err)
+	}
+	for id, addr := range c.RaftPeers {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("%w %q for raft peer %s: %w", ErrInvalidAddress, addr, id, err)
+		}
+	}
+	if _, ok := c.RaftPeers[c.RaftID]; len(c.RaftPeers) > 0 && !ok {
+		return ErrRaftPeersWithoutSelf
+	}
 	return nil
 }
 
@@ -179,3 +305,98 @@ func WithWriteBufferSize(size int) ConfigOption {
 		return nil
 	}
 }
+
+// WithBackend selects the storage backend by its registered name.
+func WithBackend(name string) ConfigOption {
+	return func(c *Config) error {
+		c.Backend = name
+		return nil
+	}
+}
+
+// WithEngineConfig sets the configuration handed to the storage backend.
+func WithEngineConfig(cfg storage.Config) ConfigOption {
+	return func(c *Config) error {
+		c.EngineConfig = cfg
+		return nil
+	}
+}
+
+// WithDatabases sets the number of logical databases.
+func WithDatabases(n int) ConfigOption {
+	return func(c *Config) error {
+		c.Databases = n
+		return nil
+	}
+}
+
+// WithDatabaseEngineConfig sets the storage configuration of a single database.
+func WithDatabaseEngineConfig(db int, cfg storage.Config) ConfigOption {
+	return func(c *Config) error {
+		if c.DatabaseConfigs == nil {
+			c.DatabaseConfigs = make(map[int]storage.Config)
+		}
+		c.DatabaseConfigs[db] = cfg
+		return nil
+	}
+}
+
+// WithUser adds a username and password accepted by AUTH.
+func WithUser(username, password string) ConfigOption {
+	return func(c *Config) error {
+		if c.Users == nil {
+			c.Users = make(map[string]string)
+		}
+		c.Users[username] = password
+		return nil
+	}
+}
+
+// WithReplicaOf makes the server start as a read-only replica of the leader at address.
+func WithReplicaOf(address string) ConfigOption {
+	return func(c *Config) error {
+		c.ReplicaOf = address
+		return nil
+	}
+}
+
+// WithLeaderAuth sets the user a replica authenticates as with its leader.
+func WithLeaderAuth(username, password string) ConfigOption {
+	return func(c *Config) error {
+		c.LeaderUser, c.LeaderPassword = username, password
+		return nil
+	}
+}
+
+// WithRaft makes the server the raft node id talking to peers on address,
+// keeping its log in dir (empty = in memory).
+func WithRaft(id, address, dir string) ConfigOption {
+	return func(c *Config) error {
+		c.RaftID = id
+		c.RaftAddr = address
+		c.RaftDir = dir
+		return nil
+	}
+}
+
+// WithRaftPeer adds a member of the founding raft cluster.
+func WithRaftPeer(id, address string) ConfigOption {
+	return func(c *Config) error {
+		if c.RaftPeers == nil {
+			c.RaftPeers = make(map[string]string)
+		}
+		c.RaftPeers[id] = address
+		return nil
+	}
+}
+
+// WithCluster makes the server a cluster node keeping its view of the cluster in file.
+func WithCluster(file string) ConfigOption {
+	return func(c *Config) error {
+		c.ClusterEnabled = true
+		if file != "" {
+			c.ClusterConfigFile = file
+		}
+		return nil
+	}
+}
@@ -2,9 +2,12 @@ package server
 
 import (
 	"bufio"
+	"errors"
 	"io"
 	"log/slog"
 	"net"
+	"strings"
+	"time"
 
 	"github.com/ElshadHu/verdis/internal/command"
 	"github.com/ElshadHu/verdis/internal/protocol"
@@ -12,6 +15,8 @@ import (
 
 // Connection wraps a client connection with RESP protocol handling
 type Connection struct {
+	server   *Server
+	id       int64
 	conn     net.Conn
 	respConn *protocol.RESPConnection
 }
@@ -26,27 +31,72 @@ func newConnection(s *Server, conn net.Conn) *Connection {
 	reader := bufio.NewReader(conn)
 	writer := bufio.NewWriter(conn)
 	return &Connection{
+		server:   s,
+		id:       s.nextClientID.Add(1),
 		conn:     conn,
 		respConn: protocol.NewRESPConnection(reader, writer),
 	}
 }
 
-// Serve handles the command loop for the connection
+// Serve handles the command loop for the connection. Replies are held back
+// while another whole command is already buffered and sent together once none
+// is, so a pipeline costs one write instead of one per command. A command only
+// partly received does not hold them back, since reading it may wait on the
+// client. The writer also writes through whenever its buffer fills, and
+// replies are always sent in the order the commands arrived.
 func (c *Connection) Serve(router *command.Router) {
+	ctx := router.NewContext()
+	ctx.Client = command.NewClient(c.id, c.conn.RemoteAddr().String())
+	ctx.OnBlock = func() { c.flush() }
 	for {
+		var result command.Result
 		cmd, err := c.respConn.ReadCommand()
-		if err != nil {
-			if err == io.EOF {
+		switch {
+		case errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed):
+			// the client hung up or the server is shutting down
+			return
+		case err != nil:
+			result = protocol.NewError("ERR " + err.Error())
+		case strings.EqualFold(cmd.Name(), "PSYNC") && ctx.Authenticate != nil && !ctx.Authenticated:
+			result = protocol.NewError("NOAUTH Authentication required.")
+		case strings.EqualFold(cmd.Name(), "PSYNC"):
+			// a replica's PSYNC turns the connection into a replication stream
+			if err := c.flush(); err != nil {
 				return
 			}
-			c.respConn.WriteResponse(protocol.NewError("ERR " + err.Error()))
-			continue
+			c.server.replication.serveReplica(c.conn, cmd)
+			return
+		default:
+			result = router.Execute(ctx, cmd)
+			c.respConn.SetVersion(ctx.Protocol)
 		}
-		result := router.Execute(cmd)
+
+		c.setWriteDeadline()
 		if err := c.respConn.WriteResponse(result); err != nil {
 			slog.Error("Unexpected result occured while attempting to write a response")
 			return
 		}
+		if c.respConn.CommandBuffered() {
+			continue
+		}
+		if err := c.flush(); err != nil {
+			slog.Error("Unexpected result occured while attempting to write a response")
+			return
+		}
+	}
+}
+
+// flush sends the replies held back
+func (c *Connection) flush() error {
+	c.setWriteDeadline()
+	return c.respConn.Flush()
+}
+
+// setWriteDeadline gives the next write WriteTimeout to complete, whether it
+// is a flush or the writer writing through a full buffer
+func (c *Connection) setWriteDeadline() {
+	if timeout := c.server.cfg.WriteTimeout; timeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(timeout))
 	}
 }
 
@@ -0,0 +1,238 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/server"
+)
+
+// startServer runs a server on a free local port until the test ends
+func startServer(tb testing.TB, opts ...server.ConfigOption) string {
+	tb.Helper()
+	addr, _ := launchServer(tb, opts...)
+	return addr
+}
+
+// launchServer starts a server like startServer and also returns a function
+// stopping it before the test ends
+func launchServer(tb testing.TB, opts ...server.ConfigOption) (string, func()) {
+	tb.Helper()
+	addr := freeAddr(tb)
+	opts = append([]server.ConfigOption{server.WithAddress(addr), server.WithMaxConnections(16)}, opts...)
+	cfg, err := server.NewDefaultConfig(opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.Start(ctx)
+	}()
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			cancel()
+			srv.Shutdown()
+			<-done
+		})
+	}
+	tb.Cleanup(stop)
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return addr, stop
+		}
+	}
+	tb.Fatalf("server did not start on %s", addr)
+	return "", nil
+}
+
+// freeAddr returns a loopback address nothing listens on
+func freeAddr(tb testing.TB) string {
+	tb.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func request(args ...string) []byte {
+	values := make([]protocol.RESPValue, len(args))
+	for i, arg := range args {
+		values[i] = protocol.NewBulkString([]byte(arg))
+	}
+	return protocol.NewArray(values).Serialize()
+}
+
+// client is a test connection that sends one command at a time
+type client struct {
+	tb     testing.TB
+	conn   net.Conn
+	parser *protocol.RESPParser
+}
+
+func dial(tb testing.TB, addr string) *client {
+	tb.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { conn.Close() })
+	return &client{tb: tb, conn: conn, parser: protocol.NewRESPParser(bufio.NewReader(conn))}
+}
+
+// do sends a command and returns its serialized reply
+func (c *client) do(args ...string) string {
+	c.tb.Helper()
+	c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.conn.Write(request(args...)); err != nil {
+		c.tb.Fatalf("%v: %v", args, err)
+	}
+	reply, err := c.parser.ParseValue()
+	if err != nil {
+		c.tb.Fatalf("%v: %v", args, err)
+	}
+	return string(reply.Serialize())
+}
+
+// expect fails the test unless the command replies want
+func (c *client) expect(want string, args ...string) {
+	c.tb.Helper()
+	if got := c.do(args...); got != want {
+		c.tb.Errorf("%v = %q, want %q", args, got, want)
+	}
+}
+
+// eventually polls cond until it holds, failing the test after timeout
+func eventually(tb testing.TB, timeout time.Duration, what string, cond func() bool) {
+	tb.Helper()
+	for deadline := time.Now().Add(timeout); !cond(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			tb.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// bulk renders a bulk string reply
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+// TestPipeline_RepliesInOrder sends a pipeline larger than the write buffer
+// in one write and expects every reply in the order of its command
+func TestPipeline_RepliesInOrder(t *testing.T) {
+	addr := startServer(t, server.WithWriteTimeout(time.Second),
+		server.WithReadBufferSize(256*1024), server.WithWriteBufferSize(256*1024))
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	const n = 5000
+	var pipeline []byte
+	for i := range n {
+		pipeline = append(pipeline, request("SET", "k", strconv.Itoa(i))...)
+		pipeline = append(pipeline, request("GET", "k")...)
+	}
+	go conn.Write(pipeline)
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	parser := protocol.NewRESPParser(bufio.NewReader(conn))
+	for i := range n {
+		set, err := parser.ParseValue()
+		if err != nil {
+			t.Fatalf("reply to SET %d: %v", i, err)
+		}
+		if s, ok := set.(*protocol.SimpleString); !ok || s.Value() != "OK" {
+			t.Fatalf("reply to SET %d = %q", i, set.Serialize())
+		}
+		get, err := parser.ParseValue()
+		if err != nil {
+			t.Fatalf("reply to GET %d: %v", i, err)
+		}
+		if b, ok := get.(*protocol.BulkString); !ok || string(b.Data()) != strconv.Itoa(i) {
+			t.Fatalf("reply to GET %d = %q", i, get.Serialize())
+		}
+	}
+}
+
+// TestPipeline_FlushesBeforeBlocking verifies replies queued ahead of a
+// blocking command reach the client while it waits
+func TestPipeline_FlushesBeforeBlocking(t *testing.T) {
+	addr := startServer(t)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write(append(request("PING"), request("BLPOP", "empty", "5")...))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	reply, err := protocol.NewRESPParser(bufio.NewReader(conn)).ParseValue()
+	if err != nil {
+		t.Fatalf("PING reply held back behind BLPOP: %v", err)
+	}
+	if s, ok := reply.(*protocol.SimpleString); !ok || s.Value() != "PONG" {
+		t.Fatalf("reply = %q, want PONG", reply.Serialize())
+	}
+}
+
+// BenchmarkPipeline measures commands per second sent in pipelines of depth.
+// The socket buffers are raised from the 4 KB default, which a deep pipeline
+// on loopback would otherwise spend in TCP window stalls.
+func BenchmarkPipeline(b *testing.B) {
+	addr := startServer(b, server.WithReadBufferSize(256*1024), server.WithWriteBufferSize(256*1024))
+	for _, depth := range []int{1, 16, 128, 1024} {
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer conn.Close()
+
+			var batch []byte
+			for i := range depth {
+				batch = append(batch, request("SET", "key:"+strconv.Itoa(i), "value")...)
+			}
+			parser := protocol.NewRESPParser(bufio.NewReaderSize(conn, 64*1024))
+
+			// the batch is written while the replies are read, like a client
+			// library does, so neither side stalls on full socket buffers
+			written := make(chan error, 1)
+			b.ResetTimer()
+			for sent := 0; sent < b.N; sent += depth {
+				go func() {
+					_, err := conn.Write(batch)
+					written <- err
+				}()
+				for range depth {
+					if _, err := parser.ParseValue(); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := <-written; err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "cmds/s")
+		})
+	}
+}
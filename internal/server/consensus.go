@@ -0,0 +1,393 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/raft"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// raftTimeout bounds how long a write or a linearizable read waits for the cluster
+const raftTimeout = 10 * time.Second
+
+// entryVersionBits is how many low bits of a version count the writes of one
+// log entry. Entry i owns the versions from i<<entryVersionBits on, so a
+// command writing several versions never pushes the next entry off its own.
+const entryVersionBits = 16
+
+// consensus runs the server as a node of a raft cluster. Every write command
+// is a log entry, run on each node by the router when the entry is applied.
+// Before running it, every database reserves the first version of the entry's
+// block and the leader's timestamp, so all nodes assign the same versions and
+// timestamps and a version names the entry that wrote it.
+//
+// The command runs at the entry's timestamp: expiry deadlines given relative
+// to now and whether a key has expired when the command reads it are both
+// decided by the leader's clock, never by the applying node's.
+type consensus struct {
+	server *Server
+	node   *raft.Node
+
+	// clients interns the authors of applied writes, only the applier uses it
+	clients map[storage.Author]*command.Client
+}
+
+var (
+	_ command.Consensus = (*consensus)(nil)
+	_ raft.StateMachine = (*consensus)(nil)
+)
+
+// appliedCommand is the result of a command run from the log
+type appliedCommand struct {
+	result  command.Result
+	blocked *command.Blocked
+}
+
+func newConsensus(s *Server) *consensus {
+	return &consensus{server: s, clients: make(map[storage.Author]*command.Client)}
+}
+
+func (c *consensus) start() error {
+	cfg := c.server.cfg
+	raftCfg := raft.Config{ID: cfg.RaftID, Addr: cfg.RaftAddr, Peers: cfg.RaftPeers}
+	if cfg.RaftDir != "" {
+		store, err := raft.OpenFileStorage(cfg.RaftDir)
+		if err != nil {
+			return fmt.Errorf("opening raft storage: %w", err)
+		}
+		raftCfg.Storage = store
+	}
+	node, err := raft.Start(raftCfg, c)
+	if err != nil {
+		return fmt.Errorf("starting raft node: %w", err)
+	}
+	c.node = node
+	return nil
+}
+
+func (c *consensus) stop() {
+	if c.node != nil {
+		c.node.Stop()
+	}
+}
+
+// Submit proposes cmd as a log entry and returns its result once this node applied it
+func (c *consensus) Submit(ctx *command.Context, cmd *protocol.Command) (command.Result, *command.Blocked) {
+	rctx, cancel := context.WithTimeout(context.Background(), raftTimeout)
+	defer cancel()
+	result, err := c.node.Propose(rctx, encodeRaftCommand(ctx, cmd))
+	if err != nil {
+		return protocol.NewError(raftError(err)), nil
+	}
+	applied := result.(appliedCommand)
+	return applied.result, applied.blocked
+}
+
+func (c *consensus) ReadBarrier(ctx *command.Context) error {
+	rctx, cancel := context.WithTimeout(context.Background(), raftTimeout)
+	defer cancel()
+	if err := c.node.ReadIndex(rctx); err != nil {
+		return errors.New(raftError(err))
+	}
+	return nil
+}
+
+func (c *consensus) AddNode(id, addr string) error {
+	rctx, cancel := context.WithTimeout(context.Background(), raftTimeout)
+	defer cancel()
+	return c.node.AddMember(rctx, id, addr)
+}
+
+func (c *consensus) RemoveNode(id string) error {
+	rctx, cancel := context.WithTimeout(context.Background(), raftTimeout)
+	defer cancel()
+	return c.node.RemoveMember(rctx, id)
+}
+
+func (c *consensus) Nodes() []command.ConsensusNode {
+	status := c.node.Status()
+	nodes := make([]command.ConsensusNode, 0, len(status.Members))
+	for id, addr := range status.Members {
+		nodes = append(nodes, command.ConsensusNode{ID: id, Addr: addr, Leader: id == status.Leader})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+func (c *consensus) Info() []string {
+	status := c.node.Status()
+	return []string{
+		"raft_node_id:" + status.ID,
+		"raft_role:" + status.Role.String(),
+		"raft_term:" + strconv.FormatUint(status.Term, 10),
+		"raft_leader:" + status.Leader,
+		"raft_members:" + strconv.Itoa(len(status.Members)),
+		"raft_commit_index:" + strconv.FormatUint(status.CommitIndex, 10),
+		"raft_applied_index:" + strconv.FormatUint(status.AppliedIndex, 10),
+		"raft_last_index:" + strconv.FormatUint(status.LastIndex, 10),
+		"raft_snapshot_index:" + strconv.FormatUint(status.SnapshotIndex, 10),
+	}
+}
+
+// raftError turns a raft failure into a client error reply
+func raftError(err error) string {
+	switch {
+	case errors.Is(err, raft.ErrNoLeader):
+		return "CLUSTERDOWN The raft cluster has no leader"
+	case errors.Is(err, raft.ErrLost):
+		return "TRYAGAIN The write was dropped by a leader change and not applied"
+	case errors.Is(err, raft.ErrResultUnknown):
+		return "ERR The write was applied through a snapshot, its reply is unknown"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "TIMEOUT The raft cluster did not answer in time, a write may still be applied"
+	case errors.Is(err, raft.ErrStopped):
+		return "ERR Server is shutting down"
+	default:
+		return "ERR " + err.Error()
+	}
+}
+
+// A command entry is the RESP array [db, identity, name, addr, command, arg...]
+// with the connection's database and the author its writes record. EXEC is
+// followed by the commands queued since MULTI, each an array [command, arg...].
+
+func encodeRaftCommand(ctx *command.Context, cmd *protocol.Command) []byte {
+	var author storage.Author
+	if ctx.Client != nil {
+		author = *ctx.Client.Author()
+	}
+	fields := []protocol.RESPValue{
+		replBulk(strconv.Itoa(ctx.DB)),
+		replBulk(author.Identity),
+		replBulk(author.Name),
+		replBulk(author.Addr),
+		replBulk(cmd.Name()),
+	}
+	for _, arg := range cmd.Args() {
+		fields = append(fields, protocol.NewBulkString(arg))
+	}
+	for _, queued := range ctx.Queued() {
+		elems := []protocol.RESPValue{replBulk(queued.Name())}
+		for _, arg := range queued.Args() {
+			elems = append(elems, protocol.NewBulkString(arg))
+		}
+		fields = append(fields, protocol.NewArray(elems))
+	}
+	return protocol.NewArray(fields).Serialize()
+}
+
+func (c *consensus) decodeRaftCommand(data []byte) (int, *command.Client, *protocol.Command, []*protocol.Command, error) {
+	value, err := protocol.NewRESPParser(bufio.NewReader(bytes.NewReader(data))).ParseValue()
+	if err != nil {
+		return 0, nil, nil, nil, err
+	}
+	arr, ok := value.(*protocol.Array)
+	if !ok || len(arr.Elements()) < 5 {
+		return 0, nil, nil, nil, errReplProtocol
+	}
+	var fields [][]byte
+	var queued []*protocol.Command
+	for _, elem := range arr.Elements() {
+		switch elem := elem.(type) {
+		case *protocol.BulkString:
+			if queued != nil {
+				return 0, nil, nil, nil, errReplProtocol
+			}
+			fields = append(fields, elem.Data())
+		case *protocol.Array:
+			cmd, err := decodeQueued(elem)
+			if err != nil || len(fields) < 5 {
+				return 0, nil, nil, nil, errReplProtocol
+			}
+			queued = append(queued, cmd)
+		default:
+			return 0, nil, nil, nil, errReplProtocol
+		}
+	}
+	db, err := strconv.Atoi(string(fields[0]))
+	if err != nil || db < 0 || db >= c.server.databases.Len() {
+		return 0, nil, nil, nil, fmt.Errorf("%w: database %q", errReplProtocol, fields[0])
+	}
+
+	author := storage.Author{Identity: string(fields[1]), Name: string(fields[2]), Addr: string(fields[3])}
+	client := c.clients[author]
+	if client == nil {
+		client = command.NewClient(0, author.Addr)
+		client.SetIdentity(author.Identity)
+		client.SetName(author.Name)
+		c.clients[author] = client
+	}
+	return db, client, protocol.NewCommand(string(fields[4]), fields[5:]), queued, nil
+}
+
+// decodeQueued decodes a command of a transaction entry
+func decodeQueued(arr *protocol.Array) (*protocol.Command, error) {
+	elems := arr.Elements()
+	if len(elems) == 0 {
+		return nil, errReplProtocol
+	}
+	fields := make([][]byte, len(elems))
+	for i, elem := range elems {
+		bulk, ok := elem.(*protocol.BulkString)
+		if !ok {
+			return nil, errReplProtocol
+		}
+		fields[i] = bulk.Data()
+	}
+	return protocol.NewCommand(string(fields[0]), fields[1:]), nil
+}
+
+// Apply runs a committed command entry through the router
+func (c *consensus) Apply(entry raft.Entry) any {
+	db, client, cmd, queued, err := c.decodeRaftCommand(entry.Data)
+	if err != nil {
+		slog.Error("raft entry is not a command", "index", entry.Index, "error", err)
+		return appliedCommand{result: protocol.NewError("ERR " + err.Error())}
+	}
+	for i := range c.server.databases.Len() {
+		engine, _ := c.server.databases.Get(i)
+		engine.(storage.Replicator).Reserve(entryVersion(entry.Index), entry.Timestamp)
+	}
+
+	ctx := c.server.router.NewContext()
+	ctx.Consensus = nil
+	ctx.NoWait = true
+	ctx.DB = db
+	ctx.Client = client
+	// the entry's connection authenticated on the node that logged it
+	ctx.Authenticated = true
+	at := time.Unix(0, entry.Timestamp)
+	ctx.Clock = func() time.Time { return at }
+	if queued != nil {
+		// a transaction is queued again here and runs as one command
+		ctx.Multi()
+		for _, q := range queued {
+			c.server.router.Execute(ctx, q)
+		}
+	}
+	result := c.server.router.Execute(ctx, cmd)
+	return appliedCommand{result: result, blocked: ctx.Blocked}
+}
+
+// entryVersion returns the first version of the block owned by the entry at index
+func entryVersion(index uint64) uint64 {
+	return index << entryVersionBits
+}
+
+// Snapshot encodes every database like a replication snapshot: a SNAPSHOT,
+// CHAIN messages for every key and LOADED per database
+func (c *consensus) Snapshot() ([]byte, error) {
+	var buf bytes.Buffer
+	for db := range c.server.databases.Len() {
+		bound, _ := c.server.databases.Get(db)
+		engine := bound.(storage.Replicator)
+		version, err := engine.SyncPoint()
+		if err != nil {
+			return nil, fmt.Errorf("snapshot of db %d: %w", db, err)
+		}
+		buf.Write(replMessage("SNAPSHOT", replInt(int64(db)), replInt(0), replInt(int64(version))).Serialize())
+		for _, key := range engine.Keys() {
+			records, err := engine.Records(key, version)
+			if err != nil {
+				return nil, fmt.Errorf("snapshot of %q: %w", key, err)
+			}
+			for len(records) > 0 {
+				n := min(len(records), replChunk)
+				more := int64(0)
+				if n < len(records) {
+					more = 1
+				}
+				buf.Write(replMessage("CHAIN", replInt(int64(db)), replBulk(key), replInt(more), encodeRecords(records[:n])).Serialize())
+				records = records[n:]
+			}
+		}
+		buf.Write(replMessage("LOADED", replInt(int64(db))).Serialize())
+	}
+	return buf.Bytes(), nil
+}
+
+// Restore replaces every database with the ones of a snapshot
+func (c *consensus) Restore(snapshot *raft.Snapshot) error {
+	parser := protocol.NewRESPParser(bufio.NewReader(bytes.NewReader(snapshot.Data)))
+	d := newReplDecoder()
+	var (
+		engine  storage.Engine
+		version uint64
+		key     string
+		records []storage.Record
+	)
+	for {
+		value, err := parser.ParseValue()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fields, _, err := d.array(value)
+		if err != nil || len(fields) < 2 {
+			return errors.Join(errReplProtocol, err)
+		}
+		kind, err := d.str(fields[0])
+		if err != nil {
+			return err
+		}
+		index, err := d.int(fields[1])
+		if err != nil {
+			return err
+		}
+		db := int(index)
+
+		switch {
+		case kind == "SNAPSHOT" && len(fields) == 4:
+			n, err := d.int(fields[3])
+			if err != nil {
+				return err
+			}
+			if engine, err = storage.Open(c.server.cfg.Backend, c.server.cfg.EngineConfigFor(db)); err != nil {
+				return err
+			}
+			version = uint64(n)
+		case kind == "CHAIN" && len(fields) == 5 && engine != nil:
+			name, err := d.str(fields[2])
+			if err != nil {
+				return err
+			}
+			more, err := d.int(fields[3])
+			if err != nil {
+				return err
+			}
+			chain, err := d.records(fields[4])
+			if err != nil {
+				return err
+			}
+			if name != key {
+				key, records = name, nil
+			}
+			records = append(records, chain...)
+			if more == 0 {
+				engine.(storage.Replicator).Load(key, records)
+				key, records = "", nil
+			}
+		case kind == "LOADED" && engine != nil:
+			engine.(storage.Replicator).SetBase(version)
+			if err := c.server.databases.Replace(db, engine); err != nil {
+				return err
+			}
+			engine = nil
+		default:
+			return fmt.Errorf("%w: unexpected %s in raft snapshot", errReplProtocol, kind)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package server_test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/server"
+)
+
+// raftNode is a server of a test raft cluster
+type raftNode struct {
+	id     string
+	client *client
+	stop   func()
+}
+
+// startRaftCluster runs n servers forming one raft cluster over loopback
+func startRaftCluster(t *testing.T, n int) []*raftNode {
+	t.Helper()
+	ids := make([]string, n)
+	raftAddrs := make([]string, n)
+	peers := make([]server.ConfigOption, n)
+	for i := range n {
+		ids[i], raftAddrs[i] = "n"+strconv.Itoa(i+1), freeAddr(t)
+		peers[i] = server.WithRaftPeer(ids[i], raftAddrs[i])
+	}
+
+	nodes := make([]*raftNode, n)
+	for i, id := range ids {
+		opts := append([]server.ConfigOption{server.WithRaft(id, raftAddrs[i], "")}, peers...)
+		addr, stop := launchServer(t, opts...)
+		nodes[i] = &raftNode{id: id, client: dial(t, addr), stop: stop}
+	}
+	return nodes
+}
+
+// raftInfo returns a field of the node's INFO raft section
+func (n *raftNode) raftInfo(field string) string {
+	for _, line := range strings.Split(n.client.do("INFO", "raft"), "\r\n") {
+		if value, ok := strings.CutPrefix(line, field+":"); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// waitLeader waits until the running nodes agree on a leader among them and returns it
+func waitLeader(t *testing.T, nodes []*raftNode) *raftNode {
+	t.Helper()
+	var leader *raftNode
+	eventually(t, 10*time.Second, "a leader", func() bool {
+		id := nodes[0].raftInfo("raft_leader")
+		leader = nil
+		for _, n := range nodes {
+			if n.raftInfo("raft_leader") != id {
+				return false
+			}
+			if n.id == id {
+				leader = n
+			}
+		}
+		return leader != nil
+	})
+	return leader
+}
+
+// TestRaft_FailoverAndLinearizableReads runs three servers, checks every node
+// reads the writes made through any other one right after they are
+// acknowledged, then stops the leader and checks the survivors elect a new
+// one that keeps the acknowledged writes and takes new ones
+func TestRaft_FailoverAndLinearizableReads(t *testing.T) {
+	nodes := startRaftCluster(t, 3)
+	leader := waitLeader(t, nodes)
+
+	// writes go through any node, reads on any other see them at once
+	for i := range 6 {
+		writer, reader := nodes[i%3], nodes[(i+1)%3]
+		value := fmt.Sprintf("v%d", i)
+		writer.client.expect("+OK\r\n", "SET", "k", value)
+		reader.client.expect(bulk(value), "GET", "k")
+	}
+
+	// a command writing several versions keeps the next entry on its own versions
+	leader.client.expect("+OK\r\n", "MSET", "a", "1", "b", "2", "c", "3")
+	leader.client.expect(":3\r\n", "DEL", "a", "b", "c")
+	reply := leader.client.do("SET", "k", "last", "RETURNVERSION")
+	version, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(reply, ":")), 10, 64)
+	if err != nil {
+		t.Fatalf("SET RETURNVERSION = %q", reply)
+	}
+	applied, _ := strconv.ParseUint(leader.raftInfo("raft_applied_index"), 10, 64)
+	if want := applied << 16; version != want {
+		t.Errorf("SET applied as entry %d took version %d, want %d", applied, version, want)
+	}
+	history := leader.client.do("HISTORY", "k")
+	for _, n := range nodes {
+		if got := n.client.do("HISTORY", "k"); got != history {
+			t.Errorf("HISTORY k on %s = %q, want %q", n.id, got, history)
+		}
+	}
+
+	leader.stop()
+	var survivors []*raftNode
+	for _, n := range nodes {
+		if n != leader {
+			survivors = append(survivors, n)
+		}
+	}
+	next := waitLeader(t, survivors)
+	if next.id == leader.id {
+		t.Fatalf("stopped leader %s is still the leader", leader.id)
+	}
+
+	for _, n := range survivors {
+		n.client.expect(bulk("last"), "GET", "k")
+	}
+	for i, writer := range survivors {
+		reader := survivors[1-i]
+		value := "after-" + writer.id
+		writer.client.expect("+OK\r\n", "SET", "k", value)
+		reader.client.expect(bulk(value), "GET", "k")
+	}
+}
+
+// TestRaft_Transaction verifies a transaction sent to any node is applied as
+// one entry on every node, with its comment
+func TestRaft_Transaction(t *testing.T) {
+	nodes := startRaftCluster(t, 3)
+	leader := waitLeader(t, nodes)
+	var follower *raftNode
+	for _, n := range nodes {
+		if n != leader {
+			follower = n
+		}
+	}
+
+	before, _ := strconv.ParseUint(leader.raftInfo("raft_applied_index"), 10, 64)
+	follower.client.expect("+OK\r\n", "MULTI")
+	follower.client.expect("+QUEUED\r\n", "SET", "a", "1")
+	follower.client.expect("+QUEUED\r\n", "INCR", "a")
+	follower.client.expect("+QUEUED\r\n", "SET", "b", "x")
+	follower.client.expect("*3\r\n+OK\r\n:2\r\n+OK\r\n", "EXEC", "COMMENT", "TICKET-9")
+	after, _ := strconv.ParseUint(follower.raftInfo("raft_applied_index"), 10, 64)
+	if after != before+1 {
+		t.Errorf("transaction took entries %d..%d, want one", before+1, after)
+	}
+
+	history := leader.client.do("HISTORY", "a", "WITHMETA")
+	if !strings.Contains(history, "TICKET-9") {
+		t.Errorf("HISTORY a = %q, want the transaction's comment", history)
+	}
+	for _, n := range nodes {
+		n.client.expect(bulk("x"), "GET", "b")
+		if got := n.client.do("HISTORY", "a", "WITHMETA"); got != history {
+			t.Errorf("HISTORY a on %s = %q, want %q", n.id, got, history)
+		}
+	}
+}
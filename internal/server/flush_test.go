@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/protocol"
+)
+
+// countingConn counts the writes that reach the underlying connection
+type countingConn struct {
+	net.Conn
+	writes atomic.Int32
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	c.writes.Add(1)
+	return c.Conn.Write(b)
+}
+
+// serveCounted serves a connection over an in-memory pipe and returns the
+// client's end with the counted server end
+func serveCounted(t *testing.T, opts ...ConfigOption) (net.Conn, *countingConn) {
+	t.Helper()
+	cfg, err := NewDefaultConfig(append([]ConfigOption{WithMaxConnections(1)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, end := net.Pipe()
+	counted := &countingConn{Conn: end}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		newConnection(s, counted).Serve(s.router)
+	}()
+	t.Cleanup(func() {
+		client.Close()
+		<-done
+	})
+	return client, counted
+}
+
+func rawCommand(args ...string) []byte {
+	values := make([]protocol.RESPValue, len(args))
+	for i, arg := range args {
+		values[i] = protocol.NewBulkString([]byte(arg))
+	}
+	return protocol.NewArray(values).Serialize()
+}
+
+// expectReplies reads the wanted replies from the client's end of the pipe
+func expectReplies(t *testing.T, conn net.Conn, parser *protocol.RESPParser, want ...string) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for _, w := range want {
+		reply, err := parser.ParseValue()
+		if err != nil {
+			t.Fatalf("waiting for %q: %v", w, err)
+		}
+		if got := string(reply.Serialize()); got != w {
+			t.Fatalf("reply = %q, want %q", got, w)
+		}
+	}
+}
+
+// TestServe_OneWritePerPipeline verifies the replies to a pipeline received at
+// once leave in a single write
+func TestServe_OneWritePerPipeline(t *testing.T) {
+	client, counted := serveCounted(t)
+	parser := protocol.NewRESPParser(bufio.NewReader(client))
+
+	var pipeline []byte
+	pipeline = append(pipeline, rawCommand("SET", "k", "v")...)
+	pipeline = append(pipeline, rawCommand("GET", "k")...)
+	pipeline = append(pipeline, rawCommand("PING")...)
+	go client.Write(pipeline)
+	expectReplies(t, client, parser, "+OK\r\n", "$1\r\nv\r\n", "+PONG\r\n")
+
+	if n := counted.writes.Load(); n != 1 {
+		t.Errorf("pipeline of 3 commands took %d writes, want 1", n)
+	}
+}
+
+// TestServe_PartialCommandDoesNotHoldReplies verifies a reply is sent while the
+// next command has only partly arrived
+func TestServe_PartialCommandDoesNotHoldReplies(t *testing.T) {
+	client, counted := serveCounted(t)
+	parser := protocol.NewRESPParser(bufio.NewReader(client))
+
+	next := rawCommand("PING")
+	go client.Write(append(rawCommand("PING"), next[:6]...))
+	expectReplies(t, client, parser, "+PONG\r\n")
+
+	go client.Write(next[6:])
+	expectReplies(t, client, parser, "+PONG\r\n")
+
+	if n := counted.writes.Load(); n != 2 {
+		t.Errorf("2 commands arriving apart took %d writes, want 2", n)
+	}
+}
+
+// TestServe_WriteTimeout verifies a client that stops reading its replies is
+// dropped once a write has waited WriteTimeout
+func TestServe_WriteTimeout(t *testing.T) {
+	client, _ := serveCounted(t, WithWriteTimeout(50*time.Millisecond))
+	go client.Write(rawCommand("PING"))
+
+	// the pipe has no buffer, so the reply waits for a read; once the write
+	// timed out there is nothing left to read
+	time.Sleep(200 * time.Millisecond)
+	client.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, err := client.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("read after the write timeout: %v, want no reply", err)
+	}
+}
@@ -0,0 +1,448 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// followedDB is what a replica knows about one of the leader's databases
+type followedDB struct {
+	// generation is the leader's generation of the database, valid once synced
+	generation uint64
+	synced     bool
+
+	// applied is the leader version the replica has caught up to
+	applied uint64
+
+	// leader is the leader's current version at the last PING
+	leader uint64
+
+	// loading holds a snapshot while it is received
+	loading *snapshotLoad
+}
+
+// snapshotLoad is a database snapshot being received into a fresh engine
+type snapshotLoad struct {
+	engine     storage.Engine
+	generation uint64
+	version    uint64
+
+	// key and records collect a chain sent over several messages
+	key     string
+	records []storage.Record
+}
+
+// pendingApply collects a version whose mutations span several messages
+type pendingApply struct {
+	version   uint64
+	timestamp int64
+	mutations []storage.Mutation
+}
+
+// follower keeps the server a replica of a leader, reconnecting and resuming
+// from the last applied versions until it is closed
+type follower struct {
+	server *Server
+	addr   string
+
+	stop     chan struct{}
+	finished chan struct{}
+
+	// mu guards the link state below, which INFO reads
+	mu        sync.Mutex
+	conn      net.Conn
+	linkUp    bool
+	lastIO    time.Time
+	replid    string
+	dbs       []followedDB
+	lagMillis int64
+
+	// clockOffset is the leader's clock minus ours as of the last PING
+	clockOffset int64
+}
+
+func newFollower(s *Server, addr string) *follower {
+	return &follower{
+		server:   s,
+		addr:     addr,
+		stop:     make(chan struct{}),
+		finished: make(chan struct{}),
+		dbs:      make([]followedDB, s.databases.Len()),
+	}
+}
+
+// run syncs with the leader until closed, reconnecting after every failure
+func (f *follower) run() {
+	defer close(f.finished)
+	for {
+		err := f.sync()
+		f.mu.Lock()
+		f.linkUp, f.conn = false, nil
+		for db := range f.dbs {
+			f.dbs[db].loading = nil
+		}
+		f.mu.Unlock()
+
+		select {
+		case <-f.stop:
+			return
+		default:
+		}
+		slog.Warn("replication link to leader lost", "leader", f.addr, "err", err)
+
+		select {
+		case <-f.stop:
+			return
+		case <-time.After(replRetry):
+		}
+	}
+}
+
+// close stops following and waits for the link to shut down
+func (f *follower) close() {
+	close(f.stop)
+	f.mu.Lock()
+	if f.conn != nil {
+		f.conn.Close()
+	}
+	f.mu.Unlock()
+	<-f.finished
+}
+
+// sync connects to the leader, asks to resume from the applied versions and
+// applies the stream until the connection breaks
+func (f *follower) sync() error {
+	conn, err := net.DialTimeout("tcp", f.addr, replTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	f.mu.Lock()
+	select {
+	case <-f.stop:
+		f.mu.Unlock()
+		return nil
+	default:
+	}
+	f.conn = conn
+	psync := f.psync()
+	f.mu.Unlock()
+
+	parser := protocol.NewRESPParser(bufio.NewReaderSize(conn, 64*1024))
+	if err := f.auth(conn, parser); err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(replTimeout))
+	if _, err := conn.Write(psync.Serialize()); err != nil {
+		return err
+	}
+
+	decoder := newReplDecoder()
+	pending := make(map[int]*pendingApply)
+	for {
+		conn.SetReadDeadline(time.Now().Add(replTimeout))
+		value, err := parser.ParseValue()
+		if err != nil {
+			return err
+		}
+		if reply, ok := value.(*protocol.Error); ok {
+			return errors.New(reply.Msg())
+		}
+		fields, _, err := decoder.array(value)
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			return fmt.Errorf("%w: empty message", errReplProtocol)
+		}
+		kind, err := decoder.str(fields[0])
+		if err != nil {
+			return err
+		}
+		if err := f.handle(decoder, kind, fields[1:], pending); err != nil {
+			return fmt.Errorf("%s: %w", kind, err)
+		}
+	}
+}
+
+// auth authenticates with the leader when the server has leader credentials
+func (f *follower) auth(conn net.Conn, parser *protocol.RESPParser) error {
+	cfg := f.server.cfg
+	if cfg.LeaderPassword == "" {
+		return nil
+	}
+	args := []protocol.RESPValue{replBulk("AUTH"), replBulk(cfg.LeaderPassword)}
+	if cfg.LeaderUser != "" {
+		args = []protocol.RESPValue{replBulk("AUTH"), replBulk(cfg.LeaderUser), replBulk(cfg.LeaderPassword)}
+	}
+	conn.SetWriteDeadline(time.Now().Add(replTimeout))
+	if _, err := conn.Write(protocol.NewArray(args).Serialize()); err != nil {
+		return err
+	}
+	conn.SetReadDeadline(time.Now().Add(replTimeout))
+	reply, err := parser.ParseValue()
+	if err != nil {
+		return err
+	}
+	if reply, ok := reply.(*protocol.Error); ok {
+		return errors.New(reply.Msg())
+	}
+	return nil
+}
+
+// psync builds the PSYNC request from the applied versions
+func (f *follower) psync() *protocol.Array {
+	args := []protocol.RESPValue{replBulk("PSYNC")}
+	if f.replid == "" {
+		return protocol.NewArray(append(args, replBulk("?")))
+	}
+	args = append(args, replBulk(f.replid), replBulk(strconv.Itoa(len(f.dbs))))
+	for _, db := range f.dbs {
+		if !db.synced {
+			args = append(args, replBulk("?"), replBulk("?"))
+			continue
+		}
+		args = append(args, replBulk(strconv.FormatUint(db.generation, 10)), replBulk(strconv.FormatUint(db.applied, 10)))
+	}
+	return protocol.NewArray(args)
+}
+
+// handle applies one message of the stream
+func (f *follower) handle(d *replDecoder, kind string, fields []protocol.RESPValue, pending map[int]*pendingApply) error {
+	f.mu.Lock()
+	f.lastIO = time.Now()
+	f.mu.Unlock()
+
+	switch kind {
+	case "SYNC":
+		return f.handleSync(d, fields)
+	case "PING":
+		return f.handlePing(d, fields)
+	}
+
+	if len(fields) == 0 {
+		return errReplProtocol
+	}
+	index, err := d.int(fields[0])
+	if err != nil {
+		return err
+	}
+	db := int(index)
+	if db < 0 || db >= len(f.dbs) {
+		return fmt.Errorf("%w: database %d out of range", errReplProtocol, db)
+	}
+
+	switch kind {
+	case "SNAPSHOT":
+		numbers, err := d.ints(fields[1:])
+		if err != nil || len(numbers) != 2 {
+			return errors.Join(errReplProtocol, err)
+		}
+		engine, err := storage.Open(f.server.cfg.Backend, f.server.cfg.EngineConfigFor(db))
+		if err != nil {
+			return err
+		}
+		f.mu.Lock()
+		f.dbs[db].loading = &snapshotLoad{engine: engine, generation: uint64(numbers[0]), version: uint64(numbers[1])}
+		f.mu.Unlock()
+	case "CHAIN":
+		return f.handleChain(d, db, fields[1:])
+	case "LOADED":
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		load := f.dbs[db].loading
+		if load == nil {
+			return fmt.Errorf("%w: no snapshot of db %d", errReplProtocol, db)
+		}
+		load.engine.(storage.Replicator).SetBase(load.version)
+		if err := f.server.databases.Replace(db, load.engine); err != nil {
+			return err
+		}
+		f.dbs[db] = followedDB{generation: load.generation, synced: true, applied: load.version, leader: f.dbs[db].leader}
+	case "CONTINUE":
+		numbers, err := d.ints(fields[1:])
+		if err != nil || len(numbers) != 2 {
+			return errors.Join(errReplProtocol, err)
+		}
+		f.mu.Lock()
+		f.dbs[db].generation, f.dbs[db].applied = uint64(numbers[0]), uint64(numbers[1])
+		f.mu.Unlock()
+	case "APPLY":
+		return f.handleApply(d, db, fields[1:], pending)
+	default:
+		return fmt.Errorf("%w: unknown message %q", errReplProtocol, kind)
+	}
+	return nil
+}
+
+func (f *follower) handleSync(d *replDecoder, fields []protocol.RESPValue) error {
+	if len(fields) != 2 {
+		return errReplProtocol
+	}
+	replid, err := d.str(fields[0])
+	if err != nil {
+		return err
+	}
+	databases, err := d.int(fields[1])
+	if err != nil {
+		return err
+	}
+	if int(databases) > len(f.dbs) {
+		return fmt.Errorf("leader has %d databases, this server only %d", databases, len(f.dbs))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replid, f.linkUp = replid, true
+	return nil
+}
+
+func (f *follower) handleChain(d *replDecoder, db int, fields []protocol.RESPValue) error {
+	if len(fields) != 3 {
+		return errReplProtocol
+	}
+	key, err := d.str(fields[0])
+	if err != nil {
+		return err
+	}
+	more, err := d.int(fields[1])
+	if err != nil {
+		return err
+	}
+	records, err := d.records(fields[2])
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	load := f.dbs[db].loading
+	f.mu.Unlock()
+	if load == nil {
+		return fmt.Errorf("%w: no snapshot of db %d", errReplProtocol, db)
+	}
+	if load.key != key {
+		load.key, load.records = key, nil
+	}
+	load.records = append(load.records, records...)
+	if more == 0 {
+		load.engine.(storage.Replicator).Load(key, load.records)
+		load.key, load.records = "", nil
+	}
+	return nil
+}
+
+func (f *follower) handleApply(d *replDecoder, db int, fields []protocol.RESPValue, pending map[int]*pendingApply) error {
+	if len(fields) != 4 {
+		return errReplProtocol
+	}
+	numbers, err := d.ints(fields[:3])
+	if err != nil {
+		return err
+	}
+	version, timestamp, more := uint64(numbers[0]), numbers[1], numbers[2]
+	encoded, _, err := d.array(fields[3])
+	if err != nil {
+		return err
+	}
+
+	apply := pending[db]
+	if apply == nil || apply.version != version {
+		apply = &pendingApply{version: version, timestamp: timestamp}
+	}
+	for _, value := range encoded {
+		m, err := d.mutation(value)
+		if err != nil {
+			return err
+		}
+		apply.mutations = append(apply.mutations, m)
+	}
+	if more == 1 {
+		pending[db] = apply
+		return nil
+	}
+	delete(pending, db)
+
+	engine, _ := f.server.databases.Get(db)
+	engine.(storage.Replicator).Apply(apply.version, apply.timestamp, apply.mutations)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dbs[db].applied = max(f.dbs[db].applied, apply.version)
+	f.lagMillis = max(time.Now().UnixNano()+f.clockOffset-apply.timestamp, 0) / int64(time.Millisecond)
+	return nil
+}
+
+func (f *follower) handlePing(d *replDecoder, fields []protocol.RESPValue) error {
+	numbers, err := d.ints(fields)
+	if err != nil || len(numbers)%2 != 1 {
+		return errors.Join(errReplProtocol, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clockOffset = numbers[0] - time.Now().UnixNano()
+	for db := 0; db < len(f.dbs) && 2+2*db < len(numbers); db++ {
+		state := &f.dbs[db]
+		state.leader = uint64(numbers[1+2*db])
+		if state.synced && state.loading == nil {
+			state.applied = max(state.applied, uint64(numbers[2+2*db]))
+		}
+	}
+	return nil
+}
+
+// info returns the replica fields of INFO replication
+func (f *follower) info() []string {
+	host, port, _ := net.SplitHostPort(f.addr)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status, lastIO := "down", int64(-1)
+	if f.linkUp {
+		status = "up"
+	}
+	if !f.lastIO.IsZero() {
+		lastIO = int64(time.Since(f.lastIO) / time.Second)
+	}
+	syncing := 0
+	var lag uint64
+	for _, db := range f.dbs {
+		if db.loading != nil {
+			syncing = 1
+		}
+		if db.leader > db.applied {
+			lag += db.leader - db.applied
+		}
+	}
+	lagMillis := f.lagMillis
+	if lag == 0 {
+		lagMillis = 0
+	}
+
+	lines := []string{
+		"master_host:" + host,
+		"master_port:" + port,
+		"master_link_status:" + status,
+		"master_last_io_seconds_ago:" + strconv.FormatInt(lastIO, 10),
+		"master_sync_in_progress:" + strconv.Itoa(syncing),
+		"master_lag_versions:" + strconv.FormatUint(lag, 10),
+		"master_lag_ms:" + strconv.FormatInt(lagMillis, 10),
+		"slave_read_only:1",
+	}
+	for db, state := range f.dbs {
+		if state.synced && state.applied > 0 {
+			lines = append(lines, fmt.Sprintf("db%d_applied_version:%d", db, state.applied))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,335 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// resumePoint is where a replica left off in one database
+type resumePoint struct {
+	generation uint64
+	version    uint64
+}
+
+// replicaLink streams every database to one replica, one goroutine per
+// database plus a heartbeat, all writing to the same connection
+type replicaLink struct {
+	conn net.Conn
+
+	// mu serialises writes and guards cursors
+	mu sync.Mutex
+	w  *bufio.Writer
+
+	// cursors are the versions streamed so far per database (0 while a snapshot is sent)
+	cursors []uint64
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newReplicaLink(conn net.Conn, databases int) *replicaLink {
+	return &replicaLink{
+		conn:    conn,
+		w:       bufio.NewWriterSize(conn, 64*1024),
+		cursors: make([]uint64, databases),
+		done:    make(chan struct{}),
+	}
+}
+
+// serveReplica takes over a connection that sent PSYNC and streams to it until
+// either side goes away. The replica resumes the databases whose generation
+// and version it still shares with this server and gets a snapshot of the rest.
+// Usage: PSYNC ? | PSYNC replid databases [generation version] ...
+func (r *replication) serveReplica(conn net.Conn, cmd *protocol.Command) {
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetReadBuffer(replSocketBuffer)
+		tcpConn.SetWriteBuffer(replSocketBuffer)
+	}
+
+	databases := r.server.databases
+	if !r.server.replicates {
+		conn.Write(protocol.NewError("ERR " + ErrReplicationUnsupported.Error()).Serialize())
+		return
+	}
+	link := newReplicaLink(conn, databases.Len())
+	r.addReplica(link)
+	defer r.removeReplica(link)
+
+	replid := r.currentReplID()
+	resume := parseResume(cmd.Args(), replid, databases.Len())
+	if err := link.send(replMessage("SYNC", replBulk(replid), replInt(int64(databases.Len())))); err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for db := range databases.Len() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := link.stream(databases, db, resume[db]); err != nil {
+				link.fail(err)
+			}
+		}()
+	}
+	go link.heartbeat(databases)
+
+	select {
+	case <-link.done:
+	case <-r.server.quit:
+		link.fail(nil)
+	}
+	wg.Wait()
+}
+
+// parseResume reads the positions a replica sent with PSYNC, none when it
+// followed a different server
+func parseResume(args [][]byte, replid string, databases int) []*resumePoint {
+	resume := make([]*resumePoint, databases)
+	if len(args) < 2 || string(args[0]) != replid {
+		return resume
+	}
+	for db := 0; db < databases && 3+2*db < len(args); db++ {
+		generation, err1 := strconv.ParseUint(string(args[2+2*db]), 10, 64)
+		version, err2 := strconv.ParseUint(string(args[3+2*db]), 10, 64)
+		if err1 == nil && err2 == nil {
+			resume[db] = &resumePoint{generation: generation, version: version}
+		}
+	}
+	return resume
+}
+
+// stream sends one database to the replica: a snapshot unless it can resume,
+// then every change as it is logged. A new snapshot is sent whenever the
+// replica falls behind the change log or the database is rebound.
+func (l *replicaLink) stream(databases *storage.Databases, db int, resume *resumePoint) error {
+	bound, generation, _ := databases.Generation(db)
+	engine := bound.(storage.Replicator)
+	full := true
+	var cursor uint64
+	if resume != nil && resume.generation == generation &&
+		resume.version+1 >= engine.OldestChange() && resume.version <= engine.CurrentVersion() {
+		full, cursor = false, resume.version
+		if err := l.send(replMessage("CONTINUE", replInt(int64(db)), replInt(int64(generation)), replInt(int64(cursor)))); err != nil {
+			return err
+		}
+		l.mark(db, cursor)
+	}
+
+	idle := time.NewTimer(replIdle)
+	defer idle.Stop()
+	for {
+		current, currentGeneration, _ := databases.Generation(db)
+		if full || currentGeneration != generation {
+			engine, generation = current.(storage.Replicator), currentGeneration
+			var err error
+			if cursor, err = l.snapshot(db, engine, generation); err != nil {
+				return err
+			}
+			full = false
+		}
+
+		next := engine.NextChange()
+		var err error
+		cursor, err = l.changes(db, engine, cursor)
+		if errors.Is(err, storage.ErrChangesPruned) {
+			full = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		idle.Reset(replIdle)
+		select {
+		case <-next:
+		case <-idle.C:
+		case <-l.done:
+			return nil
+		}
+	}
+}
+
+// snapshot sends every key's history as of a version the change log can
+// continue from and returns that version
+func (l *replicaLink) snapshot(db int, engine storage.Replicator, generation uint64) (uint64, error) {
+	version, err := engine.SyncPoint()
+	if err != nil {
+		return 0, fmt.Errorf("snapshot of db %d: %w", db, err)
+	}
+	if err := l.send(replMessage("SNAPSHOT", replInt(int64(db)), replInt(int64(generation)), replInt(int64(version)))); err != nil {
+		return 0, err
+	}
+	l.mark(db, 0)
+
+	for _, key := range engine.Keys() {
+		records, err := engine.Records(key, version)
+		if err != nil {
+			return 0, fmt.Errorf("snapshot of %q: %w", key, err)
+		}
+		for len(records) > 0 {
+			n := min(len(records), replChunk)
+			more := int64(0)
+			if n < len(records) {
+				more = 1
+			}
+			msg := replMessage("CHAIN", replInt(int64(db)), replBulk(key), replInt(more), encodeRecords(records[:n]))
+			if err := l.send(msg); err != nil {
+				return 0, err
+			}
+			records = records[n:]
+		}
+	}
+
+	if err := l.send(replMessage("LOADED", replInt(int64(db)))); err != nil {
+		return 0, err
+	}
+	l.mark(db, version)
+	return version, l.flush()
+}
+
+// changes sends every logged change after cursor and returns the new cursor
+func (l *replicaLink) changes(db int, engine storage.Replicator, cursor uint64) (uint64, error) {
+	for {
+		var sendErr error
+		next, err := engine.Changes(cursor, math.MaxUint64, replChunk, func(version uint64, changes []storage.Change) bool {
+			sendErr = l.apply(db, engine, version, changes)
+			return sendErr == nil
+		})
+		if sendErr != nil {
+			return cursor, sendErr
+		}
+		if err != nil {
+			return cursor, err
+		}
+		if next == cursor {
+			return cursor, l.flush()
+		}
+		cursor = next
+		l.mark(db, cursor)
+	}
+}
+
+// apply sends the mutations of one version
+func (l *replicaLink) apply(db int, engine storage.Replicator, version uint64, changes []storage.Change) error {
+	mutations := make([]protocol.RESPValue, 0, len(changes))
+	for _, change := range changes {
+		m, ok, err := engine.Export(change)
+		if err != nil {
+			return fmt.Errorf("exporting %q at %d: %w", change.Key, version, err)
+		}
+		if ok {
+			mutations = append(mutations, encodeMutation(m))
+		}
+	}
+	timestamp := changes[0].Timestamp
+
+	for len(mutations) > 0 {
+		n := min(len(mutations), replChunk)
+		more := int64(0)
+		if n < len(mutations) {
+			more = 1
+		}
+		msg := replMessage("APPLY", replInt(int64(db)), replInt(int64(version)), replInt(timestamp), replInt(more), protocol.NewArray(mutations[:n]))
+		if err := l.send(msg); err != nil {
+			return err
+		}
+		mutations = mutations[n:]
+	}
+	return nil
+}
+
+// heartbeat reports the current and streamed version of every database, so
+// the replica can tell how far behind it is
+func (l *replicaLink) heartbeat(databases *storage.Databases) {
+	ticker := time.NewTicker(replPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.done:
+			return
+		}
+
+		fields := []protocol.RESPValue{replInt(time.Now().UnixNano())}
+		l.mu.Lock()
+		for db, cursor := range l.cursors {
+			engine, _ := databases.Get(db)
+			fields = append(fields, replInt(int64(engine.CurrentVersion())), replInt(int64(cursor)))
+		}
+		err := l.write(replMessage("PING", fields...))
+		if err == nil {
+			err = l.w.Flush()
+		}
+		l.mu.Unlock()
+		if err != nil {
+			l.fail(err)
+			return
+		}
+	}
+}
+
+// info describes the replica for INFO replication
+func (l *replicaLink) info(databases *storage.Databases) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := "online"
+	var lag uint64
+	for db, cursor := range l.cursors {
+		engine, _ := databases.Get(db)
+		if cursor == 0 && engine.CurrentVersion() > 0 {
+			state = "sync"
+		}
+		if current := engine.CurrentVersion(); current > cursor {
+			lag += current - cursor
+		}
+	}
+	return fmt.Sprintf("addr=%s,state=%s,lag_versions=%d", l.conn.RemoteAddr(), state, lag)
+}
+
+// send queues a message on the link
+func (l *replicaLink) send(msg protocol.RESPValue) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.write(msg)
+}
+
+func (l *replicaLink) write(msg protocol.RESPValue) error {
+	l.conn.SetWriteDeadline(time.Now().Add(replTimeout))
+	_, err := l.w.Write(msg.Serialize())
+	return err
+}
+
+func (l *replicaLink) flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.conn.SetWriteDeadline(time.Now().Add(replTimeout))
+	return l.w.Flush()
+}
+
+// mark records that everything up to cursor has been queued for db
+func (l *replicaLink) mark(db int, cursor uint64) {
+	l.mu.Lock()
+	l.cursors[db] = cursor
+	l.mu.Unlock()
+}
+
+// fail closes the link, err is logged when the link broke rather than ended
+func (l *replicaLink) fail(err error) {
+	l.closeOnce.Do(func() {
+		if err != nil {
+			slog.Warn("replica link closed", "addr", l.conn.RemoteAddr().String(), "err", err)
+		}
+		close(l.done)
+		l.conn.Close()
+	})
+}
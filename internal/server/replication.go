@@ -0,0 +1,173 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/command"
+)
+
+const (
+	// replTimeout is how long a replication link may stay silent or blocked before it is dropped
+	replTimeout = 30 * time.Second
+
+	// replPingInterval is how often the leader reports its versions to replicas
+	replPingInterval = time.Second
+
+	// replRetry is how long a replica waits before reconnecting to its leader
+	replRetry = time.Second
+
+	// replIdle bounds how long a stream waits for a change before rechecking
+	// its database, which SWAPDB can rebind without logging a change
+	replIdle = 100 * time.Millisecond
+
+	// replSocketBuffer is the kernel buffer of a replica link, which streams far
+	// more than the small per-client buffers are sized for
+	replSocketBuffer = 1 << 20
+)
+
+// replication tracks the server's role: the replicas it streams to and, while
+// it follows a leader, the link to that leader
+type replication struct {
+	server *Server
+
+	mu sync.Mutex
+
+	// replid names this server's copy of the data, replicas resume only when it matches
+	replid string
+
+	// replicas are the connected replicas
+	replicas map[*replicaLink]struct{}
+
+	// follower is the link to the leader, nil while this server is a leader
+	follower *follower
+}
+
+var _ command.Replication = (*replication)(nil)
+
+func newReplication(s *Server) *replication {
+	return &replication{
+		server:   s,
+		replid:   newReplID(),
+		replicas: make(map[*replicaLink]struct{}),
+	}
+}
+
+// newReplID returns a random 40 character replication id
+func newReplID() string {
+	id := make([]byte, 20)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// ReplicaOf starts following the leader at addr, dropping the current leader
+// if there is one. An empty addr makes the server a leader again.
+func (r *replication) ReplicaOf(addr string) error {
+	if r.server.consensus != nil {
+		return ErrRaftWithReplicaOf
+	}
+	if addr != "" && !r.server.replicates {
+		return ErrReplicationUnsupported
+	}
+	if addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("%w %q: %w", ErrInvalidAddress, addr, err)
+		}
+	}
+
+	r.mu.Lock()
+	old := r.follower
+	if old != nil && old.addr == addr {
+		r.mu.Unlock()
+		return nil
+	}
+	r.follower = nil
+	r.mu.Unlock()
+
+	// the old link must be gone before a new one starts replacing databases
+	if old != nil {
+		old.close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if addr == "" {
+		if old != nil {
+			// the data may now diverge from the old leader's
+			r.replid = newReplID()
+		}
+		return nil
+	}
+	r.follower = newFollower(r.server, addr)
+	go r.follower.run()
+	return nil
+}
+
+// ReadOnly reports whether client writes are refused
+func (r *replication) ReadOnly() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.follower != nil
+}
+
+// Info returns the INFO replication fields. The field names and the "slave" role
+// are the ones Redis still reports, because client libraries, Sentinel and
+// monitoring tools parse role:slave, connected_slaves and slaveN to find
+// replicas; renaming them would break those readers.
+func (r *replication) Info() []string {
+	r.mu.Lock()
+	follower, replid := r.follower, r.replid
+	links := make([]*replicaLink, 0, len(r.replicas))
+	for link := range r.replicas {
+		links = append(links, link)
+	}
+	r.mu.Unlock()
+
+	var lines []string
+	if follower == nil {
+		lines = append(lines, "role:master")
+	} else {
+		lines = append(lines, "role:slave")
+		lines = append(lines, follower.info()...)
+	}
+	lines = append(lines, "master_replid:"+replid, "connected_slaves:"+strconv.Itoa(len(links)))
+	for i, link := range links {
+		lines = append(lines, fmt.Sprintf("slave%d:%s", i, link.info(r.server.databases)))
+	}
+	return lines
+}
+
+// currentReplID returns the replication id under the lock
+func (r *replication) currentReplID() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.replid
+}
+
+func (r *replication) addReplica(link *replicaLink) {
+	r.mu.Lock()
+	r.replicas[link] = struct{}{}
+	r.mu.Unlock()
+}
+
+func (r *replication) removeReplica(link *replicaLink) {
+	r.mu.Lock()
+	delete(r.replicas, link)
+	r.mu.Unlock()
+}
+
+// shutdown stops following the leader
+func (r *replication) shutdown() {
+	r.mu.Lock()
+	follower := r.follower
+	r.follower = nil
+	r.mu.Unlock()
+	if follower != nil {
+		follower.close()
+	}
+}
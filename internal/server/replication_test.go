@@ -0,0 +1,84 @@
+package server_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/server"
+)
+
+// TestReplicaOf_SyncsAndStreams attaches a replica to a leader holding data
+// and checks the snapshot, the writes streamed after it and that both servers
+// agree on versions
+func TestReplicaOf_SyncsAndStreams(t *testing.T) {
+	leaderAddr := startServer(t)
+	replicaAddr := startServer(t)
+	leader := dial(t, leaderAddr)
+	replica := dial(t, replicaAddr)
+
+	first := strings.TrimSpace(strings.TrimPrefix(leader.do("SET", "k", "one", "RETURNVERSION"), ":"))
+	leader.expect("+OK\r\n", "SET", "k", "two")
+	leader.expect("+OK\r\n", "SET", "gone", "x")
+	leader.expect(":1\r\n", "DEL", "gone")
+
+	host, port, _ := net.SplitHostPort(leaderAddr)
+	replica.expect("+OK\r\n", "REPLICAOF", host, port)
+	eventually(t, 5*time.Second, "the initial sync", func() bool {
+		info := replica.do("INFO", "replication")
+		return strings.Contains(info, "master_link_status:up") &&
+			strings.Contains(info, "master_sync_in_progress:0")
+	})
+
+	// the snapshot carries whole histories under the leader's versions
+	replica.expect(bulk("two"), "GET", "k")
+	replica.expect(bulk("one"), "GETV", "k", first)
+	replica.expect("$-1\r\n", "GET", "gone")
+	for _, key := range []string{"k", "gone"} {
+		if want, got := leader.do("HISTORY", key), replica.do("HISTORY", key); got != want {
+			t.Errorf("HISTORY %s on the replica = %q, want %q", key, got, want)
+		}
+	}
+
+	// writes after the sync are streamed with their versions
+	streamed := strings.TrimSpace(strings.TrimPrefix(leader.do("SET", "new", "three", "RETURNVERSION"), ":"))
+	eventually(t, 5*time.Second, "the streamed write", func() bool {
+		return replica.do("GET", "new") == bulk("three")
+	})
+	replica.expect(bulk("three"), "GETV", "new", streamed)
+	if want, got := leader.do("HISTORY", "new"), replica.do("HISTORY", "new"); got != want {
+		t.Errorf("HISTORY new on the replica = %q, want %q", got, want)
+	}
+
+	replica.expect("-READONLY You can't write against a read only replica.\r\n", "SET", "k", "local")
+	if info := replica.do("INFO", "replication"); !strings.Contains(info, "role:slave") {
+		t.Errorf("replica INFO = %q, want role:slave", info)
+	}
+	eventually(t, 5*time.Second, "the leader to list the replica", func() bool {
+		return strings.Contains(leader.do("INFO", "replication"), "connected_slaves:1")
+	})
+
+	// NO ONE detaches the replica and makes it writable again
+	replica.expect("+OK\r\n", "REPLICAOF", "NO", "ONE")
+	replica.expect("+OK\r\n", "SET", "k", "local")
+	leader.expect(bulk("two"), "GET", "k")
+}
+
+// TestReplicaOf_Authenticates verifies a leader with users refuses PSYNC from
+// a connection that did not authenticate, and that a replica given the
+// credentials syncs
+func TestReplicaOf_Authenticates(t *testing.T) {
+	leaderAddr := startServer(t, server.WithUser("repl", "secret"))
+	leader := dial(t, leaderAddr)
+	leader.expect("-NOAUTH Authentication required.\r\n", "PSYNC", "?")
+	leader.expect("+OK\r\n", "AUTH", "repl", "secret")
+	leader.expect("+OK\r\n", "SET", "k", "v")
+
+	replica := dial(t, startServer(t, server.WithLeaderAuth("repl", "secret")))
+	host, port, _ := net.SplitHostPort(leaderAddr)
+	replica.expect("+OK\r\n", "REPLICAOF", host, port)
+	eventually(t, 5*time.Second, "the initial sync", func() bool {
+		return replica.do("GET", "k") == bulk("v")
+	})
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ElshadHu/verdis/internal/protocol"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+// The replication stream is a sequence of RESP arrays sent by the leader after
+// PSYNC, each naming its kind first:
+//
+//	SYNC replid databases
+//	SNAPSHOT db generation version   a full copy of db follows
+//	CHAIN db key more [record...]    history of one key, more = 1 when continued
+//	LOADED db                        the snapshot of db is complete
+//	CONTINUE db generation version   db streams on from the replica's version
+//	APPLY db version timestamp more [mutation...]
+//	PING now [current cursor]...     per database, once a second
+//
+// A record is [version, timestamp, value, type, expires_at, author, comment,
+// lineage, merged_at] with a null value for tombstones, a mutation is [key, op,
+// replace, from, [record...]]. Long chains and versions that touch many keys are
+// split over several messages of at most replChunk entries.
+const replChunk = 1024
+
+var errReplProtocol = errors.New("replication protocol error")
+
+func replInt(n int64) protocol.RESPValue {
+	return protocol.NewInteger(n)
+}
+
+func replBulk(s string) protocol.RESPValue {
+	return protocol.NewBulkString([]byte(s))
+}
+
+func replMessage(kind string, fields ...protocol.RESPValue) *protocol.Array {
+	return protocol.NewArray(append([]protocol.RESPValue{replBulk(kind)}, fields...))
+}
+
+func encodeRecord(r storage.Record) protocol.RESPValue {
+	value := protocol.NewNullBulkString()
+	if !r.Deleted {
+		value = protocol.NewBulkString(r.Value)
+	}
+	author := protocol.NewNullArray()
+	if r.Author != nil {
+		author = protocol.NewArray([]protocol.RESPValue{replBulk(r.Author.Identity), replBulk(r.Author.Name), replBulk(r.Author.Addr)})
+	}
+	lineage := protocol.NewNullArray()
+	if r.Lineage != nil {
+		lineage = protocol.NewArray([]protocol.RESPValue{replInt(int64(r.Lineage.Op)), replBulk(r.Lineage.Key)})
+	}
+	return protocol.NewArray([]protocol.RESPValue{
+		replInt(int64(r.Version)),
+		replInt(r.Timestamp),
+		value,
+		replInt(int64(r.Type)),
+		replInt(r.ExpiresAt),
+		author,
+		replBulk(r.Comment),
+		lineage,
+		replInt(int64(r.MergedAt)),
+	})
+}
+
+func encodeRecords(records []storage.Record) protocol.RESPValue {
+	values := make([]protocol.RESPValue, len(records))
+	for i, r := range records {
+		values[i] = encodeRecord(r)
+	}
+	return protocol.NewArray(values)
+}
+
+func encodeMutation(m storage.Mutation) protocol.RESPValue {
+	replace := int64(0)
+	if m.Replace {
+		replace = 1
+	}
+	return protocol.NewArray([]protocol.RESPValue{replBulk(m.Key), replBulk(m.Op), replInt(replace), replBulk(m.From), encodeRecords(m.Records)})
+}
+
+// replDecoder reads the fields of stream messages. Authors are interned, so
+// the versions one client wrote share an Author like they do on the leader.
+type replDecoder struct {
+	authors map[storage.Author]*storage.Author
+}
+
+func newReplDecoder() *replDecoder {
+	return &replDecoder{authors: make(map[storage.Author]*storage.Author)}
+}
+
+func (d *replDecoder) array(v protocol.RESPValue) ([]protocol.RESPValue, bool, error) {
+	arr, ok := v.(*protocol.Array)
+	if !ok {
+		return nil, false, fmt.Errorf("%w: expected array", errReplProtocol)
+	}
+	return arr.Elements(), arr.IsNull(), nil
+}
+
+func (d *replDecoder) bulk(v protocol.RESPValue) ([]byte, bool, error) {
+	b, ok := v.(*protocol.BulkString)
+	if !ok {
+		return nil, false, fmt.Errorf("%w: expected bulk string", errReplProtocol)
+	}
+	return b.Data(), b.IsNull(), nil
+}
+
+func (d *replDecoder) str(v protocol.RESPValue) (string, error) {
+	b, _, err := d.bulk(v)
+	return string(b), err
+}
+
+func (d *replDecoder) int(v protocol.RESPValue) (int64, error) {
+	switch n := v.(type) {
+	case *protocol.Integer:
+		return n.Value(), nil
+	case *protocol.BulkString:
+		return strconv.ParseInt(string(n.Data()), 10, 64)
+	default:
+		return 0, fmt.Errorf("%w: expected integer", errReplProtocol)
+	}
+}
+
+// ints decodes every field as an integer
+func (d *replDecoder) ints(fields []protocol.RESPValue) ([]int64, error) {
+	values := make([]int64, len(fields))
+	for i, field := range fields {
+		var err error
+		if values[i], err = d.int(field); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func (d *replDecoder) record(v protocol.RESPValue) (storage.Record, error) {
+	fields, _, err := d.array(v)
+	if err != nil {
+		return storage.Record{}, err
+	}
+	if len(fields) != 9 {
+		return storage.Record{}, fmt.Errorf("%w: record has %d fields", errReplProtocol, len(fields))
+	}
+	var r storage.Record
+	numbers, err := d.ints([]protocol.RESPValue{fields[0], fields[1], fields[3], fields[4], fields[8]})
+	if err != nil {
+		return r, err
+	}
+	r.Version, r.Timestamp, r.Type, r.ExpiresAt = uint64(numbers[0]), numbers[1], storage.ValueType(numbers[2]), numbers[3]
+	r.MergedAt = uint64(numbers[4])
+	if r.Value, r.Deleted, err = d.bulk(fields[2]); err != nil {
+		return r, err
+	}
+	if r.Comment, err = d.str(fields[6]); err != nil {
+		return r, err
+	}
+
+	author, null, err := d.array(fields[5])
+	if err != nil {
+		return r, err
+	}
+	if !null && len(author) == 3 {
+		var a storage.Author
+		if a.Identity, err = d.str(author[0]); err != nil {
+			return r, err
+		}
+		if a.Name, err = d.str(author[1]); err != nil {
+			return r, err
+		}
+		if a.Addr, err = d.str(author[2]); err != nil {
+			return r, err
+		}
+		if r.Author = d.authors[a]; r.Author == nil {
+			r.Author = &a
+			d.authors[a] = &a
+		}
+	}
+
+	lineage, null, err := d.array(fields[7])
+	if err != nil {
+		return r, err
+	}
+	if !null && len(lineage) == 2 {
+		op, err := d.int(lineage[0])
+		if err != nil {
+			return r, err
+		}
+		key, err := d.str(lineage[1])
+		if err != nil {
+			return r, err
+		}
+		r.Lineage = &storage.Lineage{Op: storage.LineageOp(op), Key: key}
+	}
+	return r, nil
+}
+
+func (d *replDecoder) records(v protocol.RESPValue) ([]storage.Record, error) {
+	fields, _, err := d.array(v)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]storage.Record, len(fields))
+	for i, field := range fields {
+		if records[i], err = d.record(field); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func (d *replDecoder) mutation(v protocol.RESPValue) (storage.Mutation, error) {
+	fields, _, err := d.array(v)
+	if err != nil {
+		return storage.Mutation{}, err
+	}
+	if len(fields) != 5 {
+		return storage.Mutation{}, fmt.Errorf("%w: mutation has %d fields", errReplProtocol, len(fields))
+	}
+	var m storage.Mutation
+	if m.Key, err = d.str(fields[0]); err != nil {
+		return m, err
+	}
+	if m.Op, err = d.str(fields[1]); err != nil {
+		return m, err
+	}
+	replace, err := d.int(fields[2])
+	if err != nil {
+		return m, err
+	}
+	m.Replace = replace == 1
+	if m.From, err = d.str(fields[3]); err != nil {
+		return m, err
+	}
+	m.Records, err = d.records(fields[4])
+	return m, err
+}
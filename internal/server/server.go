@@ -6,13 +6,24 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/ElshadHu/verdis/internal/command"
+	"github.com/ElshadHu/verdis/internal/command/admin"
+	"github.com/ElshadHu/verdis/internal/command/client"
+	"github.com/ElshadHu/verdis/internal/command/database"
+	"github.com/ElshadHu/verdis/internal/command/hash"
+	"github.com/ElshadHu/verdis/internal/command/hll"
+	"github.com/ElshadHu/verdis/internal/command/list"
+	"github.com/ElshadHu/verdis/internal/command/set"
 	"github.com/ElshadHu/verdis/internal/command/standard"
+	"github.com/ElshadHu/verdis/internal/command/stream"
+	"github.com/ElshadHu/verdis/internal/command/transaction"
 	"github.com/ElshadHu/verdis/internal/command/version"
-	"github.com/ElshadHu/verdis/internal/mvcc"
+	"github.com/ElshadHu/verdis/internal/command/zset"
+	"github.com/ElshadHu/verdis/internal/storage"
 )
 
 type ErrAddressInUse struct {
@@ -44,10 +55,10 @@ func extractPort(addr string) string {
 }
 
 type Server struct {
-	cfg      *Config
-	listener net.Listener
-	router   *command.Router
-	engine   *mvcc.Engine
+	cfg       *Config
+	listener  net.Listener
+	router    *command.Router
+	databases *storage.Databases
 
 	// conns is a hashset of connections protected by sync.Mutex
 	conns map[*Connection]struct{}
@@ -55,9 +66,29 @@ type Server struct {
 	// mu protects conns
 	mu sync.Mutex
 
-	done bool
+	// done is set by Shutdown and read by the accept loop without the lock
+	done atomic.Bool
 	wg   sync.WaitGroup
 
+	// nextClientID numbers connections for CLIENT ID
+	nextClientID atomic.Int64
+
+	// replicates is set when the backend can ship and load history, which
+	// replicas, raft and slot migration all rely on
+	replicates bool
+
+	// replication streams to replicas and follows the leader while a replica
+	replication *replication
+
+	// consensus runs writes through the raft log, nil outside a cluster
+	consensus *consensus
+
+	// sharding serves the hash slots this node owns, nil outside a sharded cluster
+	sharding *sharding
+
+	// quit is closed on shutdown to release commands blocked on a key
+	quit chan struct{}
+
 	// connLimit is a semaphore for limiting the number of connections
 	connLimit chan struct{}
 }
@@ -67,21 +98,61 @@ func NewServer(cfg *Config) (*Server, error) {
 		return nil, err
 	}
 
-	engine := mvcc.NewEngine()
+	databases, err := storage.OpenDatabases(cfg.Backend, cfg.Databases, cfg.EngineConfigFor)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s storage: %w", cfg.Backend, err)
+	}
+	engine, _ := databases.Get(0)
+	_, replicates := engine.(storage.Replicator)
+	if !replicates && (cfg.ReplicaOf != "" || cfg.RaftID != "") {
+		return nil, fmt.Errorf("%w: %s", ErrReplicationUnsupported, cfg.Backend)
+	}
+	if _, moves := engine.(storage.Mover); cfg.ClusterEnabled && !(replicates && moves) {
+		return nil, fmt.Errorf("%w: %s", ErrReplicationUnsupported, cfg.Backend)
+	}
 
+	quit := make(chan struct{})
 	router := command.NewRouter()
-	ctx := &command.Context{Engine: engine}
+	s := &Server{
+		cfg:        cfg,
+		replicates: replicates,
+		router:     router,
+		databases:  databases,
+		quit:       quit,
+		conns:      make(map[*Connection]struct{}),
+		connLimit:  make(chan struct{}, cfg.MaxConnections),
+	}
+	s.replication = newReplication(s)
+
+	ctx := &command.Context{Engine: engine, Databases: databases, Done: quit, Replication: s.replication}
+	if len(cfg.Users) > 0 {
+		ctx.Authenticate = cfg.authenticate
+	}
+	if cfg.RaftID != "" {
+		s.consensus = newConsensus(s)
+		ctx.Consensus = s.consensus
+	}
+	if cfg.ClusterEnabled {
+		if s.sharding, err = newSharding(s, cfg.ClusterConfigFile); err != nil {
+			return nil, err
+		}
+		ctx.Cluster = s.sharding
+	}
 	router.SetContext(ctx)
 	standard.RegisterAll(router)
 	version.RegisterAll(router)
-
-	return &Server{
-		cfg:       cfg,
-		router:    router,
-		engine:    engine,
-		conns:     make(map[*Connection]struct{}),
-		connLimit: make(chan struct{}, cfg.MaxConnections),
-	}, nil
+	hash.RegisterAll(router)
+	list.RegisterAll(router)
+	zset.RegisterAll(router)
+	set.RegisterAll(router)
+	stream.RegisterAll(router)
+	hll.RegisterAll(router)
+	database.RegisterAll(router)
+	client.RegisterAll(router)
+	admin.RegisterAll(router)
+	transaction.RegisterAll(router)
+
+	return s, nil
 }
 
 func (s *Server) Start(ctx context.Context) error {
@@ -97,14 +168,32 @@ func (s *Server) Start(ctx context.Context) error {
 		s.Shutdown()
 	}()
 
+	if s.cfg.ReplicaOf != "" {
+		if err := s.replication.ReplicaOf(s.cfg.ReplicaOf); err != nil {
+			return err
+		}
+	}
+	if s.consensus != nil {
+		if err := s.consensus.start(); err != nil {
+			s.Shutdown()
+			return err
+		}
+	}
+	if s.sharding != nil {
+		if err := s.sharding.start(s.listener.Addr()); err != nil {
+			s.Shutdown()
+			return err
+		}
+	}
+
 	for {
-		if s.done {
+		if s.done.Load() {
 			break
 		}
 
 		conn, err := s.listener.Accept()
 		if err != nil {
-			if s.done || errors.Is(err, net.ErrClosed) {
+			if s.done.Load() || errors.Is(err, net.ErrClosed) {
 				break
 			}
 
@@ -202,11 +291,19 @@ func isAddressInUseError(err error) bool {
 // Shutdown gracefully stops the server
 func (s *Server) Shutdown() {
 	s.mu.Lock()
-	if s.done {
+	if s.done.Load() {
 		s.mu.Unlock()
 		return
 	}
-	s.done = true
+	s.done.Store(true)
+	close(s.quit)
+	s.replication.shutdown()
+	if s.consensus != nil {
+		s.consensus.stop()
+	}
+	if s.sharding != nil {
+		s.sharding.shutdown()
+	}
 
 	if s.listener != nil {
 		s.listener.Close()
@@ -0,0 +1,50 @@
+package storage
+
+// BatchOp is a single write queued in a Batch
+type BatchOp struct {
+	Key     string
+	Value   []byte
+	Deleted bool
+}
+
+// Batch groups writes that Engine.Write commits atomically under a single version
+type Batch struct {
+	ops      []BatchOp
+	ifAbsent bool
+}
+
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Set queues a write of value to key
+func (b *Batch) Set(key string, value []byte) {
+	b.ops = append(b.ops, BatchOp{Key: key, Value: value})
+}
+
+// Del queues a tombstone for key
+func (b *Batch) Del(key string) {
+	b.ops = append(b.ops, BatchOp{Key: key, Deleted: true})
+}
+
+// IfAbsent makes the batch fail with ErrBatchConflict if any of its keys exists
+func (b *Batch) IfAbsent() *Batch {
+	b.ifAbsent = true
+	return b
+}
+
+// Len returns the number of queued writes
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Ops returns the queued writes in the order they were made, for a backend
+// to commit
+func (b *Batch) Ops() []BatchOp {
+	return b.ops
+}
+
+// Conditional reports whether IfAbsent was set
+func (b *Batch) Conditional() bool {
+	return b.ifAbsent
+}
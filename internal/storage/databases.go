@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrDatabaseRange = errors.New("DB index is out of range")
+
+// Databases holds the numbered logical databases of a server. Each database is
+// its own engine, so version counters and retention are independent.
+type Databases struct {
+	mu      sync.RWMutex
+	engines []Engine
+
+	// generations count how often each index got a different engine, so a
+	// replica can tell whether the versions it holds still belong to it
+	generations []uint64
+}
+
+// OpenDatabases opens n databases on the named backend. configFor returns the
+// config of each database (nil = the backend's defaults).
+func OpenDatabases(backend string, n int, configFor func(db int) Config) (*Databases, error) {
+	engines := make([]Engine, n)
+	for db := range engines {
+		engine, err := Open(backend, configFor(db))
+		if err != nil {
+			return nil, fmt.Errorf("opening database %d: %w", db, err)
+		}
+		engines[db] = engine
+	}
+	return &Databases{engines: engines, generations: make([]uint64, n)}, nil
+}
+
+// Len returns the number of databases
+func (d *Databases) Len() int {
+	return len(d.engines)
+}
+
+// Get returns the engine currently bound to a database index
+func (d *Databases) Get(db int) (Engine, bool) {
+	if db < 0 || db >= len(d.engines) {
+		return nil, false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.engines[db], true
+}
+
+// Swap atomically exchanges the engines of two databases, so every connection
+// bound to one index sees the other's data from its next command on
+func (d *Databases) Swap(a, b int) error {
+	if a < 0 || a >= len(d.engines) || b < 0 || b >= len(d.engines) {
+		return ErrDatabaseRange
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.engines[a], d.engines[b] = d.engines[b], d.engines[a]
+	d.generations[a]++
+	d.generations[b]++
+	return nil
+}
+
+// Replace binds a new engine to a database index
+func (d *Databases) Replace(db int, engine Engine) error {
+	if db < 0 || db >= len(d.engines) {
+		return ErrDatabaseRange
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.engines[db] = engine
+	d.generations[db]++
+	return nil
+}
+
+// Generation returns the engine bound to a database index together with how
+// many times the index was rebound
+func (d *Databases) Generation(db int) (Engine, uint64, bool) {
+	if db < 0 || db >= len(d.engines) {
+		return nil, 0, false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.engines[db], d.generations[db], true
+}
@@ -0,0 +1,183 @@
+// Package storage defines the engine interface command handlers talk to and
+// a registry of named backends that implement it.
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// Engine is a versioned key value store. It holds the primitives every
+// backend provides; what only some backends can do is offered through the
+// optional interfaces below, which callers check for.
+type Engine interface {
+	// Get returns the latest value for a key
+	Get(key string) ([]byte, bool, error)
+	// Exists checks if a key exists and is not deleted
+	Exists(key string) bool
+	// GetAtVersion returns the value at a specific version or earlier
+	GetAtVersion(key string, version uint64) ([]byte, error)
+	// Lookup returns the latest live entry of a key with its type
+	Lookup(key string) (Entry, error)
+	// LookupAt returns the entry of a key as of a specific version
+	LookupAt(key string, version uint64) (Entry, error)
+	// GetMany reads several keys at one consistent snapshot version
+	GetMany(keys []string) ([]Entry, uint64)
+	// History returns version metadata of a key, newest first
+	History(key string, maxVersions int) ([]VersionInfo, error)
+	// KeyInfo reports metadata of a key's version chain without counting as an access
+	KeyInfo(key string) (KeyInfo, error)
+	// WalkHistory calls fn with every version of a key and its value, newest first
+	WalkHistory(key string, fn func(info VersionInfo, entry Entry) bool) error
+
+	// Set stores a value for a key and returns the version it was assigned
+	Set(key string, value []byte) uint64
+	// Del tombstones a key, false if the key never existed
+	Del(key string) bool
+	// Update atomically replaces a key's value with the result of fn
+	Update(key string, fn UpdateFunc) (uint64, error)
+	// UpdateVersioned is Update for values that embed the version they are written at
+	UpdateVersioned(key string, fn VersionedUpdateFunc) (uint64, error)
+	// UpdateMany atomically replaces several keys with the result of fn under one version
+	UpdateMany(keys []string, fn UpdateManyFunc) (uint64, error)
+	// Write atomically commits a batch under a single version
+	Write(b *Batch) (uint64, error)
+	// Drop discards every key together with its history
+	Drop()
+
+	// Keys returns every key with a version chain, including deleted ones
+	Keys() []string
+	// Range calls fn with the latest value of every live key until fn returns false
+	Range(fn func(key string, value []byte) bool)
+	// Watch returns a channel closed on the next write to any of the keys
+	Watch(keys ...string) (<-chan struct{}, func())
+	// CurrentVersion returns the global version counter
+	CurrentVersion() uint64
+}
+
+// ErrUnsupported is returned for an operation the engine's backend does not offer
+var ErrUnsupported = errors.New("not supported by the storage backend")
+
+// Renamer is implemented by engines that can give a key's value, and its
+// history, to another key
+type Renamer interface {
+	// Rename moves a key with its history to a new name, leaving a tombstone that records it
+	Rename(src, dst string, nx bool) (uint64, error)
+	// Copy writes a key's value, and optionally its history, to another key
+	Copy(src, dst string, replace, withHistory bool) (uint64, error)
+}
+
+// Mover is implemented by engines that can move a key with its history to
+// another engine
+type Mover interface {
+	// Detach atomically removes a live key together with its whole history
+	Detach(key string) (Chain, bool)
+	// Attach installs a detached chain under a key that has no live value
+	Attach(key string, chain Chain) (uint64, error)
+	// Move transfers a live key with its history to another engine of the same
+	// backend in one step, failing when the key is live there
+	Move(key string, target Engine) (uint64, error)
+	// Import installs the history of a key migrated from another engine under a key with no live value
+	Import(key string, records []Record) (uint64, error)
+}
+
+// Flusher is implemented by engines that can empty the keyspace and bring it back
+type Flusher interface {
+	// Flush tombstones every live key under a single version
+	Flush() (uint64, int, error)
+	// LastFlush returns the version of the most recent Flush (0 if none)
+	LastFlush() uint64
+	// Restore brings every key back to its state as of a version (0 = before the last Flush)
+	Restore(version uint64) (uint64, int, error)
+}
+
+// ChangeLogger is implemented by engines that keep a log of the keys each
+// version changed
+type ChangeLogger interface {
+	// Changes walks the global change log in version order from after since up to until
+	Changes(since, until uint64, limit int, fn func(version uint64, changes []Change) bool) (uint64, error)
+	// OldestChange returns the lowest version the change log can still report
+	OldestChange() uint64
+	// NextChange returns a channel closed once the next change is recorded
+	NextChange() <-chan struct{}
+	// DiffKey compares the visible state of a key at two versions
+	DiffKey(key string, from, to uint64) (KeyDiff, bool)
+	// MayExpireAfter reports whether a key may have expired after version
+	// without a change being recorded
+	MayExpireAfter(version uint64) bool
+}
+
+// Replicator is implemented by engines that can ship their versions to
+// another engine and replay the versions shipped to them
+type Replicator interface {
+	Engine
+	ChangeLogger
+
+	// SyncPoint waits until every version taken so far is logged and returns the newest
+	SyncPoint() (uint64, error)
+	// Records returns the committed versions of a key up to version, newest first
+	Records(key string, version uint64) ([]Record, error)
+	// Export returns the mutation a logged change made, for a replica to apply
+	Export(change Change) (Mutation, bool, error)
+	// Load installs a key's history taken from another engine's snapshot
+	Load(key string, records []Record)
+	// SetBase marks a loaded engine as holding everything up to version
+	SetBase(version uint64)
+	// Apply replays the mutations another engine committed at version
+	Apply(version uint64, timestamp int64, mutations []Mutation)
+	// Reserve makes the next write take version and timestamp
+	Reserve(version uint64, timestamp int64)
+}
+
+// StatsReporter is implemented by engines that report what they hold
+type StatsReporter interface {
+	// Stats returns engine statistics
+	Stats() EngineStats
+	// CompressionStats reports the space saved per compression policy
+	CompressionStats() []CompressionStats
+	// KeyCounts returns how many keys hold a value and how many of those have
+	// an expiry, kept as keys are written rather than counted on each call
+	KeyCounts() (keys, expires int64)
+}
+
+// Attributer is implemented by engines that can record who wrote each version
+type Attributer interface {
+	Attributed(author *Author) Engine
+}
+
+// Attribute returns a view of engine that records author on every version it
+// writes. Engines that cannot record authors are returned unchanged.
+func Attribute(engine Engine, author *Author) Engine {
+	if a, ok := engine.(Attributer); ok {
+		return a.Attributed(author)
+	}
+	return engine
+}
+
+// Annotator is implemented by engines that can record a comment on each version
+type Annotator interface {
+	Annotated(comment string) Engine
+}
+
+// Annotate returns a view of engine that records comment on every version it
+// writes. Engines that cannot record comments are returned unchanged.
+func Annotate(engine Engine, comment string) Engine {
+	if a, ok := engine.(Annotator); ok {
+		return a.Annotated(comment)
+	}
+	return engine
+}
+
+// Clocker is implemented by engines that can judge expiry by another clock
+type Clocker interface {
+	Clocked(clock func() time.Time) Engine
+}
+
+// Clock returns a view of engine that decides whether values have expired by
+// clock. Engines that only know the wall clock are returned unchanged.
+func Clock(engine Engine, clock func() time.Time) Engine {
+	if c, ok := engine.(Clocker); ok {
+		return c.Clocked(clock)
+	}
+	return engine
+}
@@ -0,0 +1,425 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+)
+
+// BackendMemory is the lock-free in-memory MVCC engine, configured with a
+// *mvcc.Config
+const BackendMemory = "memory"
+
+func init() {
+	Register(BackendMemory, func(cfg Config) (Engine, error) {
+		switch cfg := cfg.(type) {
+		case nil:
+			return NewMemoryEngine(nil), nil
+		case *mvcc.Config:
+			return NewMemoryEngine(cfg), nil
+		default:
+			return nil, fmt.Errorf("%w: the %s backend takes a *mvcc.Config, not %T", ErrInvalidConfig, BackendMemory, cfg)
+		}
+	})
+}
+
+// memoryEngine adapts the MVCC engine to the storage types
+type memoryEngine struct {
+	*mvcc.Engine
+}
+
+var (
+	_ Engine        = memoryEngine{}
+	_ Renamer       = memoryEngine{}
+	_ Mover         = memoryEngine{}
+	_ Flusher       = memoryEngine{}
+	_ Replicator    = memoryEngine{}
+	_ StatsReporter = memoryEngine{}
+)
+
+// NewMemoryEngine returns an in-memory MVCC engine (nil cfg = mvcc.DefaultConfig)
+func NewMemoryEngine(cfg *mvcc.Config) Engine {
+	if cfg == nil {
+		cfg = mvcc.DefaultConfig()
+	}
+	return memoryEngine{mvcc.NewEngineWithConfig(cfg)}
+}
+
+// memoryErrors pairs each engine error with the storage error reported for it
+var memoryErrors = [][2]error{
+	{mvcc.ErrSkipWrite, ErrSkipWrite},
+	{mvcc.ErrKeyNotFound, ErrKeyNotFound},
+	{mvcc.ErrVersionNotFound, ErrVersionNotFound},
+	{mvcc.ErrKeyDeleted, ErrKeyDeleted},
+	{mvcc.ErrKeyExists, ErrKeyExists},
+	{mvcc.ErrWrongType, ErrWrongType},
+	{mvcc.ErrBatchConflict, ErrBatchConflict},
+	{mvcc.ErrChangesPruned, ErrChangesPruned},
+	{mvcc.ErrNoFlush, ErrNoFlush},
+}
+
+// fromMemory returns the storage error for an engine error. Errors the engine
+// did not define, such as those returned by update functions, pass through.
+func fromMemory(err error) error {
+	for _, pair := range memoryErrors {
+		if err == pair[0] {
+			return pair[1]
+		}
+	}
+	return err
+}
+
+// toMemory hands ErrSkipWrite returned by an update function to the engine as
+// the engine's own, other errors abort the update and come back unchanged
+func toMemory(err error) error {
+	if errors.Is(err, ErrSkipWrite) {
+		return mvcc.ErrSkipWrite
+	}
+	return err
+}
+
+func (m memoryEngine) Attributed(author *Author) Engine {
+	return memoryEngine{m.Engine.Attributed((*mvcc.Author)(author))}
+}
+
+func (m memoryEngine) Annotated(comment string) Engine {
+	return memoryEngine{m.Engine.Annotated(comment)}
+}
+
+func (m memoryEngine) Clocked(clock func() time.Time) Engine {
+	return memoryEngine{m.Engine.Clocked(clock)}
+}
+
+func (m memoryEngine) Get(key string) ([]byte, bool, error) {
+	value, ok, err := m.Engine.Get(key)
+	return value, ok, fromMemory(err)
+}
+
+func (m memoryEngine) GetAtVersion(key string, version uint64) ([]byte, error) {
+	value, err := m.Engine.GetAtVersion(key, version)
+	return value, fromMemory(err)
+}
+
+func (m memoryEngine) Lookup(key string) (Entry, error) {
+	entry, err := m.Engine.Lookup(key)
+	return fromMemoryEntry(entry), fromMemory(err)
+}
+
+func (m memoryEngine) LookupAt(key string, version uint64) (Entry, error) {
+	entry, err := m.Engine.LookupAt(key, version)
+	return fromMemoryEntry(entry), fromMemory(err)
+}
+
+func (m memoryEngine) GetMany(keys []string) ([]Entry, uint64) {
+	entries, snapshot := m.Engine.GetMany(keys)
+	return fromMemoryEntries(entries), snapshot
+}
+
+func (m memoryEngine) History(key string, maxVersions int) ([]VersionInfo, error) {
+	history, err := m.Engine.History(key, maxVersions)
+	infos := make([]VersionInfo, len(history))
+	for i, info := range history {
+		infos[i] = fromMemoryInfo(info)
+	}
+	return infos, fromMemory(err)
+}
+
+func (m memoryEngine) KeyInfo(key string) (KeyInfo, error) {
+	info, err := m.Engine.KeyInfo(key)
+	return KeyInfo{
+		Exists:         info.Exists,
+		Type:           ValueType(info.Type),
+		Codec:          Codec(info.Codec),
+		Versions:       info.Versions,
+		Tombstones:     info.Tombstones,
+		FirstVersion:   info.FirstVersion,
+		FirstTimestamp: info.FirstTimestamp,
+		LastVersion:    info.LastVersion,
+		LastTimestamp:  info.LastTimestamp,
+		HeadBytes:      info.HeadBytes,
+		HistoryBytes:   info.HistoryBytes,
+		LastAccess:     info.LastAccess,
+		Frequency:      info.Frequency,
+	}, fromMemory(err)
+}
+
+func (m memoryEngine) WalkHistory(key string, fn func(info VersionInfo, entry Entry) bool) error {
+	return fromMemory(m.Engine.WalkHistory(key, func(info mvcc.VersionInfo, entry mvcc.Entry) bool {
+		return fn(fromMemoryInfo(info), fromMemoryEntry(entry))
+	}))
+}
+
+func (m memoryEngine) Update(key string, fn UpdateFunc) (uint64, error) {
+	version, err := m.Engine.Update(key, func(current mvcc.Entry) (mvcc.Entry, error) {
+		next, err := fn(fromMemoryEntry(current))
+		return toMemoryEntry(next), toMemory(err)
+	})
+	return version, fromMemory(err)
+}
+
+func (m memoryEngine) UpdateVersioned(key string, fn VersionedUpdateFunc) (uint64, error) {
+	version, err := m.Engine.UpdateVersioned(key, func(current mvcc.Entry, version uint64) (mvcc.Entry, error) {
+		next, err := fn(fromMemoryEntry(current), version)
+		return toMemoryEntry(next), toMemory(err)
+	})
+	return version, fromMemory(err)
+}
+
+func (m memoryEngine) UpdateMany(keys []string, fn UpdateManyFunc) (uint64, error) {
+	version, err := m.Engine.UpdateMany(keys, func(current []mvcc.Entry) ([]mvcc.Entry, error) {
+		next, err := fn(fromMemoryEntries(current))
+		entries := make([]mvcc.Entry, len(next))
+		for i, entry := range next {
+			entries[i] = toMemoryEntry(entry)
+		}
+		return entries, toMemory(err)
+	})
+	return version, fromMemory(err)
+}
+
+func (m memoryEngine) Write(b *Batch) (uint64, error) {
+	batch := mvcc.NewBatch()
+	for _, op := range b.Ops() {
+		if op.Deleted {
+			batch.Del(op.Key)
+		} else {
+			batch.Set(op.Key, op.Value)
+		}
+	}
+	if b.Conditional() {
+		batch.IfAbsent()
+	}
+	version, err := m.Engine.Write(batch)
+	return version, fromMemory(err)
+}
+
+func (m memoryEngine) Rename(src, dst string, nx bool) (uint64, error) {
+	version, err := m.Engine.Rename(src, dst, nx)
+	return version, fromMemory(err)
+}
+
+func (m memoryEngine) Copy(src, dst string, replace, withHistory bool) (uint64, error) {
+	version, err := m.Engine.Copy(src, dst, replace, withHistory)
+	return version, fromMemory(err)
+}
+
+func (m memoryEngine) Detach(key string) (Chain, bool) {
+	chain, ok := m.Engine.Detach(key)
+	if !ok {
+		return nil, false
+	}
+	return chain, true
+}
+
+func (m memoryEngine) Attach(key string, chain Chain) (uint64, error) {
+	c, ok := chain.(*mvcc.Chain)
+	if !ok {
+		return 0, ErrForeignChain
+	}
+	version, err := m.Engine.Attach(key, c)
+	return version, fromMemory(err)
+}
+
+func (m memoryEngine) Move(key string, target Engine) (uint64, error) {
+	t, ok := target.(memoryEngine)
+	if !ok {
+		return 0, ErrForeignEngine
+	}
+	version, err := m.Engine.Move(key, t.Engine)
+	return version, fromMemory(err)
+}
+
+func (m memoryEngine) Import(key string, records []Record) (uint64, error) {
+	version, err := m.Engine.Import(key, toMemoryRecords(records))
+	return version, fromMemory(err)
+}
+
+func (m memoryEngine) Flush() (uint64, int, error) {
+	version, n, err := m.Engine.Flush()
+	return version, n, fromMemory(err)
+}
+
+func (m memoryEngine) Restore(version uint64) (uint64, int, error) {
+	restored, n, err := m.Engine.Restore(version)
+	return restored, n, fromMemory(err)
+}
+
+func (m memoryEngine) Changes(since, until uint64, limit int, fn func(version uint64, changes []Change) bool) (uint64, error) {
+	last, err := m.Engine.Changes(since, until, limit, func(version uint64, changes []mvcc.Change) bool {
+		converted := make([]Change, len(changes))
+		for i, change := range changes {
+			converted[i] = Change(change)
+		}
+		return fn(version, converted)
+	})
+	return last, fromMemory(err)
+}
+
+func (m memoryEngine) Records(key string, version uint64) ([]Record, error) {
+	records, err := m.Engine.Records(key, version)
+	return fromMemoryRecords(records), fromMemory(err)
+}
+
+func (m memoryEngine) Export(change Change) (Mutation, bool, error) {
+	mutation, ok, err := m.Engine.Export(mvcc.Change(change))
+	return Mutation{
+		Key:     mutation.Key,
+		Op:      mutation.Op,
+		Replace: mutation.Replace,
+		Records: fromMemoryRecords(mutation.Records),
+		From:    mutation.From,
+	}, ok, fromMemory(err)
+}
+
+func (m memoryEngine) Load(key string, records []Record) {
+	m.Engine.Load(key, toMemoryRecords(records))
+}
+
+func (m memoryEngine) Apply(version uint64, timestamp int64, mutations []Mutation) {
+	converted := make([]mvcc.Mutation, len(mutations))
+	for i, mutation := range mutations {
+		converted[i] = mvcc.Mutation{
+			Key:     mutation.Key,
+			Op:      mutation.Op,
+			Replace: mutation.Replace,
+			Records: toMemoryRecords(mutation.Records),
+			From:    mutation.From,
+		}
+	}
+	m.Engine.Apply(version, timestamp, converted)
+}
+
+func (m memoryEngine) DiffKey(key string, from, to uint64) (KeyDiff, bool) {
+	diff, ok := m.Engine.DiffKey(key, from, to)
+	return KeyDiff{
+		Key:       diff.Key,
+		Status:    DiffStatus(diff.Status),
+		OldSize:   diff.OldSize,
+		NewSize:   diff.NewSize,
+		ToVersion: diff.ToVersion,
+	}, ok
+}
+
+func (m memoryEngine) Stats() EngineStats {
+	return EngineStats(m.Engine.Stats())
+}
+
+func (m memoryEngine) CompressionStats() []CompressionStats {
+	stats := m.Engine.CompressionStats()
+	converted := make([]CompressionStats, len(stats))
+	for i, s := range stats {
+		converted[i] = CompressionStats{
+			Pattern:      s.Pattern,
+			Codec:        Codec(s.Codec),
+			Versions:     s.Versions,
+			Compressed:   s.Compressed,
+			LogicalBytes: s.LogicalBytes,
+			StoredBytes:  s.StoredBytes,
+		}
+	}
+	return converted
+}
+
+func fromMemoryEntry(entry mvcc.Entry) Entry {
+	return Entry{
+		Value:     entry.Value,
+		Type:      ValueType(entry.Type),
+		Exists:    entry.Exists,
+		ExpiresAt: entry.ExpiresAt,
+		Version:   entry.Version,
+	}
+}
+
+func fromMemoryEntries(entries []mvcc.Entry) []Entry {
+	converted := make([]Entry, len(entries))
+	for i, entry := range entries {
+		converted[i] = fromMemoryEntry(entry)
+	}
+	return converted
+}
+
+func toMemoryEntry(entry Entry) mvcc.Entry {
+	return mvcc.Entry{
+		Value:     entry.Value,
+		Type:      mvcc.ValueType(entry.Type),
+		Exists:    entry.Exists,
+		ExpiresAt: entry.ExpiresAt,
+		Version:   entry.Version,
+	}
+}
+
+func fromMemoryInfo(info mvcc.VersionInfo) VersionInfo {
+	return VersionInfo{
+		Version:    info.Version,
+		Timestamp:  info.Timestamp,
+		Deleted:    info.Deleted,
+		Size:       info.Size,
+		StoredSize: info.StoredSize,
+		Codec:      Codec(info.Codec),
+		ExpiresAt:  info.ExpiresAt,
+		Type:       ValueType(info.Type),
+		Lineage:    fromMemoryLineage(info.Lineage),
+		Author:     (*Author)(info.Author),
+		Comment:    info.Comment,
+	}
+}
+
+func fromMemoryLineage(l *mvcc.Lineage) *Lineage {
+	if l == nil {
+		return nil
+	}
+	return &Lineage{Op: LineageOp(l.Op), Key: l.Key}
+}
+
+func toMemoryLineage(l *Lineage) *mvcc.Lineage {
+	if l == nil {
+		return nil
+	}
+	return &mvcc.Lineage{Op: mvcc.LineageOp(l.Op), Key: l.Key}
+}
+
+func fromMemoryRecords(records []mvcc.Record) []Record {
+	if records == nil {
+		return nil
+	}
+	converted := make([]Record, len(records))
+	for i, r := range records {
+		converted[i] = Record{
+			Version:   r.Version,
+			Timestamp: r.Timestamp,
+			Value:     r.Value,
+			Type:      ValueType(r.Type),
+			Deleted:   r.Deleted,
+			ExpiresAt: r.ExpiresAt,
+			Author:    (*Author)(r.Author),
+			Comment:   r.Comment,
+			Lineage:   fromMemoryLineage(r.Lineage),
+			MergedAt:  r.MergedAt,
+		}
+	}
+	return converted
+}
+
+func toMemoryRecords(records []Record) []mvcc.Record {
+	if records == nil {
+		return nil
+	}
+	converted := make([]mvcc.Record, len(records))
+	for i, r := range records {
+		converted[i] = mvcc.Record{
+			Version:   r.Version,
+			Timestamp: r.Timestamp,
+			Value:     r.Value,
+			Type:      mvcc.ValueType(r.Type),
+			Deleted:   r.Deleted,
+			ExpiresAt: r.ExpiresAt,
+			Author:    (*mvcc.Author)(r.Author),
+			Comment:   r.Comment,
+			Lineage:   toMemoryLineage(r.Lineage),
+			MergedAt:  r.MergedAt,
+		}
+	}
+	return converted
+}
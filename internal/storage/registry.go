@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+var (
+	ErrUnknownBackend = errors.New("unknown storage backend")
+	ErrInvalidConfig  = errors.New("invalid storage config")
+)
+
+// Config is what a backend is opened with. Each backend defines its own kind
+// and rejects others with ErrInvalidConfig, nil opens it with its defaults.
+type Config any
+
+// Factory creates an engine from the backend's configuration
+type Factory func(cfg Config) (Engine, error)
+
+var (
+	mu       sync.RWMutex
+	backends = make(map[string]Factory)
+)
+
+// Register makes a backend available by name (panics on duplicates)
+func Register(name string, factory Factory) {
+	if name == "" || factory == nil {
+		panic("storage: invalid backend")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := backends[name]; exists {
+		panic(fmt.Sprintf("storage: duplicate backend registered - %s", name))
+	}
+	backends[name] = factory
+}
+
+// Open creates an engine using the named backend
+func Open(name string, cfg Config) (Engine, error) {
+	mu.RLock()
+	factory, ok := backends[name]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownBackend, name)
+	}
+	return factory(cfg)
+}
+
+// IsRegistered reports whether a backend with the name exists
+func IsRegistered(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := backends[name]
+	return ok
+}
+
+// Backends returns the sorted names of all registered backends
+func Backends() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,131 @@
+package storage_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ElshadHu/verdis/internal/mvcc"
+	"github.com/ElshadHu/verdis/internal/storage"
+)
+
+func TestOpenMemoryBackend(t *testing.T) {
+	engine, err := storage.Open(storage.BackendMemory, nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	v := engine.Set("k", []byte("v"))
+	if got, err := engine.GetAtVersion("k", v); err != nil || string(got) != "v" {
+		t.Errorf("GetAtVersion = %q, %v", got, err)
+	}
+}
+
+func TestOpenMemoryBackend_RejectsForeignConfig(t *testing.T) {
+	if _, err := storage.Open(storage.BackendMemory, "dsn"); !errors.Is(err, storage.ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestOpenUnknownBackend(t *testing.T) {
+	_, err := storage.Open("does-not-exist", nil)
+	if !errors.Is(err, storage.ErrUnknownBackend) {
+		t.Errorf("expected ErrUnknownBackend, got %v", err)
+	}
+}
+
+func TestRegisterBackend(t *testing.T) {
+	name := fmt.Sprintf("test-backend-%d", time.Now().UnixNano())
+	opened := false
+	storage.Register(name, func(cfg storage.Config) (storage.Engine, error) {
+		opened = true
+		return storage.NewMemoryEngine(cfg.(*mvcc.Config)), nil
+	})
+
+	if !slices.Contains(storage.Backends(), name) {
+		t.Fatalf("backend not listed: %v", storage.Backends())
+	}
+	if _, err := storage.Open(name, mvcc.DefaultConfig()); err != nil || !opened {
+		t.Errorf("Open registered backend: opened=%v err=%v", opened, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate Register should panic")
+		}
+	}()
+	storage.Register(name, func(cfg storage.Config) (storage.Engine, error) { return nil, nil })
+}
+
+func TestBatchWrite(t *testing.T) {
+	engine, _ := storage.Open(storage.BackendMemory, nil)
+	engine.Set("gone", []byte("x"))
+
+	batch := storage.NewBatch()
+	batch.Set("a", []byte("1"))
+	batch.Set("b", []byte("2"))
+	batch.Del("gone")
+	if _, err := engine.Write(batch); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	live := make(map[string]string)
+	engine.Range(func(key string, value []byte) bool {
+		live[key] = string(value)
+		return true
+	})
+	if len(live) != 2 || live["a"] != "1" || live["b"] != "2" {
+		t.Errorf("live keys after batch = %v", live)
+	}
+}
+
+// fakeChain stands in for a chain detached by another backend
+type fakeChain struct{}
+
+func (fakeChain) Len() int { return 0 }
+
+func TestMemoryBackendErrors(t *testing.T) {
+	engine, _ := storage.Open(storage.BackendMemory, nil)
+	engine.Set("k", []byte("v"))
+
+	if _, err := engine.(storage.Renamer).Rename("missing", "other", false); !errors.Is(err, storage.ErrKeyNotFound) {
+		t.Errorf("Rename of a missing key: %v, want ErrKeyNotFound", err)
+	}
+	if _, err := engine.LookupAt("k", 0); !errors.Is(err, storage.ErrVersionNotFound) {
+		t.Errorf("LookupAt before the first version: %v, want ErrVersionNotFound", err)
+	}
+	if _, err := engine.Write(storage.NewBatch().IfAbsent()); err != nil {
+		t.Errorf("empty conditional batch: %v", err)
+	}
+	batch := storage.NewBatch()
+	batch.Set("k", []byte("x"))
+	if _, err := engine.Write(batch.IfAbsent()); !errors.Is(err, storage.ErrBatchConflict) {
+		t.Errorf("conditional batch on a live key: %v, want ErrBatchConflict", err)
+	}
+
+	version, err := engine.Update("k", func(storage.Entry) (storage.Entry, error) {
+		return storage.Entry{}, storage.ErrSkipWrite
+	})
+	if err != nil || version != 0 {
+		t.Errorf("skipped update = %d, %v", version, err)
+	}
+	if _, err := engine.Update("k", func(storage.Entry) (storage.Entry, error) {
+		return storage.Entry{}, storage.ErrWrongType
+	}); !errors.Is(err, storage.ErrWrongType) {
+		t.Errorf("update function error = %v, want ErrWrongType", err)
+	}
+
+	mover := engine.(storage.Mover)
+	if _, err := mover.Attach("k2", fakeChain{}); !errors.Is(err, storage.ErrForeignChain) {
+		t.Errorf("Attach of a foreign chain: %v, want ErrForeignChain", err)
+	}
+	chain, ok := mover.Detach("k")
+	if !ok || chain.Len() != 1 {
+		t.Fatalf("Detach = %v, %v", chain, ok)
+	}
+	if _, err := mover.Attach("k2", chain); err != nil {
+		t.Errorf("Attach: %v", err)
+	}
+}
@@ -0,0 +1,346 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors every backend reports with these values, so handlers can tell them apart
+// whichever engine they talk to
+var (
+	ErrSkipWrite       = errors.New("skip write")
+	ErrKeyNotFound     = errors.New("key not found")
+	ErrVersionNotFound = errors.New("version not found")
+	ErrKeyDeleted      = errors.New("key was deleted at this versi")
+	ErrKeyExists       = errors.New("key exists")
+	ErrWrongType       = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	ErrBatchConflict   = errors.New("batch precondition failed: key exists")
+	ErrChangesPruned   = errors.New("changes have been pruned")
+	ErrNoFlush         = errors.New("no flush to restore")
+	ErrForeignChain    = errors.New("chain was detached by another backend")
+	ErrForeignEngine   = errors.New("engine belongs to another backend")
+)
+
+// ValueType tags what kind of value a version holds
+type ValueType uint8
+
+const (
+	TypeString ValueType = iota
+	TypeHash
+	TypeList
+	TypeZSet
+	TypeSet
+	TypeStream
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case TypeString:
+		return "string"
+	case TypeHash:
+		return "hash"
+	case TypeList:
+		return "list"
+	case TypeZSet:
+		return "zset"
+	case TypeSet:
+		return "set"
+	case TypeStream:
+		return "stream"
+	default:
+		return fmt.Sprintf("type(%d)", uint8(t))
+	}
+}
+
+// Codec is the compression format a version is stored in
+type Codec uint8
+
+const (
+	CodecNone Codec = iota
+	CodecFlate
+	CodecZlib
+	CodecGzip
+)
+
+func (c Codec) String() string {
+	switch c {
+	case CodecNone:
+		return "none"
+	case CodecFlate:
+		return "flate"
+	case CodecZlib:
+		return "zlib"
+	case CodecGzip:
+		return "gzip"
+	default:
+		return fmt.Sprintf("codec(%d)", uint8(c))
+	}
+}
+
+// Entry is the state of a key at one version, as returned by lookups and seen by an UpdateFunc
+type Entry struct {
+	// Value is the logical value (nil when the key does not exist)
+	Value []byte
+	// Type is the kind of value (TypeString for missing keys)
+	Type ValueType
+	// Exists is false when the key is missing, deleted or expired
+	Exists bool
+	// ExpiresAt is the Unix nano expiry deadline (0 = never)
+	ExpiresAt int64
+	// Version is the version the entry was read from (0 for missing keys)
+	Version uint64
+}
+
+// WithValue returns a live entry holding value that keeps the current type and expiry
+func (en Entry) WithValue(value []byte) Entry {
+	return Entry{Value: value, Type: en.Type, Exists: true, ExpiresAt: en.ExpiresAt}
+}
+
+// Is reports whether the entry is missing or holds the given type
+func (en Entry) Is(typ ValueType) bool {
+	return !en.Exists || en.Type == typ
+}
+
+// UpdateFunc computes the next state of a key from its current one. Returning an
+// entry with Exists false writes a tombstone, returning ErrSkipWrite leaves the key
+// untouched. Current values are shared with readers and must not be modified in place.
+type UpdateFunc func(current Entry) (Entry, error)
+
+// VersionedUpdateFunc is an UpdateFunc that is also given the version the new
+// state is written at
+type VersionedUpdateFunc func(current Entry, version uint64) (Entry, error)
+
+// UpdateManyFunc computes the next state of several keys from their current ones.
+// Entries come and go in the order the keys were passed to UpdateMany.
+type UpdateManyFunc func(current []Entry) ([]Entry, error)
+
+// Author identifies the client that wrote a version. Authors are shared by
+// every version a connection writes and must not be modified once in use.
+type Author struct {
+	// Identity is the authenticated user or the identity the client declared
+	Identity string
+	// Name is the connection name set with CLIENT SETNAME
+	Name string
+	// Addr is the remote address of the connection
+	Addr string
+}
+
+// String renders the non-empty fields, e.g. "user=alice name=worker addr=10.0.0.1:5000"
+func (a *Author) String() string {
+	var b strings.Builder
+	for _, field := range [][2]string{{"user", a.Identity}, {"name", a.Name}, {"addr", a.Addr}} {
+		if field[1] == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(field[0] + "=" + field[1])
+	}
+	return b.String()
+}
+
+// LineageOp is how a version relates to the other key of its lineage
+type LineageOp uint8
+
+const (
+	// LineageRenamedTo marks the tombstone left on a key renamed away
+	LineageRenamedTo LineageOp = iota + 1
+	// LineageRenamedFrom marks the first version of a key under its new name
+	LineageRenamedFrom
+	// LineageCopiedFrom marks a version written by copying another key
+	LineageCopiedFrom
+)
+
+func (op LineageOp) String() string {
+	switch op {
+	case LineageRenamedTo:
+		return "renamed_to"
+	case LineageRenamedFrom:
+		return "renamed_from"
+	case LineageCopiedFrom:
+		return "copied_from"
+	default:
+		return "unknown"
+	}
+}
+
+// Lineage records the other key of a rename or copy
+type Lineage struct {
+	Op  LineageOp
+	Key string
+}
+
+func (l *Lineage) String() string {
+	return l.Op.String() + ":" + l.Key
+}
+
+// VersionInfo describes one version of a key without its value
+type VersionInfo struct {
+	Version    uint64
+	Timestamp  int64
+	Deleted    bool
+	Size       int // logical length of the value
+	StoredSize int // bytes held after compression
+	Codec      Codec
+	ExpiresAt  int64
+	Type       ValueType
+	Lineage    *Lineage
+	Author     *Author
+	Comment    string
+}
+
+// KeyInfo is metadata about a key's version chain
+type KeyInfo struct {
+	// Exists is false when the newest version is a tombstone or has expired
+	Exists bool
+	// Type is the kind of value in the newest version
+	Type ValueType
+	// Codec is the compression format the newest version is stored in
+	Codec Codec
+
+	Versions   int
+	Tombstones int
+
+	FirstVersion   uint64
+	FirstTimestamp int64
+	LastVersion    uint64
+	LastTimestamp  int64
+
+	// HeadBytes and HistoryBytes are the bytes held by the newest version and
+	// by all older ones
+	HeadBytes    int64
+	HistoryBytes int64
+
+	// LastAccess is the Unix second of the last read or write
+	LastAccess int64
+	// Frequency is the logarithmic access counter
+	Frequency uint8
+}
+
+// Record is a committed version of a key with its value decompressed, in a
+// form another engine can install
+type Record struct {
+	Version   uint64
+	Timestamp int64
+	Value     []byte
+	Type      ValueType
+	Deleted   bool
+	ExpiresAt int64
+	Author    *Author
+	Comment   string
+	Lineage   *Lineage
+	// MergedAt is the version that merged the record into the key's history
+	// from another key (0 when it was written to the key itself)
+	MergedAt uint64
+}
+
+// Change is one key written at a version, as reported by the change log
+type Change struct {
+	Version   uint64
+	Timestamp int64
+	Key       string
+	// Op is "set", "del", "moved" or the lineage of a rename or copy
+	Op   string
+	Size int
+	// History is set when the write also replaced the key's older versions
+	// (a rename, a copy with history or a move between databases)
+	History bool
+}
+
+// Mutation is what a logged change did to a key, for a replica to apply
+type Mutation struct {
+	Key string
+	// Op is the change log operation of the write
+	Op string
+	// Replace is set when the write rewrote the key's history. Records then
+	// holds the key's whole chain, newest first (empty when the key was
+	// removed), otherwise the single version to add.
+	Replace bool
+	Records []Record
+	// From names the key a rename or a copy with history merged the history
+	// of below the added version
+	From string
+}
+
+// DiffStatus classifies how a key changed between two versions
+type DiffStatus uint8
+
+const (
+	DiffAdded DiffStatus = iota + 1
+	DiffModified
+	DiffDeleted
+)
+
+func (s DiffStatus) String() string {
+	switch s {
+	case DiffAdded:
+		return "added"
+	case DiffModified:
+		return "modified"
+	case DiffDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// KeyDiff describes a key whose visible value differs between two versions
+type KeyDiff struct {
+	Key    string
+	Status DiffStatus
+	// OldSize and NewSize are the logical value sizes (0 when absent)
+	OldSize int
+	NewSize int
+	// ToVersion is the version the key's state at the later version was written at
+	ToVersion uint64
+}
+
+// Chain is a key's history taken out of an engine by Detach. Its content
+// belongs to the backend that detached it, Attach refuses chains of another
+// backend with ErrForeignChain.
+type Chain interface {
+	// Len is the number of versions in the chain
+	Len() int
+}
+
+// EngineStats are counters over a whole engine
+type EngineStats struct {
+	// KeyCount is the number of chains, including keys that are deleted
+	KeyCount int
+	// LiveKeys is the number of keys with a live value
+	LiveKeys int
+	// ExpiringKeys is the number of live keys with an expiry set
+	ExpiringKeys int
+	// Versions is the number of committed versions across all chains
+	Versions       int
+	CurrentVersion uint64
+}
+
+// CompressionStats is the space one compression policy saves
+type CompressionStats struct {
+	Pattern string
+	Codec   Codec
+	// Versions is the number of non-tombstone versions stored under the policy
+	Versions int
+	// Compressed is how many of those versions are stored compressed
+	Compressed int
+	// LogicalBytes is the total uncompressed size of the values
+	LogicalBytes int64
+	// StoredBytes is the total size actually held
+	StoredBytes int64
+}
+
+// Saved returns the number of bytes compression saves
+func (s CompressionStats) Saved() int64 {
+	return s.LogicalBytes - s.StoredBytes
+}
+
+// Ratio returns the stored/logical ratio (1 when nothing is stored)
+func (s CompressionStats) Ratio() float64 {
+	if s.LogicalBytes == 0 {
+		return 1
+	}
+	return float64(s.StoredBytes) / float64(s.LogicalBytes)
+}